@@ -0,0 +1,234 @@
+// Package avatar 將上傳的頭像轉為正方形的各尺寸圖片。圖片會依 EXIF 方向轉正後重新編碼，
+// 原始檔案中的 EXIF 等中繼資料（例如拍攝位置）不會被保留
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// 上傳的限制
+const (
+	MaxBytes     = 5 << 20 // 上傳檔案的大小上限
+	MaxDimension = 4096    // 圖片寬高的上限，避免解碼極大的圖片耗盡記憶體
+)
+
+// Sizes 產生的正方形尺寸（像素），由小到大排列
+var Sizes = []int{32, 64, 128, 256, 512}
+
+// 處理頭像的錯誤
+var (
+	ErrUnsupportedType = errors.New("avatar must be a JPEG, PNG or GIF image")
+	ErrTooLarge        = errors.New("avatar image dimensions are too large")
+	ErrInvalidImage    = errors.New("avatar image could not be decoded")
+)
+
+// Variant 單一尺寸的圖片
+type Variant struct {
+	Size int
+	Data []byte
+}
+
+// Result 處理後的頭像，Width 與 Height 為轉正後原圖的尺寸
+type Result struct {
+	ContentType string
+	Width       int
+	Height      int
+	Variants    []Variant
+}
+
+// Sniff 依檔案內容判斷圖片類型，不採信用戶端宣告的 Content-Type
+func Sniff(data []byte) (string, bool) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return contentType, true
+	}
+	return "", false
+}
+
+// Process 驗證並解碼圖片，轉正後裁切中央的正方形並產生 Sizes 中的每個尺寸。
+// JPEG 輸出為 JPEG，PNG 與 GIF（只取第一格）輸出為 PNG 以保留透明度
+func Process(data []byte) (Result, error) {
+	contentType, ok := Sniff(data)
+	if !ok {
+		return Result{}, ErrUnsupportedType
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return Result{}, ErrInvalidImage
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension {
+		return Result{}, ErrTooLarge
+	}
+
+	var src image.Image
+	switch contentType {
+	case "image/jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+		if err == nil {
+			src = orient(src, jpegOrientation(data))
+		}
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+	default:
+		src, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return Result{}, ErrInvalidImage
+	}
+
+	result := Result{Width: src.Bounds().Dx(), Height: src.Bounds().Dy()}
+	encode := encodePNG
+	result.ContentType = "image/png"
+	if contentType == "image/jpeg" {
+		encode = encodeJPEG
+		result.ContentType = "image/jpeg"
+	}
+
+	// 由大到小縮放，較小的尺寸以前一個尺寸為來源以減少運算
+	square := centerSquare(src)
+	variants := make([]Variant, len(Sizes))
+	from := square
+	for i := len(Sizes) - 1; i >= 0; i-- {
+		size := Sizes[i]
+		scaled := resize(from, size)
+		encoded, err := encode(scaled)
+		if err != nil {
+			return Result{}, err
+		}
+		variants[i] = Variant{Size: size, Data: encoded}
+		if size < square.Bounds().Dx() {
+			from = scaled
+		}
+	}
+	result.Variants = variants
+	return result, nil
+}
+
+// Pick 回傳不小於 size 的最小尺寸，size 大於所有尺寸時回傳最大的尺寸
+func Pick(size int) int {
+	for _, s := range Sizes {
+		if s >= size {
+			return s
+		}
+	}
+	return Sizes[len(Sizes)-1]
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	return buf.Bytes(), err
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// centerSquare 裁切圖片中央的正方形並轉為 RGBA
+func centerSquare(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	offset := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), src, offset, draw.Src)
+	return square
+}
+
+// resize 將正方形圖片縮放為 size×size。縮小時以面積平均取樣，放大時以雙線性內插；
+// RGBA 為預乘 alpha，平均時透明像素不會讓邊緣變暗
+func resize(src *image.RGBA, size int) *image.RGBA {
+	n := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	if n == size {
+		copy(dst.Pix, src.Pix)
+		return dst
+	}
+	if n < size {
+		upscale(src, dst)
+		return dst
+	}
+
+	// 每個目標像素對應原圖中 [start, end) 的像素範圍
+	bounds := make([]int, size+1)
+	for i := range bounds {
+		bounds[i] = i * n / size
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var sum [4]int
+			count := 0
+			for sy := bounds[y]; sy < bounds[y+1]; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := bounds[x]; sx < bounds[x+1]; sx++ {
+					p := row[sx*4 : sx*4+4]
+					sum[0] += int(p[0])
+					sum[1] += int(p[1])
+					sum[2] += int(p[2])
+					sum[3] += int(p[3])
+					count++
+				}
+			}
+			d := dst.Pix[y*dst.Stride+x*4:]
+			for k := 0; k < 4; k++ {
+				d[k] = uint8((sum[k] + count/2) / count)
+			}
+		}
+	}
+	return dst
+}
+
+func upscale(src, dst *image.RGBA) {
+	n := src.Bounds().Dx()
+	size := dst.Bounds().Dx()
+	scale := float64(n) / float64(size)
+	for y := 0; y < size; y++ {
+		fy := clampFloat((float64(y)+0.5)*scale-0.5, float64(n-1))
+		y0 := int(fy)
+		y1 := minInt(y0+1, n-1)
+		wy := fy - float64(y0)
+		for x := 0; x < size; x++ {
+			fx := clampFloat((float64(x)+0.5)*scale-0.5, float64(n-1))
+			x0 := int(fx)
+			x1 := minInt(x0+1, n-1)
+			wx := fx - float64(x0)
+			d := dst.Pix[y*dst.Stride+x*4:]
+			for k := 0; k < 4; k++ {
+				top := float64(src.Pix[y0*src.Stride+x0*4+k])*(1-wx) + float64(src.Pix[y0*src.Stride+x1*4+k])*wx
+				bottom := float64(src.Pix[y1*src.Stride+x0*4+k])*(1-wx) + float64(src.Pix[y1*src.Stride+x1*4+k])*wx
+				d[k] = uint8(top*(1-wy) + bottom*wy + 0.5)
+			}
+		}
+	}
+}
+
+func clampFloat(v, max float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
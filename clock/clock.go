@@ -0,0 +1,52 @@
+// Package clock 提供可替換的時間來源，服務寫入的時間戳記一律為 UTC，測試可凍結時間
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 取得目前時間
+type Clock interface {
+	Now() time.Time
+}
+
+// System 使用系統時間
+type System struct{}
+
+// Now 回傳目前的 UTC 時間
+func (System) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Frozen 固定於指定時間的時鐘，只會在呼叫 Set 或 Advance 時改變
+type Frozen struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFrozen 建立固定於 t 的時鐘
+func NewFrozen(t time.Time) *Frozen {
+	return &Frozen{now: t.UTC()}
+}
+
+// Now 回傳固定的時間
+func (f *Frozen) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set 將時鐘設定為 t
+func (f *Frozen) Set(t time.Time) {
+	f.mu.Lock()
+	f.now = t.UTC()
+	f.mu.Unlock()
+}
+
+// Advance 將時鐘往後推移 d
+func (f *Frozen) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
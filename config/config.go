@@ -9,18 +9,30 @@ import (
 
 // Config 結構體包含所有應用程序配置
 type Config struct {
-	Server    ServerConfig
-	MongoDB   MongoDBConfig
-	JWT       JWTConfig
-	Logging   LoggingConfig
-	RateLimit RateLimitConfig
-	CORS      CORSConfig
+	Server     ServerConfig
+	MongoDB    MongoDBConfig
+	JWT        JWTConfig
+	Logging    LoggingConfig
+	RateLimit  RateLimitConfig
+	CORS       CORSConfig
+	OIDC       OIDCConfig
+	SCIM       SCIMConfig
+	Mail       MailConfig
+	Invitation InvitationConfig
+	I18n       I18nConfig
+	Validation ValidationConfig
+	Jobs       JobConfig
+	Webhooks   WebhookConfig
+	GraphQL    GraphQLConfig
+	GRPC       GRPCConfig
 }
 
 // ServerConfig 包含服務器相關配置
 type ServerConfig struct {
-	Port    string
-	GinMode string
+	Port           string
+	GinMode        string
+	PublicBaseURL  string   // 對外公開的基本 URL，設定後 HATEOAS 連結一律以此為準
+	TrustedProxies []string // 可信任的反向代理 IP 或 CIDR，只有來自這些位址的轉送標頭會被採用
 }
 
 // MongoDBConfig 包含 MongoDB 相關配置
@@ -55,12 +67,86 @@ type CORSConfig struct {
 	AllowedOrigins []string
 }
 
+// OIDCConfig 包含 OpenID Connect 身分提供者相關配置
+type OIDCConfig struct {
+	Issuer            string
+	AccessTokenTTL    time.Duration
+	IDTokenTTL        time.Duration
+	AuthCodeTTL       time.Duration
+	KeyRotation       time.Duration
+	RegistrationToken string
+	OpenRegistration  bool
+}
+
+// SCIMConfig 包含 SCIM 佈建端點相關配置
+type SCIMConfig struct {
+	BearerToken string
+}
+
+// MailConfig 包含寄送電子郵件相關配置，未設定 SMTP_HOST 時郵件只寫入日誌
+type MailConfig struct {
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+}
+
+// InvitationConfig 包含邀請註冊相關配置
+type InvitationConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+	AcceptURL  string
+}
+
+// I18nConfig 包含多語系相關配置
+type I18nConfig struct {
+	FallbackLanguage string // Accept-Language 沒有支援的語言時使用的語言
+}
+
+// ValidationConfig 包含用戶資料驗證相關配置
+type ValidationConfig struct {
+	DefaultPhoneRegion string // 以 0 開頭的國內電話號碼所屬地區，TW 或 TH
+}
+
+// JobConfig 包含背景工作佇列相關配置
+type JobConfig struct {
+	Workers       int
+	LeaseDuration time.Duration
+	MaxAttempts   int
+	RetryBackoff  time.Duration
+	Retention     time.Duration
+}
+
+// WebhookConfig 包含 webhook 傳遞相關配置
+type WebhookConfig struct {
+	Workers      int
+	Timeout      time.Duration
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	Retention    time.Duration
+}
+
+// GraphQLConfig 包含 GraphQL 端點相關配置
+type GraphQLConfig struct {
+	MaxDepth      int // 查詢欄位巢狀的最大深度
+	MaxComplexity int // 查詢的最大複雜度，列表欄位依請求筆數加權
+}
+
+// GRPCConfig 包含 gRPC 伺服器相關配置
+type GRPCConfig struct {
+	Port       string // gRPC 伺服器的埠號，與 Gin 伺服器同時執行
+	Reflection bool   // 是否提供反射服務，預設關閉
+}
+
 // LoadConfig 從環境變數加載配置
 func LoadConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:    getEnv("PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:           getEnv("PORT", "8080"),
+			GinMode:        getEnv("GIN_MODE", "debug"),
+			PublicBaseURL:  getEnv("PUBLIC_BASE_URL", ""),
+			TrustedProxies: getEnvAsStringSlice("TRUSTED_PROXIES", nil),
 		},
 		MongoDB: MongoDBConfig{
 			URI:      getEnv("MONGODB_URI", "mongodb://localhost:27017"),
@@ -84,6 +170,58 @@ func LoadConfig() *Config {
 		CORS: CORSConfig{
 			AllowedOrigins: getEnvAsStringSlice("ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:8080"}),
 		},
+		OIDC: OIDCConfig{
+			Issuer:            strings.TrimSuffix(getEnv("OIDC_ISSUER", "http://localhost:8080"), "/"),
+			AccessTokenTTL:    time.Duration(getEnvAsInt("OIDC_ACCESS_TOKEN_TTL_MINUTES", 60)) * time.Minute,
+			IDTokenTTL:        time.Duration(getEnvAsInt("OIDC_ID_TOKEN_TTL_MINUTES", 60)) * time.Minute,
+			AuthCodeTTL:       time.Duration(getEnvAsInt("OIDC_AUTH_CODE_TTL_SECONDS", 60)) * time.Second,
+			KeyRotation:       time.Duration(getEnvAsInt("OIDC_KEY_ROTATION_HOURS", 720)) * time.Hour,
+			RegistrationToken: getEnv("OIDC_REGISTRATION_TOKEN", ""),
+			OpenRegistration:  getEnvAsBool("OIDC_OPEN_REGISTRATION", false),
+		},
+		SCIM: SCIMConfig{
+			BearerToken: getEnv("SCIM_BEARER_TOKEN", ""),
+		},
+		Mail: MailConfig{
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			From:         getEnv("MAIL_FROM", "no-reply@localhost"),
+		},
+		I18n: I18nConfig{
+			FallbackLanguage: getEnv("FALLBACK_LANGUAGE", "zh-TW"),
+		},
+		Validation: ValidationConfig{
+			DefaultPhoneRegion: getEnv("DEFAULT_PHONE_REGION", "TW"),
+		},
+		Jobs: JobConfig{
+			Workers:       getEnvAsInt("JOB_WORKERS", 2),
+			LeaseDuration: time.Duration(getEnvAsInt("JOB_LEASE_SECONDS", 60)) * time.Second,
+			MaxAttempts:   getEnvAsInt("JOB_MAX_ATTEMPTS", 3),
+			RetryBackoff:  time.Duration(getEnvAsInt("JOB_RETRY_BACKOFF_SECONDS", 30)) * time.Second,
+			Retention:     time.Duration(getEnvAsInt("JOB_RETENTION_HOURS", 168)) * time.Hour,
+		},
+		Webhooks: WebhookConfig{
+			Workers:      getEnvAsInt("WEBHOOK_WORKERS", 2),
+			Timeout:      time.Duration(getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+			MaxAttempts:  getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBackoff: time.Duration(getEnvAsInt("WEBHOOK_RETRY_BACKOFF_SECONDS", 30)) * time.Second,
+			Retention:    time.Duration(getEnvAsInt("WEBHOOK_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		},
+		Invitation: InvitationConfig{
+			DefaultTTL: time.Duration(getEnvAsInt("INVITATION_TTL_HOURS", 72)) * time.Hour,
+			MaxTTL:     time.Duration(getEnvAsInt("INVITATION_MAX_TTL_HOURS", 720)) * time.Hour,
+			AcceptURL:  getEnv("INVITATION_ACCEPT_URL", "http://localhost:3000/accept-invitation"),
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      getEnvAsInt("GRAPHQL_MAX_DEPTH", 10),
+			MaxComplexity: getEnvAsInt("GRAPHQL_MAX_COMPLEXITY", 1000),
+		},
+		GRPC: GRPCConfig{
+			Port:       getEnv("GRPC_PORT", "9090"),
+			Reflection: getEnvAsBool("GRPC_REFLECTION", false),
+		},
 	}
 }
 
@@ -105,6 +243,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsBool 獲取布林類型的環境變數
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvAsStringSlice 獲取字符串切片類型的環境變數
 func getEnvAsStringSlice(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
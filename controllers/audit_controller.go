@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api_for_main/exporter"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var auditCollection *mongo.Collection
+
+// 稽核紀錄與版本列表的分頁設定
+const (
+	defaultAuditPageSize = 20
+	maxAuditPageSize     = 100
+)
+
+// auditCSVHeader CSV 匯出的欄位，每個欄位變更一列
+var auditCSVHeader = []string{"at", "actor", "action", "target_type", "target_id", "field", "before", "after", "request_id", "ip"}
+
+// SetupAuditController 初始化稽核紀錄控制器
+func SetupAuditController(db *mongo.Database) {
+	if db != nil {
+		auditCollection = db.Collection("audit_log")
+		_, err := auditCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "at", Value: -1}}},
+			{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "at", Value: -1}}},
+			{Keys: bson.D{{Key: "at", Value: -1}}},
+		})
+		if err != nil {
+			log.Printf("Warning: creating audit_log indexes failed: %v\n", err)
+		}
+	}
+}
+
+// auditOrigin 稽核紀錄中變更的來源請求；背景工作沿用建立工作的請求
+type auditOrigin struct {
+	RequestID string
+	IP        string
+}
+
+// requestOrigin 取得目前請求的識別碼與用戶端位址
+func requestOrigin(c *gin.Context) auditOrigin {
+	return auditOrigin{RequestID: middleware.CurrentRequestID(c), IP: c.ClientIP()}
+}
+
+// recordUserChange 記錄一次用戶變更：寫入稽核紀錄與修改後的版本快照，before 為 nil 表示新建立的用戶
+func recordUserChange(c *gin.Context, actor, action string, targetID primitive.ObjectID, before, after *user_models.User) {
+	recordUserChangeFrom(requestOrigin(c), actor, action, targetID, before, after)
+}
+
+// recordUserChangeFrom 與 recordUserChange 相同，但來源不是目前的請求
+func recordUserChangeFrom(origin auditOrigin, actor, action string, targetID primitive.ObjectID, before, after *user_models.User) {
+	writeAuditEntry(origin, actor, action, targetID, user_models.DiffUsers(before, after))
+	if after != nil {
+		recordVersion(actor, action, *after)
+	}
+}
+
+// recordAuditChanges 寫入一筆用戶變更的稽核紀錄；寫入失敗只記錄日誌，不影響已完成的變更
+func recordAuditChanges(c *gin.Context, actor, action string, targetID primitive.ObjectID, changes []user_models.FieldChange) {
+	writeAuditEntry(requestOrigin(c), actor, action, targetID, changes)
+}
+
+func writeAuditEntry(origin auditOrigin, actor, action string, targetID primitive.ObjectID, changes []user_models.FieldChange) {
+	if auditCollection == nil {
+		return
+	}
+
+	entry := user_models.AuditEntry{
+		Actor:      actor,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   targetID.Hex(),
+		Changes:    changes,
+		RequestID:  origin.RequestID,
+		IP:         origin.IP,
+		At:         serverClock.Now(),
+	}
+	if _, err := auditCollection.InsertOne(context.Background(), entry); err != nil {
+		log.Printf("Error writing audit entry %s for user %s: %v\n", action, targetID.Hex(), err)
+	}
+}
+
+// recordTransition 記錄狀態轉換，轉換前的狀態取自剛寫入的狀態歷史
+func recordTransition(c *gin.Context, actor string, updated user_models.User) {
+	recordTransitionFrom(requestOrigin(c), actor, updated)
+}
+
+// recordTransitionFrom 與 recordTransition 相同，但來源不是目前的 HTTP 請求
+func recordTransitionFrom(origin auditOrigin, actor string, updated user_models.User) {
+	before := updated
+	action := "transition"
+	if n := len(updated.StatusHistory); n > 0 {
+		last := updated.StatusHistory[n-1]
+		before.Status = last.From
+		action = last.Action
+	}
+	recordUserChangeFrom(origin, actor, user_models.AuditTransitionAction(action), updated.ID, &before, &updated)
+}
+
+// auditFilter 由查詢參數建立稽核紀錄的查詢條件
+func auditFilter(c *gin.Context) (bson.M, error) {
+	filter := bson.M{}
+	if target := c.Query("target"); target != "" {
+		filter["target_id"] = target
+	}
+	if actor := c.Query("actor"); actor != "" {
+		filter["actor"] = actor
+	}
+	if action := c.Query("action"); action != "" {
+		filter["action"] = action
+	}
+
+	at := bson.M{}
+	for param, op := range map[string]string{"from": "$gte", "to": "$lt"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+		}
+		at[op] = t.UTC()
+	}
+	if len(at) > 0 {
+		filter["at"] = at
+	}
+	return filter, nil
+}
+
+// auditQuery 保留篩選條件、去除分頁與格式參數的查詢字串，供產生分頁連結
+func auditQuery(c *gin.Context) string {
+	values := url.Values{}
+	for _, key := range []string{"target", "actor", "action", "from", "to"} {
+		if value := c.Query(key); value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values.Encode()
+}
+
+// pageParams 解析 page 與 size 查詢參數，失敗時已回應錯誤
+func pageParams(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		RespondWithAPIError(c, http.StatusBadRequest, "page must be a positive integer")
+		return 0, 0, false
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(defaultAuditPageSize)))
+	if err != nil || size < 1 || size > maxAuditPageSize {
+		RespondWithAPIError(c, http.StatusBadRequest, "size must be between 1 and 100")
+		return 0, 0, false
+	}
+	return page, size, true
+}
+
+// wantsCSV 以 format=csv 或 Accept: text/csv 要求 CSV 匯出
+func wantsCSV(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(c.GetHeader("Accept"), "text/csv")
+}
+
+// GetAuditLog godoc
+// @Summary 查詢稽核紀錄
+// @Description 依目標、操作者、動作與時間範圍查詢用戶變更的稽核紀錄，由新到舊排序；format=csv 時匯出所有符合的紀錄
+// @Tags audit
+// @Produce json,text/csv
+// @Security BearerAuth
+// @Param target query string false "目標用戶ID"
+// @Param actor query string false "操作者"
+// @Param action query string false "動作，例如 user.update"
+// @Param from query string false "起始時間（含），RFC 3339"
+// @Param to query string false "結束時間（不含），RFC 3339"
+// @Param page query int false "頁碼" default(1)
+// @Param size query int false "每頁筆數，最多 100" default(20)
+// @Param format query string false "匯出格式" Enums(csv)
+// @Success 200 {object} user_models.AuditLogResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /audit [get]
+func GetAuditLog(c *gin.Context) {
+	if auditCollection == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if wantsCSV(c) {
+		exportAuditCSV(c, filter)
+		return
+	}
+
+	page, size, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	total, err := auditCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	cursor, err := auditCollection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page-1)*size)).
+		SetLimit(int64(size)))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer cursor.Close(ctx)
+
+	entries := []user_models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, user_models.AuditLogResponse{
+		Data:  entries,
+		Links: localizeLinks(c, user_models.GenerateAuditLinks(getAPIBaseURL(c), auditQuery(c), page, size, total)),
+		Page:  page,
+		Size:  size,
+		Total: total,
+	})
+}
+
+// exportAuditCSV 以串流方式輸出所有符合條件的稽核紀錄
+func exportAuditCSV(c *gin.Context, filter bson.M) {
+	ctx := c.Request.Context()
+	cursor, err := auditCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer cursor.Close(ctx)
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(auditCSVHeader)
+	for cursor.Next(ctx) {
+		var entry user_models.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			log.Printf("Error decoding audit entry during export: %v\n", err)
+			break
+		}
+		for _, row := range auditCSVRows(entry) {
+			w.Write(row)
+		}
+		w.Flush()
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Error exporting audit log: %v\n", err)
+	}
+	w.Flush()
+}
+
+// auditCSVRows 將稽核紀錄展開為 CSV 列，沒有欄位變更的紀錄輸出一列
+func auditCSVRows(entry user_models.AuditEntry) [][]string {
+	base := func(field, before, after string) []string {
+		return []string{
+			entry.At.UTC().Format(time.RFC3339),
+			exporter.CSVSafe(entry.Actor),
+			exporter.CSVSafe(entry.Action),
+			entry.TargetType,
+			entry.TargetID,
+			exporter.CSVSafe(field),
+			exporter.CSVSafe(before),
+			exporter.CSVSafe(after),
+			exporter.CSVSafe(entry.RequestID),
+			entry.IP,
+		}
+	}
+	if len(entry.Changes) == 0 {
+		return [][]string{base("", "", "")}
+	}
+	rows := make([][]string, 0, len(entry.Changes))
+	for _, change := range entry.Changes {
+		rows = append(rows, base(change.Field, csvValue(change.Before), csvValue(change.After)))
+	}
+	return rows
+}
+
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrAdminRequired 表示操作需要管理員角色
+var ErrAdminRequired = errors.New("administrator role is required")
+
+// ErrOwnerOrAdminRequired 表示操作只限帳號本人或管理員
+var ErrOwnerOrAdminRequired = errors.New("only the account owner or an administrator can do this")
+
+// isAdmin 判斷權杖主體是否為啟用中的管理員；權杖不含角色，因此每次都從 users 集合讀取
+func isAdmin(ctx context.Context, subject string) (bool, error) {
+	if userCollection == nil {
+		return false, ErrMongoDBNotConnected
+	}
+	id, err := primitive.ObjectIDFromHex(subject)
+	if err != nil {
+		return false, nil
+	}
+
+	var user user_models.User
+	err = userCollection.FindOne(ctx, bson.M{"_id": id},
+		options.FindOne().SetProjection(bson.M{"role": 1, "status": 1})).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.Role == user_models.RoleAdmin && user.Status.CanAuthenticate(), nil
+}
+
+// RequireAdmin 要求已登入的主體是管理員，需放在 middleware.RequireAuth 之後
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.CurrentPrincipal(c)
+		if !ok {
+			RespondWithAPIError(c, http.StatusUnauthorized, "authentication is required")
+			c.Abort()
+			return
+		}
+
+		admin, err := isAdmin(c.Request.Context(), claims.Subject)
+		switch {
+		case errors.Is(err, ErrMongoDBNotConnected):
+			RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		case err != nil:
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		case !admin:
+			RespondWithAPIError(c, http.StatusForbidden, ErrAdminRequired.Error())
+		default:
+			c.Next()
+			return
+		}
+		c.Abort()
+	}
+}
+
+// RequireOwnerOrAdmin 要求已登入的主體是路徑參數 param 指定的用戶本人或管理員，需放在 middleware.RequireAuth 之後
+func RequireOwnerOrAdmin(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.CurrentPrincipal(c)
+		if !ok {
+			RespondWithAPIError(c, http.StatusUnauthorized, "authentication is required")
+			c.Abort()
+			return
+		}
+		if claims.Subject == c.Param(param) {
+			c.Next()
+			return
+		}
+
+		admin, err := isAdmin(c.Request.Context(), claims.Subject)
+		switch {
+		case errors.Is(err, ErrMongoDBNotConnected):
+			RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		case err != nil:
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		case !admin:
+			RespondWithAPIError(c, http.StatusForbidden, ErrOwnerOrAdminRequired.Error())
+		default:
+			c.Next()
+			return
+		}
+		c.Abort()
+	}
+}
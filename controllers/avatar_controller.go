@@ -0,0 +1,397 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-api_for_main/avatar"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var avatarBucket *gridfs.Bucket
+
+// errNoAvatar 用戶沒有可刪除的頭像
+var errNoAvatar = errors.New("user has no avatar")
+
+// 頭像的預設尺寸與快取時間；更新後的頭像有新的 ETag，快取過期後重新驗證即可取得
+const (
+	defaultAvatarSize  = 128
+	avatarCacheControl = "public, max-age=300"
+)
+
+// SetupAvatarController 初始化頭像控制器，各尺寸的圖片存放在 avatars GridFS bucket
+func SetupAvatarController(db *mongo.Database) {
+	if db == nil {
+		return
+	}
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName("avatars"))
+	if err != nil {
+		log.Printf("Warning: avatar storage is unavailable: %v\n", err)
+		return
+	}
+	avatarBucket = bucket
+}
+
+func checkAvatarStorage() error {
+	if avatarBucket == nil {
+		return errors.New("avatar storage is not initialized")
+	}
+	return checkMongoDBConnection()
+}
+
+// UploadAvatar godoc
+// @Summary 上傳頭像
+// @Description 以 multipart 的 file 欄位或直接以圖片作為請求內容上傳頭像，類型依內容判斷，只接受 JPEG、PNG 與 GIF，檔案不超過 5MB、寬高不超過 4096 像素。圖片依 EXIF 方向轉正並裁切為正方形後產生 32 到 512 像素的尺寸，EXIF 等中繼資料不會被保存。只限本人或管理員上傳
+// @Tags users
+// @Accept multipart/form-data,image/jpeg,image/png,image/gif
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param file formData file false "頭像圖片"
+// @Success 200 {object} user_models.AvatarResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 413 {object} user_models.APIResponse
+// @Failure 415 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Router /users/{id}/avatar [put]
+func UploadAvatar(c *gin.Context) {
+	if err := checkAvatarStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	data, ok := readAvatarUpload(c)
+	if !ok {
+		return
+	}
+	processed, err := avatar.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, avatar.ErrUnsupportedType):
+			RespondWithAPIError(c, http.StatusUnsupportedMediaType, "Avatar must be a JPEG, PNG or GIF image")
+		case errors.Is(err, avatar.ErrTooLarge):
+			RespondWithAPIError(c, http.StatusRequestEntityTooLarge, "Avatar image dimensions are too large")
+		case errors.Is(err, avatar.ErrInvalidImage):
+			RespondWithAPIError(c, http.StatusBadRequest, "Avatar image could not be decoded")
+		default:
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	uploaded := user_models.Avatar{
+		Hash:        hex.EncodeToString(sum[:16]),
+		ContentType: processed.ContentType,
+		Width:       processed.Width,
+		Height:      processed.Height,
+		UpdatedAt:   serverClock.Now(),
+		UpdatedBy:   currentActor(c),
+	}
+	for _, variant := range processed.Variants {
+		fileID, err := avatarBucket.UploadFromStream(id.Hex()+"-"+strconv.Itoa(variant.Size), bytes.NewReader(variant.Data),
+			options.GridFSUpload().SetMetadata(bson.M{"user_id": id, "size": variant.Size, "content_type": processed.ContentType}))
+		if err != nil {
+			deleteAvatarFiles(uploaded)
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		uploaded.Variants = append(uploaded.Variants, user_models.AvatarVariant{Size: variant.Size, FileID: fileID, Length: int64(len(variant.Data))})
+	}
+
+	ctx := context.Background()
+	previous, err := findActiveUser(ctx, id)
+	if err == nil {
+		_, err = saveAvatar(ctx, previous, &uploaded, uploaded.UpdatedBy)
+	}
+	if err != nil {
+		deleteAvatarFiles(uploaded)
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			RespondWithAPIError(c, http.StatusNotFound, "User not found")
+		case errors.Is(err, ErrConcurrentModification):
+			RespondWithAPIError(c, http.StatusConflict, localizeError(c, err))
+		default:
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	change := user_models.FieldChange{Field: "avatar", After: uploaded.Hash}
+	if previous.Avatar != nil {
+		deleteAvatarFiles(*previous.Avatar)
+		change.Before = previous.Avatar.Hash
+	}
+	recordAuditChanges(c, uploaded.UpdatedBy, user_models.AuditAvatarUpdate, id, []user_models.FieldChange{change})
+
+	c.JSON(http.StatusOK, user_models.AvatarResponse{
+		Data:  user_models.NewAvatarView(uploaded),
+		Links: localizeLinks(c, user_models.GenerateAvatarLinks(getAPIBaseURL(c), id.Hex(), uploaded)),
+	})
+}
+
+// saveAvatar 更換或移除（avatar 為 nil）頭像並遞增版本，以 updated_at 作為樂觀鎖；
+// 與個人資料的修改相同，會寫入 user.updated 事件並保存版本快照
+func saveAvatar(ctx context.Context, original user_models.User, avatar *user_models.Avatar, actor string) (user_models.User, error) {
+	updated := original
+	updated.Avatar = avatar
+	updated.UpdatedAt = serverClock.Now()
+	updated.UpdatedBy = actor
+	updated.Version = original.Version + 1
+
+	set := bson.M{"updated_at": updated.UpdatedAt, "updated_by": updated.UpdatedBy, "version": updated.Version}
+	update := bson.M{"$set": set, "$push": bson.M{"outbox": outboxEach(user_models.UserEvents(&original, updated, updated.UpdatedAt))}}
+	if avatar != nil {
+		set["avatar"] = avatar
+	} else {
+		update["$unset"] = bson.M{"avatar": ""}
+	}
+	result, err := userCollection.UpdateOne(ctx, bson.M{"_id": original.ID, "updated_at": original.UpdatedAt}, update)
+	if err != nil {
+		return original, err
+	}
+	if result.MatchedCount == 0 {
+		return original, ErrConcurrentModification
+	}
+	action := user_models.AuditAvatarUpdate
+	if avatar == nil {
+		action = user_models.AuditAvatarDelete
+	}
+	recordVersion(actor, action, updated)
+	return updated, nil
+}
+
+// readAvatarUpload 讀取 multipart 的 file 欄位或整個請求內容，失敗時已回傳錯誤響應
+func readAvatarUpload(c *gin.Context) ([]byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, avatar.MaxBytes+1<<20)
+
+	var source io.Reader = c.Request.Body
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				RespondWithAPIError(c, http.StatusRequestEntityTooLarge, "Avatar file is too large")
+				return nil, false
+			}
+			RespondWithAPIError(c, http.StatusBadRequest, "An avatar image is required in the file field")
+			return nil, false
+		}
+		file, err := header.Open()
+		if err != nil {
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+			return nil, false
+		}
+		defer file.Close()
+		source = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(source, avatar.MaxBytes+1))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			RespondWithAPIError(c, http.StatusRequestEntityTooLarge, "Avatar file is too large")
+			return nil, false
+		}
+		RespondWithAPIError(c, http.StatusBadRequest, "Request body is invalid")
+		return nil, false
+	}
+	switch {
+	case len(data) > avatar.MaxBytes:
+		RespondWithAPIError(c, http.StatusRequestEntityTooLarge, "Avatar file is too large")
+		return nil, false
+	case len(data) == 0:
+		RespondWithAPIError(c, http.StatusBadRequest, "An avatar image is required in the file field")
+		return nil, false
+	}
+	return data, true
+}
+
+// deleteAvatarFiles 刪除頭像的所有尺寸，失敗只記錄日誌
+func deleteAvatarFiles(a user_models.Avatar) {
+	for _, variant := range a.Variants {
+		if err := avatarBucket.Delete(variant.FileID); err != nil && err != gridfs.ErrFileNotFound {
+			log.Printf("Error deleting avatar file %s: %v\n", variant.FileID.Hex(), err)
+		}
+	}
+}
+
+// findAvatar 載入未刪除用戶的頭像，失敗時已回傳錯誤響應
+func findAvatar(c *gin.Context) (primitive.ObjectID, user_models.Avatar, bool) {
+	if err := checkAvatarStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return primitive.NilObjectID, user_models.Avatar{}, false
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return id, user_models.Avatar{}, false
+	}
+
+	var user user_models.User
+	err = userCollection.FindOne(context.Background(), bson.M{"_id": id, "status": notDeletedFilter()},
+		options.FindOne().SetProjection(bson.M{"avatar": 1})).Decode(&user)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		RespondWithAPIError(c, http.StatusNotFound, "User not found")
+		return id, user_models.Avatar{}, false
+	case err != nil:
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return id, user_models.Avatar{}, false
+	case user.Avatar == nil:
+		RespondWithAPIError(c, http.StatusNotFound, "User has no avatar")
+		return id, user_models.Avatar{}, false
+	}
+	return id, *user.Avatar, true
+}
+
+// GetAvatar godoc
+// @Summary 獲取頭像
+// @Description 回傳不小於 size 的最小尺寸頭像（32、64、128、256 或 512 像素），未指定時為 128。以 ETag 與 If-None-Match 驗證快取，未變更時回傳 304
+// @Tags users
+// @Produce image/jpeg,image/png
+// @Param id path string true "用戶ID"
+// @Param size query int false "頭像邊長（像素）" minimum(1) maximum(512) default(128)
+// @Param If-None-Match header string false "先前取得的 ETag"
+// @Success 200 {file} file
+// @Success 304 "頭像未變更"
+// @Header 200 {string} ETag "頭像與尺寸的實體標籤"
+// @Header 200 {string} Cache-Control "快取設定"
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Router /users/{id}/avatar [get]
+func GetAvatar(c *gin.Context) {
+	size := defaultAvatarSize
+	if value := c.Query("size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > avatar.Sizes[len(avatar.Sizes)-1] {
+			RespondWithAPIError(c, http.StatusBadRequest, "size must be an integer between 1 and 512")
+			return
+		}
+		size = n
+	}
+
+	_, current, ok := findAvatar(c)
+	if !ok {
+		return
+	}
+	size = avatar.Pick(size)
+	variant, ok := current.Variant(size)
+	if !ok {
+		RespondWithAPIError(c, http.StatusNotFound, "User has no avatar")
+		return
+	}
+
+	etag := current.ETag(size)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", avatarCacheControl)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	stream, err := avatarBucket.OpenDownloadStream(variant.FileID)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer stream.Close()
+
+	c.Header("Content-Type", current.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(variant.Length, 10))
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, stream); err != nil {
+		log.Printf("Error sending avatar %s: %v\n", variant.FileID.Hex(), err)
+	}
+}
+
+// etagMatches 判斷 If-None-Match 是否包含指定的 ETag，比對時忽略弱標籤的 W/ 前綴
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteAvatar godoc
+// @Summary 刪除頭像
+// @Description 刪除用戶的頭像與所有尺寸的檔案，只限本人或管理員
+// @Tags users
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Success 204 "頭像已刪除"
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Router /users/{id}/avatar [delete]
+func DeleteAvatar(c *gin.Context) {
+	if err := checkAvatarStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	ctx := context.Background()
+	previous, err := findActiveUser(ctx, id)
+	if err == nil && previous.Avatar == nil {
+		err = errNoAvatar
+	}
+	if err == nil {
+		_, err = saveAvatar(ctx, previous, nil, currentActor(c))
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			RespondWithAPIError(c, http.StatusNotFound, "User not found")
+		case errors.Is(err, errNoAvatar):
+			RespondWithAPIError(c, http.StatusNotFound, "User has no avatar")
+		case errors.Is(err, ErrConcurrentModification):
+			RespondWithAPIError(c, http.StatusConflict, localizeError(c, err))
+		default:
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	deleteAvatarFiles(*previous.Avatar)
+	recordAuditChanges(c, currentActor(c), user_models.AuditAvatarDelete, id,
+		[]user_models.FieldChange{{Field: "avatar", Before: previous.Avatar.Hash}})
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"go-api_for_main/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAPIPrefix 無法從路由判斷群組時，HATEOAS 連結使用的路由前綴
+const defaultAPIPrefix = "/api/v1"
+
+var publicBaseURL string
+var trustedProxies []*net.IPNet
+
+// SetupBaseURL 設定對外公開的基本 URL 與可信任的反向代理
+// 設定 PUBLIC_BASE_URL 時一律使用該值；否則只採用來自可信任代理的 Forwarded 與 X-Forwarded-* 標頭
+func SetupBaseURL(cfg config.ServerConfig) error {
+	if cfg.PublicBaseURL != "" {
+		u, err := url.Parse(cfg.PublicBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("invalid PUBLIC_BASE_URL %q: must be an absolute http or https URL without credentials, query or fragment", cfg.PublicBaseURL)
+		}
+	}
+	publicBaseURL = strings.TrimSuffix(cfg.PublicBaseURL, "/")
+	trustedProxies = nil
+	for _, entry := range cfg.TrustedProxies {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+	return nil
+}
+
+// fromTrustedProxy 判斷請求的直接來源是否為可信任的代理
+func fromTrustedProxy(c *gin.Context) bool {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		host = c.Request.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForwarded 取得 RFC 7239 Forwarded 標頭單一元素中的 proto 與 host
+func parseForwarded(element string) (proto, host string) {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(key) {
+		case "proto":
+			proto = strings.ToLower(value)
+		case "host":
+			host = value
+		}
+	}
+	return proto, host
+}
+
+// lastHeaderValue 取得以逗號分隔的標頭（可能有多行）中的最後一個值，也就是可信任代理自己加入的值；
+// 較前面的值由用戶端或更外層的代理提供，可能是偽造的
+func lastHeaderValue(c *gin.Context, name string) string {
+	values := c.Request.Header.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+	value := values[len(values)-1]
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		value = value[i+1:]
+	}
+	return strings.TrimSpace(value)
+}
+
+// getBaseURL 獲取請求對外的基本 URL（不含路由群組前綴）
+func getBaseURL(c *gin.Context) string {
+	if publicBaseURL != "" {
+		return publicBaseURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	host := c.Request.Host
+	prefix := ""
+
+	if fromTrustedProxy(c) {
+		if forwarded := lastHeaderValue(c, "Forwarded"); forwarded != "" {
+			proto, fwdHost := parseForwarded(forwarded)
+			if proto == "http" || proto == "https" {
+				scheme = proto
+			}
+			if fwdHost != "" {
+				host = fwdHost
+			}
+		} else {
+			if proto := strings.ToLower(lastHeaderValue(c, "X-Forwarded-Proto")); proto == "http" || proto == "https" {
+				scheme = proto
+			}
+			if fwdHost := lastHeaderValue(c, "X-Forwarded-Host"); fwdHost != "" {
+				host = fwdHost
+			}
+		}
+		if fwdPrefix := lastHeaderValue(c, "X-Forwarded-Prefix"); fwdPrefix != "" {
+			prefix = "/" + strings.Trim(fwdPrefix, "/")
+		}
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, host, prefix)
+}
+
+// apiPrefix 由目前路由推導路由群組前綴，例如 /api/v1 或 /api/test
+func apiPrefix(c *gin.Context) string {
+	segments := strings.SplitN(strings.TrimPrefix(c.FullPath(), "/"), "/", 3)
+	if len(segments) >= 2 && segments[0] == "api" && segments[1] != "" {
+		return "/api/" + segments[1]
+	}
+	return defaultAPIPrefix
+}
+
+// getAPIBaseURL 獲取 HATEOAS 連結使用的基本 URL，包含路由群組前綴
+func getAPIBaseURL(c *gin.Context) string {
+	return getBaseURL(c) + apiPrefix(c)
+}
@@ -0,0 +1,532 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errBulkDuplicateUser 同一個用戶在批次中出現多次
+var errBulkDuplicateUser = errors.New("user appears more than once in the batch")
+
+// ErrBulkNotExecuted 因為其他操作失敗而沒有執行的操作
+var ErrBulkNotExecuted = errors.New("not executed because another operation failed")
+
+// mongoDocumentValidationFailure 文件不符合集合驗證規則時的 MongoDB 錯誤碼
+const mongoDocumentValidationFailure = 121
+
+// bulkItem 通過格式與欄位驗證的操作
+type bulkItem struct {
+	index  int
+	op     string
+	id     primitive.ObjectID
+	create *user_models.AdminCreateUserRequest
+	patch  *user_models.PatchUserRequest
+}
+
+// bulkWrite 已決定寫入內容、等待執行的操作
+type bulkWrite struct {
+	index  int
+	op     string
+	model  mongo.WriteModel
+	before *user_models.User
+	after  user_models.User
+}
+
+// BulkErrorStatus 將操作的錯誤對應為單筆端點會回傳的 HTTP 狀態碼
+func BulkErrorStatus(err error) int {
+	var serverErr mongo.ServerError
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errEmailInUse), errors.Is(err, errBulkDuplicateUser),
+		errors.Is(err, ErrConcurrentModification), mongo.IsDuplicateKeyError(err):
+		return http.StatusConflict
+	case errors.As(err, &serverErr) && serverErr.HasErrorCode(mongoDocumentValidationFailure):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrBulkNotExecuted):
+		return http.StatusFailedDependency
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// BulkUsers godoc
+// @Summary 批次建立、更新與刪除用戶
+// @Description 以 BulkWrite 執行多個操作。ordered 遇到第一個失敗即停止；unordered 執行所有通過檢查的操作；atomic 在交易中執行，任一失敗時全部不寫入。每個操作回報各自的狀態碼：201、200、404、409、422，未執行的操作為 424
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body user_models.BulkRequest true "批次操作"
+// @Success 200 {object} user_models.BulkResponse
+// @Success 207 {object} user_models.BulkResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /users/bulk [post]
+func BulkUsers(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	var req user_models.BulkRequest
+	if err := bindBody(c, &req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	mode := req.EffectiveMode()
+
+	results := make([]user_models.BulkItemResult, len(req.Operations))
+	items := make([]*bulkItem, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = user_models.BulkItemResult{Index: i, Op: op.Op, ID: op.ID}
+		items[i] = decodeBulkOperation(c, i, op, &results[i])
+	}
+
+	ctx := context.Background()
+	writes, err := planBulkWrites(c, ctx, mode, items, results)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// atomic 模式中只要有操作未通過檢查就不寫入任何資料
+	if mode == user_models.BulkModeAtomic && len(writes) < len(items) {
+		for _, w := range writes {
+			failBulkItem(c, &results[w.index], ErrBulkNotExecuted)
+		}
+		writes = nil
+	}
+
+	errs := executeBulkWrites(ctx, mode, writes)
+	baseURL := getAPIBaseURL(c)
+	for i, w := range writes {
+		if errs[i] != nil {
+			failBulkItem(c, &results[w.index], errs[i])
+			continue
+		}
+		finishBulkItem(c, baseURL, w, &results[w.index])
+	}
+
+	response := user_models.NewBulkResponse(mode, results, localizeLinks(c, user_models.GenerateBulkLinks(baseURL)))
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, response)
+}
+
+// decodeBulkOperation 檢查 ID 並以單筆端點相同的規則驗證 data，失敗時記錄 422 並回傳 nil
+func decodeBulkOperation(c *gin.Context, index int, op user_models.BulkOperation, result *user_models.BulkItemResult) *bulkItem {
+	item := &bulkItem{index: index, op: op.Op}
+
+	if op.Op != user_models.BulkOpCreate {
+		id, err := primitive.ObjectIDFromHex(op.ID)
+		if err != nil {
+			result.Status = http.StatusUnprocessableEntity
+			result.Error = tr(c, "Invalid ID")
+			return nil
+		}
+		item.id = id
+	}
+
+	var dest interface{}
+	switch op.Op {
+	case user_models.BulkOpCreate:
+		item.create = &user_models.AdminCreateUserRequest{}
+		dest = item.create
+	case user_models.BulkOpUpdate:
+		item.patch = &user_models.PatchUserRequest{}
+		dest = item.patch
+	default:
+		return item
+	}
+
+	err := json.Unmarshal(op.Data, dest)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(dest)
+	}
+	if err != nil {
+		result.Status = http.StatusUnprocessableEntity
+		result.Error, result.Errors = bindingErrors(c, err)
+		return nil
+	}
+	return item
+}
+
+// planBulkWrites 依序為每個操作產生寫入內容；ordered 與 atomic 模式在第一個失敗後不再規劃後續操作
+func planBulkWrites(c *gin.Context, ctx context.Context, mode string, items []*bulkItem, results []user_models.BulkItemResult) ([]bulkWrite, error) {
+	targets, err := loadBulkTargets(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := loadEmailOwners(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := currentActor(c)
+	now := serverClock.Now()
+	touched := map[primitive.ObjectID]bool{}
+	var writes []bulkWrite
+	stopped := false
+	for i, item := range items {
+		if stopped {
+			failBulkItem(c, &results[i], ErrBulkNotExecuted)
+			continue
+		}
+		if item == nil {
+			stopped = mode != user_models.BulkModeUnordered
+			continue
+		}
+
+		w, err := planBulkWrite(item, actor, now, targets, owners, touched)
+		if err != nil {
+			if BulkErrorStatus(err) == http.StatusInternalServerError {
+				return nil, err
+			}
+			failBulkItem(c, &results[i], err)
+			stopped = mode != user_models.BulkModeUnordered
+			continue
+		}
+		writes = append(writes, w)
+	}
+	return writes, nil
+}
+
+// planBulkWrite 產生單一操作的寫入內容，同時記錄批次中已使用的 email 與用戶
+func planBulkWrite(item *bulkItem, actor string, now time.Time, targets map[primitive.ObjectID]user_models.User, owners map[string]primitive.ObjectID, touched map[primitive.ObjectID]bool) (bulkWrite, error) {
+	w := bulkWrite{index: item.index, op: item.op}
+
+	if item.op == user_models.BulkOpCreate {
+		email := strings.ToLower(item.create.Email)
+		if _, taken := owners[email]; taken {
+			return w, errEmailInUse
+		}
+		hashed, err := user_models.HashPassword(item.create.Password)
+		if err != nil {
+			return w, err
+		}
+		user := item.create.ToUser(hashed)
+		user.ID = primitive.NewObjectID()
+		user.Status = user_models.StatusActive
+		user.CreatedAt, user.UpdatedAt = now, now
+		user.CreatedBy, user.UpdatedBy = actor, actor
+		user.Version = 1
+		user = withCreatedEvent(user)
+		owners[email] = user.ID
+
+		w.model = mongo.NewInsertOneModel().SetDocument(user)
+		w.after = user
+		return w, nil
+	}
+
+	original, ok := targets[item.id]
+	if !ok {
+		return w, ErrUserNotFound
+	}
+	if touched[item.id] {
+		return w, errBulkDuplicateUser
+	}
+
+	updated := original
+	updated.UpdatedAt = now
+	updated.UpdatedBy = actor
+	updated.Version = original.Version + 1
+	filter := bson.M{"_id": original.ID, "updated_at": original.UpdatedAt}
+
+	if item.op == user_models.BulkOpUpdate {
+		item.patch.Apply(&updated)
+		if !strings.EqualFold(original.Email, updated.Email) {
+			email := strings.ToLower(updated.Email)
+			if owner, taken := owners[email]; taken && owner != original.ID {
+				return w, errEmailInUse
+			}
+			owners[email] = original.ID
+		}
+		update := bson.M{"$set": profileSet(updated)}
+		if events := user_models.UserEvents(&original, updated, now); len(events) > 0 {
+			update["$push"] = bson.M{"outbox": outboxEach(events)}
+		}
+		w.model = mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update)
+	} else {
+		// 刪除與 DeleteUser 相同，轉為 deleted 狀態並保留狀態歷史
+		action, _ := user_models.FindLifecycleAction("delete")
+		entry := user_models.StatusTransition{
+			From:   original.Status.Effective(),
+			To:     action.To,
+			Action: action.Name,
+			Actor:  actor,
+			At:     now,
+		}
+		updated.Status = action.To
+		updated.StatusHistory = append(append([]user_models.StatusTransition{}, original.StatusHistory...), entry)
+		w.model = mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.M{
+			"$set": bson.M{
+				"status":     updated.Status,
+				"updated_at": updated.UpdatedAt,
+				"updated_by": updated.UpdatedBy,
+				"version":    updated.Version,
+			},
+			"$push": bson.M{
+				"status_history": entry,
+				"outbox":         outboxEach(user_models.UserEvents(&original, updated, now)),
+			},
+		})
+	}
+
+	touched[item.id] = true
+	w.before = &original
+	w.after = updated
+	return w, nil
+}
+
+// loadBulkTargets 一次載入所有更新與刪除的目標，已刪除的用戶視為不存在
+func loadBulkTargets(ctx context.Context, items []*bulkItem) (map[primitive.ObjectID]user_models.User, error) {
+	ids := bson.A{}
+	for _, item := range items {
+		if item != nil && item.op != user_models.BulkOpCreate {
+			ids = append(ids, item.id)
+		}
+	}
+	targets := map[primitive.ObjectID]user_models.User{}
+	if len(ids) == 0 {
+		return targets, nil
+	}
+
+	cursor, err := userCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "status": notDeletedFilter()})
+	if err != nil {
+		return nil, err
+	}
+	var users []user_models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		targets[user.ID] = user
+	}
+	return targets, nil
+}
+
+// loadEmailOwners 一次查詢批次中出現的 email 目前屬於哪些用戶，以小寫 email 為鍵
+func loadEmailOwners(ctx context.Context, items []*bulkItem) (map[string]primitive.ObjectID, error) {
+	patterns := bson.A{}
+	for _, item := range items {
+		switch {
+		case item == nil:
+		case item.create != nil:
+			patterns = append(patterns, emailPattern(item.create.Email))
+		case item.patch != nil && item.patch.Email != nil:
+			patterns = append(patterns, emailPattern(*item.patch.Email))
+		}
+	}
+	owners := map[string]primitive.ObjectID{}
+	if len(patterns) == 0 {
+		return owners, nil
+	}
+
+	cursor, err := userCollection.Find(ctx, bson.M{"email": bson.M{"$in": patterns}},
+		options.Find().SetProjection(bson.M{"_id": 1, "email": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var users []user_models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		owners[strings.ToLower(user.Email)] = user.ID
+	}
+	return owners, nil
+}
+
+func emailPattern(email string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(email) + "$", Options: "i"}
+}
+
+// executeBulkWrites 執行寫入並回傳每個寫入的錯誤，順序與 writes 相同
+func executeBulkWrites(ctx context.Context, mode string, writes []bulkWrite) []error {
+	errs := make([]error, len(writes))
+	if len(writes) == 0 {
+		return errs
+	}
+
+	models := make([]mongo.WriteModel, len(writes))
+	updates := int64(0)
+	for i, w := range writes {
+		models[i] = w.model
+		if w.op != user_models.BulkOpCreate {
+			updates++
+		}
+	}
+	opts := options.BulkWrite().SetOrdered(mode != user_models.BulkModeUnordered)
+
+	run := func(ctx context.Context) error {
+		result, err := userCollection.BulkWrite(ctx, models, opts)
+		if err != nil {
+			MapBulkWriteError(mode, err, errs)
+		}
+		// 樂觀鎖條件不符的更新不會產生錯誤，只有數量不符時才逐筆確認
+		if result != nil && result.MatchedCount < updates {
+			if err := markConcurrentWrites(ctx, writes, errs); err != nil {
+				return err
+			}
+		}
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if mode != user_models.BulkModeAtomic {
+		if err := run(ctx); err != nil && !hasBulkItemError(errs) {
+			fillBulkErrors(errs, err)
+		}
+		return errs
+	}
+
+	session, err := userCollection.Database().Client().StartSession()
+	if err != nil {
+		fillBulkErrors(errs, err)
+		return errs
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		for i := range errs {
+			errs[i] = nil
+		}
+		return nil, run(sc)
+	})
+	if err != nil {
+		// 交易已回復，沒有個別錯誤的操作都視為未執行
+		if !hasBulkItemError(errs) {
+			fillBulkErrors(errs, err)
+		}
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = ErrBulkNotExecuted
+			}
+		}
+	}
+	return errs
+}
+
+// MapBulkWriteError 將 BulkWrite 的錯誤分配到對應的寫入；ordered 與 atomic 模式中第一個失敗之後的寫入不會執行，
+// 不是 BulkWriteException 的錯誤套用到所有寫入
+func MapBulkWriteError(mode string, err error, errs []error) {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+		fillBulkErrors(errs, err)
+		return
+	}
+	first := len(errs)
+	for _, writeErr := range bulkErr.WriteErrors {
+		errs[writeErr.Index] = writeErr
+		if writeErr.Index < first {
+			first = writeErr.Index
+		}
+	}
+	if mode != user_models.BulkModeUnordered {
+		for i := first + 1; i < len(errs); i++ {
+			if errs[i] == nil {
+				errs[i] = ErrBulkNotExecuted
+			}
+		}
+	}
+}
+
+// markConcurrentWrites 找出因為樂觀鎖條件不符而沒有寫入的更新與刪除
+func markConcurrentWrites(ctx context.Context, writes []bulkWrite, errs []error) error {
+	ids := bson.A{}
+	for i, w := range writes {
+		if errs[i] == nil && w.op != user_models.BulkOpCreate {
+			ids = append(ids, w.after.ID)
+		}
+	}
+	cursor, err := userCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"_id": 1, "updated_at": 1, "version": 1}))
+	if err != nil {
+		return err
+	}
+	var stored []user_models.User
+	if err := cursor.All(ctx, &stored); err != nil {
+		return err
+	}
+	current := map[primitive.ObjectID]user_models.User{}
+	for _, user := range stored {
+		current[user.ID] = user
+	}
+
+	for i, w := range writes {
+		if errs[i] != nil || w.op == user_models.BulkOpCreate {
+			continue
+		}
+		user := current[w.after.ID]
+		if user.Version != w.after.Version || !user.UpdatedAt.Equal(w.after.UpdatedAt.Truncate(time.Millisecond)) {
+			errs[i] = ErrConcurrentModification
+		}
+	}
+	return nil
+}
+
+func hasBulkItemError(errs []error) bool {
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, ErrBulkNotExecuted) {
+			return true
+		}
+	}
+	return false
+}
+
+func fillBulkErrors(errs []error, err error) {
+	for i := range errs {
+		errs[i] = err
+	}
+}
+
+// failBulkItem 記錄操作失敗的狀態碼與本地化訊息
+func failBulkItem(c *gin.Context, result *user_models.BulkItemResult, err error) {
+	result.Status = BulkErrorStatus(err)
+	result.Error = localizeError(c, err)
+}
+
+// finishBulkItem 記錄成功的操作並寫入稽核紀錄
+func finishBulkItem(c *gin.Context, baseURL string, w bulkWrite, result *user_models.BulkItemResult) {
+	user := w.after
+	result.ID = user.ID.Hex()
+	switch w.op {
+	case user_models.BulkOpCreate:
+		result.Status = http.StatusCreated
+		recordUserChange(c, user.CreatedBy, user_models.AuditUserCreate, user.ID, nil, &user)
+	case user_models.BulkOpUpdate:
+		result.Status = http.StatusOK
+		recordUserChange(c, user.UpdatedBy, user_models.AuditUserUpdate, user.ID, w.before, &user)
+	default:
+		result.Status = http.StatusOK
+		recordTransition(c, user.UpdatedBy, user)
+	}
+
+	view := user_models.NewUserView(user)
+	result.Data = &view
+	result.Links = localizeLinks(c, user_models.GenerateUserLinks(baseURL, result.ID, user.Status))
+}
@@ -0,0 +1,14 @@
+package controllers
+
+import "go-api_for_main/clock"
+
+// serverClock 控制器寫入時間戳記使用的時鐘
+var serverClock clock.Clock = clock.System{}
+
+// SetClock 替換控制器使用的時鐘，傳入 nil 時恢復為系統時間
+func SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.System{}
+	}
+	serverClock = c
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"go-api_for_main/exporter"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 匯出時每批從 Mongo 取得的文件數，以及每寫出多少筆送出一次緩衝
+const (
+	exportBatchSize  = 500
+	exportFlushEvery = 500
+)
+
+// exportProjection 只從資料庫取出選擇的欄位；密碼不在可匯出的欄位中，永遠不會被讀取
+func exportProjection(fields []string) bson.M {
+	projection := bson.M{}
+	for _, field := range fields {
+		if field == "id" {
+			field = "_id"
+		}
+		projection[field] = 1
+	}
+	return projection
+}
+
+// ExportUsers godoc
+// @Summary 匯出用戶
+// @Description 以串流方式匯出符合條件的所有用戶，篩選條件與用戶列表相同。fields 以逗號分隔選擇欄位，未提供時匯出所有欄位；密碼永遠不會被匯出。vCard 只包含可對應到 vCard 屬性的欄位。大量資料請改用 POST /jobs/exports 在背景執行
+// @Tags users
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,text/vcard
+// @Security BearerAuth
+// @Param format query string false "匯出格式" Enums(csv, ndjson, xlsx, vcf) default(csv)
+// @Param fields query string false "要匯出的欄位，例如 name,email,phone"
+// @Param status query string false "只匯出指定狀態的用戶" Enums(pending_verification, active, suspended, locked, deactivated)
+// @Success 200 {file} file
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /users/export [get]
+func ExportUsers(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	format, fields, ok := parseExportQuery(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	cursor, err := findExportUsers(ctx, usersFilter(c), fields)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer cursor.Close(ctx)
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+exportFilename(format)+`"`)
+	c.Status(http.StatusOK)
+
+	// 標頭送出後無法再回傳錯誤狀態，中途失敗只記錄日誌並中斷輸出
+	w, err := exporter.NewWriter(format, c.Writer, fields)
+	if err != nil {
+		log.Printf("Error starting %s export: %v\n", format, err)
+		return
+	}
+	_, err = writeExport(ctx, cursor, w, func(int) error {
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error writing %s export: %v\n", format, err)
+	}
+}
+
+// parseExportQuery 解析匯出格式與欄位選擇，失敗時已回傳錯誤響應
+func parseExportQuery(c *gin.Context) (exporter.Format, []string, bool) {
+	format, ok := exporter.ParseFormat(c.DefaultQuery("format", string(exporter.CSV)))
+	if !ok {
+		RespondWithAPIError(c, http.StatusBadRequest, "format must be one of csv, ndjson, xlsx, vcf")
+		return "", nil, false
+	}
+	fields, err := exporter.ParseFields(c.Query("fields"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, err.Error())
+		return "", nil, false
+	}
+	return format, fields, true
+}
+
+// exportFilename 匯出檔案的下載名稱
+func exportFilename(format exporter.Format) string {
+	return "users." + string(format)
+}
+
+// findExportUsers 依 _id 順序分批讀取要匯出的用戶
+func findExportUsers(ctx context.Context, filter bson.M, fields []string) (*mongo.Cursor, error) {
+	return userCollection.Find(ctx, filter, options.Find().
+		SetProjection(exportProjection(fields)).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetBatchSize(exportBatchSize))
+}
+
+// writeExport 將游標中的用戶逐筆寫出並在結束時關閉 Writer，回傳寫出的筆數；
+// 每寫出 exportFlushEvery 筆送出緩衝並呼叫 flushed
+func writeExport(ctx context.Context, cursor *mongo.Cursor, w exporter.Writer, flushed func(written int) error) (int, error) {
+	written := 0
+	for cursor.Next(ctx) {
+		var user user_models.User
+		if err := cursor.Decode(&user); err != nil {
+			return written, err
+		}
+		if err := w.Write(user_models.NewUserView(user)); err != nil {
+			return written, err
+		}
+		if written++; written%exportFlushEvery == 0 {
+			if err := w.Flush(); err != nil {
+				return written, err
+			}
+			if err := flushed(written); err != nil {
+				return written, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return written, err
+	}
+	return written, w.Close()
+}
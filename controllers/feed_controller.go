@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go-api_for_main/feed"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/websocket"
+)
+
+// 即時事件流的設定
+const (
+	feedHistorySize  = 1000             // 行程內匯流排保留供續傳的事件數
+	feedHeartbeat    = 15 * time.Second // 沒有事件時送出心跳的間隔，避免代理伺服器關閉閒置連線
+	feedRetryMillis  = 3000             // 建議 EventSource 斷線後重新連線前等待的毫秒數
+	feedResetEvent   = "reset"          // 無法續傳時送出的事件，用戶端應重新載入資料
+	feedKeepAliveMsg = "keep-alive"     // WebSocket 的心跳訊息類型
+)
+
+var (
+	feedBus              = feed.NewBus(feedHistorySize)
+	userFeed feed.Source = feedBus
+)
+
+// SetupFeedController 選擇即時事件流的來源：replica set 使用 change stream，單機部署使用行程內的匯流排。
+// 必須在 SetupWebhookController 之前呼叫，轉送程序依此決定是否發布到匯流排
+func SetupFeedController(db *mongo.Database) {
+	if db == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if feed.SupportsChangeStreams(ctx, db) {
+		userFeed = feed.NewChangeStream(db.Collection(webhooks.EventsCollection))
+		log.Println("User change feed is backed by MongoDB change streams")
+		return
+	}
+	log.Println("MongoDB is not a replica set, user change feed uses the in-process event bus")
+}
+
+// SetUserFeed 替換即時事件流的來源，傳入 nil 時恢復為行程內的匯流排
+func SetUserFeed(source feed.Source) {
+	if source == nil {
+		source = feedBus
+	}
+	userFeed = source
+}
+
+// publishFeedEvent 轉送程序第一次轉送事件時呼叫，使用行程內匯流排時發布事件
+func publishFeedEvent(userID primitive.ObjectID, event user_models.OutboxEvent) {
+	if bus, ok := userFeed.(*feed.Bus); ok {
+		bus.Publish(feed.Event{
+			Type:       event.Type,
+			UserID:     userID.Hex(),
+			OccurredAt: event.OccurredAt,
+			Data:       json.RawMessage(event.Payload),
+		})
+	}
+}
+
+// queryList 取得可重複且以逗號分隔的查詢參數
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// parseFeedFilter 由 type 與 user_id 查詢參數建立訂閱條件
+func parseFeedFilter(c *gin.Context) (feed.Filter, string, bool) {
+	filter := feed.Filter{Types: queryList(c, "type"), UserIDs: queryList(c, "user_id")}
+	message, ok := validateFeedFilter(filter)
+	return filter, message, ok
+}
+
+// validateFeedFilter 檢查事件類型與用戶ID，不符時回傳待翻譯的錯誤訊息
+func validateFeedFilter(filter feed.Filter) (string, bool) {
+	for _, t := range filter.Types {
+		if !slices.Contains(user_models.WebhookEventTypes, t) {
+			return "type must be one of user.created, user.updated, user.status_changed, user.deleted", false
+		}
+	}
+	for _, id := range filter.UserIDs {
+		if _, err := primitive.ObjectIDFromHex(id); err != nil {
+			return "Invalid ID", false
+		}
+	}
+	return "", true
+}
+
+// lastEventID EventSource 重新連線時以 Last-Event-ID 標頭帶回最後的事件ID，第一次連線可用查詢參數指定
+func lastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
+
+// isWebSocketUpgrade 判斷請求是否要求升級為 WebSocket
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// StreamUsers godoc
+// @Summary 即時用戶變更事件流
+// @Description 以 Server-Sent Events 推送用戶事件（user.created、user.updated、user.status_changed、user.deleted），
+// @Description 每個事件的 id 可作為重新連線時的 Last-Event-ID 以補上中斷期間的事件；無法續傳時先送出 reset 事件。
+// @Description 以 Upgrade: websocket 連線時改以 WebSocket 傳送，每則訊息為含 id、type、user_id、occurred_at 與 data 的 JSON
+// @Tags users
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param type query []string false "只接收這些事件類型，可重複或以逗號分隔" collectionFormat(multi)
+// @Param user_id query []string false "只接收這些用戶的事件，可重複或以逗號分隔" collectionFormat(multi)
+// @Param last_event_id query string false "從這個事件之後開始，Last-Event-ID 標頭優先"
+// @Param Last-Event-ID header string false "最後收到的事件ID"
+// @Success 200 {string} string "事件流"
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /users/stream [get]
+func StreamUsers(c *gin.Context) {
+	filter, message, ok := parseFeedFilter(c)
+	if !ok {
+		RespondWithAPIError(c, http.StatusBadRequest, message)
+		return
+	}
+	if isWebSocketUpgrade(c.Request) {
+		streamWebSocket(c, filter, lastEventID(c))
+		return
+	}
+
+	ctx := c.Request.Context()
+	sub, err := userFeed.Subscribe(ctx, lastEventID(c), filter)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // 避免 nginx 緩衝事件
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", feedRetryMillis)
+	if sub.Reset {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", feedResetEvent)
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(feedHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, open := <-sub.Events:
+			if !open {
+				return
+			}
+			writeServerSentEvent(w, event)
+		}
+		w.Flush()
+	}
+}
+
+// writeServerSentEvent 以 SSE 格式寫入事件，內容中的每一行各自加上 data 前綴
+func writeServerSentEvent(w io.Writer, event feed.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\n", event.ID, event.Type)
+	for _, line := range strings.Split(string(event.Data), "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+// streamWebSocket 以 WebSocket 傳送事件；用戶端不需要傳送訊息，讀取只用於偵測連線關閉
+func streamWebSocket(c *gin.Context, filter feed.Filter, lastID string) {
+	server := websocket.Server{
+		// API 不以 cookie 驗證身分，跨來源的頁面無法冒用，因此與其他路由一樣不限制 Origin
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+			go func() {
+				io.Copy(io.Discard, ws)
+				cancel()
+			}()
+
+			sub, err := userFeed.Subscribe(ctx, lastID, filter)
+			if err != nil {
+				log.Printf("Error subscribing to user change feed: %v\n", err)
+				return
+			}
+			if sub.Reset {
+				if err := websocket.JSON.Send(ws, feed.Event{Type: feedResetEvent}); err != nil {
+					return
+				}
+			}
+
+			heartbeat := time.NewTicker(feedHeartbeat)
+			defer heartbeat.Stop()
+			for {
+				var message feed.Event
+				select {
+				case <-ctx.Done():
+					return
+				case <-heartbeat.C:
+					message = feed.Event{Type: feedKeepAliveMsg}
+				case event, open := <-sub.Events:
+					if !open {
+						return
+					}
+					message = event
+				}
+				if err := websocket.JSON.Send(ws, message); err != nil {
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,781 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-api_for_main/config"
+	"go-api_for_main/graphql"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GraphQL 錯誤的 extensions.code
+const (
+	gqlUnavailable     = "UNAVAILABLE"
+	gqlUnauthenticated = "UNAUTHENTICATED"
+	gqlForbidden       = "FORBIDDEN"
+	gqlBadUserInput    = "BAD_USER_INPUT"
+	gqlNotFound        = "NOT_FOUND"
+	gqlConflict        = "CONFLICT"
+	gqlInternalError   = "INTERNAL_SERVER_ERROR"
+)
+
+// users 查詢每頁的預設與最大筆數
+const (
+	graphQLDefaultFirst = 20
+	graphQLMaxFirst     = 100
+)
+
+var graphQLConfig = config.GraphQLConfig{MaxDepth: 10, MaxComplexity: 1000}
+
+// graphQLSchema 查詢與變更直接呼叫 REST 控制器使用的服務函式
+var graphQLSchema = newGraphQLSchema()
+
+// SetupGraphQLController 初始化 GraphQL 端點的查詢限制
+func SetupGraphQLController(cfg config.GraphQLConfig) {
+	graphQLConfig = cfg
+}
+
+// graphQLRequest 單一請求內 resolver 共用的狀態，用戶 Loader 的快取只在請求內有效
+type graphQLRequest struct {
+	c     *gin.Context
+	users *graphql.Loader[primitive.ObjectID, user_models.User]
+	admin *bool // 主體是否為管理員，第一次檢查後快取
+}
+
+type graphQLRequestKey struct{}
+
+func newGraphQLRequest(c *gin.Context) *graphQLRequest {
+	return &graphQLRequest{c: c, users: graphql.NewLoader(loadUsersByID)}
+}
+
+func requestFrom(ctx context.Context) *graphQLRequest {
+	return ctx.Value(graphQLRequestKey{}).(*graphQLRequest)
+}
+
+// loadUsersByID 以一次查詢載入多位未刪除的用戶
+func loadUsersByID(ids []primitive.ObjectID) (map[primitive.ObjectID]user_models.User, error) {
+	ctx := context.Background()
+	cursor, err := userCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "status": notDeletedFilter()})
+	if err != nil {
+		return nil, err
+	}
+	var users []user_models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	found := make(map[primitive.ObjectID]user_models.User, len(users))
+	for _, user := range users {
+		found[user.ID] = user
+	}
+	return found, nil
+}
+
+// gqlError 將服務函式的錯誤轉換為帶有 extensions.code 的 GraphQL 錯誤，訊息依請求語言翻譯
+func (r *graphQLRequest) gqlError(err error) error {
+	switch {
+	case errors.Is(err, ErrMongoDBNotConnected):
+		return graphql.NewError(gqlUnavailable, tr(r.c, "Database service is currently unavailable"))
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrInvalidTransition):
+		return graphql.NewError(gqlNotFound, tr(r.c, "User not found"))
+	case errors.Is(err, errEmailInUse), errors.Is(err, ErrConcurrentModification):
+		return graphql.NewError(gqlConflict, localizeError(r.c, err))
+	case mongo.IsDuplicateKeyError(err):
+		return graphql.NewError(gqlConflict, localizeError(r.c, errEmailInUse))
+	}
+	return graphql.NewError(gqlInternalError, err.Error())
+}
+
+// requireAdmin 要求請求帶有管理員的存取權杖，與 gRPC 的 AuthorizeGRPC 相同
+func (r *graphQLRequest) requireAdmin() error {
+	return r.authorize(primitive.NilObjectID, ErrAdminRequired)
+}
+
+// requireOwnerOrAdmin 要求請求的主體是 id 指定的用戶本人或管理員
+func (r *graphQLRequest) requireOwnerOrAdmin(id primitive.ObjectID) error {
+	return r.authorize(id, ErrOwnerOrAdminRequired)
+}
+
+func (r *graphQLRequest) authorize(owner primitive.ObjectID, denied error) error {
+	claims, ok := middleware.CurrentPrincipal(r.c)
+	if !ok {
+		return graphql.NewError(gqlUnauthenticated, tr(r.c, "authentication is required"))
+	}
+	if !owner.IsZero() && claims.Subject == owner.Hex() {
+		return nil
+	}
+	if r.admin == nil {
+		admin, err := isAdmin(r.c.Request.Context(), claims.Subject)
+		if err != nil {
+			return r.gqlError(err)
+		}
+		r.admin = &admin
+	}
+	if !*r.admin {
+		return graphql.NewError(gqlForbidden, localizeError(r.c, denied))
+	}
+	return nil
+}
+
+// badInput 參數驗證失敗，欄位錯誤放在 extensions.errors
+func (r *graphQLRequest) badInput(err error) error {
+	message, fieldErrors := bindingErrors(r.c, err)
+	gqlErr := graphql.NewError(gqlBadUserInput, message)
+	if len(fieldErrors) > 0 {
+		gqlErr.Extensions["errors"] = fieldErrors
+	}
+	return gqlErr
+}
+
+func (r *graphQLRequest) invalid(message string) error {
+	return graphql.NewError(gqlBadUserInput, tr(r.c, message))
+}
+
+// objectID 解析 ID 參數
+func (r *graphQLRequest) objectID(value interface{}) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(value.(string))
+	if err != nil {
+		return id, r.invalid("Invalid ID")
+	}
+	return id, nil
+}
+
+// decodeInput 將輸入物件轉為請求結構並以 REST 端點相同的規則正規化與驗證
+func (r *graphQLRequest) decodeInput(input interface{}, dest interface{}) error {
+	data, err := json.Marshal(input)
+	if err == nil {
+		err = json.Unmarshal(data, dest)
+	}
+	if err == nil {
+		err = binding.Validator.ValidateStruct(dest)
+	}
+	if err != nil {
+		return r.badInput(err)
+	}
+	return nil
+}
+
+// userConnection users 查詢的結果，多取一筆以判斷是否還有下一頁
+type userConnection struct {
+	filter  bson.M
+	offset  int
+	users   []user_models.User
+	hasNext bool
+}
+
+type userEdge struct {
+	cursor string
+	node   user_models.User
+}
+
+func (conn *userConnection) edges() []userEdge {
+	edges := make([]userEdge, len(conn.users))
+	for i, user := range conn.users {
+		edges[i] = userEdge{cursor: encodeCursor(conn.offset + i), node: user}
+	}
+	return edges
+}
+
+// usersGraphQLFilter 將 UserFilter 轉換為查詢條件，已刪除的用戶一律排除
+func (r *graphQLRequest) usersGraphQLFilter(input interface{}) (bson.M, error) {
+	args, _ := input.(map[string]interface{})
+	status, _ := args["status"].(user_models.UserStatus)
+	if status == user_models.StatusDeleted {
+		return nil, r.invalid("Invalid status")
+	}
+	name, _ := args["name"].(string)
+	email, _ := args["email"].(string)
+	role, _ := args["role"].(string)
+	return userSearchFilter(status, name, email, role), nil
+}
+
+// usersGraphQLSort 依序套用排序條件
+func usersGraphQLSort(input interface{}) bson.D {
+	var sort bson.D
+	items, _ := input.([]interface{})
+	for _, item := range items {
+		spec := item.(map[string]interface{})
+		sort = append(sort, bson.E{Key: spec["field"].(string), Value: spec["direction"].(int)})
+	}
+	return sort
+}
+
+func (r *graphQLRequest) resolveUsers(p graphql.ResolveParams) (interface{}, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, err
+	}
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, r.gqlError(err)
+	}
+	first := p.Args["first"].(int)
+	if first < 0 || first > graphQLMaxFirst {
+		return nil, r.invalid("first must be between 0 and 100")
+	}
+	offset := 0
+	if after, ok := p.Args["after"].(string); ok {
+		position, valid := decodeCursor(after)
+		if !valid {
+			return nil, r.invalid("Invalid cursor")
+		}
+		offset = position + 1
+	}
+	filter, err := r.usersGraphQLFilter(p.Args["filter"])
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &userConnection{filter: filter, offset: offset, users: []user_models.User{}}
+	if first == 0 {
+		return conn, nil
+	}
+	conn.users, conn.hasNext, err = findUserPage(p.Context, filter, usersGraphQLSort(p.Args["sort"]), offset, first)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	// 列表中的用戶放入 Loader，同一請求中以 user(id) 或 creator 查詢時不必再讀取
+	for _, user := range conn.users {
+		r.users.Prime(user.ID, user)
+	}
+	return conn, nil
+}
+
+func (r *graphQLRequest) resolveUser(p graphql.ResolveParams) (interface{}, error) {
+	id, err := r.objectID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	if err := r.requireOwnerOrAdmin(id); err != nil {
+		return nil, err
+	}
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, r.gqlError(err)
+	}
+	return r.loadUser(id), nil
+}
+
+// loadUser 透過 Loader 載入用戶，同一層的多個請求合併為一次查詢
+func (r *graphQLRequest) loadUser(id primitive.ObjectID) graphql.Thunk {
+	thunk := r.users.Load(id)
+	return func() (interface{}, error) {
+		user, err := thunk()
+		if err != nil {
+			return nil, r.gqlError(err)
+		}
+		return user, nil
+	}
+}
+
+// resolveActor 建立者與修改者為用戶 ID 時載入該用戶，其他身分（如 anonymous）回傳 null；
+// 非管理員只能看到自己
+func (r *graphQLRequest) resolveActor(actor string) (interface{}, error) {
+	id, err := primitive.ObjectIDFromHex(actor)
+	if err != nil || userCollection == nil || r.requireOwnerOrAdmin(id) != nil {
+		return nil, nil
+	}
+	return r.loadUser(id), nil
+}
+
+func (r *graphQLRequest) createUser(p graphql.ResolveParams) (interface{}, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, err
+	}
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, r.gqlError(err)
+	}
+	var req user_models.AdminCreateUserRequest
+	if err := r.decodeInput(p.Args["input"], &req); err != nil {
+		return nil, err
+	}
+	user, err := insertUser(p.Context, req, currentActor(r.c))
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	recordUserChange(r.c, user.CreatedBy, user_models.AuditUserCreate, user.ID, nil, &user)
+	return user, nil
+}
+
+func (r *graphQLRequest) updateUser(p graphql.ResolveParams) (interface{}, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, err
+	}
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, r.gqlError(err)
+	}
+	id, err := r.objectID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	var req user_models.PatchUserRequest
+	if err := r.decodeInput(p.Args["input"], &req); err != nil {
+		return nil, err
+	}
+	original, err := findActiveUser(p.Context, id)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	updated := original
+	req.Apply(&updated)
+	saved, err := saveProfile(p.Context, original, updated, currentActor(r.c))
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	recordUserChange(r.c, saved.UpdatedBy, user_models.AuditUserUpdate, saved.ID, &original, &saved)
+	return saved, nil
+}
+
+func (r *graphQLRequest) deleteUser(p graphql.ResolveParams) (interface{}, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, err
+	}
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, r.gqlError(err)
+	}
+	id, err := r.objectID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	action, _ := user_models.FindLifecycleAction("delete")
+	deleted, err := applyTransition(p.Context, id, action, "", currentActor(r.c))
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	recordTransition(r.c, deleted.UpdatedBy, deleted)
+	return true, nil
+}
+
+// withRequest 將 resolver 綁定到請求狀態
+func withRequest(resolve func(r *graphQLRequest, p graphql.ResolveParams) (interface{}, error)) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return resolve(requestFrom(p.Context), p)
+	}
+}
+
+// userField 由 user_models.User 取值的欄位
+func userField(name, description string, t graphql.Type, get func(u user_models.User) interface{}) *graphql.FieldDefinition {
+	return &graphql.FieldDefinition{
+		Name:        name,
+		Description: description,
+		Type:        t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(user_models.User)), nil
+		},
+	}
+}
+
+// newGraphQLSchema 建立用戶的查詢與變更結構描述
+func newGraphQLSchema() *graphql.Schema {
+	nonNull := func(t graphql.Type) graphql.Type { return &graphql.NonNull{OfType: t} }
+
+	dateTime := &graphql.Scalar{
+		Name:        "DateTime",
+		Description: "RFC 3339 格式的 UTC 時間",
+		Serialize: func(value interface{}) (interface{}, error) {
+			t, ok := value.(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("DateTime cannot represent value: %v", value)
+			}
+			return t.UTC().Format(time.RFC3339Nano), nil
+		},
+		ParseValue: func(value interface{}) (interface{}, error) {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("DateTime cannot represent a non string value: %v", value)
+			}
+			return time.Parse(time.RFC3339Nano, s)
+		},
+		ParseLiteral: func(v *graphql.Value) (interface{}, error) {
+			if v.Kind != graphql.StringValue {
+				return nil, fmt.Errorf("DateTime cannot represent a non string value")
+			}
+			return time.Parse(time.RFC3339Nano, v.Raw)
+		},
+	}
+
+	userStatus := &graphql.Enum{Name: "UserStatus", Description: "用戶的生命週期狀態"}
+	for _, status := range []user_models.UserStatus{
+		user_models.StatusPendingVerification,
+		user_models.StatusActive,
+		user_models.StatusSuspended,
+		user_models.StatusLocked,
+		user_models.StatusDeactivated,
+		user_models.StatusDeleted,
+	} {
+		userStatus.Values = append(userStatus.Values, &graphql.EnumValueDefinition{Name: strings.ToUpper(string(status)), Value: status})
+	}
+
+	sortField := &graphql.Enum{
+		Name:        "UserSortField",
+		Description: "用戶列表可排序的欄位",
+		Values: []*graphql.EnumValueDefinition{
+			{Name: "NAME", Value: "name"},
+			{Name: "EMAIL", Value: "email"},
+			{Name: "AGE", Value: "age"},
+			{Name: "CREATED_AT", Value: "created_at"},
+			{Name: "UPDATED_AT", Value: "updated_at"},
+		},
+	}
+	sortDirection := &graphql.Enum{
+		Name:        "SortDirection",
+		Description: "排序方向",
+		Values: []*graphql.EnumValueDefinition{
+			{Name: "ASC", Value: 1},
+			{Name: "DESC", Value: -1},
+		},
+	}
+	userSort := &graphql.InputObject{
+		Name:        "UserSort",
+		Description: "排序條件，多個條件依序套用",
+		Fields: []*graphql.InputValue{
+			{Name: "field", Type: nonNull(sortField)},
+			{Name: "direction", Type: sortDirection, Default: 1, HasDefault: true},
+		},
+	}
+	userFilter := &graphql.InputObject{
+		Name:        "UserFilter",
+		Description: "用戶列表的篩選條件，多個條件須同時符合",
+		Fields: []*graphql.InputValue{
+			{Name: "status", Description: "只列出指定狀態的用戶", Type: userStatus},
+			{Name: "name", Description: "姓名包含此字串，不分大小寫", Type: graphql.String},
+			{Name: "email", Description: "email 完全相符，不分大小寫", Type: graphql.String},
+			{Name: "role", Description: "只列出指定角色的用戶", Type: graphql.String},
+		},
+	}
+	createInput := &graphql.InputObject{
+		Name:        "CreateUserInput",
+		Description: "建立用戶的資料，驗證規則與 REST 端點相同",
+		Fields: []*graphql.InputValue{
+			{Name: "name", Type: nonNull(graphql.String)},
+			{Name: "email", Type: nonNull(graphql.String)},
+			{Name: "password", Type: nonNull(graphql.String)},
+			{Name: "sex", Type: nonNull(graphql.String)},
+			{Name: "age", Type: nonNull(graphql.Int)},
+			{Name: "phone", Type: nonNull(graphql.String)},
+			{Name: "address", Type: nonNull(graphql.String)},
+			{Name: "role", Description: "admin 或 member，預設為 member", Type: graphql.String},
+		},
+	}
+	updateInput := &graphql.InputObject{
+		Name:        "UpdateUserInput",
+		Description: "要更新的個人資料欄位，未提供的欄位保持不變",
+		Fields: []*graphql.InputValue{
+			{Name: "name", Type: graphql.String},
+			{Name: "email", Type: graphql.String},
+			{Name: "sex", Type: graphql.String},
+			{Name: "age", Type: graphql.Int},
+			{Name: "phone", Type: graphql.String},
+			{Name: "address", Type: graphql.String},
+		},
+	}
+
+	statusTransition := &graphql.Object{
+		Name:        "StatusTransition",
+		Description: "一次狀態變更",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "from", Type: userStatus, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(user_models.StatusTransition).From.Effective(), nil
+			}},
+			{Name: "to", Type: nonNull(userStatus), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(user_models.StatusTransition).To, nil
+			}},
+			{Name: "action", Type: nonNull(graphql.String)},
+			{Name: "reason", Type: graphql.String},
+			{Name: "actor", Type: nonNull(graphql.String)},
+			{Name: "at", Type: nonNull(dateTime)},
+		},
+	}
+
+	user := &graphql.Object{Name: "User", Description: "用戶資料，不包含密碼"}
+	user.Fields = []*graphql.FieldDefinition{
+		userField("id", "", nonNull(graphql.ID), func(u user_models.User) interface{} { return u.ID.Hex() }),
+		userField("name", "", nonNull(graphql.String), func(u user_models.User) interface{} { return u.Name }),
+		userField("email", "", nonNull(graphql.String), func(u user_models.User) interface{} { return u.Email }),
+		userField("sex", "", nonNull(graphql.String), func(u user_models.User) interface{} { return u.Sex }),
+		userField("age", "", nonNull(graphql.Int), func(u user_models.User) interface{} { return u.Age }),
+		userField("phone", "", nonNull(graphql.String), func(u user_models.User) interface{} { return u.Phone }),
+		userField("address", "", nonNull(graphql.String), func(u user_models.User) interface{} { return u.Address }),
+		userField("role", "", graphql.String, func(u user_models.User) interface{} {
+			if u.Role == "" {
+				return nil
+			}
+			return u.Role
+		}),
+		userField("status", "", nonNull(userStatus), func(u user_models.User) interface{} { return u.Status.Effective() }),
+		userField("version", "每次修改遞增", nonNull(graphql.Int), func(u user_models.User) interface{} { return u.Version }),
+		userField("createdAt", "", nonNull(dateTime), func(u user_models.User) interface{} { return u.CreatedAt }),
+		userField("updatedAt", "", nonNull(dateTime), func(u user_models.User) interface{} { return u.UpdatedAt }),
+		userField("createdBy", "建立者的身分識別", graphql.String, func(u user_models.User) interface{} { return nilIfEmpty(u.CreatedBy) }),
+		userField("updatedBy", "最後修改者的身分識別", graphql.String, func(u user_models.User) interface{} { return nilIfEmpty(u.UpdatedBy) }),
+		{
+			Name:        "creator",
+			Description: "建立此用戶的用戶，由其他身分建立或已刪除時為 null",
+			Type:        user,
+			Resolve: withRequest(func(r *graphQLRequest, p graphql.ResolveParams) (interface{}, error) {
+				return r.resolveActor(p.Source.(user_models.User).CreatedBy)
+			}),
+		},
+		{
+			Name:        "updater",
+			Description: "最後修改此用戶的用戶，由其他身分修改或已刪除時為 null",
+			Type:        user,
+			Resolve: withRequest(func(r *graphQLRequest, p graphql.ResolveParams) (interface{}, error) {
+				return r.resolveActor(p.Source.(user_models.User).UpdatedBy)
+			}),
+		},
+		userField("statusHistory", "狀態變更歷史，由舊到新", nonNull(&graphql.List{OfType: nonNull(statusTransition)}), func(u user_models.User) interface{} {
+			if u.StatusHistory == nil {
+				return []user_models.StatusTransition{}
+			}
+			return u.StatusHistory
+		}),
+	}
+
+	edge := &graphql.Object{
+		Name: "UserEdge",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "cursor", Type: nonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(userEdge).cursor, nil
+			}},
+			{Name: "node", Type: nonNull(user), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(userEdge).node, nil
+			}},
+		},
+	}
+	pageInfo := &graphql.Object{
+		Name: "PageInfo",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "hasNextPage", Type: nonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*userConnection).hasNext, nil
+			}},
+			{Name: "hasPreviousPage", Type: nonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*userConnection).offset > 0, nil
+			}},
+			{Name: "startCursor", Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				conn := p.Source.(*userConnection)
+				if len(conn.users) == 0 {
+					return nil, nil
+				}
+				return encodeCursor(conn.offset), nil
+			}},
+			{Name: "endCursor", Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				conn := p.Source.(*userConnection)
+				if len(conn.users) == 0 {
+					return nil, nil
+				}
+				return encodeCursor(conn.offset + len(conn.users) - 1), nil
+			}},
+		},
+	}
+	connection := &graphql.Object{
+		Name:        "UserConnection",
+		Description: "Relay 風格的用戶分頁結果",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "edges", Type: nonNull(&graphql.List{OfType: nonNull(edge)}), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*userConnection).edges(), nil
+			}},
+			{Name: "nodes", Type: nonNull(&graphql.List{OfType: nonNull(user)}), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*userConnection).users, nil
+			}},
+			{Name: "pageInfo", Type: nonNull(pageInfo), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source, nil
+			}},
+			{
+				Name:        "totalCount",
+				Description: "符合篩選條件的用戶總數，只在查詢此欄位時計算",
+				Type:        nonNull(graphql.Int),
+				Resolve: withRequest(func(r *graphQLRequest, p graphql.ResolveParams) (interface{}, error) {
+					total, err := userCollection.CountDocuments(p.Context, p.Source.(*userConnection).filter)
+					if err != nil {
+						return nil, r.gqlError(err)
+					}
+					return total, nil
+				}),
+			},
+		},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.FieldDefinition{
+			{
+				Name:        "user",
+				Description: "以 ID 取得未刪除的用戶，只限本人或管理員，找不到時為 null",
+				Type:        user,
+				Args:        []*graphql.InputValue{{Name: "id", Type: nonNull(graphql.ID)}},
+				Resolve:     withRequest((*graphQLRequest).resolveUser),
+			},
+			{
+				Name:        "users",
+				Description: "分頁列出未刪除的用戶，只限管理員，first 最多為 100",
+				Type:        nonNull(connection),
+				Args: []*graphql.InputValue{
+					{Name: "filter", Type: userFilter},
+					{Name: "sort", Type: &graphql.List{OfType: nonNull(userSort)}},
+					{Name: "first", Type: graphql.Int, Default: graphQLDefaultFirst, HasDefault: true},
+					{Name: "after", Description: "從此游標之後開始", Type: graphql.String},
+				},
+				Resolve: withRequest((*graphQLRequest).resolveUsers),
+				// 列表欄位的複雜度依請求筆數加權
+				Complexity: func(child int, args map[string]interface{}) int {
+					first, ok := args["first"].(int)
+					if !ok || first < 1 {
+						first = 1
+					}
+					return 1 + child*first
+				},
+			},
+		},
+	}
+
+	mutation := &graphql.Object{
+		Name: "Mutation",
+		Fields: []*graphql.FieldDefinition{
+			{
+				Name:        "createUser",
+				Description: "建立 active 狀態的用戶，只限管理員",
+				Type:        nonNull(user),
+				Args:        []*graphql.InputValue{{Name: "input", Type: nonNull(createInput)}},
+				Resolve:     withRequest((*graphQLRequest).createUser),
+			},
+			{
+				Name:        "updateUser",
+				Description: "部分更新用戶的個人資料，只限管理員",
+				Type:        nonNull(user),
+				Args: []*graphql.InputValue{
+					{Name: "id", Type: nonNull(graphql.ID)},
+					{Name: "input", Type: nonNull(updateInput)},
+				},
+				Resolve: withRequest((*graphQLRequest).updateUser),
+			},
+			{
+				Name:        "deleteUser",
+				Description: "將用戶轉為 deleted 狀態，只限管理員",
+				Type:        nonNull(graphql.Boolean),
+				Args:        []*graphql.InputValue{{Name: "id", Type: nonNull(graphql.ID)}},
+				Resolve:     withRequest((*graphQLRequest).deleteUser),
+			},
+		},
+	}
+
+	schema, err := graphql.NewSchema(query, mutation)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func nilIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// graphQLBody POST 請求的內容，GET 請求以同名的查詢參數提供
+type graphQLBody struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQLError 執行前的錯誤以 GraphQL 的格式回應
+func graphQLError(c *gin.Context, status int, message string) {
+	c.JSON(status, gin.H{"errors": []*graphql.Error{{Message: tr(c, message)}}})
+}
+
+// decodeJSON 以 json.Number 保留數字，整數參數不會先轉成浮點數
+func decodeJSON(data string, dest interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(dest)
+}
+
+// GraphQL 執行 GraphQL 查詢。GET 只允許 query 操作；debug 模式下瀏覽器開啟時顯示 GraphiQL
+func GraphQL(c *gin.Context) {
+	var body graphQLBody
+	var allow []string
+	if c.Request.Method == http.MethodGet {
+		if gin.IsDebugging() && strings.Contains(c.GetHeader("Accept"), "text/html") {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(graphiQLPage))
+			return
+		}
+		body.Query = c.Query("query")
+		body.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := decodeJSON(variables, &body.Variables); err != nil {
+				graphQLError(c, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+		allow = []string{"query"}
+	} else {
+		decoder := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBodySize))
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				graphQLError(c, http.StatusRequestEntityTooLarge, errBodyTooLarge.Error())
+				return
+			}
+			graphQLError(c, http.StatusBadRequest, "Request body is invalid")
+			return
+		}
+	}
+	if strings.TrimSpace(body.Query) == "" {
+		graphQLError(c, http.StatusBadRequest, "Must provide query string")
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        graphQLSchema,
+		Query:         body.Query,
+		OperationName: body.OperationName,
+		Variables:     body.Variables,
+		Context:       context.WithValue(c.Request.Context(), graphQLRequestKey{}, newGraphQLRequest(c)),
+		MaxDepth:      graphQLConfig.MaxDepth,
+		MaxComplexity: graphQLConfig.MaxComplexity,
+		Allow:         allow,
+	})
+
+	status := http.StatusOK
+	if !result.Executed {
+		status = http.StatusBadRequest
+		if len(result.Errors) == 1 && result.Errors[0].Extensions["code"] == graphql.CodeOperationNotAllowed {
+			c.Header("Allow", "POST")
+			status = http.StatusMethodNotAllowed
+		}
+	}
+	c.JSON(status, result)
+}
+
+// graphiQLPage 以 CDN 載入的 GraphiQL，只在 debug 模式提供
+const graphiQLPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>GraphiQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+</head>
+<body style="margin: 0">
+  <div id="graphiql" style="height: 100vh"></div>
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+    ReactDOM.createRoot(document.getElementById("graphiql")).render(React.createElement(GraphiQL, { fetcher }));
+  </script>
+</body>
+</html>
+`
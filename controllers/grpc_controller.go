@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	"go-api_for_main/feed"
+	"go-api_for_main/grpcserver"
+	"go-api_for_main/i18n"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/oidc"
+	userv1 "go-api_for_main/proto/user/v1"
+
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ListUsers 每頁的預設與最大筆數
+const (
+	grpcDefaultPageSize = 20
+	grpcMaxPageSize     = 100
+)
+
+// userStatusToProto 用戶狀態與 protobuf 列舉的對應
+var userStatusToProto = map[user_models.UserStatus]userv1.UserStatus{
+	user_models.StatusPendingVerification: userv1.UserStatus_USER_STATUS_PENDING_VERIFICATION,
+	user_models.StatusActive:              userv1.UserStatus_USER_STATUS_ACTIVE,
+	user_models.StatusSuspended:           userv1.UserStatus_USER_STATUS_SUSPENDED,
+	user_models.StatusLocked:              userv1.UserStatus_USER_STATUS_LOCKED,
+	user_models.StatusDeactivated:         userv1.UserStatus_USER_STATUS_DEACTIVATED,
+	user_models.StatusDeleted:             userv1.UserStatus_USER_STATUS_DELETED,
+}
+
+// UserServiceServer 以 REST 控制器的服務函式實作 userv1.UserServiceServer
+type UserServiceServer struct {
+	userv1.UnimplementedUserServiceServer
+}
+
+// NewUserServiceServer 建立 gRPC 的用戶服務
+func NewUserServiceServer() *UserServiceServer {
+	return &UserServiceServer{}
+}
+
+// AuthorizeGRPC 只允許啟用中的管理員呼叫用戶服務，與 REST 的 RequireAdmin 相同
+func AuthorizeGRPC(ctx context.Context, claims *oidc.AccessTokenClaims) error {
+	admin, err := isAdmin(ctx, claims.Subject)
+	if err != nil {
+		return grpcError(ctx, err)
+	}
+	if !admin {
+		return status.Error(codes.PermissionDenied, localizeErrorIn(grpcserver.Language(ctx), ErrAdminRequired))
+	}
+	return nil
+}
+
+// grpcActor 回傳執行操作者的識別，未驗證的請求記錄為 anonymous
+func grpcActor(ctx context.Context) string {
+	if claims, ok := grpcserver.Principal(ctx); ok {
+		return claims.Subject
+	}
+	return "anonymous"
+}
+
+// grpcOrigin 稽核紀錄中 gRPC 請求的識別碼與用戶端位址
+func grpcOrigin(ctx context.Context) auditOrigin {
+	return auditOrigin{RequestID: grpcserver.RequestID(ctx), IP: grpcserver.ClientIP(ctx)}
+}
+
+func grpcTr(ctx context.Context, message string) string {
+	return i18n.T(grpcserver.Language(ctx), message)
+}
+
+// grpcError 將服務函式的錯誤轉換為 gRPC 狀態碼，訊息依 accept-language 翻譯
+func grpcError(ctx context.Context, err error) error {
+	lang := grpcserver.Language(ctx)
+	switch {
+	case errors.Is(err, ErrMongoDBNotConnected):
+		return status.Error(codes.Unavailable, grpcTr(ctx, "Database service is currently unavailable"))
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrInvalidTransition):
+		return status.Error(codes.NotFound, grpcTr(ctx, "User not found"))
+	case errors.Is(err, errEmailInUse):
+		return status.Error(codes.AlreadyExists, localizeErrorIn(lang, err))
+	case mongo.IsDuplicateKeyError(err):
+		return status.Error(codes.AlreadyExists, localizeErrorIn(lang, errEmailInUse))
+	case errors.Is(err, ErrConcurrentModification):
+		return status.Error(codes.Aborted, localizeErrorIn(lang, err))
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// invalidArgument 驗證失敗時以 BadRequest 細節列出每個未通過的欄位
+func invalidArgument(ctx context.Context, err error) error {
+	message, fieldErrors := bindingErrorsIn(grpcserver.Language(ctx), err)
+	st := status.New(codes.InvalidArgument, message)
+	if len(fieldErrors) == 0 {
+		return st.Err()
+	}
+	details := &errdetails.BadRequest{}
+	for _, fe := range fieldErrors {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fe.Field,
+			Description: fe.Message,
+		})
+	}
+	if withDetails, detailErr := st.WithDetails(details); detailErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func grpcInvalid(ctx context.Context, message string) error {
+	return status.Error(codes.InvalidArgument, grpcTr(ctx, message))
+}
+
+func grpcObjectID(ctx context.Context, hex string) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return id, grpcInvalid(ctx, "Invalid ID")
+	}
+	return id, nil
+}
+
+// toProtoUser 轉換為 protobuf 的用戶，不包含密碼
+func toProtoUser(u user_models.User) *userv1.User {
+	return &userv1.User{
+		Id:         u.ID.Hex(),
+		Name:       u.Name,
+		Email:      u.Email,
+		Sex:        u.Sex,
+		Age:        int32(u.Age),
+		Phone:      u.Phone,
+		Address:    u.Address,
+		Role:       u.Role,
+		Status:     userStatusToProto[u.Status.Effective()],
+		Version:    u.Version,
+		CreateTime: timestamppb.New(u.CreatedAt),
+		UpdateTime: timestamppb.New(u.UpdatedAt),
+		CreatedBy:  u.CreatedBy,
+		UpdatedBy:  u.UpdatedBy,
+	}
+}
+
+// fromProtoStatus 將列舉轉為用戶狀態，UNSPECIFIED 表示不限制
+func fromProtoStatus(s userv1.UserStatus) (user_models.UserStatus, bool) {
+	if s == userv1.UserStatus_USER_STATUS_UNSPECIFIED {
+		return "", true
+	}
+	for status, value := range userStatusToProto {
+		if value == s {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// GetUser 取得未刪除的用戶
+func (s *UserServiceServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.User, error) {
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	id, err := grpcObjectID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	user, err := findActiveUser(ctx, id)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProtoUser(user), nil
+}
+
+// ListUsers 分頁列出未刪除的用戶，分頁權杖與 GraphQL 的游標相同
+func (s *UserServiceServer) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = grpcDefaultPageSize
+	}
+	if pageSize < 1 || pageSize > grpcMaxPageSize {
+		return nil, grpcInvalid(ctx, "size must be between 1 and 100")
+	}
+	offset := 0
+	if token := req.GetPageToken(); token != "" {
+		position, ok := decodeCursor(token)
+		if !ok {
+			return nil, grpcInvalid(ctx, "Invalid cursor")
+		}
+		offset = position + 1
+	}
+	userStatus, ok := fromProtoStatus(req.GetStatus())
+	if !ok || userStatus == user_models.StatusDeleted {
+		return nil, grpcInvalid(ctx, "Invalid status")
+	}
+
+	filter := userSearchFilter(userStatus, req.GetName(), req.GetEmail(), req.GetRole())
+	users, hasNext, err := findUserPage(ctx, filter, nil, offset, pageSize)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	total, err := userCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp := &userv1.ListUsersResponse{TotalSize: total}
+	for _, user := range users {
+		resp.Users = append(resp.Users, toProtoUser(user))
+	}
+	if hasNext {
+		resp.NextPageToken = encodeCursor(offset + len(users) - 1)
+	}
+	return resp, nil
+}
+
+// CreateUser 以 REST 相同的規則驗證後建立用戶
+func (s *UserServiceServer) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.User, error) {
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	create := user_models.AdminCreateUserRequest{
+		CreateUserRequest: user_models.CreateUserRequest{
+			Name:     req.GetName(),
+			Email:    req.GetEmail(),
+			Password: req.GetPassword(),
+			Sex:      req.GetSex(),
+			Age:      int(req.GetAge()),
+			Phone:    req.GetPhone(),
+			Address:  req.GetAddress(),
+		},
+		Role: req.GetRole(),
+	}
+	if err := binding.Validator.ValidateStruct(&create); err != nil {
+		return nil, invalidArgument(ctx, err)
+	}
+	user, err := insertUser(ctx, create, grpcActor(ctx))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	recordUserChangeFrom(grpcOrigin(ctx), user.CreatedBy, user_models.AuditUserCreate, user.ID, nil, &user)
+	return toProtoUser(user), nil
+}
+
+// UpdateUser 部分更新用戶，只修改請求中有提供的欄位
+func (s *UserServiceServer) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.User, error) {
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	id, err := grpcObjectID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	patch := user_models.PatchUserRequest{
+		Name:    req.Name,
+		Email:   req.Email,
+		Sex:     req.Sex,
+		Phone:   req.Phone,
+		Address: req.Address,
+	}
+	if req.Age != nil {
+		age := int(*req.Age)
+		patch.Age = &age
+	}
+	if err := binding.Validator.ValidateStruct(&patch); err != nil {
+		return nil, invalidArgument(ctx, err)
+	}
+
+	original, err := findActiveUser(ctx, id)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	updated := original
+	patch.Apply(&updated)
+	saved, err := saveProfile(ctx, original, updated, grpcActor(ctx))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	recordUserChangeFrom(grpcOrigin(ctx), saved.UpdatedBy, user_models.AuditUserUpdate, saved.ID, &original, &saved)
+	return toProtoUser(saved), nil
+}
+
+// DeleteUser 將用戶轉為 deleted 狀態
+func (s *UserServiceServer) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*userv1.DeleteUserResponse, error) {
+	if err := checkMongoDBConnection(); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	id, err := grpcObjectID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	action, _ := user_models.FindLifecycleAction("delete")
+	deleted, err := applyTransition(ctx, id, action, "", grpcActor(ctx))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	recordTransitionFrom(grpcOrigin(ctx), deleted.UpdatedBy, deleted)
+	return &userv1.DeleteUserResponse{}, nil
+}
+
+// WatchUsers 轉送即時變更事件；事件流因跟不上而結束時回傳 UNAVAILABLE，用戶端應以最後的事件ID重新呼叫
+func (s *UserServiceServer) WatchUsers(req *userv1.WatchUsersRequest, stream userv1.UserService_WatchUsersServer) error {
+	ctx := stream.Context()
+	filter := feed.Filter{Types: req.GetTypes(), UserIDs: req.GetUserIds()}
+	if message, ok := validateFeedFilter(filter); !ok {
+		return grpcInvalid(ctx, message)
+	}
+
+	sub, err := userFeed.Subscribe(ctx, req.GetLastEventId(), filter)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if sub.Reset {
+		if err := stream.Send(&userv1.UserEvent{ResetRequired: true}); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, open := <-sub.Events:
+			if !open {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return status.Error(codes.Unavailable, "event stream ended, resume with last_event_id")
+			}
+			err := stream.Send(&userv1.UserEvent{
+				Id:        event.ID,
+				Type:      event.Type,
+				UserId:    event.UserID,
+				OccurTime: timestamppb.New(event.OccurredAt),
+				Data:      event.Data,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"go-api_for_main/formats"
+	"go-api_for_main/i18n"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tr 以目前請求的語言翻譯訊息
+func tr(c *gin.Context, message string, args ...interface{}) string {
+	return i18n.T(middleware.Language(c), message, args...)
+}
+
+// localizeLinks 翻譯連結標題，回傳新的切片不修改原本的連結
+func localizeLinks(c *gin.Context, links []user_models.HATEOASLink) []user_models.HATEOASLink {
+	return localizeLinksIn(middleware.Language(c), links)
+}
+
+// localizeLinksIn 以指定語言翻譯連結標題
+func localizeLinksIn(lang string, links []user_models.HATEOASLink) []user_models.HATEOASLink {
+	if links == nil {
+		return nil
+	}
+	localized := make([]user_models.HATEOASLink, len(links))
+	for i, link := range links {
+		link.Title = i18n.T(lang, link.Title)
+		localized[i] = link
+	}
+	return localized
+}
+
+// localizedErrors 有譯文的錯誤，依序比對以取得最明確的訊息
+var localizedErrors = []error{
+	ErrUserNotFound,
+	ErrInvalidTransition,
+	ErrReasonRequired,
+	ErrConcurrentModification,
+	ErrInactiveUser,
+	ErrSessionRevoked,
+	ErrAdminRequired,
+	ErrOwnerOrAdminRequired,
+	errEmailTaken,
+	errEmailInUse,
+	errBulkDuplicateUser,
+	ErrBulkNotExecuted,
+	errImportDuplicateRow,
+	errUnsupportedMediaType,
+	errNotAcceptable,
+	errBodyTooLarge,
+	ErrMongoDBNotConnected,
+}
+
+// localizeError 翻譯已知的錯誤，其他錯誤回傳原本的訊息
+func localizeError(c *gin.Context, err error) string {
+	return localizeErrorIn(middleware.Language(c), err)
+}
+
+// localizeErrorIn 以指定語言翻譯已知的錯誤，供沒有請求的背景工作使用
+func localizeErrorIn(lang string, err error) string {
+	for _, known := range localizedErrors {
+		if errors.Is(err, known) {
+			return i18n.T(lang, known.Error())
+		}
+	}
+	return err.Error()
+}
+
+// bindingErrors 將 bindBody 的錯誤轉為目前語言的摘要訊息與欄位錯誤清單
+func bindingErrors(c *gin.Context, err error) (string, []user_models.FieldError) {
+	return bindingErrorsIn(middleware.Language(c), err)
+}
+
+// bindingErrorsIn 以指定語言轉換驗證錯誤，供沒有請求的背景工作使用
+func bindingErrorsIn(lang string, err error) (string, []user_models.FieldError) {
+	if fieldErrors, ok := validation.Translate(err, lang); ok {
+		messages := make([]string, 0, len(fieldErrors))
+		for _, fe := range fieldErrors {
+			messages = append(messages, fe.Message)
+		}
+		return strings.Join(messages, "; "), fieldErrors
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, formats.ErrMalformed) {
+		return i18n.T(lang, "Request body is invalid"), nil
+	}
+	return localizeErrorIn(lang, err), nil
+}
+
+// bindingErrorResponse 產生舊版 ErrorResponse 格式的驗證錯誤
+func bindingErrorResponse(c *gin.Context, err error) user_models.ErrorResponse {
+	message, fieldErrors := bindingErrors(c, err)
+	return user_models.ErrorResponse{Error: message, Errors: fieldErrors}
+}
+
+// respondBindingError 回傳本地化的請求內容驗證錯誤，errors 欄位列出每個未通過驗證的欄位
+func respondBindingError(c *gin.Context, err error) {
+	status := bindingStatus(err)
+	message, fieldErrors := bindingErrors(c, err)
+	respondEncoded(c, status, user_models.APIResponse{
+		Status:  status,
+		Message: tr(c, "Request validation failed"),
+		Error:   message,
+		Errors:  fieldErrors,
+	})
+}
@@ -0,0 +1,492 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api_for_main/exporter"
+	"go-api_for_main/i18n"
+	"go-api_for_main/importer"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var importReportCollection *mongo.Collection
+
+// 匯入檔案的大小上限與錯誤報表的保存期限
+const (
+	maxImportSize    = 10 << 20
+	importReportTTL  = 7 * 24 * time.Hour
+	importReportName = "import-errors.csv"
+)
+
+// errImportDuplicateRow 表示同一個 email 在檔案中出現多次
+var errImportDuplicateRow = errors.New("email appears more than once in the file")
+
+// SetupImportController 初始化用戶匯入控制器，錯誤報表在保存期限後自動刪除
+func SetupImportController(db *mongo.Database) {
+	if db != nil {
+		importReportCollection = db.Collection("user_import_reports")
+		_, err := importReportCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(importReportTTL.Seconds())),
+		})
+		if err != nil {
+			log.Printf("Warning: creating user_import_reports index failed: %v\n", err)
+		}
+	}
+}
+
+// importRun 單次匯入的設定與過程中的狀態；背景工作沒有請求，語言與稽核來源取自建立工作的請求
+type importRun struct {
+	ctx      context.Context
+	lang     string
+	origin   auditOrigin
+	actor    string
+	policy   string
+	dryRun   bool
+	existing map[string]user_models.User
+	seen     map[string]bool
+}
+
+// importUpload 已讀取的匯入表單與檔案內容
+type importUpload struct {
+	opts     user_models.ImportOptions
+	format   importer.Format
+	filename string
+	data     []byte
+}
+
+// ImportUsers godoc
+// @Summary 匯入用戶
+// @Description 由 CSV、NDJSON 或 XLSX 檔案匯入用戶，每一列以建立用戶相同的規則驗證。mapping 為 {"檔案欄位": "用戶欄位"} 的 JSON 物件，未提供時以同名欄位對應。email 已存在時依 on_duplicate 略過、更新或視為失敗；dry_run 只回報預計執行的動作。有失敗的資料列時可由 errors 連結下載錯誤報表。大型檔案請改用 POST /jobs/imports 在背景執行
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "匯入檔案"
+// @Param format formData string false "檔案格式，未提供時依副檔名判斷" Enums(csv, ndjson, xlsx)
+// @Param mapping formData string false "欄位對應，例如 {\"E-mail\": \"email\"}"
+// @Param on_duplicate formData string false "email 已存在時的處理方式" Enums(skip, update, fail) default(fail)
+// @Param dry_run formData bool false "只檢查不寫入" default(false)
+// @Success 200 {object} user_models.ImportResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 413 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /users/import [post]
+func ImportUsers(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+	upload, ok := bindImportUpload(c)
+	if !ok {
+		return
+	}
+
+	run := newImportRun(context.Background(), middleware.Language(c), requestOrigin(c), currentActor(c), upload.opts)
+	response, reportID, err := run.importFile(upload.format, upload.data, upload.opts.Mapping, nil)
+	if err != nil {
+		if isImportInputError(err) {
+			RespondWithAPIError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Links = localizeLinks(c, user_models.GenerateImportLinks(getAPIBaseURL(c), reportID))
+	c.JSON(http.StatusOK, response)
+}
+
+// bindImportUpload 讀取匯入表單與檔案並判斷格式，失敗時已回傳錯誤響應
+func bindImportUpload(c *gin.Context) (importUpload, bool) {
+	var upload importUpload
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+1<<20)
+	if err := c.ShouldBind(&upload.opts); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			RespondWithAPIError(c, http.StatusRequestEntityTooLarge, "Import file is too large")
+			return upload, false
+		}
+		respondBindingError(c, err)
+		return upload, false
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "An import file is required in the file field")
+		return upload, false
+	}
+	if header.Size > maxImportSize {
+		RespondWithAPIError(c, http.StatusRequestEntityTooLarge, "Import file is too large")
+		return upload, false
+	}
+
+	format, ok := importer.ParseFormat(upload.opts.Format)
+	if !ok {
+		if format, ok = importer.DetectFormat(header.Filename, header.Header.Get("Content-Type")); !ok {
+			RespondWithAPIError(c, http.StatusBadRequest, importer.ErrUnknownFormat.Error())
+			return upload, false
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return upload, false
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return upload, false
+	}
+
+	upload.format = format
+	upload.filename = header.Filename
+	upload.data = data
+	return upload, true
+}
+
+func newImportRun(ctx context.Context, lang string, origin auditOrigin, actor string, opts user_models.ImportOptions) importRun {
+	return importRun{
+		ctx:    ctx,
+		lang:   lang,
+		origin: origin,
+		actor:  actor,
+		policy: opts.DuplicatePolicy(),
+		dryRun: opts.DryRun,
+		seen:   map[string]bool{},
+	}
+}
+
+// isImportInputError 判斷錯誤是否來自檔案內容或欄位對應，這類錯誤重新執行也不會成功
+func isImportInputError(err error) bool {
+	return errors.Is(err, importer.ErrInvalidFile) || errors.Is(err, importer.ErrTooManyRows) ||
+		errors.Is(err, importer.ErrInvalidMapping) || errors.Is(err, importer.ErrUnknownFormat)
+}
+
+// importFile 解析檔案並逐列匯入，回傳不含連結的結果與錯誤報表ID。
+// progress 在每一列處理後呼叫，回傳錯誤時停止匯入，已寫入的資料列不會被復原
+func (r importRun) importFile(format importer.Format, data []byte, mappingSpec string, progress func(done, total int) error) (user_models.ImportResponse, string, error) {
+	table, err := importer.Read(format, data)
+	if err != nil {
+		return user_models.ImportResponse{}, "", err
+	}
+	mapping, err := importer.ParseMapping(mappingSpec, table.Headers)
+	if err != nil {
+		return user_models.ImportResponse{}, "", err
+	}
+
+	rows := make([]map[string]string, len(table.Rows))
+	emails := make([]string, 0, len(table.Rows))
+	for i, row := range table.Rows {
+		rows[i] = mapping.Apply(row)
+		emails = append(emails, validation.NormalizeEmail(rows[i]["email"]))
+	}
+
+	if r.existing, err = loadImportTargets(r.ctx, emails); err != nil {
+		return user_models.ImportResponse{}, "", err
+	}
+
+	results := make([]user_models.ImportRowResult, len(table.Rows))
+	for i, row := range table.Rows {
+		results[i] = r.importRow(row.Number, rows[i])
+		if progress != nil {
+			if err := progress(i+1, len(table.Rows)); err != nil {
+				return user_models.ImportResponse{}, "", err
+			}
+		}
+	}
+
+	reportID := saveImportReport(r, string(format), table, mapping.ReportColumns(table.Headers), results)
+	return user_models.NewImportResponse(string(format), r.dryRun, results), reportID, nil
+}
+
+// loadImportTargets 一次載入檔案中 email 已存在的用戶，以小寫 email 為鍵；已刪除的用戶仍佔用 email
+func loadImportTargets(ctx context.Context, emails []string) (map[string]user_models.User, error) {
+	patterns := bson.A{}
+	for _, email := range emails {
+		if email != "" {
+			patterns = append(patterns, emailPattern(email))
+		}
+	}
+	existing := map[string]user_models.User{}
+	if len(patterns) == 0 {
+		return existing, nil
+	}
+
+	cursor, err := userCollection.Find(ctx, bson.M{"email": bson.M{"$in": patterns}})
+	if err != nil {
+		return nil, err
+	}
+	var users []user_models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		existing[strings.ToLower(user.Email)] = user
+	}
+	return existing, nil
+}
+
+// importRow 驗證並匯入單一資料列
+func (r importRun) importRow(number int, fields map[string]string) user_models.ImportRowResult {
+	email := validation.NormalizeEmail(fields["email"])
+	result := user_models.ImportRowResult{Row: number, Email: email}
+
+	if email != "" {
+		if r.seen[email] {
+			if r.policy == user_models.ImportOnDuplicateSkip {
+				result.Action = user_models.ImportActionSkip
+				return result
+			}
+			return r.fail(result, errImportDuplicateRow)
+		}
+		r.seen[email] = true
+	}
+
+	user, exists := r.existing[email]
+	if !exists {
+		return r.createRow(result, fields)
+	}
+
+	result.ID = user.ID.Hex()
+	switch {
+	case r.policy == user_models.ImportOnDuplicateSkip:
+		result.Action = user_models.ImportActionSkip
+		return result
+	case r.policy == user_models.ImportOnDuplicateFail, user.Status.Effective() == user_models.StatusDeleted:
+		return r.fail(result, errEmailInUse)
+	}
+	return r.updateRow(result, user, fields)
+}
+
+// createRow 以建立用戶的規則驗證並新增用戶
+func (r importRun) createRow(result user_models.ImportRowResult, fields map[string]string) user_models.ImportRowResult {
+	age, ageValid := importAge(fields["age"])
+	req := &user_models.AdminCreateUserRequest{
+		CreateUserRequest: user_models.CreateUserRequest{
+			Name:     fields["name"],
+			Email:    fields["email"],
+			Password: fields["password"],
+			Sex:      fields["sex"],
+			Age:      age,
+			Phone:    fields["phone"],
+			Address:  fields["address"],
+		},
+		Role: fields["role"],
+	}
+	if !r.validate(&result, req, ageValid) {
+		return result
+	}
+
+	result.Action = user_models.ImportActionCreate
+	if r.dryRun {
+		return result
+	}
+
+	user, err := insertUser(r.ctx, *req, r.actor)
+	if err != nil {
+		return r.fail(result, err)
+	}
+	recordUserChangeFrom(r.origin, r.actor, user_models.AuditUserCreate, user.ID, nil, &user)
+	result.ID = user.ID.Hex()
+	return result
+}
+
+// updateRow 以部分更新的規則驗證檔案中有值的欄位並更新已存在的用戶；密碼與角色不會被更新
+func (r importRun) updateRow(result user_models.ImportRowResult, original user_models.User, fields map[string]string) user_models.ImportRowResult {
+	value := func(field string) *string {
+		if v := fields[field]; v != "" {
+			return &v
+		}
+		return nil
+	}
+	req := &user_models.PatchUserRequest{
+		Name:    value("name"),
+		Sex:     value("sex"),
+		Phone:   value("phone"),
+		Address: value("address"),
+	}
+	ageValid := true
+	if fields["age"] != "" {
+		var age int
+		age, ageValid = importAge(fields["age"])
+		req.Age = &age
+	}
+	if !r.validate(&result, req, ageValid) {
+		return result
+	}
+
+	result.Action = user_models.ImportActionUpdate
+	if r.dryRun {
+		return result
+	}
+
+	updated := original
+	req.Apply(&updated)
+	saved, err := saveProfile(r.ctx, original, updated, r.actor)
+	if err != nil {
+		return r.fail(result, err)
+	}
+	recordUserChangeFrom(r.origin, r.actor, user_models.AuditUserUpdate, saved.ID, &original, &saved)
+	return result
+}
+
+// validate 驗證並正規化請求，age 不是數字時另外列出欄位錯誤
+func (r importRun) validate(result *user_models.ImportRowResult, req interface{}, ageValid bool) bool {
+	var fieldErrors []user_models.FieldError
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		_, fieldErrors = bindingErrorsIn(r.lang, err)
+	}
+	if !ageValid {
+		kept := []user_models.FieldError{{Field: "age", Code: "number", Message: i18n.T(r.lang, "age must be a number")}}
+		for _, fe := range fieldErrors {
+			if fe.Field != "age" {
+				kept = append(kept, fe)
+			}
+		}
+		fieldErrors = kept
+	}
+	if len(fieldErrors) == 0 {
+		return true
+	}
+
+	messages := make([]string, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		messages = append(messages, fe.Message)
+	}
+	result.Action = user_models.ImportActionError
+	result.Error = strings.Join(messages, "; ")
+	result.Errors = fieldErrors
+	return false
+}
+
+func (r importRun) fail(result user_models.ImportRowResult, err error) user_models.ImportRowResult {
+	result.Action = user_models.ImportActionError
+	result.Error = localizeErrorIn(r.lang, err)
+	return result
+}
+
+// importAge 解析年齡，空白視為未提供
+func importAge(value string) (int, bool) {
+	if value == "" {
+		return 0, true
+	}
+	age, err := strconv.Atoi(value)
+	return age, err == nil
+}
+
+// saveImportReport 保存失敗資料列的錯誤報表，只保存 columns 列出的欄位；回傳報表ID，沒有失敗或無法保存時回傳空字串
+func saveImportReport(r importRun, format string, table importer.Table, columns []int, results []user_models.ImportRowResult) string {
+	if importReportCollection == nil {
+		return ""
+	}
+	report := user_models.ImportReport{
+		Format:    format,
+		DryRun:    r.dryRun,
+		Headers:   make([]string, len(columns)),
+		CreatedBy: r.actor,
+		CreatedAt: serverClock.Now(),
+	}
+	for j, column := range columns {
+		report.Headers[j] = table.Headers[column]
+	}
+	for i, result := range results {
+		if result.Action != user_models.ImportActionError {
+			continue
+		}
+		values := make([]string, len(columns))
+		for j, column := range columns {
+			values[j] = table.Rows[i].Value(column)
+		}
+		report.Rows = append(report.Rows, user_models.ImportFailedRow{Row: result.Row, Values: values, Errors: strings.Split(result.Error, "; ")})
+	}
+	if len(report.Rows) == 0 {
+		return ""
+	}
+
+	inserted, err := importReportCollection.InsertOne(context.Background(), report)
+	if err != nil {
+		log.Printf("Error saving import report: %v\n", err)
+		return ""
+	}
+	return inserted.InsertedID.(primitive.ObjectID).Hex()
+}
+
+// GetImportErrors godoc
+// @Summary 下載匯入錯誤報表
+// @Description 以 CSV 下載匯入失敗的資料列，包含原始欄位（不含密碼）與錯誤訊息；報表保存 7 天，只有匯入者可以下載
+// @Tags users
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path string true "報表ID"
+// @Success 200 {file} file
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /users/import/{id}/errors [get]
+func GetImportErrors(c *gin.Context) {
+	if importReportCollection == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	// 只有匯入者可以下載報表，其他人視為不存在
+	var report user_models.ImportReport
+	filter := bson.M{"_id": id, "created_by": currentActor(c)}
+	if err := importReportCollection.FindOne(context.Background(), filter).Decode(&report); err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusNotFound, "Import report not found")
+			return
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+importReportName+`"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	header := []string{"row"}
+	for _, name := range report.Headers {
+		header = append(header, exporter.CSVSafe(name))
+	}
+	w.Write(append(header, "errors"))
+	for _, row := range report.Rows {
+		record := []string{strconv.Itoa(row.Row)}
+		for _, value := range row.Values {
+			record = append(record, exporter.CSVSafe(value))
+		}
+		w.Write(append(record, exporter.CSVSafe(strings.Join(row.Errors, "; "))))
+	}
+	w.Flush()
+}
@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"go-api_for_main/config"
+	"go-api_for_main/hypermedia"
+	"go-api_for_main/mailer"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/oidc"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var invitationCollection *mongo.Collection
+var invitationConfig config.InvitationConfig
+var invitationMailer mailer.Mailer = mailer.LogMailer{}
+
+// SetupInvitationController 初始化邀請控制器，m 為 nil 時郵件只寫入日誌
+func SetupInvitationController(db *mongo.Database, cfg config.InvitationConfig, m mailer.Mailer) {
+	invitationConfig = cfg
+	if m != nil {
+		invitationMailer = m
+	}
+	if db != nil {
+		invitationCollection = db.Collection("invitations")
+		ensureInvitationIndexes()
+	}
+}
+
+func ensureInvitationIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := invitationCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Warning: creating invitations indexes failed: %v\n", err)
+	}
+}
+
+func checkInvitationStorage() error {
+	if invitationCollection == nil || userCollection == nil {
+		return ErrMongoDBNotConnected
+	}
+	return nil
+}
+
+// invitationStatusFilter 依邀請的實際狀態產生查詢條件，過期與否以 expires_at 判斷
+func invitationStatusFilter(status user_models.InvitationStatus, now time.Time) (bson.M, bool) {
+	switch status {
+	case user_models.InvitationPending:
+		return bson.M{"status": user_models.InvitationPending, "expires_at": bson.M{"$gt": now}}, true
+	case user_models.InvitationExpired:
+		return bson.M{"status": user_models.InvitationPending, "expires_at": bson.M{"$lte": now}}, true
+	case user_models.InvitationAccepted, user_models.InvitationRevoked:
+		return bson.M{"status": status}, true
+	}
+	return nil, false
+}
+
+func respondInvitation(c *gin.Context, statusCode int, invitation user_models.Invitation) {
+	now := serverClock.Now()
+	links := localizeLinks(c, user_models.GenerateInvitationLinks(getAPIBaseURL(c), invitation, now))
+	invitation.Status = invitation.EffectiveStatus(now)
+	respondNegotiated(c, statusCode, user_models.InvitationResponse{Data: invitation, Links: links}, func(mediaType string) interface{} {
+		return hypermedia.RenderResource(mediaType, hypermedia.Resource{
+			Type: "invitations", ID: invitation.ID.Hex(), Data: invitation, Links: links,
+		})
+	})
+}
+
+// CreateInvitation godoc
+// @Summary 建立邀請
+// @Description 以電子郵件邀請新用戶，受邀者透過郵件中的連結設定密碼；僅限管理員
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param invitation body user_models.CreateInvitationRequest true "邀請資料"
+// @Success 201 {object} user_models.InvitationResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 502 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /invitations [post]
+func CreateInvitation(c *gin.Context) {
+	if err := checkInvitationStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	var req user_models.CreateInvitationRequest
+	if err := bindBody(c, &req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	email := req.Email
+	role := req.Role
+	if role == "" {
+		role = user_models.RoleMember
+	}
+	ttl := invitationConfig.DefaultTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if invitationConfig.MaxTTL > 0 && ttl > invitationConfig.MaxTTL {
+		RespondWithAPIError(c, http.StatusBadRequest, "expires_in_hours exceeds the maximum allowed")
+		return
+	}
+
+	ctx := context.Background()
+	taken, err := emailTaken(email, primitive.NilObjectID)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if taken {
+		RespondWithAPIError(c, http.StatusConflict, "a user with this email already exists")
+		return
+	}
+
+	now := serverClock.Now()
+	pending, _ := invitationStatusFilter(user_models.InvitationPending, now)
+	pending["email"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(email) + "$", Options: "i"}
+	count, err := invitationCollection.CountDocuments(ctx, pending)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if count > 0 {
+		RespondWithAPIError(c, http.StatusConflict, "a pending invitation for this email already exists")
+		return
+	}
+
+	token := oidc.RandomToken(32)
+	invitation := user_models.Invitation{
+		Email:     email,
+		Role:      role,
+		Status:    user_models.InvitationPending,
+		TokenHash: oidc.HashToken(token),
+		InvitedBy: currentActor(c),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	result, err := invitationCollection.InsertOne(ctx, invitation)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	invitation.ID = result.InsertedID.(primitive.ObjectID)
+
+	// 寄送失敗時移除邀請，避免留下無法使用的紀錄
+	msg := mailer.InvitationMessage(email, role, invitationConfig.AcceptURL, token, invitation.ExpiresAt)
+	if err := invitationMailer.Send(ctx, msg); err != nil {
+		log.Printf("Error delivering invitation %s: %v\n", invitation.ID.Hex(), err)
+		if _, delErr := invitationCollection.DeleteOne(ctx, bson.M{"_id": invitation.ID}); delErr != nil {
+			log.Printf("Error removing undelivered invitation %s: %v\n", invitation.ID.Hex(), delErr)
+		}
+		RespondWithAPIError(c, http.StatusBadGateway, "failed to deliver invitation email")
+		return
+	}
+
+	respondInvitation(c, http.StatusCreated, invitation)
+}
+
+// GetInvitations godoc
+// @Summary 獲取邀請列表
+// @Description 獲取所有邀請，可依狀態篩選
+// @Tags invitations
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "只列出指定狀態的邀請" Enums(pending, accepted, revoked, expired)
+// @Success 200 {object} user_models.InvitationsCollectionResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /invitations [get]
+func GetInvitations(c *gin.Context) {
+	if err := checkInvitationStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	now := serverClock.Now()
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		var ok bool
+		if filter, ok = invitationStatusFilter(user_models.InvitationStatus(status), now); !ok {
+			RespondWithAPIError(c, http.StatusBadRequest, "Invalid status")
+			return
+		}
+	}
+
+	cursor, err := invitationCollection.Find(context.Background(), filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	invitations := []user_models.Invitation{}
+	if err := cursor.All(context.Background(), &invitations); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i := range invitations {
+		invitations[i].Status = invitations[i].EffectiveStatus(now)
+	}
+
+	c.JSON(http.StatusOK, user_models.InvitationsCollectionResponse{
+		Data: invitations,
+		Links: localizeLinks(c, []user_models.HATEOASLink{
+			{Href: getAPIBaseURL(c) + "/invitations", Rel: "self", Method: "GET", Title: "List invitations"},
+			{Href: getAPIBaseURL(c) + "/invitations", Rel: "create", Method: "POST", Title: "Create invitation"},
+		}),
+		Total: len(invitations),
+	})
+}
+
+// GetInvitation godoc
+// @Summary 獲取特定邀請
+// @Description 通過ID獲取邀請
+// @Tags invitations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "邀請ID"
+// @Success 200 {object} user_models.InvitationResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /invitations/{id} [get]
+func GetInvitation(c *gin.Context) {
+	if err := checkInvitationStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var invitation user_models.Invitation
+	if err := invitationCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&invitation); err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusNotFound, "Invitation not found")
+			return
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondInvitation(c, http.StatusOK, invitation)
+}
+
+// RevokeInvitation godoc
+// @Summary 撤銷邀請
+// @Description 撤銷尚未接受的邀請，撤銷後連結立即失效
+// @Tags invitations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "邀請ID"
+// @Success 200 {object} user_models.InvitationResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /invitations/{id} [delete]
+func RevokeInvitation(c *gin.Context) {
+	if err := checkInvitationStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	now := serverClock.Now()
+	var invitation user_models.Invitation
+	err = invitationCollection.FindOneAndUpdate(context.Background(),
+		bson.M{"_id": id, "status": user_models.InvitationPending},
+		bson.M{"$set": bson.M{"status": user_models.InvitationRevoked, "revoked_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invitation)
+	if err == nil {
+		respondInvitation(c, http.StatusOK, invitation)
+		return
+	}
+	if err != mongo.ErrNoDocuments {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	count, err := invitationCollection.CountDocuments(context.Background(), bson.M{"_id": id})
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if count == 0 {
+		RespondWithAPIError(c, http.StatusNotFound, "Invitation not found")
+		return
+	}
+	RespondWithAPIError(c, http.StatusConflict, "only pending invitations can be revoked")
+}
+
+// AcceptInvitation godoc
+// @Summary 接受邀請
+// @Description 受邀者以郵件中的權杖設定密碼，建立的用戶會由 pending_verification 轉為 active
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param acceptance body user_models.AcceptInvitationRequest true "權杖與用戶資料"
+// @Success 201 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 410 {object} user_models.APIResponse
+// @Router /invitations/accept [post]
+func AcceptInvitation(c *gin.Context) {
+	if err := checkInvitationStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	var req user_models.AcceptInvitationRequest
+	if err := bindBody(c, &req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	// 先將邀請標記為已接受，同一權杖的並行請求只有一個會成功
+	ctx := context.Background()
+	now := serverClock.Now()
+	var invitation user_models.Invitation
+	err := invitationCollection.FindOneAndUpdate(ctx,
+		bson.M{"token_hash": oidc.HashToken(req.Token), "status": user_models.InvitationPending, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"status": user_models.InvitationAccepted, "accepted_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusGone, "invitation is invalid, revoked or expired")
+			return
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	pending, user, err := createInvitedUser(ctx, invitation, req, now)
+	if err != nil {
+		releaseInvitation(ctx, invitation.ID)
+		if errors.Is(err, errEmailTaken) {
+			RespondWithAPIError(c, http.StatusConflict, localizeError(c, err))
+			return
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if _, err := invitationCollection.UpdateOne(ctx, bson.M{"_id": invitation.ID}, bson.M{"$set": bson.M{"user_id": user.ID}}); err != nil {
+		log.Printf("Warning: linking invitation %s to user failed: %v\n", invitation.ID.Hex(), err)
+	}
+
+	// 建立與啟用各自遞增版本，兩個版本都保存快照
+	recordUserChange(c, pending.CreatedBy, user_models.AuditUserCreate, pending.ID, nil, &pending)
+	recordTransition(c, user.UpdatedBy, user)
+	RespondWithUserHATEOAS(c, http.StatusCreated, user)
+}
+
+var errEmailTaken = errors.New("a user with this email already exists")
+
+// createInvitedUser 以 pending_verification 建立用戶，再經由 activate 轉換啟用並留下狀態紀錄；
+// 回傳建立時（版本 1）與啟用後的用戶
+func createInvitedUser(ctx context.Context, invitation user_models.Invitation, req user_models.AcceptInvitationRequest, now time.Time) (user_models.User, user_models.User, error) {
+	taken, err := emailTaken(invitation.Email, primitive.NilObjectID)
+	if err != nil {
+		return user_models.User{}, user_models.User{}, err
+	}
+	if taken {
+		return user_models.User{}, user_models.User{}, errEmailTaken
+	}
+
+	hashed, err := user_models.HashPassword(req.Password)
+	if err != nil {
+		return user_models.User{}, user_models.User{}, err
+	}
+	actor := "invitation:" + invitation.ID.Hex()
+	user := user_models.User{
+		Name:      req.Name,
+		Email:     invitation.Email,
+		Password:  hashed,
+		Sex:       req.Sex,
+		Age:       req.Age,
+		Phone:     req.Phone,
+		Address:   req.Address,
+		Role:      invitation.Role,
+		Status:    user_models.StatusPendingVerification,
+		CreatedAt: now,
+		UpdatedAt: now,
+		CreatedBy: actor,
+		UpdatedBy: actor,
+		Version:   1,
+	}
+	user = withCreatedEvent(user)
+	result, err := userCollection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return user, user, errEmailTaken
+	}
+	if err != nil {
+		return user, user, err
+	}
+	user.ID = result.InsertedID.(primitive.ObjectID)
+
+	action, _ := user_models.FindLifecycleAction("activate")
+	activated, err := applyTransition(ctx, user.ID, action, "invitation accepted", actor)
+	if err != nil {
+		if _, delErr := userCollection.DeleteOne(ctx, bson.M{"_id": user.ID}); delErr != nil {
+			log.Printf("Error removing partially created user %s: %v\n", user.ID.Hex(), delErr)
+		}
+		return user, user, err
+	}
+	return user, activated, nil
+}
+
+// releaseInvitation 建立用戶失敗時讓邀請恢復為可接受
+func releaseInvitation(ctx context.Context, id primitive.ObjectID) {
+	_, err := invitationCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": user_models.InvitationAccepted},
+		bson.M{"$set": bson.M{"status": user_models.InvitationPending}, "$unset": bson.M{"accepted_at": ""}})
+	if err != nil {
+		log.Printf("Error releasing invitation %s: %v\n", id.Hex(), err)
+	}
+}
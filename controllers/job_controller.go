@@ -0,0 +1,391 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api_for_main/config"
+	"go-api_for_main/exporter"
+	"go-api_for_main/importer"
+	"go-api_for_main/jobs"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var jobQueue *jobs.Queue
+
+// importResultName 匯入工作結果檔案的名稱，內容與同步匯入的響應相同
+const importResultName = "import-result.json"
+
+// SetupJobController 初始化背景工作佇列並啟動 worker，MongoDB 未連接時工作端點回傳 503
+func SetupJobController(db *mongo.Database, cfg config.JobConfig) {
+	if db == nil {
+		return
+	}
+	queue, err := jobs.New(db, serverClock, jobs.Options{
+		Workers:       cfg.Workers,
+		LeaseDuration: cfg.LeaseDuration,
+		MaxAttempts:   cfg.MaxAttempts,
+		RetryBackoff:  cfg.RetryBackoff,
+		Retention:     cfg.Retention,
+	})
+	if err != nil {
+		log.Printf("Warning: job queue is unavailable: %v\n", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := queue.EnsureIndexes(ctx); err != nil {
+		log.Printf("Warning: creating jobs indexes failed: %v\n", err)
+	}
+
+	queue.Handle(user_models.JobKindUserExport, runExportJob)
+	queue.Handle(user_models.JobKindUserImport, runImportJob)
+	queue.Start(context.Background())
+	jobQueue = queue
+}
+
+// newJob 建立工作並記錄建立工作的請求，worker 以此產生連結、訊息與稽核紀錄
+func newJob(c *gin.Context, kind string, params map[string]string) user_models.Job {
+	origin := requestOrigin(c)
+	return user_models.Job{
+		Kind:      kind,
+		Params:    params,
+		BaseURL:   getAPIBaseURL(c),
+		Language:  middleware.Language(c),
+		RequestID: origin.RequestID,
+		IP:        origin.IP,
+		CreatedBy: currentActor(c),
+	}
+}
+
+// respondJob 回傳工作與其 HATEOAS 連結
+func respondJob(c *gin.Context, status int, job user_models.Job) {
+	c.JSON(status, user_models.JobResponse{
+		Data:  user_models.NewJobView(job),
+		Links: localizeLinks(c, user_models.GenerateJobLinks(getAPIBaseURL(c), job)),
+	})
+}
+
+// respondJobAccepted 以 202 回傳新建立的工作，Location 指向工作狀態
+func respondJobAccepted(c *gin.Context, job user_models.Job) {
+	c.Header("Location", getAPIBaseURL(c)+"/jobs/"+job.ID.Hex())
+	respondJob(c, http.StatusAccepted, job)
+}
+
+// CreateExportJob godoc
+// @Summary 建立匯出工作
+// @Description 在背景匯出符合條件的用戶，參數與 GET /users/export 相同。回傳 202 與 Location，完成後由工作的 result 連結下載檔案
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "匯出格式" Enums(csv, ndjson, xlsx, vcf) default(csv)
+// @Param fields query string false "要匯出的欄位，例如 name,email,phone"
+// @Param status query string false "只匯出指定狀態的用戶" Enums(pending_verification, active, suspended, locked, deactivated)
+// @Success 202 {object} user_models.JobResponse
+// @Header 202 {string} Location "工作狀態的網址"
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /jobs/exports [post]
+func CreateExportJob(c *gin.Context) {
+	if jobQueue == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+	format, fields, ok := parseExportQuery(c)
+	if !ok {
+		return
+	}
+
+	params := map[string]string{"format": string(format), "fields": strings.Join(fields, ",")}
+	if status := c.Query("status"); status != "" {
+		params["status"] = status
+	}
+	job, err := jobQueue.Enqueue(context.Background(), newJob(c, user_models.JobKindUserExport, params), nil, "")
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJobAccepted(c, job)
+}
+
+// CreateImportJob godoc
+// @Summary 建立匯入工作
+// @Description 在背景匯入用戶，表單欄位與 POST /users/import 相同。回傳 202 與 Location，完成後由工作的 result 連結下載與同步匯入相同格式的 JSON 結果。匯入中途取消時，已處理的資料列不會被復原
+// @Tags jobs
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "匯入檔案"
+// @Param format formData string false "檔案格式，未提供時依副檔名判斷" Enums(csv, ndjson, xlsx)
+// @Param mapping formData string false "欄位對應，例如 {\"E-mail\": \"email\"}"
+// @Param on_duplicate formData string false "email 已存在時的處理方式" Enums(skip, update, fail) default(fail)
+// @Param dry_run formData bool false "只檢查不寫入" default(false)
+// @Success 202 {object} user_models.JobResponse
+// @Header 202 {string} Location "工作狀態的網址"
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 413 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /jobs/imports [post]
+func CreateImportJob(c *gin.Context) {
+	if jobQueue == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+	upload, ok := bindImportUpload(c)
+	if !ok {
+		return
+	}
+
+	params := map[string]string{
+		"format":       string(upload.format),
+		"on_duplicate": upload.opts.DuplicatePolicy(),
+		"dry_run":      strconv.FormatBool(upload.opts.DryRun),
+		"filename":     upload.filename,
+	}
+	if upload.opts.Mapping != "" {
+		params["mapping"] = upload.opts.Mapping
+	}
+	job := newJob(c, user_models.JobKindUserImport, params)
+	job, err := jobQueue.Enqueue(context.Background(), job, bytes.NewReader(upload.data), upload.filename)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJobAccepted(c, job)
+}
+
+// findJob 依路徑參數取得工作，失敗時已回傳錯誤響應
+func findJob(c *gin.Context) (user_models.Job, bool) {
+	if jobQueue == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return user_models.Job{}, false
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return user_models.Job{}, false
+	}
+	job, err := jobQueue.Get(context.Background(), id)
+	if err != nil {
+		respondJobError(c, err)
+		return job, false
+	}
+	return job, true
+}
+
+func respondJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		RespondWithAPIError(c, http.StatusNotFound, "Job not found")
+	case errors.Is(err, jobs.ErrFinished):
+		RespondWithAPIError(c, http.StatusConflict, "Job has already finished")
+	case errors.Is(err, jobs.ErrNoResult):
+		RespondWithAPIError(c, http.StatusConflict, "Job has no result to download")
+	default:
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetJob godoc
+// @Summary 獲取工作狀態
+// @Description 取得背景工作的狀態、進度與每次失敗的原因；成功結束後 result 連結提供結果檔案的下載
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "工作ID"
+// @Success 200 {object} user_models.JobResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /jobs/{id} [get]
+func GetJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+	respondJob(c, http.StatusOK, job)
+}
+
+// CancelJob godoc
+// @Summary 取消工作
+// @Description 等待中的工作立即取消；執行中的工作標記為取消，由 worker 在下次續約時停止。已結束的工作回傳 409
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "工作ID"
+// @Success 200 {object} user_models.JobResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /jobs/{id}/cancel [post]
+func CancelJob(c *gin.Context) {
+	if jobQueue == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	job, err := jobQueue.Cancel(context.Background(), id)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+	respondJob(c, http.StatusOK, job)
+}
+
+// GetJobResult godoc
+// @Summary 下載工作結果
+// @Description 下載成功結束的工作的結果檔案：匯出工作為匯出的檔案，匯入工作為匯入結果的 JSON。工作尚未成功結束時回傳 409
+// @Tags jobs
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "工作ID"
+// @Success 200 {file} file
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /jobs/{id}/result [get]
+func GetJobResult(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+	result, err := jobQueue.OpenResult(job)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+	defer result.Close()
+
+	c.Header("Content-Type", job.Result.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(job.Result.Size, 10))
+	c.Header("Content-Disposition", `attachment; filename="`+job.Result.Filename+`"`)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, result); err != nil {
+		log.Printf("Error sending result of job %s: %v\n", job.ID.Hex(), err)
+	}
+}
+
+// runExportJob 將符合條件的用戶匯出為結果檔案，進度以已寫出的筆數計算
+func runExportJob(ctx context.Context, run *jobs.Run) error {
+	job := run.Job()
+	format, ok := exporter.ParseFormat(job.Params["format"])
+	if !ok {
+		return jobs.Permanent(fmt.Errorf("unsupported export format %q", job.Params["format"]))
+	}
+	fields, err := exporter.ParseFields(job.Params["fields"])
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	filter := usersFilterFor(job.Params["status"])
+	total, err := userCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	run.SetProgress(0, int(total))
+
+	cursor, err := findExportUsers(ctx, filter, fields)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	return run.WriteResult(exportFilename(format), format.ContentType(), func(out io.Writer) error {
+		w, err := exporter.NewWriter(format, out, fields)
+		if err != nil {
+			return err
+		}
+		written, err := writeExport(ctx, cursor, w, func(written int) error {
+			run.SetProgress(written, int(total))
+			return ctx.Err()
+		})
+		if err == nil {
+			// 匯出期間新增或刪除的用戶會讓總數改變，以實際寫出的筆數為準
+			run.SetProgress(written, written)
+		}
+		return err
+	})
+}
+
+// runImportJob 匯入建立工作時上傳的檔案。資料列一旦開始寫入就不再重試，
+// 避免重新執行時已建立的用戶被當成重複的 email
+func runImportJob(ctx context.Context, run *jobs.Run) error {
+	job := run.Job()
+	format, ok := importer.ParseFormat(job.Params["format"])
+	if !ok {
+		return jobs.Permanent(importer.ErrUnknownFormat)
+	}
+	input, err := run.OpenInput()
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(input)
+	input.Close()
+	if err != nil {
+		return err
+	}
+
+	opts := user_models.ImportOptions{
+		Format:      job.Params["format"],
+		Mapping:     job.Params["mapping"],
+		OnDuplicate: job.Params["on_duplicate"],
+		DryRun:      job.Params["dry_run"] == "true",
+	}
+	started := false
+	r := newImportRun(ctx, job.Language, auditOrigin{RequestID: job.RequestID, IP: job.IP}, job.CreatedBy, opts)
+	response, reportID, err := r.importFile(format, data, opts.Mapping, func(done, total int) error {
+		started = true
+		run.SetProgress(done, total)
+		return ctx.Err()
+	})
+	switch {
+	case err != nil && isImportInputError(err):
+		return jobs.Permanent(err)
+	case err != nil && started && !opts.DryRun:
+		return jobs.Permanent(err)
+	case err != nil:
+		return err
+	}
+
+	response.Links = localizeLinksIn(job.Language, user_models.GenerateImportLinks(job.BaseURL, reportID))
+	err = run.WriteResult(importResultName, "application/json; charset=utf-8", func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(response)
+	})
+	if err != nil && !opts.DryRun {
+		return jobs.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 生命週期相關錯誤
+var (
+	ErrUserNotFound           = errors.New("User not found")
+	ErrInvalidTransition      = errors.New("invalid status transition")
+	ErrReasonRequired         = errors.New("a reason is required for this transition")
+	ErrConcurrentModification = errors.New("user was modified concurrently")
+	ErrInactiveUser           = errors.New("user account is not active")
+)
+
+// currentActor 回傳執行操作者的識別，未驗證的請求記錄為 anonymous
+func currentActor(c *gin.Context) string {
+	if claims, ok := middleware.CurrentPrincipal(c); ok {
+		return claims.Subject
+	}
+	return "anonymous"
+}
+
+// statusFilter 產生符合指定狀態的查詢條件，沒有 status 欄位的舊資料視為 active
+func statusFilter(status user_models.UserStatus) interface{} {
+	if status.Effective() == user_models.StatusActive {
+		return bson.M{"$in": bson.A{nil, "", user_models.StatusActive}}
+	}
+	return status
+}
+
+// notDeletedFilter 排除已刪除的使用者
+func notDeletedFilter() bson.M {
+	return bson.M{"$ne": user_models.StatusDeleted}
+}
+
+// applyTransition 驗證並執行狀態轉換，以目前狀態作為條件避免並行修改
+func applyTransition(ctx context.Context, id primitive.ObjectID, action user_models.LifecycleAction, reason, actor string) (user_models.User, error) {
+	var user user_models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return user, ErrUserNotFound
+		}
+		return user, err
+	}
+
+	if !action.Allows(user.Status) {
+		return user, fmt.Errorf("%w: cannot %s a user in state %s", ErrInvalidTransition, action.Name, user.Status.Effective())
+	}
+	if action.RequiresReason && reason == "" {
+		return user, ErrReasonRequired
+	}
+
+	now := serverClock.Now()
+	entry := user_models.StatusTransition{
+		From:   user.Status.Effective(),
+		To:     action.To,
+		Action: action.Name,
+		Reason: reason,
+		Actor:  actor,
+		At:     now,
+	}
+
+	// 事件依轉換後的用戶產生，與狀態在同一次寫入中保存
+	after := user
+	after.Status = action.To
+	after.UpdatedAt = now
+	after.UpdatedBy = actor
+	after.Version = user.Version + 1
+	after.StatusHistory = append(append([]user_models.StatusTransition{}, user.StatusHistory...), entry)
+	events := user_models.UserEvents(&user, after, now)
+
+	var updated user_models.User
+	err := userCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": statusFilter(user.Status)},
+		bson.M{
+			"$set":  bson.M{"status": action.To, "updated_at": now, "updated_by": actor},
+			"$inc":  bson.M{"version": 1},
+			"$push": bson.M{"status_history": entry, "outbox": outboxEach(events)},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return user, ErrConcurrentModification
+		}
+		return user, err
+	}
+	return updated, nil
+}
+
+// respondTransitionError 將狀態轉換錯誤對應為 HTTP 狀態碼
+func respondTransitionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		RespondWithAPIError(c, http.StatusNotFound, localizeError(c, err))
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrConcurrentModification):
+		RespondWithAPIError(c, http.StatusConflict, localizeError(c, err))
+	case errors.Is(err, ErrReasonRequired):
+		RespondWithAPIError(c, http.StatusBadRequest, localizeError(c, err))
+	default:
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// transitionUser 各狀態轉換端點共用的處理流程
+func transitionUser(c *gin.Context, actionName string) {
+	if err := checkMongoDBConnection(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req user_models.TransitionRequest
+	if c.Request.ContentLength != 0 {
+		if err := bindBody(c, &req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+	}
+
+	action, _ := user_models.FindLifecycleAction(actionName)
+	user, err := applyTransition(context.Background(), id, action, req.Reason, currentActor(c))
+	if err != nil {
+		respondTransitionError(c, err)
+		return
+	}
+	recordTransition(c, user.UpdatedBy, user)
+
+	RespondWithUserHATEOAS(c, http.StatusOK, user)
+}
+
+// ActivateUser godoc
+// @Summary 啟用用戶
+// @Description 將待驗證的用戶轉為 active
+// @Tags lifecycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param transition body user_models.TransitionRequest false "變更原因"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /users/{id}/activate [post]
+func ActivateUser(c *gin.Context) {
+	transitionUser(c, "activate")
+}
+
+// SuspendUser godoc
+// @Summary 停權用戶
+// @Description 停權 active 或 locked 的用戶，必須提供原因
+// @Tags lifecycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param transition body user_models.TransitionRequest true "停權原因"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /users/{id}/suspend [post]
+func SuspendUser(c *gin.Context) {
+	transitionUser(c, "suspend")
+}
+
+// ReinstateUser godoc
+// @Summary 恢復用戶
+// @Description 將停權的用戶恢復為 active
+// @Tags lifecycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param transition body user_models.TransitionRequest false "變更原因"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /users/{id}/reinstate [post]
+func ReinstateUser(c *gin.Context) {
+	transitionUser(c, "reinstate")
+}
+
+// LockUser godoc
+// @Summary 鎖定用戶
+// @Description 鎖定 active 的用戶
+// @Tags lifecycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param transition body user_models.TransitionRequest false "變更原因"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /users/{id}/lock [post]
+func LockUser(c *gin.Context) {
+	transitionUser(c, "lock")
+}
+
+// UnlockUser godoc
+// @Summary 解除鎖定用戶
+// @Description 將鎖定的用戶恢復為 active
+// @Tags lifecycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param transition body user_models.TransitionRequest false "變更原因"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+	transitionUser(c, "unlock")
+}
+
+// DeactivateUser godoc
+// @Summary 停用用戶
+// @Description 停用尚未刪除的用戶
+// @Tags lifecycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param transition body user_models.TransitionRequest false "變更原因"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /users/{id}/deactivate [post]
+func DeactivateUser(c *gin.Context) {
+	transitionUser(c, "deactivate")
+}
+
+// ReactivateUser godoc
+// @Summary 重新啟用用戶
+// @Description 將停用的用戶恢復為 active
+// @Tags lifecycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param transition body user_models.TransitionRequest false "變更原因"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /users/{id}/reactivate [post]
+func ReactivateUser(c *gin.Context) {
+	transitionUser(c, "reactivate")
+}
+
+// GetUserStatusHistory godoc
+// @Summary 獲取用戶狀態歷史
+// @Description 獲取用戶目前狀態與所有狀態變更紀錄
+// @Tags lifecycle
+// @Produce json,application/msgpack,application/cbor,application/xml,application/yaml
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Success 200 {object} user_models.StatusHistoryResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Router /users/{id}/status-history [get]
+func GetUserStatusHistory(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var user user_models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusNotFound, "User not found")
+			return
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	history := user.StatusHistory
+	if history == nil {
+		history = []user_models.StatusTransition{}
+	}
+	respondEncoded(c, http.StatusOK, user_models.StatusHistoryResponse{
+		Status:  user.Status.Effective(),
+		History: history,
+		Links:   localizeLinks(c, user_models.GenerateUserLinks(getAPIBaseURL(c), user.ID.Hex(), user.Status)),
+	})
+}
@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrSessionRevoked 表示權杖所屬的工作階段已被撤銷
+var ErrSessionRevoked = errors.New("session has been revoked")
+
+// createSession 依授權碼建立工作階段，記錄登入時的裝置資訊
+func createSession(ctx context.Context, authCode user_models.AuthorizationCode, now, expiresAt time.Time) (user_models.Session, error) {
+	session := user_models.Session{
+		UserID:    authCode.UserID,
+		ClientID:  authCode.ClientID,
+		UserAgent: authCode.UserAgent,
+		IPAddress: authCode.IPAddress,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	result, err := sessionCollection.InsertOne(ctx, session)
+	if err != nil {
+		return session, err
+	}
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return session, nil
+}
+
+// sessionActive 判斷工作階段是否屬於該使用者且尚未撤銷
+func sessionActive(ctx context.Context, sessionID string, userID primitive.ObjectID) bool {
+	id, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return false
+	}
+	count, err := sessionCollection.CountDocuments(ctx, bson.M{"_id": id, "user_id": userID, "revoked_at": nil})
+	return err == nil && count > 0
+}
+
+// revokeSessions 撤銷使用者的工作階段，except 不為空時保留該工作階段
+func revokeSessions(ctx context.Context, userID primitive.ObjectID, except string) error {
+	filter := bson.M{"user_id": userID, "revoked_at": nil}
+	if id, err := primitive.ObjectIDFromHex(except); err == nil {
+		filter["_id"] = bson.M{"$ne": id}
+	}
+	_, err := sessionCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": serverClock.Now()}})
+	return err
+}
+
+func checkMeStorage() error {
+	if userCollection == nil || sessionCollection == nil {
+		return ErrMongoDBNotConnected
+	}
+	return nil
+}
+
+// loadCurrentUser 載入權杖所屬的使用者，失敗時已回應錯誤
+func loadCurrentUser(c *gin.Context) (user_models.User, bool) {
+	var user user_models.User
+	if err := checkMeStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return user, false
+	}
+
+	claims, ok := middleware.CurrentPrincipal(c)
+	if !ok {
+		RespondWithAPIError(c, http.StatusUnauthorized, "authentication is required")
+		return user, false
+	}
+	id, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusUnauthorized, "authentication is required")
+		return user, false
+	}
+
+	err = userCollection.FindOne(context.Background(), bson.M{"_id": id, "status": notDeletedFilter()}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusNotFound, "User not found")
+			return user, false
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return user, false
+	}
+	return user, true
+}
+
+func currentSessionID(c *gin.Context) string {
+	if claims, ok := middleware.CurrentPrincipal(c); ok {
+		return claims.SessionID
+	}
+	return ""
+}
+
+// GetMe godoc
+// @Summary 獲取個人資料
+// @Description 獲取存取權杖所屬用戶的資料
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} user_models.UserResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Router /me [get]
+func GetMe(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	RespondWithUserHATEOAS(c, http.StatusOK, user)
+}
+
+// UpdateMe godoc
+// @Summary 更新個人資料
+// @Description 部分更新存取權杖所屬用戶的資料，提供的欄位以與管理端相同的規則驗證；角色、狀態與密碼不可由此變更
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user body user_models.PatchUserRequest true "要更新的欄位"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /me [patch]
+func UpdateMe(c *gin.Context) {
+	original, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user_models.PatchUserRequest
+	if err := bindBody(c, &req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	// 未提供的欄位維持原值；角色、狀態與密碼不在請求型別中
+	updated := original
+	req.Apply(&updated)
+	saved, err := saveProfile(context.Background(), original, updated, currentActor(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, errEmailInUse), errors.Is(err, ErrConcurrentModification):
+			RespondWithAPIError(c, http.StatusConflict, localizeError(c, err))
+		default:
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	recordUserChange(c, saved.UpdatedBy, user_models.AuditUserUpdate, saved.ID, &original, &saved)
+
+	RespondWithUserHATEOAS(c, http.StatusOK, saved)
+}
+
+// ChangeMyPassword godoc
+// @Summary 變更密碼
+// @Description 以目前的密碼驗證後設定新密碼，其他工作階段會一併登出
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body user_models.UpdatePasswordRequest true "目前與新的密碼"
+// @Success 200 {object} user_models.APIResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Router /me/password [post]
+func ChangeMyPassword(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user_models.UpdatePasswordRequest
+	if err := bindBody(c, &req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if !user_models.CheckPassword(user.Password, req.CurrentPassword) {
+		RespondWithAPIError(c, http.StatusForbidden, "current password is incorrect")
+		return
+	}
+
+	hashed, err := user_models.HashPassword(req.NewPassword)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var changed user_models.User
+	err = userCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": user.ID},
+		bson.M{
+			"$set": bson.M{"password": hashed, "updated_at": serverClock.Now(), "updated_by": currentActor(c)},
+			"$inc": bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&changed)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	recordUserChange(c, changed.UpdatedBy, user_models.AuditPasswordChange, user.ID, &user, &changed)
+	if err := revokeSessions(context.Background(), user.ID, currentSessionID(c)); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithAPISuccess(c, http.StatusOK, "Password updated", nil, user_models.GenerateMeLinks(getAPIBaseURL(c)))
+}
+
+// GetMySessions godoc
+// @Summary 獲取登入工作階段
+// @Description 列出目前用戶尚未過期或撤銷的工作階段
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} user_models.SessionsCollectionResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Router /me/sessions [get]
+func GetMySessions(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	cursor, err := sessionCollection.Find(context.Background(),
+		bson.M{"user_id": user.ID, "revoked_at": nil, "expires_at": bson.M{"$gt": serverClock.Now()}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var sessions []user_models.Session
+	if err := cursor.All(context.Background(), &sessions); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	baseURL := getAPIBaseURL(c)
+	current := currentSessionID(c)
+	resources := make([]user_models.SessionResource, 0, len(sessions))
+	for _, session := range sessions {
+		session.Current = session.ID.Hex() == current
+		resources = append(resources, user_models.SessionResource{
+			Session: session,
+			Links:   localizeLinks(c, user_models.GenerateSessionLinks(baseURL, session.ID.Hex())),
+		})
+	}
+
+	c.JSON(http.StatusOK, user_models.SessionsCollectionResponse{
+		Data: resources,
+		Links: localizeLinks(c, []user_models.HATEOASLink{
+			{Href: baseURL + "/me/sessions", Rel: "self", Method: "GET", Title: "List my sessions"},
+			{Href: baseURL + "/me", Rel: "me", Method: "GET", Title: "Get my profile"},
+		}),
+	})
+}
+
+// RevokeMySession godoc
+// @Summary 登出工作階段
+// @Description 撤銷目前用戶的指定工作階段，使用該工作階段的權杖立即失效
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "工作階段ID"
+// @Success 200 {object} user_models.APIResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Router /me/sessions/{id} [delete]
+func RevokeMySession(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	result, err := sessionCollection.UpdateOne(context.Background(),
+		bson.M{"_id": id, "user_id": user.ID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": serverClock.Now()}})
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if result.MatchedCount == 0 {
+		RespondWithAPIError(c, http.StatusNotFound, "Session not found")
+		return
+	}
+	recordAuditChanges(c, currentActor(c), user_models.AuditSessionRevoke, user.ID,
+		[]user_models.FieldChange{{Field: "session", Before: id.Hex()}})
+
+	RespondWithAPISuccess(c, http.StatusOK, "Session signed out", nil, user_models.GenerateMeLinks(getAPIBaseURL(c)))
+}
+
+// DeactivateMe godoc
+// @Summary 停用自己的帳號
+// @Description 將目前用戶轉為 deactivated 並登出所有工作階段
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param transition body user_models.TransitionRequest false "停用原因"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Router /me [delete]
+func DeactivateMe(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user_models.TransitionRequest
+	if c.Request.ContentLength != 0 {
+		if err := bindBody(c, &req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+	}
+
+	action, _ := user_models.FindLifecycleAction("deactivate")
+	updated, err := applyTransition(context.Background(), user.ID, action, req.Reason, user.ID.Hex())
+	if err != nil {
+		respondTransitionError(c, err)
+		return
+	}
+	recordTransition(c, updated.UpdatedBy, updated)
+	if err := revokeSessions(context.Background(), user.ID, ""); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithUserHATEOAS(c, http.StatusOK, updated)
+}
@@ -0,0 +1,621 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-api_for_main/config"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/oidc"
+	"go-api_for_main/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var oidcClientCollection *mongo.Collection
+var authCodeCollection *mongo.Collection
+var sessionCollection *mongo.Collection
+var keyManager *oidc.KeyManager
+var oidcConfig config.OIDCConfig
+
+// SetupOIDCController 初始化 OpenID Connect 身分提供者
+// db 為 nil 時簽章金鑰只保存在記憶體中，discovery 與 JWKS 仍可使用
+func SetupOIDCController(db *mongo.Database, cfg config.OIDCConfig) {
+	oidcConfig = cfg
+
+	var keyCollection *mongo.Collection
+	if db != nil {
+		oidcClientCollection = db.Collection("oidc_clients")
+		authCodeCollection = db.Collection("oidc_auth_codes")
+		keyCollection = db.Collection("oidc_keys")
+		sessionCollection = db.Collection("sessions")
+		ensureOIDCIndexes()
+	}
+
+	// 舊金鑰至少保留一個權杖有效期，讓輪替前簽發的權杖仍可驗證
+	retention := cfg.AccessTokenTTL
+	if cfg.IDTokenTTL > retention {
+		retention = cfg.IDTokenTTL
+	}
+	keyManager = oidc.NewKeyManager(keyCollection, cfg.KeyRotation, retention)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := keyManager.Load(ctx); err != nil {
+		log.Printf("Warning: loading OIDC signing keys failed: %v\n", err)
+	}
+	// 每分鐘重新載入一次，其他副本輪替的新金鑰很快就會出現在本副本的 JWKS
+	keyManager.StartRotation(context.Background(), time.Minute)
+}
+
+func ensureOIDCIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := oidcClientCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "client_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Warning: creating oidc_clients index failed: %v\n", err)
+	}
+
+	_, err = authCodeCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "code_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		log.Printf("Warning: creating oidc_auth_codes indexes failed: %v\n", err)
+	}
+
+	_, err = sessionCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		log.Printf("Warning: creating sessions indexes failed: %v\n", err)
+	}
+}
+
+func checkOIDCStorage() error {
+	if oidcClientCollection == nil || authCodeCollection == nil || sessionCollection == nil || userCollection == nil {
+		return ErrMongoDBNotConnected
+	}
+	return nil
+}
+
+// VerifyAccessToken 驗證本服務簽發的存取權杖，供驗證中介軟體使用
+// 資料庫可用時會確認使用者仍為 active 且工作階段未被撤銷，停權、鎖定或登出後既有權杖立即失效
+func VerifyAccessToken(token string) (*oidc.AccessTokenClaims, error) {
+	if keyManager == nil {
+		return nil, oidc.ErrInvalidToken
+	}
+	claims, err := keyManager.VerifyAccessToken(token, oidcConfig.Issuer)
+	if err != nil || userCollection == nil {
+		return claims, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return nil, oidc.ErrInvalidToken
+	}
+	var user user_models.User
+	err = userCollection.FindOne(context.Background(), bson.M{"_id": id},
+		options.FindOne().SetProjection(bson.M{"status": 1})).Decode(&user)
+	if err != nil {
+		return nil, errors.Join(oidc.ErrInvalidToken, err)
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, errors.Join(oidc.ErrInvalidToken, ErrInactiveUser)
+	}
+	if claims.SessionID != "" && sessionCollection != nil {
+		if !sessionActive(context.Background(), claims.SessionID, id) {
+			return nil, errors.Join(oidc.ErrInvalidToken, ErrSessionRevoked)
+		}
+	}
+	return claims, nil
+}
+
+// respondOAuthError 回傳 RFC 6749 格式的錯誤
+func respondOAuthError(c *gin.Context, statusCode int, errCode, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(statusCode, user_models.OAuthError{Error: errCode, ErrorDescription: description})
+}
+
+// OpenIDConfiguration 回傳身分提供者的中繼資料
+func OpenIDConfiguration(c *gin.Context) {
+	issuer := oidcConfig.Issuer
+	registrationEndpoint := ""
+	if registrationEnabled() {
+		registrationEndpoint = issuer + "/oauth2/register"
+	}
+	c.JSON(http.StatusOK, user_models.OIDCDiscoveryDocument{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth2/authorize",
+		TokenEndpoint:                     issuer + "/oauth2/token",
+		UserinfoEndpoint:                  issuer + "/oauth2/userinfo",
+		JwksURI:                           issuer + "/oauth2/jwks",
+		RegistrationEndpoint:              registrationEndpoint,
+		ScopesSupported:                   oidc.SupportedScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{oidc.CodeChallengeMethodS256},
+		ClaimsSupported:                   oidc.SupportedClaims,
+	})
+}
+
+// JWKS 回傳目前與輪替保留期間內的簽章公鑰
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keyManager.JWKS())
+}
+
+// authorizeRequest 授權請求參數
+type authorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="zh-Hant">
+<head><meta charset="utf-8"><title>登入</title></head>
+<body>
+<h1>登入 {{.ClientName}}</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="/oauth2/authorize">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<label>Email <input type="email" name="email" required></label>
+<label>密碼 <input type="password" name="password" required></label>
+<button type="submit">登入</button>
+</form>
+</body>
+</html>`))
+
+// validateAuthorizeRequest 驗證用戶端與 redirect_uri，失敗時直接回應錯誤
+// 在確認 redirect_uri 之前不可重新導向，避免成為開放式重新導向
+func validateAuthorizeRequest(c *gin.Context, req *authorizeRequest) (*user_models.OIDCClient, bool) {
+	var client user_models.OIDCClient
+	err := oidcClientCollection.FindOne(context.Background(), bson.M{"client_id": req.ClientID}).Decode(&client)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondOAuthError(c, http.StatusBadRequest, "invalid_client", "unknown client_id")
+			return nil, false
+		}
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return nil, false
+	}
+
+	if !client.HasRedirectURI(req.RedirectURI) {
+		respondOAuthError(c, http.StatusBadRequest, "invalid_request", "redirect_uri is not registered for this client")
+		return nil, false
+	}
+
+	switch {
+	case req.ResponseType != "code":
+		redirectAuthorizeError(c, req, "unsupported_response_type", "only response_type=code is supported")
+	case !oidc.HasScope(req.Scope, "openid"):
+		redirectAuthorizeError(c, req, "invalid_scope", "scope must include openid")
+	case !oidc.ValidCodeChallenge(req.CodeChallenge, req.CodeChallengeMethod):
+		redirectAuthorizeError(c, req, "invalid_request", "PKCE code_challenge with code_challenge_method=S256 is required")
+	default:
+		return &client, true
+	}
+	return nil, false
+}
+
+// redirectAuthorizeError 將錯誤以查詢參數帶回已驗證的 redirect_uri
+func redirectAuthorizeError(c *gin.Context, req *authorizeRequest, errCode, description string) {
+	params := url.Values{}
+	params.Set("error", errCode)
+	params.Set("error_description", description)
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	c.Redirect(http.StatusFound, appendQuery(req.RedirectURI, params))
+}
+
+func appendQuery(rawURL string, params url.Values) string {
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + params.Encode()
+	}
+	return rawURL + "?" + params.Encode()
+}
+
+func renderLogin(c *gin.Context, statusCode int, client *user_models.OIDCClient, req *authorizeRequest, errMessage string) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Status(statusCode)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := loginTemplate.Execute(c.Writer, gin.H{
+		"ClientName": client.ClientName,
+		"Request":    req,
+		"Error":      errMessage,
+	}); err != nil {
+		log.Printf("Error rendering login page: %v\n", err)
+	}
+}
+
+// Authorize 驗證授權請求 (authorization code + PKCE) 並顯示登入頁面
+func Authorize(c *gin.Context) {
+	if err := checkOIDCStorage(); err != nil {
+		respondOAuthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "Database service is currently unavailable")
+		return
+	}
+
+	var req authorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondOAuthError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	client, ok := validateAuthorizeRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	renderLogin(c, http.StatusOK, client, &req, "")
+}
+
+// AuthorizeLogin 驗證使用者帳密後簽發授權碼並重新導向回用戶端
+func AuthorizeLogin(c *gin.Context) {
+	if err := checkOIDCStorage(); err != nil {
+		respondOAuthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "Database service is currently unavailable")
+		return
+	}
+
+	var req authorizeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondOAuthError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	client, ok := validateAuthorizeRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	// 保存的 email 已轉為小寫，舊資料可能仍有大小寫混用，因此不分大小寫比對
+	email := validation.NormalizeEmail(c.PostForm("email"))
+	password := c.PostForm("password")
+
+	var user user_models.User
+	err := mongo.ErrNoDocuments
+	if email != "" {
+		err = userCollection.FindOne(context.Background(),
+			bson.M{"email": emailPattern(email), "status": notDeletedFilter()}).Decode(&user)
+	}
+	if err != nil && err != mongo.ErrNoDocuments {
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	if err == mongo.ErrNoDocuments || !user_models.CheckPassword(user.Password, password) {
+		renderLogin(c, http.StatusUnauthorized, client, &req, "Email 或密碼錯誤")
+		return
+	}
+	if !user.Status.CanAuthenticate() {
+		renderLogin(c, http.StatusForbidden, client, &req, "帳號目前無法登入")
+		return
+	}
+
+	code := oidc.RandomToken(32)
+	now := serverClock.Now()
+	authCode := user_models.AuthorizationCode{
+		CodeHash:            oidc.HashToken(code),
+		ClientID:            client.ClientID,
+		RedirectURI:         req.RedirectURI,
+		UserID:              user.ID,
+		Scope:               oidc.FilterScope(req.Scope, client.Scope),
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		AuthTime:            now,
+		UserAgent:           c.Request.UserAgent(),
+		IPAddress:           c.ClientIP(),
+		ExpiresAt:           now.Add(oidcConfig.AuthCodeTTL),
+	}
+	if _, err := authCodeCollection.InsertOne(context.Background(), authCode); err != nil {
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	c.Redirect(http.StatusFound, appendQuery(req.RedirectURI, params))
+}
+
+// authenticateClient 依 client_secret_basic、client_secret_post 或 none 驗證用戶端
+func authenticateClient(c *gin.Context) (*user_models.OIDCClient, error) {
+	clientID, clientSecret, basic := c.Request.BasicAuth()
+	if basic {
+		// RFC 6749 §2.3.1 要求 Basic 驗證的帳密先經過 form 編碼
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+	if clientID == "" {
+		return nil, errors.New("client authentication is required")
+	}
+
+	var client user_models.OIDCClient
+	if err := oidcClientCollection.FindOne(context.Background(), bson.M{"client_id": clientID}).Decode(&client); err != nil {
+		return nil, errors.New("unknown client")
+	}
+
+	if client.IsPublic() {
+		return &client, nil
+	}
+
+	method := "client_secret_post"
+	if basic {
+		method = "client_secret_basic"
+	}
+	if method != client.TokenEndpointAuthMethod || !user_models.CheckPassword(client.ClientSecretHash, clientSecret) {
+		return nil, errors.New("client authentication failed")
+	}
+	return &client, nil
+}
+
+// Token 以授權碼與 PKCE code_verifier 交換存取權杖與 ID 權杖
+func Token(c *gin.Context) {
+	if err := checkOIDCStorage(); err != nil {
+		respondOAuthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "Database service is currently unavailable")
+		return
+	}
+
+	if c.PostForm("grant_type") != "authorization_code" {
+		respondOAuthError(c, http.StatusBadRequest, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	client, err := authenticateClient(c)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Basic realm="oauth2"`)
+		respondOAuthError(c, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	}
+
+	// 以原子操作標記授權碼已使用，確保授權碼只能交換一次
+	var authCode user_models.AuthorizationCode
+	err = authCodeCollection.FindOneAndUpdate(context.Background(),
+		bson.M{"code_hash": oidc.HashToken(c.PostForm("code")), "used": false},
+		bson.M{"$set": bson.M{"used": true}},
+	).Decode(&authCode)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondOAuthError(c, http.StatusBadRequest, "invalid_grant", "authorization code is invalid or already used")
+			return
+		}
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	now := serverClock.Now()
+	switch {
+	case now.After(authCode.ExpiresAt):
+		respondOAuthError(c, http.StatusBadRequest, "invalid_grant", "authorization code expired")
+		return
+	case authCode.ClientID != client.ClientID:
+		respondOAuthError(c, http.StatusBadRequest, "invalid_grant", "authorization code was issued to another client")
+		return
+	case authCode.RedirectURI != c.PostForm("redirect_uri"):
+		respondOAuthError(c, http.StatusBadRequest, "invalid_grant", "redirect_uri does not match")
+		return
+	case !oidc.VerifyPKCE(c.PostForm("code_verifier"), authCode.CodeChallenge, authCode.CodeChallengeMethod):
+		respondOAuthError(c, http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	var user user_models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": authCode.UserID}).Decode(&user); err != nil {
+		respondOAuthError(c, http.StatusBadRequest, "invalid_grant", "user no longer exists")
+		return
+	}
+	if !user.Status.CanAuthenticate() {
+		respondOAuthError(c, http.StatusBadRequest, "invalid_grant", ErrInactiveUser.Error())
+		return
+	}
+
+	session, err := createSession(context.Background(), authCode, now, now.Add(oidcConfig.AccessTokenTTL))
+	if err != nil {
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	accessClaims := oidc.NewAccessTokenClaims(
+		oidcConfig.Issuer, user.ID.Hex(), client.ClientID, authCode.Scope, now, oidcConfig.AccessTokenTTL,
+	)
+	accessClaims.SessionID = session.ID.Hex()
+	accessToken, err := keyManager.Sign(accessClaims)
+	if err != nil {
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	idClaims := oidc.NewIDTokenClaims(
+		oidcConfig.Issuer, user.ID.Hex(), client.ClientID, authCode.Nonce, authCode.AuthTime, now, oidcConfig.IDTokenTTL,
+	)
+	if oidc.HasScope(authCode.Scope, "profile") {
+		idClaims.Name = user.Name
+	}
+	if oidc.HasScope(authCode.Scope, "email") {
+		idClaims.Email = user.Email
+	}
+	idToken, err := keyManager.Sign(idClaims)
+	if err != nil {
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, user_models.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(oidcConfig.AccessTokenTTL.Seconds()),
+		IDToken:     idToken,
+		Scope:       authCode.Scope,
+	})
+}
+
+// UserInfo 依存取權杖的 scope 回傳使用者的 name、email、phone 與 address 宣告
+func UserInfo(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		respondOAuthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "Database service is currently unavailable")
+		return
+	}
+
+	claims, _ := middleware.CurrentPrincipal(c)
+	if !claims.HasScope("openid") {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		respondOAuthError(c, http.StatusForbidden, "insufficient_scope", "access token does not include the openid scope")
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		respondOAuthError(c, http.StatusUnauthorized, "invalid_token", "invalid subject")
+		return
+	}
+
+	var user user_models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondOAuthError(c, http.StatusUnauthorized, "invalid_token", "user no longer exists")
+			return
+		}
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, oidc.UserInfoFromUser(user, claims.Scope))
+}
+
+// registrationEnabled 設定 OIDC_REGISTRATION_TOKEN 或明確開放 OIDC_OPEN_REGISTRATION 時才接受動態註冊
+func registrationEnabled() bool {
+	return oidcConfig.RegistrationToken != "" || oidcConfig.OpenRegistration
+}
+
+// RegisterClient 註冊新的 OIDC 用戶端 (RFC 7591)，需以 Bearer 提供 OIDC_REGISTRATION_TOKEN；
+// 未設定權杖時只有 OIDC_OPEN_REGISTRATION=true 才開放匿名註冊，否則回應 403
+func RegisterClient(c *gin.Context) {
+	if !registrationEnabled() {
+		respondOAuthError(c, http.StatusForbidden, "access_denied", "dynamic client registration is disabled")
+		return
+	}
+	expected := "Bearer " + oidcConfig.RegistrationToken
+	if oidcConfig.RegistrationToken != "" &&
+		subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte(expected)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="oauth2-registration"`)
+		respondOAuthError(c, http.StatusUnauthorized, "invalid_token", "a valid initial access token is required")
+		return
+	}
+
+	if oidcClientCollection == nil {
+		respondOAuthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "Database service is currently unavailable")
+		return
+	}
+
+	var req user_models.ClientRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondOAuthError(c, http.StatusBadRequest, "invalid_client_metadata", err.Error())
+		return
+	}
+
+	client := user_models.OIDCClient{
+		ClientID:                oidc.RandomToken(16),
+		ClientName:              req.ClientName,
+		RedirectURIs:            req.RedirectURIs,
+		GrantTypes:              []string{"authorization_code"},
+		ResponseTypes:           []string{"code"},
+		Scope:                   oidc.FilterScope(req.Scope, ""),
+		TokenEndpointAuthMethod: req.TokenEndpointAuthMethod,
+		CreatedAt:               serverClock.Now(),
+	}
+	if client.Scope == "" {
+		client.Scope = strings.Join(oidc.SupportedScopes, " ")
+	}
+	if client.TokenEndpointAuthMethod == "" {
+		client.TokenEndpointAuthMethod = "client_secret_basic"
+	}
+
+	switch client.TokenEndpointAuthMethod {
+	case "client_secret_basic", "client_secret_post", "none":
+	default:
+		respondOAuthError(c, http.StatusBadRequest, "invalid_client_metadata", "unsupported token_endpoint_auth_method")
+		return
+	}
+	for _, grantType := range req.GrantTypes {
+		if grantType != "authorization_code" {
+			respondOAuthError(c, http.StatusBadRequest, "invalid_client_metadata", "unsupported grant_type "+grantType)
+			return
+		}
+	}
+	for _, responseType := range req.ResponseTypes {
+		if responseType != "code" {
+			respondOAuthError(c, http.StatusBadRequest, "invalid_client_metadata", "unsupported response_type "+responseType)
+			return
+		}
+	}
+	for _, uri := range req.RedirectURIs {
+		if !oidc.ValidRedirectURI(uri) {
+			respondOAuthError(c, http.StatusBadRequest, "invalid_redirect_uri", "redirect_uri must be an absolute URI without fragment")
+			return
+		}
+	}
+
+	response := user_models.ClientRegistrationResponse{
+		ClientIDIssuedAt: client.CreatedAt.Unix(),
+	}
+	if !client.IsPublic() {
+		secret := oidc.RandomToken(32)
+		hash, err := user_models.HashPassword(secret)
+		if err != nil {
+			respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+			return
+		}
+		client.ClientSecretHash = hash
+		response.ClientSecret = secret
+	}
+
+	if _, err := oidcClientCollection.InsertOne(context.Background(), client); err != nil {
+		respondOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	response.OIDCClient = client
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, response)
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"go-api_for_main/formats"
+	"go-api_for_main/hypermedia"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxRequestBodySize 以 bindBody 解碼的請求內容大小上限
+const maxRequestBodySize = 1 << 20
+
+var (
+	errUnsupportedMediaType = errors.New("request body must be JSON, MessagePack, CBOR, XML or YAML")
+	errNotAcceptable        = errors.New("none of the accepted media types can be produced")
+	errBodyTooLarge         = errors.New("request body is too large")
+)
+
+// bindBody 依 Content-Type 解碼請求內容，再以與 ShouldBindJSON 相同的規則綁定與驗證；
+// 未指定 Content-Type 或為 +json 類型時視為 JSON。Accept 無法滿足時在修改資料前就回傳錯誤，
+// 內容超過 maxRequestBodySize 時回傳 errBodyTooLarge
+func bindBody(c *gin.Context, obj interface{}) error {
+	if _, ok := hypermedia.Select(c.GetHeader("Accept")); !ok {
+		return errNotAcceptable
+	}
+	if c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBodySize)
+	}
+
+	err := decodeBody(c, obj)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errBodyTooLarge
+	}
+	return err
+}
+
+// decodeBody 依 Content-Type 將請求內容轉為 JSON 後綁定
+func decodeBody(c *gin.Context, obj interface{}) error {
+	mediaType := formats.MediaTypeJSON
+	if contentType := c.GetHeader("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		canonical, ok := formats.Lookup(contentType)
+		switch {
+		case ok:
+			mediaType = canonical
+		case err == nil && strings.HasSuffix(parsed, "+json"):
+		default:
+			return errUnsupportedMediaType
+		}
+	}
+	if mediaType == formats.MediaTypeJSON {
+		return c.ShouldBindJSON(obj)
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	converted, err := formats.ToJSON(mediaType, body, obj)
+	if err != nil {
+		return err
+	}
+	return binding.JSON.BindBody(converted, obj)
+}
+
+// bindingStatus 回傳綁定錯誤對應的狀態碼
+func bindingStatus(err error) int {
+	switch {
+	case errors.Is(err, errUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, errNotAcceptable):
+		return http.StatusNotAcceptable
+	case errors.Is(err, errBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
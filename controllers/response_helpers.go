@@ -1,67 +1,164 @@
 package controllers
 
 import (
-	"fmt"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go-api_for_main/formats"
+	"go-api_for_main/hypermedia"
 	user_models "go-api_for_main/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-// getBaseURL 獲取請求的基本 URL
-func getBaseURL(c *gin.Context) string {
-	scheme := "http"
-	if c.Request.TLS != nil {
-		scheme = "https"
+// respondNegotiated 依 Accept 標頭選擇超媒體格式或編碼，預設輸出原本的 _links 格式；
+// 沒有可接受的格式時回應 406
+func respondNegotiated(c *gin.Context, statusCode int, defaultBody interface{}, render func(mediaType string) interface{}) {
+	mediaType, ok := hypermedia.Select(c.GetHeader("Accept"))
+	if !ok {
+		RespondWithAPIError(c, http.StatusNotAcceptable, errNotAcceptable.Error())
+		return
+	}
+	c.Writer.Header().Add("Vary", "Accept")
+	if _, encoding := formats.Lookup(mediaType); encoding {
+		c.Render(statusCode, encodedBody{mediaType: mediaType, data: defaultBody})
+		return
 	}
-	return fmt.Sprintf("%s://%s", scheme, c.Request.Host)
+	c.Render(statusCode, hypermediaJSON{contentType: mediaType, data: render(mediaType)})
+}
+
+// respondEncoded 依 Accept 標頭選擇編碼輸出錯誤等沒有超媒體格式的回應，沒有可接受的編碼時輸出 JSON
+func respondEncoded(c *gin.Context, statusCode int, body interface{}) {
+	c.Writer.Header().Add("Vary", "Accept")
+	mediaType, ok := hypermedia.Select(c.GetHeader("Accept"))
+	if _, encoding := formats.Lookup(mediaType); !ok || !encoding {
+		mediaType = formats.MediaTypeJSON
+	}
+	c.Render(statusCode, encodedBody{mediaType: mediaType, data: body})
 }
 
 // RespondWithUserHATEOAS 回傳單個使用者的 HATEOAS 響應
 func RespondWithUserHATEOAS(c *gin.Context, statusCode int, user user_models.User) {
-	baseURL := getBaseURL(c)
+	respondWithUser(c, statusCode, user, nil)
+}
+
+// respondWithUser 回傳單個使用者，fields 不為 nil 時只輸出選擇的欄位，連結不受影響
+func respondWithUser(c *gin.Context, statusCode int, user user_models.User, fields user_models.FieldSet) {
+	baseURL := getAPIBaseURL(c)
+
+	// ETag 為目前的版本號，可作為還原版本時的 If-Match
+	if user.Version > 0 {
+		c.Header("ETag", strconv.Quote(strconv.FormatInt(user.Version, 10)))
+	}
 
 	response := user_models.UserResponse{
-		Data:  user,
-		Links: user_models.GenerateUserLinks(baseURL, user.ID.Hex()),
+		Data:  user_models.NewUserView(user).Select(fields),
+		Links: localizeLinks(c, user_models.GenerateUserLinks(baseURL, user.ID.Hex(), user.Status)),
 	}
 
-	c.JSON(statusCode, response)
+	respondNegotiated(c, statusCode, response, func(mediaType string) interface{} {
+		return hypermedia.RenderResource(mediaType, userResource(response.Data, response.Links))
+	})
+}
+
+func userResource(user user_models.UserView, links []user_models.HATEOASLink) hypermedia.Resource {
+	return hypermedia.Resource{Type: "users", ID: user.ID, Data: user, Links: links}
 }
 
 // RespondWithUsersHATEOAS 回傳多個使用者的 HATEOAS 響應
 func RespondWithUsersHATEOAS(c *gin.Context, statusCode int, users []user_models.User, page int, size int, total int) {
-	baseURL := getBaseURL(c)
+	respondWithUsers(c, statusCode, users, page, size, total, nil)
+}
+
+// respondWithUsers 回傳多個使用者，fields 不為 nil 時每個使用者只輸出選擇的欄位
+func respondWithUsers(c *gin.Context, statusCode int, users []user_models.User, page int, size int, total int, fields user_models.FieldSet) {
+	baseURL := getAPIBaseURL(c)
 
+	views := user_models.NewUserViews(users)
+	for i := range views {
+		views[i] = views[i].Select(fields)
+	}
 	response := user_models.UsersCollectionResponse{
-		Data:  users,
-		Links: user_models.GenerateUsersCollectionLinks(baseURL, page, size, total),
+		Data:  views,
+		Links: localizeLinks(c, user_models.GenerateUsersCollectionLinks(baseURL, page, size, total)),
 		Page:  page,
 		Size:  size,
 		Total: total,
 	}
 
-	c.JSON(statusCode, response)
+	respondNegotiated(c, statusCode, response, func(mediaType string) interface{} {
+		items := make([]hypermedia.Resource, 0, len(response.Data))
+		for _, user := range response.Data {
+			items = append(items, userResource(user, localizeLinks(c, user_models.GenerateUserLinks(baseURL, user.ID, user.Status))))
+		}
+		return hypermedia.RenderCollection(mediaType, hypermedia.Collection{
+			Type:  "users",
+			Items: items,
+			Links: response.Links,
+			Meta:  map[string]interface{}{"page": page, "size": size, "total": total},
+		})
+	})
 }
 
 // RespondWithAPIError 回傳 API 錯誤響應
 func RespondWithAPIError(c *gin.Context, statusCode int, errMessage string) {
 	response := user_models.APIResponse{
 		Status:  statusCode,
-		Message: "操作失敗",
-		Error:   errMessage,
+		Message: tr(c, "Operation failed"),
+		Error:   tr(c, errMessage),
 	}
 
-	c.JSON(statusCode, response)
+	respondEncoded(c, statusCode, response)
 }
 
 // RespondWithAPISuccess 回傳 API 成功響應
 func RespondWithAPISuccess(c *gin.Context, statusCode int, message string, data interface{}, links []user_models.HATEOASLink) {
 	response := user_models.APIResponse{
 		Status:  statusCode,
-		Message: message,
+		Message: tr(c, message),
 		Data:    data,
-		Links:   links,
+		Links:   localizeLinks(c, links),
 	}
 
-	c.JSON(statusCode, response)
+	respondEncoded(c, statusCode, response)
+}
+
+// hypermediaJSON 以指定的超媒體 Content-Type 輸出 JSON
+type hypermediaJSON struct {
+	contentType string
+	data        interface{}
+}
+
+func (r hypermediaJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.data)
+}
+
+func (r hypermediaJSON) WriteContentType(w http.ResponseWriter) {
+	w.Header()["Content-Type"] = []string{r.contentType + "; charset=utf-8"}
+}
+
+// encodedBody 以協商出的編碼輸出回應內容
+type encodedBody struct {
+	mediaType string
+	data      interface{}
+}
+
+func (r encodedBody) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	body, err := formats.Marshal(r.mediaType, r.data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (r encodedBody) WriteContentType(w http.ResponseWriter) {
+	contentType := r.mediaType
+	if formats.IsText(r.mediaType) {
+		contentType += "; charset=utf-8"
+	}
+	w.Header()["Content-Type"] = []string{contentType}
 }
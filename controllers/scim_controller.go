@@ -0,0 +1,507 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go-api_for_main/config"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/scim"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const scimContentType = "application/scim+json"
+
+// scimActor SCIM 佈建所做變更記錄的操作者
+const scimActor = "scim"
+
+var scimConfig config.SCIMConfig
+
+// SetupSCIMController 初始化 SCIM 佈建端點，使用與用戶控制器相同的 users 集合
+func SetupSCIMController(cfg config.SCIMConfig) {
+	scimConfig = cfg
+}
+
+// SCIMAuth 要求請求帶有與 SCIM_BEARER_TOKEN 相同的 Bearer 權杖；未設定權杖時所有請求回應 503，
+// 避免佈建端點在未設定的部署中對外開放
+func SCIMAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scimConfig.BearerToken == "" {
+			respondSCIMError(c, http.StatusServiceUnavailable, "", "SCIM provisioning is disabled until SCIM_BEARER_TOKEN is configured")
+			c.Abort()
+			return
+		}
+		expected := "Bearer " + scimConfig.BearerToken
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte(expected)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="scim"`)
+			respondSCIMError(c, http.StatusUnauthorized, "", "a valid bearer token is required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func scimBaseURL(c *gin.Context) string {
+	return getBaseURL(c) + "/scim/v2"
+}
+
+func respondSCIM(c *gin.Context, statusCode int, body interface{}) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(statusCode, body)
+}
+
+// respondSCIMError 回傳 RFC 7644 §3.12 格式的錯誤
+func respondSCIMError(c *gin.Context, statusCode int, scimType, detail string) {
+	respondSCIM(c, statusCode, user_models.SCIMError{
+		Schemas:  []string{user_models.SCIMSchemaError},
+		Status:   strconv.Itoa(statusCode),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// respondSCIMRequestError 依錯誤類型對應 scimType
+func respondSCIMRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, scim.ErrInvalidFilter):
+		respondSCIMError(c, http.StatusBadRequest, "invalidFilter", err.Error())
+	case errors.Is(err, scim.ErrInvalidPath):
+		respondSCIMError(c, http.StatusBadRequest, "invalidPath", err.Error())
+	case errors.Is(err, scim.ErrNoTarget):
+		respondSCIMError(c, http.StatusBadRequest, "noTarget", err.Error())
+	case errors.Is(err, scim.ErrInvalidSyntax):
+		respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+	case errors.Is(err, scim.ErrInvalidValue):
+		respondSCIMError(c, http.StatusBadRequest, "invalidValue", err.Error())
+	default:
+		respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+}
+
+func respondSCIMUser(c *gin.Context, statusCode int, user user_models.User) {
+	location := scimBaseURL(c) + "/Users/" + user.ID.Hex()
+	resource := scim.FromUser(user, location)
+	c.Header("ETag", resource.Meta.Version)
+	if statusCode == http.StatusCreated {
+		c.Header("Location", location)
+	}
+	respondSCIM(c, statusCode, resource)
+}
+
+// findSCIMUser 依路徑參數載入使用者，失敗時已回應錯誤
+func findSCIMUser(c *gin.Context) (user_models.User, bool) {
+	var user user_models.User
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		respondSCIMError(c, http.StatusNotFound, "", "User not found")
+		return user, false
+	}
+
+	err = userCollection.FindOne(context.Background(), bson.M{"_id": id, "status": notDeletedFilter()}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondSCIMError(c, http.StatusNotFound, "", "User not found")
+			return user, false
+		}
+		respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		return user, false
+	}
+	return user, true
+}
+
+// checkSCIMPrecondition 檢查 If-Match 標頭，不符合時回應 412
+func checkSCIMPrecondition(c *gin.Context, current user_models.User) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+	etag := scim.ETag(scim.FromUser(current, ""))
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(candidate) == etag {
+			return true
+		}
+	}
+	respondSCIMError(c, http.StatusPreconditionFailed, "", "resource has been modified")
+	return false
+}
+
+// emailTaken 以不分大小寫的方式檢查 userName 是否已被其他使用者使用
+func emailTaken(email string, exclude primitive.ObjectID) (bool, error) {
+	filter := bson.M{"email": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(email) + "$", Options: "i"}}
+	if !exclude.IsZero() {
+		filter["_id"] = bson.M{"$ne": exclude}
+	}
+	count, err := userCollection.CountDocuments(context.Background(), filter)
+	return count > 0, err
+}
+
+// saveSCIMUser 以 updated_at 作為樂觀鎖更新使用者，期間被修改時回應 412
+func saveSCIMUser(c *gin.Context, original, updated user_models.User) bool {
+	if !strings.EqualFold(original.Email, updated.Email) {
+		taken, err := emailTaken(updated.Email, original.ID)
+		if err != nil {
+			respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+			return false
+		}
+		if taken {
+			respondSCIMError(c, http.StatusConflict, "uniqueness", "userName is already in use")
+			return false
+		}
+	}
+
+	updated.ID = original.ID
+	updated.CreatedAt = original.CreatedAt
+	updated.CreatedBy = original.CreatedBy
+	updated.UpdatedAt = serverClock.Now()
+	updated.UpdatedBy = scimActor
+	updated.Version = original.Version + 1
+
+	// active 的變更必須是合法的生命週期轉換
+	if updated.Status.Effective() != original.Status.Effective() {
+		action, ok := user_models.FindTransition(original.Status, updated.Status)
+		if !ok {
+			respondSCIMError(c, http.StatusBadRequest, "mutability", "cannot change active for a user in state "+string(original.Status.Effective()))
+			return false
+		}
+		updated.StatusHistory = append(updated.StatusHistory, user_models.StatusTransition{
+			From:   original.Status.Effective(),
+			To:     updated.Status,
+			Action: action.Name,
+			Reason: "SCIM provisioning",
+			Actor:  scimActor,
+			At:     updated.UpdatedAt,
+		})
+	}
+
+	// 取代整份文件時保留尚未轉送的事件
+	events := user_models.UserEvents(&original, updated, updated.UpdatedAt)
+	updated.Outbox = append(append([]user_models.OutboxEvent{}, original.Outbox...), events...)
+
+	result, err := userCollection.ReplaceOne(context.Background(),
+		bson.M{"_id": original.ID, "updated_at": original.UpdatedAt}, updated)
+	if mongo.IsDuplicateKeyError(err) {
+		respondSCIMError(c, http.StatusConflict, "uniqueness", "userName is already in use")
+		return false
+	}
+	if err != nil {
+		respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		return false
+	}
+	if result.MatchedCount == 0 {
+		respondSCIMError(c, http.StatusPreconditionFailed, "", "resource was modified concurrently")
+		return false
+	}
+
+	recordUserChange(c, scimActor, user_models.AuditUserUpdate, updated.ID, &original, &updated)
+	respondSCIMUser(c, http.StatusOK, updated)
+	return true
+}
+
+// SCIMListUsers 依 filter、sortBy 與 startIndex/count 分頁查詢使用者
+func SCIMListUsers(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		respondSCIMError(c, http.StatusServiceUnavailable, "", "Database service is currently unavailable")
+		return
+	}
+
+	query := bson.M{"status": notDeletedFilter()}
+	if filter := c.Query("filter"); filter != "" {
+		expr, err := scim.ParseFilter(filter)
+		if err != nil {
+			respondSCIMRequestError(c, err)
+			return
+		}
+		filterQuery, err := scim.ToMongo(expr)
+		if err != nil {
+			respondSCIMRequestError(c, err)
+			return
+		}
+		query = bson.M{"$and": bson.A{query, filterQuery}}
+	}
+
+	startIndex, err := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(scim.DefaultCount)))
+	if err != nil || count < 0 {
+		count = 0
+	}
+	if count > scim.MaxResults {
+		count = scim.MaxResults
+	}
+
+	total, err := userCollection.CountDocuments(context.Background(), query)
+	if err != nil {
+		respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	resources := make([]interface{}, 0, count)
+	if count > 0 {
+		findOptions := options.Find().SetSkip(int64(startIndex - 1)).SetLimit(int64(count))
+		sortField := "_id"
+		if sortBy := c.Query("sortBy"); sortBy != "" {
+			if sortField, err = scim.SortField(sortBy); err != nil {
+				respondSCIMRequestError(c, err)
+				return
+			}
+		}
+		order := 1
+		if strings.EqualFold(c.Query("sortOrder"), "descending") {
+			order = -1
+		}
+		findOptions.SetSort(bson.D{{Key: sortField, Value: order}})
+
+		cursor, err := userCollection.Find(context.Background(), query, findOptions)
+		if err != nil {
+			respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+		defer cursor.Close(context.Background())
+
+		var users []user_models.User
+		if err := cursor.All(context.Background(), &users); err != nil {
+			respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+		for _, user := range users {
+			resources = append(resources, scim.FromUser(user, scimBaseURL(c)+"/Users/"+user.ID.Hex()))
+		}
+	}
+
+	respondSCIM(c, http.StatusOK, user_models.SCIMListResponse{
+		Schemas:      []string{user_models.SCIMSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// SCIMGetUser 取得單一 SCIM 使用者，支援 If-None-Match
+func SCIMGetUser(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		respondSCIMError(c, http.StatusServiceUnavailable, "", "Database service is currently unavailable")
+		return
+	}
+
+	user, ok := findSCIMUser(c)
+	if !ok {
+		return
+	}
+
+	etag := scim.ETag(scim.FromUser(user, ""))
+	if c.GetHeader("If-None-Match") == etag {
+		c.Header("ETag", etag)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	respondSCIMUser(c, http.StatusOK, user)
+}
+
+// SCIMCreateUser 由 SCIM 資源建立使用者
+func SCIMCreateUser(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		respondSCIMError(c, http.StatusServiceUnavailable, "", "Database service is currently unavailable")
+		return
+	}
+
+	var resource user_models.SCIMUser
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	user, err := scim.ToUser(resource, user_models.User{})
+	if err != nil {
+		respondSCIMRequestError(c, err)
+		return
+	}
+
+	taken, err := emailTaken(user.Email, primitive.NilObjectID)
+	if err != nil {
+		respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	if taken {
+		respondSCIMError(c, http.StatusConflict, "uniqueness", "userName is already in use")
+		return
+	}
+
+	if user.Status == "" {
+		user.Status = user_models.StatusActive
+	}
+	now := serverClock.Now()
+	user.CreatedAt, user.UpdatedAt = now, now
+	user.CreatedBy, user.UpdatedBy = scimActor, scimActor
+	user.Version = 1
+	user = withCreatedEvent(user)
+	result, err := userCollection.InsertOne(context.Background(), user)
+	if mongo.IsDuplicateKeyError(err) {
+		respondSCIMError(c, http.StatusConflict, "uniqueness", "userName is already in use")
+		return
+	}
+	if err != nil {
+		respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	recordUserChange(c, scimActor, user_models.AuditUserCreate, user.ID, nil, &user)
+	respondSCIMUser(c, http.StatusCreated, user)
+}
+
+// SCIMReplaceUser 以 PUT 取代整個 SCIM 使用者，支援 If-Match
+func SCIMReplaceUser(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		respondSCIMError(c, http.StatusServiceUnavailable, "", "Database service is currently unavailable")
+		return
+	}
+
+	var resource user_models.SCIMUser
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	original, ok := findSCIMUser(c)
+	if !ok || !checkSCIMPrecondition(c, original) {
+		return
+	}
+
+	updated, err := scim.ToUser(resource, original)
+	if err != nil {
+		respondSCIMRequestError(c, err)
+		return
+	}
+
+	saveSCIMUser(c, original, updated)
+}
+
+// SCIMPatchUser 套用 PatchOp 操作，支援 If-Match
+func SCIMPatchUser(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		respondSCIMError(c, http.StatusServiceUnavailable, "", "Database service is currently unavailable")
+		return
+	}
+
+	var req user_models.SCIMPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	if len(req.Operations) == 0 {
+		respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", "Operations must not be empty")
+		return
+	}
+
+	original, ok := findSCIMUser(c)
+	if !ok || !checkSCIMPrecondition(c, original) {
+		return
+	}
+
+	resource, err := scim.ApplyPatch(scim.FromUser(original, ""), req.Operations)
+	if err != nil {
+		respondSCIMRequestError(c, err)
+		return
+	}
+
+	updated, err := scim.ToUser(resource, original)
+	if err != nil {
+		respondSCIMRequestError(c, err)
+		return
+	}
+
+	saveSCIMUser(c, original, updated)
+}
+
+// SCIMDeleteUser 刪除 SCIM 使用者，支援 If-Match
+func SCIMDeleteUser(c *gin.Context) {
+	if err := checkMongoDBConnection(); err != nil {
+		respondSCIMError(c, http.StatusServiceUnavailable, "", "Database service is currently unavailable")
+		return
+	}
+
+	user, ok := findSCIMUser(c)
+	if !ok || !checkSCIMPrecondition(c, user) {
+		return
+	}
+
+	action, _ := user_models.FindLifecycleAction("delete")
+	deleted, err := applyTransition(context.Background(), user.ID, action, "SCIM deprovisioning", scimActor)
+	if err != nil {
+		if errors.Is(err, ErrConcurrentModification) {
+			respondSCIMError(c, http.StatusPreconditionFailed, "", err.Error())
+			return
+		}
+		respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	recordTransition(c, scimActor, deleted)
+
+	c.Status(http.StatusNoContent)
+}
+
+// SCIMServiceProviderConfig 回傳服務提供者能力說明
+func SCIMServiceProviderConfig(c *gin.Context) {
+	respondSCIM(c, http.StatusOK, scim.ServiceProviderConfig(scimBaseURL(c)))
+}
+
+// SCIMResourceTypes 回傳支援的資源類型
+func SCIMResourceTypes(c *gin.Context) {
+	types := scim.ResourceTypes(scimBaseURL(c))
+	if name := c.Param("name"); name != "" {
+		for _, t := range types {
+			if t["id"] == name {
+				respondSCIM(c, http.StatusOK, t)
+				return
+			}
+		}
+		respondSCIMError(c, http.StatusNotFound, "", "ResourceType not found")
+		return
+	}
+	respondSCIM(c, http.StatusOK, scimList(types))
+}
+
+// SCIMSchemas 回傳支援的 schema 定義
+func SCIMSchemas(c *gin.Context) {
+	schemas := scim.Schemas(scimBaseURL(c))
+	if id := c.Param("id"); id != "" {
+		for _, s := range schemas {
+			if strings.EqualFold(s["id"].(string), id) {
+				respondSCIM(c, http.StatusOK, s)
+				return
+			}
+		}
+		respondSCIMError(c, http.StatusNotFound, "", "Schema not found")
+		return
+	}
+	respondSCIM(c, http.StatusOK, scimList(schemas))
+}
+
+func scimList(items []map[string]interface{}) user_models.SCIMListResponse {
+	resources := make([]interface{}, len(items))
+	for i, item := range items {
+		resources[i] = item
+	}
+	return user_models.SCIMListResponse{
+		Schemas:      []string{user_models.SCIMSchemaListResponse},
+		TotalResults: int64(len(items)),
+		StartIndex:   1,
+		ItemsPerPage: len(items),
+		Resources:    resources,
+	}
+}
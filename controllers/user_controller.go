@@ -2,25 +2,43 @@ package controllers
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"log"
 	"net/http"
-	"time"
+	"regexp"
+	"strconv"
+	"strings"
 
 	user_models "go-api_for_main/models"
+	"go-api_for_main/validation"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var userCollection *mongo.Collection
 var ErrMongoDBNotConnected = errors.New("MongoDB is not connected")
 
+// errEmailInUse 表示更新後的 email 已被其他用戶使用
+var errEmailInUse = errors.New("email is already in use")
+
 // SetupUserController 初始化用戶控制器
 func SetupUserController(db *mongo.Database) {
 	if db != nil {
 		userCollection = db.Collection("users")
+		// email 不分大小寫唯一；已有重複資料時建立會失敗，仍由 emailTaken 在寫入前檢查
+		_, err := userCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email_unique").SetUnique(true).
+				SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+		})
+		if err != nil {
+			log.Printf("Warning: creating users email index failed: %v\n", err)
+		}
 	}
 }
 
@@ -36,8 +54,11 @@ func checkMongoDBConnection() error {
 // @Description 獲取系統中的所有用戶列表
 // @Tags users
 // @Accept json
-// @Produce json
+// @Produce json,application/hal+json,application/vnd.api+json,application/vnd.siren+json,application/msgpack,application/cbor,application/xml,application/yaml
+// @Param status query string false "只列出指定狀態的用戶" Enums(pending_verification, active, suspended, locked, deactivated)
+// @Param fields query string false "只回傳這些欄位，以逗號分隔，例如 name,email；id 一律回傳"
 // @Success 200 {object} user_models.UsersCollectionResponse
+// @Failure 400 {object} user_models.APIResponse
 // @Failure 500 {object} user_models.APIResponse
 // @Router /users [get]
 func GetUsers(c *gin.Context) {
@@ -46,12 +67,23 @@ func GetUsers(c *gin.Context) {
 		return
 	}
 
+	fields, ok := userFields(c)
+	if !ok {
+		return
+	}
+
 	// 獲取分頁參數
 	page := 1
 	size := 10
 
+	filter := usersFilter(c)
+
 	var users []user_models.User
-	cursor, err := userCollection.Find(context.Background(), bson.M{})
+	opts := options.Find()
+	if fields != nil {
+		opts.SetProjection(fields.Projection())
+	}
+	cursor, err := userCollection.Find(context.Background(), filter, opts)
 	if err != nil {
 		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
 		return
@@ -66,7 +98,87 @@ func GetUsers(c *gin.Context) {
 	// 計算總記錄數
 	total := len(users)
 
-	RespondWithUsersHATEOAS(c, http.StatusOK, users, page, size, total)
+	respondWithUsers(c, http.StatusOK, users, page, size, total, fields)
+}
+
+// userFields 解析 fields 查詢參數，包含不允許的欄位時回應 400 並回傳 false
+func userFields(c *gin.Context) (user_models.FieldSet, bool) {
+	fields, unknown, ok := user_models.ParseFieldSet(c.Query("fields"))
+	if !ok {
+		respondEncoded(c, http.StatusBadRequest, user_models.APIResponse{
+			Status:  http.StatusBadRequest,
+			Message: tr(c, "Operation failed"),
+			Error:   tr(c, "fields contains a field that cannot be selected: %s", unknown),
+		})
+	}
+	return fields, ok
+}
+
+// usersFilter 用戶列表與匯出共用的查詢條件，已刪除的用戶不會出現在結果中
+func usersFilter(c *gin.Context) bson.M {
+	return usersFilterFor(c.Query("status"))
+}
+
+// usersFilterFor 建立用戶列表的查詢條件，status 為空時列出所有未刪除的用戶
+func usersFilterFor(status string) bson.M {
+	filter := bson.M{"status": notDeletedFilter()}
+	if status != "" {
+		filter["status"] = statusFilter(user_models.UserStatus(status))
+	}
+	return filter
+}
+
+// userSearchFilter GraphQL 與 gRPC 共用的搜尋條件：name 不分大小寫部分比對，email 不分大小寫完全比對，空值不限制
+func userSearchFilter(status user_models.UserStatus, name, email, role string) bson.M {
+	filter := usersFilterFor(string(status))
+	if name != "" {
+		filter["name"] = primitive.Regex{Pattern: regexp.QuoteMeta(name), Options: "i"}
+	}
+	if email != "" {
+		filter["email"] = emailPattern(validation.NormalizeEmail(email))
+	}
+	if role != "" {
+		filter["role"] = role
+	}
+	return filter
+}
+
+// findUserPage 依排序載入第 offset 筆之後最多 limit 筆用戶，多取一筆以判斷是否還有下一頁；
+// 最後以 _id 排序確保分頁結果穩定
+func findUserPage(ctx context.Context, filter bson.M, sort bson.D, offset, limit int) ([]user_models.User, bool, error) {
+	opts := options.Find().
+		SetSort(append(sort, bson.E{Key: "_id", Value: 1})).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit + 1))
+	cursor, err := userCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	users := []user_models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, false, err
+	}
+	if len(users) > limit {
+		return users[:limit], true, nil
+	}
+	return users, false, nil
+}
+
+// encodeCursor GraphQL 游標與 gRPC 分頁權杖，內容為用戶在排序結果中的位置
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, bool) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), "cursor:"))
+	if err != nil || !strings.HasPrefix(string(data), "cursor:") || offset < 0 {
+		return 0, false
+	}
+	return offset, true
 }
 
 func GetUsers_test(c *gin.Context) {
@@ -82,22 +194,28 @@ func GetUsers_test(c *gin.Context) {
 		Age:       20,
 		Phone:     "1234567890",
 		Address:   "台北市",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Status:    user_models.StatusActive,
+		CreatedAt: serverClock.Now(),
+		UpdatedAt: serverClock.Now(),
 	})
 
-	RespondWithUsersHATEOAS(c, http.StatusOK, users, 1, 10, len(users))
+	fields, ok := userFields(c)
+	if !ok {
+		return
+	}
+	respondWithUsers(c, http.StatusOK, users, 1, 10, len(users), fields)
 }
 
 // CreateUser godoc
 // @Summary 創建新用戶
-// @Description 創建一個新的用戶
+// @Description 創建一個新的用戶，id、狀態、版本與時間戳記由伺服器設定
 // @Tags users
-// @Accept json
-// @Produce json
-// @Param user body user_models.User true "用戶信息"
+// @Accept json,application/msgpack,application/cbor,application/xml,application/yaml
+// @Produce json,application/hal+json,application/vnd.api+json,application/vnd.siren+json,application/msgpack,application/cbor,application/xml,application/yaml
+// @Param user body user_models.CreateUserRequest true "用戶信息"
 // @Success 201 {object} user_models.UserResponse
 // @Failure 400 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
 // @Failure 500 {object} user_models.APIResponse
 // @Router /users [post]
 func CreateUser(c *gin.Context) {
@@ -106,22 +224,62 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
-	var user user_models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		RespondWithAPIError(c, http.StatusBadRequest, err.Error())
+	var req user_models.CreateUserRequest
+	if err := bindBody(c, &req); err != nil {
+		respondBindingError(c, err)
 		return
 	}
 
-	result, err := userCollection.InsertOne(context.Background(), user)
+	user, err := insertUser(context.Background(), user_models.AdminCreateUserRequest{CreateUserRequest: req}, currentActor(c))
 	if err != nil {
+		if errors.Is(err, errEmailInUse) {
+			RespondWithAPIError(c, http.StatusConflict, localizeError(c, err))
+			return
+		}
 		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	user.ID = result.InsertedID.(primitive.ObjectID)
+	recordUserChange(c, user.CreatedBy, user_models.AuditUserCreate, user.ID, nil, &user)
 	RespondWithUserHATEOAS(c, http.StatusCreated, user)
 }
 
+// insertUser 以已驗證的請求建立 active 狀態的用戶，REST、匯入、GraphQL 與 gRPC 共用；
+// 角色只有限管理員的路徑可以指定，公開的建立一律為 member；email 已被使用時回傳 errEmailInUse
+func insertUser(ctx context.Context, req user_models.AdminCreateUserRequest, actor string) (user_models.User, error) {
+	taken, err := emailTaken(req.Email, primitive.NilObjectID)
+	if err != nil {
+		return user_models.User{}, err
+	}
+	if taken {
+		return user_models.User{}, errEmailInUse
+	}
+
+	// 密碼以 bcrypt 雜湊保存，供身分提供者登入時比對
+	hashed, err := user_models.HashPassword(req.Password)
+	if err != nil {
+		return user_models.User{}, err
+	}
+	user := req.ToUser(hashed)
+	user.Status = user_models.StatusActive
+	user.CreatedAt = serverClock.Now()
+	user.UpdatedAt = user.CreatedAt
+	user.CreatedBy = actor
+	user.UpdatedBy = actor
+	user.Version = 1
+	user = withCreatedEvent(user)
+
+	result, err := userCollection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return user_models.User{}, errEmailInUse
+	}
+	if err != nil {
+		return user_models.User{}, err
+	}
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	return user, nil
+}
+
 func CreateUser_test(c *gin.Context) {
 
 	var user user_models.User
@@ -135,8 +293,9 @@ func CreateUser_test(c *gin.Context) {
 		Age:       20,
 		Phone:     "1234567890",
 		Address:   "台北市",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Status:    user_models.StatusActive,
+		CreatedAt: serverClock.Now(),
+		UpdatedAt: serverClock.Now(),
 	}
 
 	RespondWithUserHATEOAS(c, http.StatusCreated, user)
@@ -147,8 +306,9 @@ func CreateUser_test(c *gin.Context) {
 // @Description 通過ID獲取特定用戶的信息
 // @Tags users
 // @Accept json
-// @Produce json
+// @Produce json,application/hal+json,application/vnd.api+json,application/vnd.siren+json,application/msgpack,application/cbor,application/xml,application/yaml
 // @Param id path string true "用戶ID"
+// @Param fields query string false "只回傳這些欄位，以逗號分隔，例如 name,email；id 一律回傳"
 // @Success 200 {object} user_models.UserResponse
 // @Failure 400 {object} user_models.APIResponse
 // @Failure 404 {object} user_models.APIResponse
@@ -166,10 +326,18 @@ func GetUser(c *gin.Context) {
 		return
 	}
 
-	var user user_models.User
-	err = userCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&user)
+	fields, ok := userFields(c)
+	if !ok {
+		return
+	}
+
+	opts := options.FindOne()
+	if fields != nil {
+		opts.SetProjection(fields.Projection())
+	}
+	user, err := findActiveUser(context.Background(), id, opts)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, ErrUserNotFound) {
 			RespondWithAPIError(c, http.StatusNotFound, "User not found")
 			return
 		}
@@ -177,7 +345,17 @@ func GetUser(c *gin.Context) {
 		return
 	}
 
-	RespondWithUserHATEOAS(c, http.StatusOK, user)
+	respondWithUser(c, http.StatusOK, user, fields)
+}
+
+// findActiveUser 載入未刪除的用戶，找不到時回傳 ErrUserNotFound
+func findActiveUser(ctx context.Context, id primitive.ObjectID, opts ...*options.FindOneOptions) (user_models.User, error) {
+	var user user_models.User
+	err := userCollection.FindOne(ctx, bson.M{"_id": id, "status": notDeletedFilter()}, opts...).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return user, ErrUserNotFound
+	}
+	return user, err
 }
 
 func GetUser_test(c *gin.Context) {
@@ -198,63 +376,146 @@ func GetUser_test(c *gin.Context) {
 		Age:       20,
 		Phone:     "1234567890",
 		Address:   "台北市",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Status:    user_models.StatusActive,
+		CreatedAt: serverClock.Now(),
+		UpdatedAt: serverClock.Now(),
 	}
 
-	RespondWithUserHATEOAS(c, http.StatusOK, user)
+	fields, ok := userFields(c)
+	if !ok {
+		return
+	}
+	respondWithUser(c, http.StatusOK, user, fields)
 }
 
 // UpdateUser godoc
 // @Summary 更新用戶
-// @Description 更新特定用戶的信息
+// @Description 以請求內容取代特定用戶的個人資料，密碼、狀態與時間戳記不可由此變更
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,application/msgpack,application/cbor,application/xml,application/yaml
+// @Produce json,application/hal+json,application/vnd.api+json,application/vnd.siren+json,application/msgpack,application/cbor,application/xml,application/yaml
 // @Param id path string true "用戶ID"
-// @Param user body user_models.User true "用戶信息"
-// @Success 200 {object} user_models.APIResponse
-// @Failure 400 {object} user_models.APIResponse
-// @Failure 404 {object} user_models.APIResponse
-// @Failure 500 {object} user_models.APIResponse
+// @Param user body user_models.UpdateUserRequest true "用戶信息"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.ErrorResponse
+// @Failure 404 {object} user_models.ErrorResponse
+// @Failure 409 {object} user_models.ErrorResponse
+// @Failure 500 {object} user_models.ErrorResponse
 // @Router /users/{id} [put]
 func UpdateUser(c *gin.Context) {
+	var req user_models.UpdateUserRequest
+	updateProfile(c, &req, func(user *user_models.User) { req.Apply(user) })
+}
+
+// PatchUser godoc
+// @Summary 部分更新用戶
+// @Description 只更新請求中提供的欄位，密碼、狀態與時間戳記不可由此變更
+// @Tags users
+// @Accept json,application/msgpack,application/cbor,application/xml,application/yaml
+// @Produce json,application/hal+json,application/vnd.api+json,application/vnd.siren+json,application/msgpack,application/cbor,application/xml,application/yaml
+// @Param id path string true "用戶ID"
+// @Param user body user_models.PatchUserRequest true "要更新的欄位"
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.ErrorResponse
+// @Failure 404 {object} user_models.ErrorResponse
+// @Failure 409 {object} user_models.ErrorResponse
+// @Failure 500 {object} user_models.ErrorResponse
+// @Router /users/{id} [patch]
+func PatchUser(c *gin.Context) {
+	var req user_models.PatchUserRequest
+	updateProfile(c, &req, func(user *user_models.User) { req.Apply(user) })
+}
+
+// updateProfile PUT 與 PATCH 共用的流程：綁定請求、載入用戶、套用變更後保存
+func updateProfile(c *gin.Context, req interface{}, apply func(*user_models.User)) {
 	if err := checkMongoDBConnection(); err != nil {
-		c.JSON(http.StatusServiceUnavailable, user_models.ErrorResponse{Error: "Database service is currently unavailable"})
+		respondEncoded(c, http.StatusServiceUnavailable, user_models.ErrorResponse{Error: tr(c, "Database service is currently unavailable")})
 		return
 	}
 
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, user_models.ErrorResponse{Error: "Invalid ID"})
+		respondEncoded(c, http.StatusBadRequest, user_models.ErrorResponse{Error: tr(c, "Invalid ID")})
 		return
 	}
 
-	var user user_models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, user_models.ErrorResponse{Error: err.Error()})
+	if err := bindBody(c, req); err != nil {
+		respondEncoded(c, bindingStatus(err), bindingErrorResponse(c, err))
 		return
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"name":  user.Name,
-			"email": user.Email,
-		},
+	original, err := findActiveUser(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			respondEncoded(c, http.StatusNotFound, user_models.ErrorResponse{Error: tr(c, "User not found")})
+			return
+		}
+		respondEncoded(c, http.StatusInternalServerError, user_models.ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	result, err := userCollection.UpdateOne(context.Background(), bson.M{"_id": id}, update)
+	updated := original
+	apply(&updated)
+	saved, err := saveProfile(context.Background(), original, updated, currentActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, user_models.ErrorResponse{Error: err.Error()})
+		switch {
+		case errors.Is(err, errEmailInUse), errors.Is(err, ErrConcurrentModification):
+			respondEncoded(c, http.StatusConflict, user_models.ErrorResponse{Error: localizeError(c, err)})
+		default:
+			respondEncoded(c, http.StatusInternalServerError, user_models.ErrorResponse{Error: err.Error()})
+		}
 		return
 	}
+	recordUserChange(c, saved.UpdatedBy, user_models.AuditUserUpdate, saved.ID, &original, &saved)
 
+	RespondWithUserHATEOAS(c, http.StatusOK, saved)
+}
+
+// saveProfile 保存個人資料欄位並遞增版本，以 updated_at 作為樂觀鎖；email 變更時檢查是否已被其他用戶使用
+func saveProfile(ctx context.Context, original, updated user_models.User, actor string) (user_models.User, error) {
+	if !strings.EqualFold(original.Email, updated.Email) {
+		taken, err := emailTaken(updated.Email, original.ID)
+		if err != nil {
+			return original, err
+		}
+		if taken {
+			return original, errEmailInUse
+		}
+	}
+
+	updated.UpdatedAt = serverClock.Now()
+	updated.UpdatedBy = actor
+	updated.Version = original.Version + 1
+	update := bson.M{"$set": profileSet(updated)}
+	if events := user_models.UserEvents(&original, updated, updated.UpdatedAt); len(events) > 0 {
+		update["$push"] = bson.M{"outbox": outboxEach(events)}
+	}
+	result, err := userCollection.UpdateOne(ctx, bson.M{"_id": original.ID, "updated_at": original.UpdatedAt}, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return original, errEmailInUse
+	}
+	if err != nil {
+		return original, err
+	}
 	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, user_models.ErrorResponse{Error: "User not found"})
-		return
+		return original, ErrConcurrentModification
 	}
+	return updated, nil
+}
 
-	c.JSON(http.StatusOK, user_models.SuccessResponse{Message: "User updated successfully"})
+// profileSet 個人資料欄位與修改紀錄的 $set 內容
+func profileSet(user user_models.User) bson.M {
+	return bson.M{
+		"name":       user.Name,
+		"email":      user.Email,
+		"sex":        user.Sex,
+		"age":        user.Age,
+		"phone":      user.Phone,
+		"address":    user.Address,
+		"updated_at": user.UpdatedAt,
+		"updated_by": user.UpdatedBy,
+		"version":    user.Version,
+	}
 }
 
 func UpdateUser_test(c *gin.Context) {
@@ -263,72 +524,76 @@ func UpdateUser_test(c *gin.Context) {
 	// 將字符串ID轉換為ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, user_models.ErrorResponse{Error: "Invalid ID"})
+		respondEncoded(c, http.StatusBadRequest, user_models.ErrorResponse{Error: tr(c, "Invalid ID")})
 		return
 	}
 
-	var updateUser user_models.User
-	if err := c.ShouldBindJSON(&updateUser); err != nil {
-		c.JSON(http.StatusBadRequest, user_models.ErrorResponse{Error: err.Error()})
+	var req user_models.UpdateUserRequest
+	if err := bindBody(c, &req); err != nil {
+		respondEncoded(c, bindingStatus(err), bindingErrorResponse(c, err))
 		return
 	}
 
 	// 模擬更新後的用戶數據
 	user := user_models.User{
 		ID:        objectID,
-		Name:      updateUser.Name,
-		Email:     updateUser.Email,
 		Password:  "1234567890",
-		Sex:       "男",
-		Age:       20,
-		Phone:     "1234567890",
-		Address:   "台北市",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Status:    user_models.StatusActive,
+		CreatedAt: serverClock.Now(),
+		UpdatedAt: serverClock.Now(),
+		UpdatedBy: currentActor(c),
+		Version:   2,
 	}
+	req.Apply(&user)
 
-	c.JSON(http.StatusOK, user)
+	RespondWithUserHATEOAS(c, http.StatusOK, user)
 }
 
 // DeleteUser godoc
 // @Summary 刪除用戶
-// @Description 刪除特定用戶
+// @Description 將特定用戶轉為 deleted 狀態
 // @Tags users
 // @Accept json
-// @Produce json
+// @Produce json,application/msgpack,application/cbor,application/xml,application/yaml
 // @Param id path string true "用戶ID"
 // @Success 200 {object} user_models.APIResponse
 // @Failure 400 {object} user_models.APIResponse
 // @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
 // @Failure 500 {object} user_models.APIResponse
 // @Router /users/{id} [delete]
 func DeleteUser(c *gin.Context) {
 	if err := checkMongoDBConnection(); err != nil {
-		c.JSON(http.StatusServiceUnavailable, user_models.ErrorResponse{Error: "Database service is currently unavailable"})
+		respondEncoded(c, http.StatusServiceUnavailable, user_models.ErrorResponse{Error: tr(c, "Database service is currently unavailable")})
 		return
 	}
 
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, user_models.ErrorResponse{Error: "Invalid ID"})
+		respondEncoded(c, http.StatusBadRequest, user_models.ErrorResponse{Error: tr(c, "Invalid ID")})
 		return
 	}
 
-	result, err := userCollection.DeleteOne(context.Background(), bson.M{"_id": id})
+	// 刪除為生命週期的終止狀態，保留文件與狀態歷史
+	action, _ := user_models.FindLifecycleAction("delete")
+	deleted, err := applyTransition(context.Background(), id, action, "", currentActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, user_models.ErrorResponse{Error: err.Error()})
-		return
-	}
-
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, user_models.ErrorResponse{Error: "User not found"})
+		switch {
+		case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrInvalidTransition):
+			respondEncoded(c, http.StatusNotFound, user_models.ErrorResponse{Error: tr(c, "User not found")})
+		case errors.Is(err, ErrConcurrentModification):
+			respondEncoded(c, http.StatusConflict, user_models.ErrorResponse{Error: localizeError(c, err)})
+		default:
+			respondEncoded(c, http.StatusInternalServerError, user_models.ErrorResponse{Error: err.Error()})
+		}
 		return
 	}
+	recordTransition(c, deleted.UpdatedBy, deleted)
 
-	c.JSON(http.StatusOK, user_models.SuccessResponse{Message: "User deleted successfully"})
+	respondEncoded(c, http.StatusOK, user_models.SuccessResponse{Message: tr(c, "User deleted successfully")})
 }
 
 func DeleteUser_test(c *gin.Context) {
 	// 在測試環境中，直接返回刪除成功的訊息
-	c.JSON(http.StatusOK, user_models.SuccessResponse{Message: "User deleted successfully"})
+	respondEncoded(c, http.StatusOK, user_models.SuccessResponse{Message: tr(c, "User deleted successfully")})
 }
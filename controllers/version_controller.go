@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var versionCollection *mongo.Collection
+
+// SetupVersionController 初始化用戶版本歷史控制器
+func SetupVersionController(db *mongo.Database) {
+	if db != nil {
+		versionCollection = db.Collection("user_versions")
+		_, err := versionCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "version", Value: -1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Warning: creating user_versions index failed: %v\n", err)
+		}
+		// 早期的快照保存了密碼雜湊，啟動時一併清除
+		_, err = versionCollection.UpdateMany(context.Background(),
+			bson.M{"snapshot.password": bson.M{"$exists": true, "$ne": ""}},
+			bson.M{"$set": bson.M{"snapshot.password": ""}})
+		if err != nil {
+			log.Printf("Warning: clearing passwords from user_versions failed: %v\n", err)
+		}
+	}
+}
+
+func checkVersionStorage() error {
+	if userCollection == nil || versionCollection == nil {
+		return ErrMongoDBNotConnected
+	}
+	return nil
+}
+
+// recordVersion 保存修改後的快照（不含密碼雜湊）；沒有版本號的舊資料不保存
+func recordVersion(actor, action string, user user_models.User) {
+	if versionCollection == nil || user.Version < 1 {
+		return
+	}
+	_, err := versionCollection.InsertOne(context.Background(), user_models.NewUserVersion(user, action, actor, serverClock.Now()))
+	if err != nil {
+		log.Printf("Error saving version %d of user %s: %v\n", user.Version, user.ID.Hex(), err)
+	}
+}
+
+// parseVersion 解析版本號，版本從 1 開始
+func parseVersion(value string) (int64, bool) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	return n, err == nil && n >= 1
+}
+
+// findVersion 取得用戶的指定版本，失敗時已回應錯誤
+func findVersion(c *gin.Context, userID primitive.ObjectID, n int64) (user_models.UserVersion, bool) {
+	var version user_models.UserVersion
+	err := versionCollection.FindOne(context.Background(), bson.M{"user_id": userID, "version": n}).Decode(&version)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusNotFound, "Version not found")
+			return version, false
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return version, false
+	}
+	return version, true
+}
+
+// versionParams 解析路徑中的用戶ID與版本號，失敗時已回應錯誤
+func versionParams(c *gin.Context) (primitive.ObjectID, int64, bool) {
+	if err := checkVersionStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return primitive.NilObjectID, 0, false
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return id, 0, false
+	}
+	n, ok := parseVersion(c.Param("n"))
+	if !ok {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid version")
+		return id, 0, false
+	}
+	return id, n, requireLiveUser(c, id)
+}
+
+// requireLiveUser 確認用戶存在且未被刪除，已刪除用戶的歷史不再提供；失敗時已回應錯誤
+func requireLiveUser(c *gin.Context, id primitive.ObjectID) bool {
+	err := userCollection.FindOne(context.Background(), bson.M{"_id": id, "status": notDeletedFilter()},
+		options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusNotFound, "User not found")
+			return false
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	return true
+}
+
+// parseIfMatch 解析 If-Match 標頭中的版本號，接受 "3"、W/"3" 與 3
+func parseIfMatch(header string) (int64, bool) {
+	value := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	value = strings.Trim(value, `"`)
+	return parseVersion(value)
+}
+
+// GetUserVersions godoc
+// @Summary 獲取用戶版本列表
+// @Description 分頁列出用戶每次修改後的快照，由新到舊排序；已刪除的用戶回應 404
+// @Tags versions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param page query int false "頁碼" default(1)
+// @Param size query int false "每頁筆數，最多 100" default(20)
+// @Success 200 {object} user_models.UserVersionsResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Router /users/{id}/versions [get]
+func GetUserVersions(c *gin.Context) {
+	if err := checkVersionStorage(); err != nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	page, size, ok := pageParams(c)
+	if !ok || !requireLiveUser(c, id) {
+		return
+	}
+
+	ctx := context.Background()
+	filter := bson.M{"user_id": id}
+	total, err := versionCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cursor, err := versionCollection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetSkip(int64((page-1)*size)).
+		SetLimit(int64(size)))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var versions []user_models.UserVersion
+	if err := cursor.All(ctx, &versions); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	views := make([]user_models.UserVersionView, 0, len(versions))
+	for _, version := range versions {
+		views = append(views, user_models.NewUserVersionView(version))
+	}
+	c.JSON(http.StatusOK, user_models.UserVersionsResponse{
+		Data:  views,
+		Links: localizeLinks(c, user_models.GenerateUserVersionsLinks(getAPIBaseURL(c), id.Hex(), page, size, total)),
+		Page:  page,
+		Size:  size,
+		Total: total,
+	})
+}
+
+// GetUserVersion godoc
+// @Summary 獲取用戶的特定版本
+// @Description 獲取用戶在指定版本的完整快照
+// @Tags versions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param n path int true "版本號"
+// @Success 200 {object} user_models.UserVersionResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Router /users/{id}/versions/{n} [get]
+func GetUserVersion(c *gin.Context) {
+	id, n, ok := versionParams(c)
+	if !ok {
+		return
+	}
+	version, ok := findVersion(c, id, n)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, user_models.UserVersionResponse{
+		Data:  user_models.NewUserVersionView(version),
+		Links: localizeLinks(c, user_models.GenerateUserVersionLinks(getAPIBaseURL(c), id.Hex(), n)),
+	})
+}
+
+// DiffUserVersions godoc
+// @Summary 比較用戶的兩個版本
+// @Description 列出從 against 版本到指定版本之間變更的欄位，未指定 against 時與前一版比較
+// @Tags versions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param n path int true "版本號"
+// @Param against query int false "比較的基準版本"
+// @Success 200 {object} user_models.UserVersionDiffResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Router /users/{id}/versions/{n}/diff [get]
+func DiffUserVersions(c *gin.Context) {
+	id, n, ok := versionParams(c)
+	if !ok {
+		return
+	}
+
+	against := n - 1
+	if value := c.Query("against"); value != "" {
+		if against, ok = parseVersion(value); !ok {
+			RespondWithAPIError(c, http.StatusBadRequest, "Invalid version")
+			return
+		}
+	}
+	if against < 1 {
+		RespondWithAPIError(c, http.StatusBadRequest, "Version 1 has no previous version")
+		return
+	}
+
+	from, ok := findVersion(c, id, against)
+	if !ok {
+		return
+	}
+	to, ok := findVersion(c, id, n)
+	if !ok {
+		return
+	}
+
+	changes := user_models.DiffUsers(&from.Snapshot, &to.Snapshot)
+	if changes == nil {
+		changes = []user_models.FieldChange{}
+	}
+	c.JSON(http.StatusOK, user_models.UserVersionDiffResponse{
+		From:    against,
+		To:      n,
+		Changes: changes,
+		Links:   localizeLinks(c, user_models.GenerateUserVersionLinks(getAPIBaseURL(c), id.Hex(), n)),
+	})
+}
+
+// RestoreUserVersion godoc
+// @Summary 還原用戶至特定版本
+// @Description 以指定版本的個人資料欄位覆寫目前的資料並產生新版本；狀態、角色與密碼不會被還原。If-Match 必須是目前的版本號
+// @Tags versions
+// @Produce json,application/hal+json,application/vnd.api+json,application/vnd.siren+json,application/msgpack,application/cbor,application/xml,application/yaml
+// @Security BearerAuth
+// @Param id path string true "用戶ID"
+// @Param n path int true "要還原的版本號"
+// @Param If-Match header string true "目前的版本號，例如 \"5\""
+// @Success 200 {object} user_models.UserResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 412 {object} user_models.APIResponse
+// @Failure 428 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Router /users/{id}/versions/{n}/restore [post]
+func RestoreUserVersion(c *gin.Context) {
+	id, n, ok := versionParams(c)
+	if !ok {
+		return
+	}
+
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		RespondWithAPIError(c, http.StatusPreconditionRequired, "If-Match header with the current version is required")
+		return
+	}
+	expected, ok := parseIfMatch(header)
+	if !ok {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	version, ok := findVersion(c, id, n)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	var current user_models.User
+	err := userCollection.FindOne(ctx, bson.M{"_id": id, "status": notDeletedFilter()}).Decode(&current)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusNotFound, "User not found")
+			return
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if current.Version != expected {
+		RespondWithAPIError(c, http.StatusPreconditionFailed, localizeError(c, ErrConcurrentModification))
+		return
+	}
+
+	snapshot := version.Snapshot
+	restored := current
+	restored.Name = snapshot.Name
+	restored.Email = snapshot.Email
+	restored.Sex = snapshot.Sex
+	restored.Age = snapshot.Age
+	restored.Phone = snapshot.Phone
+	restored.Address = snapshot.Address
+
+	actor := currentActor(c)
+	saved, err := saveProfile(ctx, current, restored, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, errEmailInUse):
+			RespondWithAPIError(c, http.StatusConflict, localizeError(c, err))
+		case errors.Is(err, ErrConcurrentModification):
+			RespondWithAPIError(c, http.StatusPreconditionFailed, localizeError(c, err))
+		default:
+			RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	recordUserChange(c, actor, user_models.AuditUserRestore, saved.ID, &current, &saved)
+
+	RespondWithUserHATEOAS(c, http.StatusOK, saved)
+}
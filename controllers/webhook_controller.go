@@ -0,0 +1,529 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-api_for_main/config"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	webhookCollection *mongo.Collection
+	webhookDispatcher *webhooks.Dispatcher
+)
+
+// 傳遞紀錄查詢的分頁設定
+const (
+	defaultDeliveryPageSize = 20
+	maxDeliveryPageSize     = 100
+)
+
+// SetupWebhookController 初始化 webhook 訂閱並啟動事件轉送與傳遞，必須在 SetupUserController 之後呼叫
+func SetupWebhookController(db *mongo.Database, cfg config.WebhookConfig) {
+	if db == nil || userCollection == nil {
+		return
+	}
+	dispatcher := webhooks.New(db, userCollection, serverClock, webhooks.Options{
+		Workers:      cfg.Workers,
+		Timeout:      cfg.Timeout,
+		MaxAttempts:  cfg.MaxAttempts,
+		RetryBackoff: cfg.RetryBackoff,
+		Retention:    cfg.Retention,
+		OnPublish:    publishFeedEvent,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dispatcher.EnsureIndexes(ctx); err != nil {
+		log.Printf("Warning: creating webhook indexes failed: %v\n", err)
+	}
+
+	dispatcher.Start(context.Background())
+	webhookDispatcher = dispatcher
+	webhookCollection = dispatcher.Subscriptions()
+}
+
+// withCreatedEvent 為新用戶指定 ID 並加入 user.created 事件，事件與用戶在同一次寫入中保存
+func withCreatedEvent(user user_models.User) user_models.User {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	user.Outbox = user_models.UserEvents(nil, user, user.CreatedAt)
+	return user
+}
+
+// outboxEach 將事件加入用戶文件 outbox 的 $push 內容
+func outboxEach(events []user_models.OutboxEvent) bson.M {
+	if events == nil {
+		events = []user_models.OutboxEvent{}
+	}
+	return bson.M{"$each": events}
+}
+
+// validWebhookURL 只接受 http 與 https 的絕對網址
+func validWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// checkWebhookURL 檢查網址格式，並確認主機只解析到公開位址；不通過時回應 400 並回傳 false
+func checkWebhookURL(c *gin.Context, raw string) bool {
+	if !validWebhookURL(raw) {
+		RespondWithAPIError(c, http.StatusBadRequest, "url must be an absolute http or https URL")
+		return false
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	if err := webhooks.CheckURL(ctx, raw); err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, webhooks.ErrForbiddenAddress.Error())
+		return false
+	}
+	return true
+}
+
+// uniqueEvents 去除重複的事件並保留順序
+func uniqueEvents(events []string) []string {
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	return unique
+}
+
+// respondWebhook 回傳訂閱與其 HATEOAS 連結，secret 不為空時一併回傳
+func respondWebhook(c *gin.Context, status int, sub user_models.WebhookSubscription, secret string) {
+	view := user_models.NewWebhookView(sub)
+	view.Secret = secret
+	c.JSON(status, user_models.WebhookResponse{
+		Data:  view,
+		Links: localizeLinks(c, user_models.GenerateWebhookLinks(getAPIBaseURL(c), view.ID)),
+	})
+}
+
+// respondDelivery 回傳傳遞與其 HATEOAS 連結
+func respondDelivery(c *gin.Context, status int, delivery user_models.WebhookDelivery) {
+	c.JSON(status, user_models.WebhookDeliveryResponse{
+		Data:  user_models.NewWebhookDeliveryView(delivery),
+		Links: localizeLinks(c, user_models.GenerateWebhookDeliveryLinks(getAPIBaseURL(c), delivery)),
+	})
+}
+
+// findWebhook 依路徑參數取得訂閱，失敗時已回應錯誤
+func findWebhook(c *gin.Context) (user_models.WebhookSubscription, bool) {
+	var sub user_models.WebhookSubscription
+	if webhookCollection == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return sub, false
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return sub, false
+	}
+	if err := webhookCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&sub); err != nil {
+		if err == mongo.ErrNoDocuments {
+			RespondWithAPIError(c, http.StatusNotFound, "Webhook not found")
+			return sub, false
+		}
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return sub, false
+	}
+	return sub, true
+}
+
+// findDelivery 依路徑參數取得訂閱的傳遞，失敗時已回應錯誤
+func findDelivery(c *gin.Context) (user_models.WebhookDelivery, bool) {
+	var delivery user_models.WebhookDelivery
+	sub, ok := findWebhook(c)
+	if !ok {
+		return delivery, false
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("delivery_id"))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusBadRequest, "Invalid ID")
+		return delivery, false
+	}
+	delivery, err = webhookDispatcher.Get(context.Background(), sub.ID, id)
+	if err != nil {
+		respondDeliveryError(c, err)
+		return delivery, false
+	}
+	return delivery, true
+}
+
+// respondDeliveryError 將傳遞操作的錯誤對應為 HTTP 狀態碼
+func respondDeliveryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, webhooks.ErrNotFound):
+		RespondWithAPIError(c, http.StatusNotFound, "Webhook delivery not found")
+	case errors.Is(err, webhooks.ErrNotFinished):
+		RespondWithAPIError(c, http.StatusConflict, "Webhook delivery has not finished yet")
+	default:
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// CreateWebhook godoc
+// @Summary 建立 webhook 訂閱
+// @Description 訂閱用戶事件，事件以 POST 傳送到指定網址，並以 X-Webhook-Signature 標頭提供 HMAC-SHA256 簽章。
+// @Description 簽章密鑰只會在這個響應中回傳一次
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook body user_models.CreateWebhookRequest true "訂閱內容"
+// @Success 201 {object} user_models.WebhookResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks [post]
+func CreateWebhook(c *gin.Context) {
+	if webhookCollection == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	var req user_models.CreateWebhookRequest
+	if err := bindBody(c, &req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if !checkWebhookURL(c, req.URL) {
+		return
+	}
+
+	now := serverClock.Now()
+	sub := user_models.WebhookSubscription{
+		ID:          primitive.NewObjectID(),
+		URL:         req.URL,
+		Events:      uniqueEvents(req.Events),
+		Description: req.Description,
+		Secret:      webhooks.NewSecret(),
+		Active:      req.Active == nil || *req.Active,
+		CreatedBy:   currentActor(c),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if _, err := webhookCollection.InsertOne(context.Background(), sub); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWebhook(c, http.StatusCreated, sub, sub.Secret)
+}
+
+// GetWebhooks godoc
+// @Summary 獲取 webhook 訂閱列表
+// @Description 依建立時間列出所有訂閱，不含簽章密鑰
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} user_models.WebhookListResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 500 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks [get]
+func GetWebhooks(c *gin.Context) {
+	if webhookCollection == nil {
+		RespondWithAPIError(c, http.StatusServiceUnavailable, "Database service is currently unavailable")
+		return
+	}
+
+	ctx := context.Background()
+	cursor, err := webhookCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var subs []user_models.WebhookSubscription
+	if err := cursor.All(ctx, &subs); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	baseURL := getAPIBaseURL(c)
+	items := make([]user_models.WebhookResponse, 0, len(subs))
+	for _, sub := range subs {
+		view := user_models.NewWebhookView(sub)
+		items = append(items, user_models.WebhookResponse{
+			Data:  view,
+			Links: localizeLinks(c, user_models.GenerateWebhookLinks(baseURL, view.ID)),
+		})
+	}
+	c.JSON(http.StatusOK, user_models.WebhookListResponse{
+		Data: items,
+		Links: localizeLinks(c, []user_models.HATEOASLink{
+			{Href: baseURL + "/webhooks", Rel: "self", Method: "GET", Title: "List webhooks"},
+			{Href: baseURL + "/webhooks", Rel: "create", Method: "POST", Title: "Create webhook"},
+		}),
+	})
+}
+
+// GetWebhook godoc
+// @Summary 獲取 webhook 訂閱
+// @Description 取得訂閱的設定，不含簽章密鑰
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Success 200 {object} user_models.WebhookResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks/{id} [get]
+func GetWebhook(c *gin.Context) {
+	sub, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+	respondWebhook(c, http.StatusOK, sub, "")
+}
+
+// UpdateWebhook godoc
+// @Summary 修改 webhook 訂閱
+// @Description 修改網址、訂閱的事件、說明或是否啟用，未提供的欄位不會變更。停用期間發生的事件不會傳遞給這個訂閱
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Param webhook body user_models.UpdateWebhookRequest true "要修改的欄位"
+// @Success 200 {object} user_models.WebhookResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks/{id} [patch]
+func UpdateWebhook(c *gin.Context) {
+	sub, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req user_models.UpdateWebhookRequest
+	if err := bindBody(c, &req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if req.URL != nil {
+		if !checkWebhookURL(c, *req.URL) {
+			return
+		}
+		sub.URL = *req.URL
+	}
+	if req.Events != nil {
+		sub.Events = uniqueEvents(*req.Events)
+	}
+	if req.Description != nil {
+		sub.Description = *req.Description
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	sub.UpdatedAt = serverClock.Now()
+
+	_, err := webhookCollection.UpdateOne(context.Background(), bson.M{"_id": sub.ID}, bson.M{"$set": bson.M{
+		"url":         sub.URL,
+		"events":      sub.Events,
+		"description": sub.Description,
+		"active":      sub.Active,
+		"updated_at":  sub.UpdatedAt,
+	}})
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWebhook(c, http.StatusOK, sub, "")
+}
+
+// DeleteWebhook godoc
+// @Summary 刪除 webhook 訂閱
+// @Description 刪除訂閱與其所有傳遞紀錄，尚未完成的傳遞不會再送出
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Success 204 "已刪除"
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {
+	sub, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+	ctx := context.Background()
+	if _, err := webhookCollection.DeleteOne(ctx, bson.M{"_id": sub.ID}); err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := webhookDispatcher.Forget(ctx, sub.ID); err != nil {
+		log.Printf("Error deleting deliveries of webhook %s: %v\n", sub.ID.Hex(), err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RotateWebhookSecret godoc
+// @Summary 更換 webhook 簽章密鑰
+// @Description 產生新的簽章密鑰並立即生效，之後的傳遞（包含重試）都以新密鑰簽章。新密鑰只會在這個響應中回傳一次
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Success 200 {object} user_models.WebhookResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks/{id}/rotate-secret [post]
+func RotateWebhookSecret(c *gin.Context) {
+	sub, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+	sub.Secret = webhooks.NewSecret()
+	sub.UpdatedAt = serverClock.Now()
+	_, err := webhookCollection.UpdateOne(context.Background(), bson.M{"_id": sub.ID},
+		bson.M{"$set": bson.M{"secret": sub.Secret, "updated_at": sub.UpdatedAt}})
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWebhook(c, http.StatusOK, sub, sub.Secret)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary 獲取 webhook 傳遞紀錄
+// @Description 由新到舊列出訂閱的傳遞與每次嘗試的回應狀態碼、錯誤與耗時
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Param status query string false "傳遞狀態" Enums(pending, delivering, succeeded, dead_letter)
+// @Param page query int false "頁碼" default(1)
+// @Param size query int false "每頁筆數，最多 100" default(20)
+// @Success 200 {object} user_models.WebhookDeliveryListResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(c *gin.Context) {
+	sub, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch user_models.WebhookDeliveryStatus(status) {
+	case "", user_models.DeliveryPending, user_models.DeliveryDelivering, user_models.DeliverySucceeded, user_models.DeliveryDeadLetter:
+	default:
+		RespondWithAPIError(c, http.StatusBadRequest, "status must be one of pending, delivering, succeeded, dead_letter")
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		RespondWithAPIError(c, http.StatusBadRequest, "page must be a positive integer")
+		return
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(defaultDeliveryPageSize)))
+	if err != nil || size < 1 || size > maxDeliveryPageSize {
+		RespondWithAPIError(c, http.StatusBadRequest, "size must be between 1 and 100")
+		return
+	}
+
+	deliveries, total, err := webhookDispatcher.List(context.Background(), sub.ID, status, page, size)
+	if err != nil {
+		RespondWithAPIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	views := make([]user_models.WebhookDeliveryView, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		views = append(views, user_models.NewWebhookDeliveryView(delivery))
+	}
+	c.JSON(http.StatusOK, user_models.WebhookDeliveryListResponse{
+		Data:  views,
+		Links: localizeLinks(c, user_models.GenerateWebhookDeliveryListLinks(getAPIBaseURL(c), sub.ID.Hex(), status, page, size, total)),
+		Page:  page,
+		Size:  size,
+		Total: total,
+	})
+}
+
+// GetWebhookDelivery godoc
+// @Summary 獲取 webhook 傳遞
+// @Description 取得傳遞的狀態、事件內容與最近的嘗試紀錄
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Param delivery_id path string true "傳遞ID"
+// @Success 200 {object} user_models.WebhookDeliveryResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks/{id}/deliveries/{delivery_id} [get]
+func GetWebhookDelivery(c *gin.Context) {
+	delivery, ok := findDelivery(c)
+	if !ok {
+		return
+	}
+	respondDelivery(c, http.StatusOK, delivery)
+}
+
+// RedeliverWebhook godoc
+// @Summary 重新傳遞 webhook
+// @Description 將已成功或進入 dead letter 的傳遞放回佇列立即傳遞，內容與事件ID不變並重新計算重試次數；尚未結束的傳遞回傳 409
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Param delivery_id path string true "傳遞ID"
+// @Success 202 {object} user_models.WebhookDeliveryResponse
+// @Failure 400 {object} user_models.APIResponse
+// @Failure 401 {object} user_models.APIResponse
+// @Failure 403 {object} user_models.APIResponse
+// @Failure 404 {object} user_models.APIResponse
+// @Failure 409 {object} user_models.APIResponse
+// @Failure 503 {object} user_models.APIResponse
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhook(c *gin.Context) {
+	delivery, ok := findDelivery(c)
+	if !ok {
+		return
+	}
+	delivery, err := webhookDispatcher.Redeliver(context.Background(), delivery.SubscriptionID, delivery.ID)
+	if err != nil {
+		respondDeliveryError(c, err)
+		return
+	}
+	respondDelivery(c, http.StatusAccepted, delivery)
+}
@@ -18,24 +18,169 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/users": {
+        "/audit": {
             "get": {
-                "description": "獲取系統中的所有用戶列表",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "依目標、操作者、動作與時間範圍查詢用戶變更的稽核紀錄，由新到舊排序；format=csv 時匯出所有符合的紀錄",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "查詢稽核紀錄",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "目標用戶ID",
+                        "name": "target",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "操作者",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "動作，例如 user.update",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "起始時間（含），RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "結束時間（不含），RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "頁碼",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每頁筆數，最多 100",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "匯出格式",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.AuditLogResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/invitations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "獲取所有邀請，可依狀態篩選",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "invitations"
+                ],
+                "summary": "獲取邀請列表",
+                "parameters": [
+                    {
+                        "enum": [
+                            "pending",
+                            "accepted",
+                            "revoked",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "只列出指定狀態的邀請",
+                        "name": "status",
+                        "in": "query"
+                    }
                 ],
-                "summary": "獲取所有用戶",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user_models.UsersCollectionResponse"
+                            "$ref": "#/definitions/user_models.InvitationsCollectionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
                         }
                     },
                     "500": {
@@ -43,11 +188,22 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "創建一個新的用戶",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以電子郵件邀請新用戶，受邀者透過郵件中的連結設定密碼；僅限管理員",
                 "consumes": [
                     "application/json"
                 ],
@@ -55,17 +211,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "invitations"
                 ],
-                "summary": "創建新用戶",
+                "summary": "建立邀請",
                 "parameters": [
                     {
-                        "description": "用戶信息",
-                        "name": "user",
+                        "description": "邀請資料",
+                        "name": "invitation",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user_models.User"
+                            "$ref": "#/definitions/user_models.CreateInvitationRequest"
                         }
                     }
                 ],
@@ -73,7 +229,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/user_models.UserResponse"
+                            "$ref": "#/definitions/user_models.InvitationResponse"
                         }
                     },
                     "400": {
@@ -82,8 +238,32 @@ const docTemplate = `{
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
@@ -91,9 +271,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
-            "get": {
-                "description": "通過ID獲取特定用戶的信息",
+        "/invitations/accept": {
+            "post": {
+                "description": "受邀者以郵件中的權杖設定密碼，建立的用戶會由 pending_verification 轉為 active",
                 "consumes": [
                     "application/json"
                 ],
@@ -101,21 +281,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "invitations"
                 ],
-                "summary": "獲取特定用戶",
+                "summary": "接受邀請",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "用戶ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "權杖與用戶資料",
+                        "name": "acceptance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.AcceptInvitationRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "$ref": "#/definitions/user_models.UserResponse"
                         }
@@ -126,55 +308,50 @@ const docTemplate = `{
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "410": {
+                        "description": "Gone",
                         "schema": {
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "更新特定用戶的信息",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/invitations/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "通過ID獲取邀請",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "invitations"
                 ],
-                "summary": "更新用戶",
+                "summary": "獲取特定邀請",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "用戶ID",
+                        "description": "邀請ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "用戶信息",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/user_models.User"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user_models.APIResponse"
+                            "$ref": "#/definitions/user_models.InvitationResponse"
                         }
                     },
                     "400": {
@@ -183,14 +360,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
@@ -198,21 +387,23 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "刪除特定用戶",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "撤銷尚未接受的邀請，撤銷後連結立即失效",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "invitations"
                 ],
-                "summary": "刪除用戶",
+                "summary": "撤銷邀請",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "用戶ID",
+                        "description": "邀請ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -222,7 +413,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user_models.APIResponse"
+                            "$ref": "#/definitions/user_models.InvitationResponse"
                         }
                     },
                     "400": {
@@ -231,91 +422,4544 @@ const docTemplate = `{
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/exports": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "在背景匯出符合條件的用戶，參數與 GET /users/export 相同。回傳 202 與 Location，完成後由工作的 result 連結下載檔案",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "建立匯出工作",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx",
+                            "vcf"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "匯出格式",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "要匯出的欄位，例如 name,email,phone",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending_verification",
+                            "active",
+                            "suspended",
+                            "locked",
+                            "deactivated"
+                        ],
+                        "type": "string",
+                        "description": "只匯出指定狀態的用戶",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.JobResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "工作狀態的網址"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/user_models.APIResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "user_models.APIResponse": {
-            "description": "API 通用響應結構",
-            "type": "object",
-            "properties": {
-                "_links": {
-                    "description": "HATEOAS 連結 (可選)",
-                    "type": "array",
+        },
+        "/jobs/imports": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "在背景匯入用戶，表單欄位與 POST /users/import 相同。回傳 202 與 Location，完成後由工作的 result 連結下載與同步匯入相同格式的 JSON 結果。匯入中途取消時，已處理的資料列不會被復原",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "建立匯入工作",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "匯入檔案",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "檔案格式，未提供時依副檔名判斷",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "欄位對應，例如 {\\",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "skip",
+                            "update",
+                            "fail"
+                        ],
+                        "type": "string",
+                        "default": "fail",
+                        "description": "email 已存在時的處理方式",
+                        "name": "on_duplicate",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "只檢查不寫入",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.JobResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "工作狀態的網址"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "取得背景工作的狀態、進度與每次失敗的原因；成功結束後 result 連結提供結果檔案的下載",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "獲取工作狀態",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.JobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "等待中的工作立即取消；執行中的工作標記為取消，由 worker 在下次續約時停止。已結束的工作回傳 409",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "取消工作",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.JobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/result": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "下載成功結束的工作的結果檔案：匯出工作為匯出的檔案，匯入工作為匯入結果的 JSON。工作尚未成功結束時回傳 409",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "下載工作結果",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "獲取存取權杖所屬用戶的資料",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "獲取個人資料",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將目前用戶轉為 deactivated 並登出所有工作階段",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "停用自己的帳號",
+                "parameters": [
+                    {
+                        "description": "停用原因",
+                        "name": "transition",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "部分更新存取權杖所屬用戶的資料，提供的欄位以與管理端相同的規則驗證；角色、狀態與密碼不可由此變更",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "更新個人資料",
+                "parameters": [
+                    {
+                        "description": "要更新的欄位",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.PatchUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以目前的密碼驗證後設定新密碼，其他工作階段會一併登出",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "變更密碼",
+                "parameters": [
+                    {
+                        "description": "目前與新的密碼",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UpdatePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "列出目前用戶尚未過期或撤銷的工作階段",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "獲取登入工作階段",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.SessionsCollectionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "撤銷目前用戶的指定工作階段，使用該工作階段的權杖立即失效",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "登出工作階段",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作階段ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "獲取系統中的所有用戶列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/hal+json",
+                    "application/vnd.api+json",
+                    "application/vnd.siren+json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "獲取所有用戶",
+                "parameters": [
+                    {
+                        "enum": [
+                            "pending_verification",
+                            "active",
+                            "suspended",
+                            "locked",
+                            "deactivated"
+                        ],
+                        "type": "string",
+                        "description": "只列出指定狀態的用戶",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只回傳這些欄位，以逗號分隔，例如 name,email；id 一律回傳",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UsersCollectionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "創建一個新的用戶，id、狀態、版本與時間戳記由伺服器設定",
+                "consumes": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/hal+json",
+                    "application/vnd.api+json",
+                    "application/vnd.siren+json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "創建新用戶",
+                "parameters": [
+                    {
+                        "description": "用戶信息",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以 BulkWrite 執行多個操作。ordered 遇到第一個失敗即停止；unordered 執行所有通過檢查的操作；atomic 在交易中執行，任一失敗時全部不寫入。每個操作回報各自的狀態碼：201、200、404、409、422，未執行的操作為 424",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "批次建立、更新與刪除用戶",
+                "parameters": [
+                    {
+                        "description": "批次操作",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.BulkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.BulkResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.BulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以串流方式匯出符合條件的所有用戶，篩選條件與用戶列表相同。fields 以逗號分隔選擇欄位，未提供時匯出所有欄位；密碼永遠不會被匯出。vCard 只包含可對應到 vCard 屬性的欄位。大量資料請改用 POST /jobs/exports 在背景執行",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "text/vcard"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "匯出用戶",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx",
+                            "vcf"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "匯出格式",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "要匯出的欄位，例如 name,email,phone",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending_verification",
+                            "active",
+                            "suspended",
+                            "locked",
+                            "deactivated"
+                        ],
+                        "type": "string",
+                        "description": "只匯出指定狀態的用戶",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "由 CSV、NDJSON 或 XLSX 檔案匯入用戶，每一列以建立用戶相同的規則驗證。mapping 為 {\"檔案欄位\": \"用戶欄位\"} 的 JSON 物件，未提供時以同名欄位對應。email 已存在時依 on_duplicate 略過、更新或視為失敗；dry_run 只回報預計執行的動作。有失敗的資料列時可由 errors 連結下載錯誤報表。大型檔案請改用 POST /jobs/imports 在背景執行",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "匯入用戶",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "匯入檔案",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "檔案格式，未提供時依副檔名判斷",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "欄位對應，例如 {\\",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "skip",
+                            "update",
+                            "fail"
+                        ],
+                        "type": "string",
+                        "default": "fail",
+                        "description": "email 已存在時的處理方式",
+                        "name": "on_duplicate",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "只檢查不寫入",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/import/{id}/errors": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以 CSV 下載匯入失敗的資料列，包含原始欄位（不含密碼）與錯誤訊息；報表保存 7 天，只有匯入者可以下載",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "下載匯入錯誤報表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "報表ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以 Server-Sent Events 推送用戶事件（user.created、user.updated、user.status_changed、user.deleted），\n每個事件的 id 可作為重新連線時的 Last-Event-ID 以補上中斷期間的事件；無法續傳時先送出 reset 事件。\n以 Upgrade: websocket 連線時改以 WebSocket 傳送，每則訊息為含 id、type、user_id、occurred_at 與 data 的 JSON",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "即時用戶變更事件流",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "只接收這些事件類型，可重複或以逗號分隔",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "只接收這些用戶的事件，可重複或以逗號分隔",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "從這個事件之後開始，Last-Event-ID 標頭優先",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "最後收到的事件ID",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "事件流",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "通過ID獲取特定用戶的信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/hal+json",
+                    "application/vnd.api+json",
+                    "application/vnd.siren+json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "獲取特定用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "只回傳這些欄位，以逗號分隔，例如 name,email；id 一律回傳",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "以請求內容取代特定用戶的個人資料，密碼、狀態與時間戳記不可由此變更",
+                "consumes": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/hal+json",
+                    "application/vnd.api+json",
+                    "application/vnd.siren+json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "更新用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "用戶信息",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "將特定用戶轉為 deleted 狀態",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "刪除用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "只更新請求中提供的欄位，密碼、狀態與時間戳記不可由此變更",
+                "consumes": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/hal+json",
+                    "application/vnd.api+json",
+                    "application/vnd.siren+json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "部分更新用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "要更新的欄位",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.PatchUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/activate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將待驗證的用戶轉為 active",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lifecycle"
+                ],
+                "summary": "啟用用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "變更原因",
+                        "name": "transition",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/avatar": {
+            "get": {
+                "description": "回傳不小於 size 的最小尺寸頭像（32、64、128、256 或 512 像素），未指定時為 128。以 ETag 與 If-None-Match 驗證快取，未變更時回傳 304",
+                "produces": [
+                    "image/jpeg",
+                    "image/png"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "獲取頭像",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 512,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 128,
+                        "description": "頭像邊長（像素）",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "先前取得的 ETag",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "快取設定"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "頭像與尺寸的實體標籤"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "頭像未變更"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以 multipart 的 file 欄位或直接以圖片作為請求內容上傳頭像，類型依內容判斷，只接受 JPEG、PNG 與 GIF，檔案不超過 5MB、寬高不超過 4096 像素。圖片依 EXIF 方向轉正並裁切為正方形後產生 32 到 512 像素的尺寸，EXIF 等中繼資料不會被保存。只限本人或管理員上傳",
+                "consumes": [
+                    "multipart/form-data",
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "上傳頭像",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "頭像圖片",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.AvatarResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "刪除用戶的頭像與所有尺寸的檔案，只限本人或管理員",
+                "tags": [
+                    "users"
+                ],
+                "summary": "刪除頭像",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "頭像已刪除"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/deactivate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "停用尚未刪除的用戶",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lifecycle"
+                ],
+                "summary": "停用用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "變更原因",
+                        "name": "transition",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/lock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "鎖定 active 的用戶",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lifecycle"
+                ],
+                "summary": "鎖定用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "變更原因",
+                        "name": "transition",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/reactivate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將停用的用戶恢復為 active",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lifecycle"
+                ],
+                "summary": "重新啟用用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "變更原因",
+                        "name": "transition",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/reinstate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將停權的用戶恢復為 active",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lifecycle"
+                ],
+                "summary": "恢復用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "變更原因",
+                        "name": "transition",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/status-history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "獲取用戶目前狀態與所有狀態變更紀錄",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "lifecycle"
+                ],
+                "summary": "獲取用戶狀態歷史",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.StatusHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/suspend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "停權 active 或 locked 的用戶，必須提供原因",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lifecycle"
+                ],
+                "summary": "停權用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "停權原因",
+                        "name": "transition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將鎖定的用戶恢復為 active",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lifecycle"
+                ],
+                "summary": "解除鎖定用戶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "變更原因",
+                        "name": "transition",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "分頁列出用戶每次修改後的快照，由新到舊排序；已刪除的用戶回應 404",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "獲取用戶版本列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "頁碼",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每頁筆數，最多 100",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserVersionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/versions/{n}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "獲取用戶在指定版本的完整快照",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "獲取用戶的特定版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "版本號",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserVersionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/versions/{n}/diff": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "列出從 against 版本到指定版本之間變更的欄位，未指定 against 時與前一版比較",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "比較用戶的兩個版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "版本號",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "比較的基準版本",
+                        "name": "against",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserVersionDiffResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/versions/{n}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以指定版本的個人資料欄位覆寫目前的資料並產生新版本；狀態、角色與密碼不會被還原。If-Match 必須是目前的版本號",
+                "produces": [
+                    "application/json",
+                    "application/hal+json",
+                    "application/vnd.api+json",
+                    "application/vnd.siren+json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "還原用戶至特定版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用戶ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "要還原的版本號",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "目前的版本號，例如 \\",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "依建立時間列出所有訂閱，不含簽章密鑰",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "獲取 webhook 訂閱列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.WebhookListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "訂閱用戶事件，事件以 POST 傳送到指定網址，並以 X-Webhook-Signature 標頭提供 HMAC-SHA256 簽章。\n簽章密鑰只會在這個響應中回傳一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "建立 webhook 訂閱",
+                "parameters": [
+                    {
+                        "description": "訂閱內容",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "取得訂閱的設定，不含簽章密鑰",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "獲取 webhook 訂閱",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "訂閱ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "刪除訂閱與其所有傳遞紀錄，尚未完成的傳遞不會再送出",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "刪除 webhook 訂閱",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "訂閱ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "已刪除"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "修改網址、訂閱的事件、說明或是否啟用，未提供的欄位不會變更。停用期間發生的事件不會傳遞給這個訂閱",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "修改 webhook 訂閱",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "訂閱ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "要修改的欄位",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_models.UpdateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "由新到舊列出訂閱的傳遞與每次嘗試的回應狀態碼、錯誤與耗時",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "獲取 webhook 傳遞紀錄",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "訂閱ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivering",
+                            "succeeded",
+                            "dead_letter"
+                        ],
+                        "type": "string",
+                        "description": "傳遞狀態",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "頁碼",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每頁筆數，最多 100",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.WebhookDeliveryListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{delivery_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "取得傳遞的狀態、事件內容與最近的嘗試紀錄",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "獲取 webhook 傳遞",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "訂閱ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "傳遞ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.WebhookDeliveryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{delivery_id}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將已成功或進入 dead letter 的傳遞放回佇列立即傳遞，內容與事件ID不變並重新計算重試次數；尚未結束的傳遞回傳 409",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "重新傳遞 webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "訂閱ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "傳遞ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.WebhookDeliveryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/rotate-secret": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "產生新的簽章密鑰並立即生效，之後的傳遞（包含重試）都以新密鑰簽章。新密鑰只會在這個響應中回傳一次",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "更換 webhook 簽章密鑰",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "訂閱ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/user_models.APIResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "user_models.APIResponse": {
+            "description": "API 通用響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "description": "HATEOAS 連結 (可選)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "description": "響應資料 (可選)"
+                },
+                "error": {
+                    "description": "錯誤訊息 (可選)",
+                    "type": "string",
+                    "example": "錯誤訊息"
+                },
+                "errors": {
+                    "description": "欄位驗證錯誤 (可選)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.FieldError"
+                    }
+                },
+                "message": {
+                    "description": "響應訊息",
+                    "type": "string",
+                    "example": "操作成功"
+                },
+                "status": {
+                    "description": "HTTP 狀態碼",
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "user_models.AcceptInvitationRequest": {
+            "description": "接受邀請請求",
+            "type": "object",
+            "required": [
+                "name",
+                "password",
+                "token"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "台北市"
+                },
+                "age": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "name": {
+                    "type": "string",
+                    "example": "張三"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8,
+                    "example": "s3cret-pass"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+886912345678"
+                },
+                "sex": {
+                    "type": "string",
+                    "example": "male"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "Jq3x..."
+                }
+            }
+        },
+        "user_models.AuditEntry": {
+            "description": "稽核紀錄",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "user.update"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439099"
+                },
+                "at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.FieldChange"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439013"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "4f9c2a7d0b1e4c3a9e8d7c6b5a4f3e2d"
+                },
+                "target_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "target_type": {
+                    "type": "string",
+                    "example": "user"
+                }
+            }
+        },
+        "user_models.AuditLogResponse": {
+            "description": "符合 HATEOAS 的稽核紀錄列表響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.AuditEntry"
+                    }
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "user_models.AvatarResponse": {
+            "description": "符合 HATEOAS 的頭像響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "$ref": "#/definitions/user_models.AvatarView"
+                }
+            }
+        },
+        "user_models.AvatarView": {
+            "description": "頭像資訊",
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 768
+                },
+                "sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        32,
+                        64,
+                        128,
+                        256,
+                        512
+                    ]
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 1024
+                }
+            }
+        },
+        "user_models.BulkItemResult": {
+            "description": "批次中單一操作的結果",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "$ref": "#/definitions/user_models.UserView"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "email is already in use"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.FieldError"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "user_models.BulkOperation": {
+            "description": "批次中的單一操作",
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "data": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "update"
+                }
+            }
+        },
+        "user_models.BulkRequest": {
+            "description": "批次操作請求，mode 預設為 ordered",
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "ordered",
+                        "unordered",
+                        "atomic"
+                    ],
+                    "example": "ordered"
+                },
+                "operations": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/user_models.BulkOperation"
+                    }
+                }
+            }
+        },
+        "user_models.BulkResponse": {
+            "description": "批次操作響應，results 與請求中的 operations 順序相同",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "ordered"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.BulkItemResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "user_models.CreateInvitationRequest": {
+            "description": "建立邀請請求",
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "zhangsan@example.com"
+                },
+                "expires_in_hours": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 72
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "member"
+                    ],
+                    "example": "member"
+                }
+            }
+        },
+        "user_models.CreateUserRequest": {
+            "description": "建立用戶請求，id、狀態與時間戳記由伺服器設定",
+            "type": "object",
+            "required": [
+                "address",
+                "age",
+                "email",
+                "name",
+                "password",
+                "phone",
+                "sex"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "台北市"
+                },
+                "age": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "email": {
+                    "type": "string",
+                    "example": "zhangsan@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "張三"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8,
+                    "example": "s3cret-pass"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+886912345678"
+                },
+                "sex": {
+                    "type": "string",
+                    "example": "male"
+                }
+            }
+        },
+        "user_models.CreateWebhookRequest": {
+            "description": "建立 webhook 訂閱的請求",
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "CRM 同步"
+                },
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "user.created",
+                        "user.deleted"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://hooks.example.com/users"
+                }
+            }
+        },
+        "user_models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "error message"
+                },
+                "errors": {
+                    "description": "欄位驗證錯誤",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.FieldError"
+                    }
+                }
+            }
+        },
+        "user_models.FieldChange": {
+            "description": "欄位變更",
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "string",
+                    "example": "new@example.com"
+                },
+                "before": {
+                    "type": "string",
+                    "example": "old@example.com"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "email"
+                }
+            }
+        },
+        "user_models.FieldError": {
+            "description": "欄位驗證錯誤，field 為 JSON 欄位名稱，code 為驗證規則",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "未通過的驗證規則",
+                    "type": "string",
+                    "example": "email"
+                },
+                "field": {
+                    "description": "JSON 欄位名稱",
+                    "type": "string",
+                    "example": "email"
+                },
+                "message": {
+                    "description": "依 Accept-Language 翻譯的訊息",
+                    "type": "string",
+                    "example": "email必須是一個有效的信箱"
+                }
+            }
+        },
+        "user_models.HATEOASLink": {
+            "description": "HATEOAS 連結結構",
+            "type": "object",
+            "properties": {
+                "href": {
+                    "description": "連結目標 URL",
+                    "type": "string",
+                    "example": "http://api.example.com/users/1"
+                },
+                "method": {
+                    "description": "HTTP 方法",
+                    "type": "string",
+                    "example": "GET"
+                },
+                "rel": {
+                    "description": "關係類型",
+                    "type": "string",
+                    "example": "self"
+                },
+                "title": {
+                    "description": "連結描述",
+                    "type": "string",
+                    "example": "Get user"
+                }
+            }
+        },
+        "user_models.ImportResponse": {
+            "description": "匯入結果，有失敗的資料列時 _links 提供錯誤報表的下載連結",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "created": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "format": {
+                    "type": "string",
+                    "example": "csv"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.ImportRowResult"
+                    }
+                },
+                "skipped": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "user_models.ImportRowResult": {
+            "description": "單一資料列的匯入結果",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "create"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "zhangsan@example.com"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "email is already in use"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.FieldError"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "user_models.Invitation": {
+            "description": "邀請紀錄",
+            "type": "object",
+            "properties": {
+                "accepted_at": {
+                    "type": "string",
+                    "example": "2021-01-02T00:00:00Z"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "zhangsan@example.com"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2021-01-04T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "invited_by": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2021-01-02T00:00:00Z"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "member"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user_models.InvitationStatus"
+                        }
+                    ],
+                    "example": "pending"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                }
+            }
+        },
+        "user_models.InvitationResponse": {
+            "description": "符合 HATEOAS 的邀請響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "$ref": "#/definitions/user_models.Invitation"
+                }
+            }
+        },
+        "user_models.InvitationStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "accepted",
+                "revoked",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "InvitationPending",
+                "InvitationAccepted",
+                "InvitationRevoked",
+                "InvitationExpired"
+            ]
+        },
+        "user_models.InvitationsCollectionResponse": {
+            "description": "符合 HATEOAS 的邀請列表響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.Invitation"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "user_models.JobError": {
+            "description": "某次執行失敗的原因",
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "attempt": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "message": {
+                    "type": "string",
+                    "example": "connection reset by peer"
+                }
+            }
+        },
+        "user_models.JobProgress": {
+            "description": "工作進度",
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 10000
+                }
+            }
+        },
+        "user_models.JobResponse": {
+            "description": "符合 HATEOAS 的背景工作響應結構，完成後 result 連結提供結果檔案的下載",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "$ref": "#/definitions/user_models.JobView"
+                }
+            }
+        },
+        "user_models.JobStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "succeeded",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "JobQueued",
+                "JobRunning",
+                "JobSucceeded",
+                "JobFailed",
+                "JobCancelled"
+            ]
+        },
+        "user_models.JobView": {
+            "description": "背景工作",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "cancel_requested": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.JobError"
+                    }
+                },
+                "finished_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:01:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "users.export"
+                },
+                "max_attempts": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "params": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "progress": {
+                    "$ref": "#/definitions/user_models.JobProgress"
+                },
+                "result_filename": {
+                    "type": "string",
+                    "example": "users.csv"
+                },
+                "result_size": {
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "run_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "started_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:01Z"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user_models.JobStatus"
+                        }
+                    ],
+                    "example": "running"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                }
+            }
+        },
+        "user_models.PatchUserRequest": {
+            "description": "部分更新用戶的請求，省略的欄位維持原值",
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "台北市"
+                },
+                "age": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "email": {
+                    "type": "string",
+                    "example": "zhangsan@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "張三"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+886912345678"
+                },
+                "sex": {
+                    "type": "string",
+                    "example": "male"
+                }
+            }
+        },
+        "user_models.SessionResource": {
+            "description": "附帶 HATEOAS 連結的工作階段",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "client_id": {
+                    "type": "string",
+                    "example": "my-app"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "current": {
+                    "description": "是否為目前請求所使用的工作階段",
+                    "type": "boolean",
+                    "example": true
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2021-01-01T01:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439013"
+                },
+                "ip_address": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0"
+                }
+            }
+        },
+        "user_models.SessionsCollectionResponse": {
+            "description": "符合 HATEOAS 的工作階段列表響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.SessionResource"
+                    }
+                }
+            }
+        },
+        "user_models.StatusHistoryResponse": {
+            "description": "使用者狀態變更歷史",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.StatusTransition"
+                    }
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user_models.UserStatus"
+                        }
+                    ],
+                    "example": "suspended"
+                }
+            }
+        },
+        "user_models.StatusTransition": {
+            "description": "使用者狀態變更紀錄",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "suspend"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "from": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user_models.UserStatus"
+                        }
+                    ],
+                    "example": "active"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "違反使用規範"
+                },
+                "to": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user_models.UserStatus"
+                        }
+                    ],
+                    "example": "suspended"
+                }
+            }
+        },
+        "user_models.TransitionRequest": {
+            "description": "狀態變更請求",
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "違反使用規範"
+                }
+            }
+        },
+        "user_models.UpdatePasswordRequest": {
+            "description": "變更密碼請求，必須提供目前的密碼",
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "example": "old-secret"
+                },
+                "new_password": {
+                    "type": "string",
+                    "minLength": 8,
+                    "example": "new-s3cret"
+                }
+            }
+        },
+        "user_models.UpdateUserRequest": {
+            "description": "以 PUT 取代用戶資料的請求，所有個人資料欄位都必須提供",
+            "type": "object",
+            "required": [
+                "address",
+                "age",
+                "email",
+                "name",
+                "phone",
+                "sex"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "台北市"
+                },
+                "age": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "email": {
+                    "type": "string",
+                    "example": "zhangsan@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "張三"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+886912345678"
+                },
+                "sex": {
+                    "type": "string",
+                    "example": "male"
+                }
+            }
+        },
+        "user_models.UpdateWebhookRequest": {
+            "description": "修改 webhook 訂閱的請求",
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "CRM 同步"
+                },
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "user.updated"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://hooks.example.com/users"
+                }
+            }
+        },
+        "user_models.UserResponse": {
+            "description": "符合 HATEOAS 的使用者響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "description": "HATEOAS 連結",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "description": "使用者資料",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user_models.UserView"
+                        }
+                    ]
+                }
+            }
+        },
+        "user_models.UserStatus": {
+            "type": "string",
+            "enum": [
+                "pending_verification",
+                "active",
+                "suspended",
+                "locked",
+                "deactivated",
+                "deleted"
+            ],
+            "x-enum-varnames": [
+                "StatusPendingVerification",
+                "StatusActive",
+                "StatusSuspended",
+                "StatusLocked",
+                "StatusDeactivated",
+                "StatusDeleted"
+            ]
+        },
+        "user_models.UserVersionDiffResponse": {
+            "description": "兩個版本之間的欄位差異，密碼以 [REDACTED] 表示",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.FieldChange"
+                    }
+                },
+                "from": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "to": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "user_models.UserVersionResponse": {
+            "description": "符合 HATEOAS 的用戶版本響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
                     "items": {
                         "$ref": "#/definitions/user_models.HATEOASLink"
                     }
                 },
                 "data": {
-                    "description": "響應資料 (可選)"
+                    "$ref": "#/definitions/user_models.UserVersionView"
+                }
+            }
+        },
+        "user_models.UserVersionView": {
+            "description": "用戶版本快照",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "user.update"
                 },
-                "error": {
-                    "description": "錯誤訊息 (可選)",
+                "actor": {
                     "type": "string",
-                    "example": "錯誤訊息"
+                    "example": "507f1f77bcf86cd799439099"
                 },
-                "message": {
-                    "description": "響應訊息",
+                "at": {
                     "type": "string",
-                    "example": "操作成功"
+                    "example": "2021-01-01T00:00:00Z"
                 },
-                "status": {
-                    "description": "HTTP 狀態碼",
+                "user": {
+                    "$ref": "#/definitions/user_models.UserView"
+                },
+                "version": {
                     "type": "integer",
-                    "example": 200
+                    "example": 3
                 }
             }
         },
-        "user_models.HATEOASLink": {
-            "description": "HATEOAS 連結結構",
+        "user_models.UserVersionsResponse": {
+            "description": "符合 HATEOAS 的用戶版本列表響應結構",
             "type": "object",
             "properties": {
-                "href": {
-                    "description": "連結目標 URL",
-                    "type": "string",
-                    "example": "http://api.example.com/users/1"
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
                 },
-                "method": {
-                    "description": "HTTP 方法",
-                    "type": "string",
-                    "example": "GET"
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.UserVersionView"
+                    }
                 },
-                "rel": {
-                    "description": "關係類型",
-                    "type": "string",
-                    "example": "self"
+                "page": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "title": {
-                    "description": "連結描述",
-                    "type": "string",
-                    "example": "取得使用者資訊"
+                "size": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "user_models.User": {
-            "description": "用戶模型",
+        "user_models.UserView": {
+            "description": "用戶資料",
             "type": "object",
-            "required": [
-                "address",
-                "age",
-                "email",
-                "name",
-                "phone",
-                "sex"
-            ],
             "properties": {
                 "address": {
                     "type": "string",
@@ -329,6 +4973,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2021-01-01T00:00:00Z"
                 },
+                "created_by": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
                 "email": {
                     "type": "string",
                     "example": "zhangsan@example.com"
@@ -343,20 +4991,40 @@ const docTemplate = `{
                 },
                 "phone": {
                     "type": "string",
-                    "example": "1234567890"
+                    "example": "+886912345678"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "member"
                 },
                 "sex": {
                     "type": "string",
-                    "example": "男"
+                    "example": "male"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user_models.UserStatus"
+                        }
+                    ],
+                    "example": "active"
                 },
                 "updated_at": {
                     "type": "string",
                     "example": "2021-01-01T00:00:00Z"
+                },
+                "updated_by": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "user_models.UserResponse": {
-            "description": "符合 HATEOAS 的使用者響應結構",
+        "user_models.UsersCollectionResponse": {
+            "description": "符合 HATEOAS 的多使用者響應結構",
             "type": "object",
             "properties": {
                 "_links": {
@@ -367,50 +5035,269 @@ const docTemplate = `{
                     }
                 },
                 "data": {
-                    "description": "使用者資料",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/user_models.User"
-                        }
-                    ]
+                    "description": "使用者資料陣列",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.UserView"
+                    }
+                },
+                "page": {
+                    "description": "頁碼",
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "description": "每頁大小",
+                    "type": "integer",
+                    "example": 10
+                },
+                "total": {
+                    "description": "總資料數",
+                    "type": "integer",
+                    "example": 100
                 }
             }
         },
-        "user_models.UsersCollectionResponse": {
-            "description": "符合 HATEOAS 的多使用者響應結構",
+        "user_models.WebhookAttempt": {
+            "description": "Webhook 傳遞嘗試紀錄",
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 182
+                },
+                "error": {
+                    "type": "string",
+                    "example": "unexpected status 503"
+                },
+                "response_body": {
+                    "type": "string",
+                    "example": "upstream unavailable"
+                },
+                "status_code": {
+                    "type": "integer",
+                    "example": 503
+                }
+            }
+        },
+        "user_models.WebhookDeliveryListResponse": {
+            "description": "符合 HATEOAS 的 webhook 傳遞紀錄列表響應結構",
             "type": "object",
             "properties": {
                 "_links": {
-                    "description": "HATEOAS 連結",
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/user_models.HATEOASLink"
                     }
                 },
                 "data": {
-                    "description": "使用者資料陣列",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/user_models.User"
+                        "$ref": "#/definitions/user_models.WebhookDeliveryView"
                     }
                 },
                 "page": {
-                    "description": "頁碼",
                     "type": "integer",
                     "example": 1
                 },
                 "size": {
-                    "description": "每頁大小",
                     "type": "integer",
-                    "example": 10
+                    "example": 20
                 },
                 "total": {
-                    "description": "總資料數",
                     "type": "integer",
-                    "example": 100
+                    "example": 42
+                }
+            }
+        },
+        "user_models.WebhookDeliveryResponse": {
+            "description": "符合 HATEOAS 的 webhook 傳遞響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "$ref": "#/definitions/user_models.WebhookDeliveryView"
+                }
+            }
+        },
+        "user_models.WebhookDeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivering",
+                "succeeded",
+                "dead_letter"
+            ],
+            "x-enum-comments": {
+                "DeliveryDeadLetter": "用盡重試次數，只能手動重新傳遞",
+                "DeliveryDelivering": "傳遞中，租約過期時視為中斷",
+                "DeliveryPending": "等待傳遞或退避後重試",
+                "DeliverySucceeded": "訂閱者回應 2xx"
+            },
+            "x-enum-varnames": [
+                "DeliveryPending",
+                "DeliveryDelivering",
+                "DeliverySucceeded",
+                "DeliveryDeadLetter"
+            ]
+        },
+        "user_models.WebhookDeliveryView": {
+            "description": "Webhook 傳遞紀錄",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "delivered_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:01Z"
+                },
+                "event_id": {
+                    "type": "string",
+                    "example": "evt_507f1f77bcf86cd799439013"
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "user.updated"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                },
+                "log": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.WebhookAttempt"
+                    }
+                },
+                "next_attempt_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:01:00Z"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user_models.WebhookDeliveryStatus"
+                        }
+                    ],
+                    "example": "pending"
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:01Z"
+                }
+            }
+        },
+        "user_models.WebhookListResponse": {
+            "description": "符合 HATEOAS 的 webhook 訂閱列表響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.WebhookResponse"
+                    }
+                }
+            }
+        },
+        "user_models.WebhookResponse": {
+            "description": "符合 HATEOAS 的 webhook 訂閱響應結構",
+            "type": "object",
+            "properties": {
+                "_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user_models.HATEOASLink"
+                    }
+                },
+                "data": {
+                    "$ref": "#/definitions/user_models.WebhookView"
+                }
+            }
+        },
+        "user_models.WebhookView": {
+            "description": "Webhook 訂閱",
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "CRM 同步"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "user.created",
+                        "user.deleted"
+                    ]
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "whsec_4f1c..."
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://hooks.example.com/users"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "以 \"Bearer \u003caccess_token\u003e\" 格式帶入 /oauth2/token 取得的存取權杖",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,249 @@
+// Package exporter 將用戶逐筆寫成 CSV、NDJSON、XLSX 或 vCard，不在記憶體中保留已寫出的資料
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	user_models "go-api_for_main/models"
+	"go-api_for_main/xlsx"
+)
+
+// Format 匯出檔案的格式
+type Format string
+
+// 支援的匯出格式
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+	VCF    Format = "vcf"
+)
+
+// ErrUnknownField 表示欄位選擇中有不能匯出的欄位
+var ErrUnknownField = errors.New("unknown export field")
+
+// Fields 可匯出的欄位，與 UserView 的 JSON 欄位相同；密碼不在其中
+var Fields = []string{"id", "name", "email", "sex", "age", "phone", "address", "role", "status", "version", "created_at", "updated_at", "created_by", "updated_by"}
+
+// ParseFormat 解析格式名稱
+func ParseFormat(name string) (Format, bool) {
+	switch f := Format(strings.ToLower(name)); f {
+	case CSV, NDJSON, XLSX, VCF:
+		return f, true
+	}
+	return "", false
+}
+
+// ContentType 回傳格式的 MIME 類型
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/vcard; charset=utf-8"
+	}
+}
+
+// ParseFields 解析以逗號分隔的欄位選擇，未提供時匯出所有欄位；重複的欄位只保留第一個
+func ParseFields(spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return Fields, nil
+	}
+	var fields []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if !isField(name) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, name)
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	if len(fields) == 0 {
+		return Fields, nil
+	}
+	return fields, nil
+}
+
+func isField(name string) bool {
+	for _, field := range Fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Value 取得用戶的欄位值，時間以 RFC 3339 的 UTC 字串表示
+func Value(user user_models.UserView, field string) interface{} {
+	switch field {
+	case "id":
+		return user.ID
+	case "name":
+		return user.Name
+	case "email":
+		return user.Email
+	case "sex":
+		return user.Sex
+	case "age":
+		return user.Age
+	case "phone":
+		return user.Phone
+	case "address":
+		return user.Address
+	case "role":
+		return user.Role
+	case "status":
+		return string(user.Status)
+	case "version":
+		return user.Version
+	case "created_at":
+		return timestamp(user.CreatedAt)
+	case "updated_at":
+		return timestamp(user.UpdatedAt)
+	case "created_by":
+		return user.CreatedBy
+	case "updated_by":
+		return user.UpdatedBy
+	}
+	return nil
+}
+
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Writer 逐筆寫出用戶；Close 寫出結尾並送出緩衝，但不關閉底層的 io.Writer
+type Writer interface {
+	Write(user user_models.UserView) error
+	Flush() error
+	Close() error
+}
+
+// NewWriter 建立指定格式的 Writer，CSV 與 XLSX 會先寫出標題列
+func NewWriter(format Format, w io.Writer, fields []string) (Writer, error) {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(fields); err != nil {
+			return nil, err
+		}
+		return &csvWriter{csv: cw, fields: fields}, nil
+	case NDJSON:
+		return &ndjsonWriter{w: w, fields: fields}, nil
+	case XLSX:
+		xw, err := xlsx.NewWriter(w, "Users")
+		if err != nil {
+			return nil, err
+		}
+		header := make([]interface{}, len(fields))
+		for i, field := range fields {
+			header[i] = field
+		}
+		if err := xw.WriteRow(header); err != nil {
+			return nil, err
+		}
+		return &xlsxWriter{xlsx: xw, fields: fields}, nil
+	case VCF:
+		return newVCardWriter(w, fields), nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+type csvWriter struct {
+	csv    *csv.Writer
+	fields []string
+}
+
+func (w *csvWriter) Write(user user_models.UserView) error {
+	record := make([]string, len(w.fields))
+	for i, field := range w.fields {
+		record[i] = CSVSafe(fmt.Sprint(Value(user, field)))
+	}
+	return w.csv.Write(record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+// e164Phone 只有 + 與數字的電話號碼不會被當作公式，保持原樣才能再次匯入
+var e164Phone = regexp.MustCompile(`^\+[0-9]+$`)
+
+// CSVSafe 避免以 = + - @ 開頭的值在試算表中被當作公式執行，E.164 電話號碼保持原樣
+func CSVSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) && !e164Phone.MatchString(value) {
+		return "'" + value
+	}
+	return value
+}
+
+// ndjsonWriter 每行一個 JSON 物件，欄位依選擇的順序排列
+type ndjsonWriter struct {
+	w      io.Writer
+	fields []string
+	buf    bytes.Buffer
+}
+
+func (w *ndjsonWriter) Write(user user_models.UserView) error {
+	w.buf.Reset()
+	w.buf.WriteByte('{')
+	for i, field := range w.fields {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+		value, err := json.Marshal(Value(user, field))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&w.buf, "%q:", field)
+		w.buf.Write(value)
+	}
+	w.buf.WriteString("}\n")
+	_, err := w.w.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *ndjsonWriter) Flush() error { return nil }
+
+func (w *ndjsonWriter) Close() error { return nil }
+
+type xlsxWriter struct {
+	xlsx   *xlsx.Writer
+	fields []string
+}
+
+func (w *xlsxWriter) Write(user user_models.UserView) error {
+	row := make([]interface{}, len(w.fields))
+	for i, field := range w.fields {
+		row[i] = Value(user, field)
+	}
+	return w.xlsx.WriteRow(row)
+}
+
+func (w *xlsxWriter) Flush() error { return w.xlsx.Flush() }
+
+func (w *xlsxWriter) Close() error { return w.xlsx.Close() }
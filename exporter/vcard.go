@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	user_models "go-api_for_main/models"
+	"go-api_for_main/validation"
+)
+
+// vCardLineLimit RFC 6350 建議每行不超過 75 個位元組，超過時折行
+const vCardLineLimit = 75
+
+// vCardWriter 以 vCard 4.0 寫出聯絡人，只有可對應到 vCard 屬性的欄位會被寫出：
+// id→UID、name→FN、email→EMAIL、phone→TEL、address→ADR、sex→GENDER、updated_at→REV
+type vCardWriter struct {
+	w        *bufio.Writer
+	selected map[string]bool
+}
+
+func newVCardWriter(w io.Writer, fields []string) *vCardWriter {
+	selected := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		selected[field] = true
+	}
+	return &vCardWriter{w: bufio.NewWriter(w), selected: selected}
+}
+
+func (w *vCardWriter) Write(user user_models.UserView) error {
+	w.line("BEGIN:VCARD")
+	w.line("VERSION:4.0")
+	if w.selected["id"] {
+		w.line("UID:urn:user:" + user.ID)
+	}
+
+	// FN 是必要屬性，沒有選擇姓名時以 email 代替
+	fn := ""
+	switch {
+	case w.selected["name"] && user.Name != "":
+		fn = user.Name
+	case w.selected["email"]:
+		fn = user.Email
+	}
+	w.line("FN:" + vCardEscape(fn))
+
+	if w.selected["email"] && user.Email != "" {
+		w.line("EMAIL:" + vCardEscape(user.Email))
+	}
+	if w.selected["phone"] && user.Phone != "" {
+		if validation.IsE164(user.Phone) {
+			w.line("TEL;VALUE=uri:tel:" + user.Phone)
+		} else {
+			w.line("TEL;VALUE=text:" + vCardEscape(user.Phone))
+		}
+	}
+	if w.selected["address"] && user.Address != "" {
+		w.line("ADR:;;" + vCardEscape(user.Address) + ";;;;")
+	}
+	if w.selected["sex"] && user.Sex != "" {
+		w.line("GENDER:" + vCardGender(user.Sex))
+	}
+	if w.selected["updated_at"] && !user.UpdatedAt.IsZero() {
+		w.line("REV:" + user.UpdatedAt.UTC().Format("20060102T150405Z"))
+	}
+	return w.line("END:VCARD")
+}
+
+func (w *vCardWriter) Flush() error { return w.w.Flush() }
+
+func (w *vCardWriter) Close() error { return w.w.Flush() }
+
+// line 寫出一行並以 CRLF 結尾，過長時在 UTF-8 字元邊界折行，續行以空白開頭
+func (w *vCardWriter) line(text string) error {
+	limit := vCardLineLimit
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		w.w.WriteString(text[:cut])
+		w.w.WriteString("\r\n ")
+		text = text[cut:]
+		limit = vCardLineLimit - 1
+	}
+	w.w.WriteString(text)
+	_, err := w.w.WriteString("\r\n")
+	return err
+}
+
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func vCardEscape(text string) string {
+	return vCardEscaper.Replace(text)
+}
+
+func vCardGender(sex string) string {
+	switch sex {
+	case validation.SexMale:
+		return "M"
+	case validation.SexFemale:
+		return "F"
+	case validation.SexOther:
+		return "O"
+	}
+	return "U"
+}
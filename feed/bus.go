@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer 每個訂閱者可以暫存的事件數，超過時關閉訂閱，由用戶端續傳
+const subscriberBuffer = 64
+
+// Bus 行程內的事件匯流排，只保留最近 capacity 個事件供續傳。
+// 事件ID包含啟動識別碼，重新啟動後舊的事件ID無法續傳
+type Bus struct {
+	mu       sync.Mutex
+	boot     string
+	seq      uint64
+	capacity int
+	history  []busEntry
+	subs     map[*busSubscriber]struct{}
+}
+
+type busEntry struct {
+	seq   uint64
+	event Event
+}
+
+type busSubscriber struct {
+	ch     chan Event
+	filter Filter
+}
+
+// NewBus 建立保留最近 capacity 個事件的匯流排
+func NewBus(capacity int) *Bus {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &Bus{
+		boot:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		capacity: capacity,
+		subs:     map[*busSubscriber]struct{}{},
+	}
+}
+
+// Publish 指定事件ID並傳送給符合條件的訂閱者，回傳指定ID後的事件
+func (b *Bus) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	e.ID = b.boot + "-" + strconv.FormatUint(b.seq, 10)
+	b.history = append(b.history, busEntry{seq: b.seq, event: e})
+	if len(b.history) > b.capacity {
+		b.history = b.history[len(b.history)-b.capacity:]
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			// 訂閱者跟不上，關閉後由用戶端以最後的事件ID續傳
+			b.remove(sub)
+		}
+	}
+	return e
+}
+
+// Subscribe 訂閱事件；lastEventID 仍在保留的範圍內時先補送之後的事件
+func (b *Bus) Subscribe(ctx context.Context, lastEventID string, filter Filter) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	reset := false
+	if lastEventID != "" {
+		if missed, ok := b.since(lastEventID); ok {
+			for _, e := range missed {
+				if filter.Match(e) {
+					replay = append(replay, e)
+				}
+			}
+		} else {
+			reset = true
+		}
+	}
+
+	sub := &busSubscriber{ch: make(chan Event, subscriberBuffer+len(replay)), filter: filter}
+	for _, e := range replay {
+		sub.ch <- e
+	}
+	b.subs[sub] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(sub)
+	}()
+	return Subscription{Events: sub.ch, Reset: reset}, nil
+}
+
+// remove 移除並關閉訂閱，呼叫時必須持有鎖
+func (b *Bus) remove(sub *busSubscriber) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// since 回傳 id 之後的事件；id 不是這次啟動產生的，或之後的事件已不在保留範圍內時回傳 false
+func (b *Bus) since(id string) ([]Event, bool) {
+	boot, seqText, ok := strings.Cut(id, "-")
+	if !ok || boot != b.boot {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || seq > b.seq {
+		return nil, false
+	}
+	if seq == b.seq {
+		return nil, true
+	}
+	if len(b.history) == 0 || b.history[0].seq > seq+1 {
+		return nil, false
+	}
+	start := int(seq + 1 - b.history[0].seq)
+	events := make([]Event, 0, len(b.history)-start)
+	for _, entry := range b.history[start:] {
+		events = append(events, entry.event)
+	}
+	return events, true
+}
@@ -0,0 +1,112 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ChangeStream 以 change stream 監看已轉送事件集合的新增，事件ID為 change stream 的續傳點
+type ChangeStream struct {
+	events *mongo.Collection
+}
+
+// NewChangeStream 建立監看 events 集合的來源
+func NewChangeStream(events *mongo.Collection) *ChangeStream {
+	return &ChangeStream{events: events}
+}
+
+// SupportsChangeStreams 判斷資料庫是否為 replica set 或分片叢集
+func SupportsChangeStreams(ctx context.Context, db *mongo.Database) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
+}
+
+// changeEvent change stream 中新增事件的通知
+type changeEvent struct {
+	ID struct {
+		Data string `bson:"_data"`
+	} `bson:"_id"`
+	FullDocument struct {
+		Type       string             `bson:"type"`
+		UserID     primitive.ObjectID `bson:"user_id"`
+		OccurredAt time.Time          `bson:"occurred_at"`
+		Payload    string             `bson:"payload"`
+	} `bson:"fullDocument"`
+}
+
+// Subscribe 開啟 change stream，條件在資料庫端篩選；續傳點無效或已不在 oplog 中時從現在開始並標記 Reset
+func (s *ChangeStream) Subscribe(ctx context.Context, lastEventID string, filter Filter) (Subscription, error) {
+	match := bson.M{"operationType": "insert"}
+	if len(filter.Types) > 0 {
+		match["fullDocument.type"] = bson.M{"$in": filter.Types}
+	}
+	if len(filter.UserIDs) > 0 {
+		ids := bson.A{}
+		for _, id := range filter.UserIDs {
+			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+				ids = append(ids, oid)
+			}
+		}
+		match["fullDocument.user_id"] = bson.M{"$in": ids}
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+
+	var sub Subscription
+	var stream *mongo.ChangeStream
+	var err error
+	if lastEventID != "" {
+		stream, err = s.events.Watch(ctx, pipeline, options.ChangeStream().SetStartAfter(bson.M{"_data": lastEventID}))
+		if err != nil && ctx.Err() == nil {
+			sub.Reset = true
+			stream = nil
+		}
+	}
+	if stream == nil {
+		if stream, err = s.events.Watch(ctx, pipeline); err != nil {
+			return sub, err
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	go func() {
+		defer close(ch)
+		defer stream.Close(context.Background())
+		for stream.Next(ctx) {
+			var change changeEvent
+			if err := stream.Decode(&change); err != nil {
+				log.Printf("Error decoding user change event: %v\n", err)
+				continue
+			}
+			event := Event{
+				ID:         change.ID.Data,
+				Type:       change.FullDocument.Type,
+				UserID:     change.FullDocument.UserID.Hex(),
+				OccurredAt: change.FullDocument.OccurredAt,
+				Data:       json.RawMessage(change.FullDocument.Payload),
+			}
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			log.Printf("User change stream stopped: %v\n", err)
+		}
+	}()
+	sub.Events = ch
+	return sub, nil
+}
@@ -0,0 +1,55 @@
+// Package feed 即時的用戶變更事件流。事件與 webhook 相同，來自轉送程序從用戶文件 outbox 取出的事件：
+// 有 replica set 時每個訂閱者以 MongoDB change stream 監看已轉送的事件，並以 change stream 的續傳點作為事件ID；
+// 單機部署與測試則使用行程內的事件匯流排，只保留最近的事件供續傳
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Event 事件流中的一個事件，Data 為與 webhook 相同的事件內容
+type Event struct {
+	ID         string          `json:"id"` // 續傳用的事件ID，重新連線時以 Last-Event-ID 帶回
+	Type       string          `json:"type"`
+	UserID     string          `json:"user_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Filter 訂閱的條件，空的欄位不限制
+type Filter struct {
+	Types   []string
+	UserIDs []string
+}
+
+// Match 判斷事件是否符合條件
+func (f Filter) Match(e Event) bool {
+	return contains(f.Types, e.Type) && contains(f.UserIDs, e.UserID)
+}
+
+func contains(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription 一個訂閱。Events 在 ctx 結束、來源停止或訂閱者跟不上時關閉，
+// 用戶端應以最後收到的事件ID重新連線
+type Subscription struct {
+	Events <-chan Event
+	Reset  bool // 無法從指定的事件ID續傳（太舊或無效），用戶端應重新載入資料
+}
+
+// Source 事件流的來源
+type Source interface {
+	// Subscribe 訂閱 lastEventID 之後符合條件的事件，lastEventID 為空時只接收新的事件
+	Subscribe(ctx context.Context, lastEventID string, filter Filter) (Subscription, error)
+}
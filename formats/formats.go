@@ -0,0 +1,169 @@
+// Package formats 以 JSON、MessagePack、CBOR、XML 與 YAML 編碼回應並解碼請求內容。
+// 其他格式都先轉為與 JSON 相同的結構，欄位名稱、omitempty 與時間格式因此與 JSON 回應一致
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"reflect"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v3"
+)
+
+// 支援的媒體類型
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeMsgPack = "application/msgpack"
+	MediaTypeCBOR    = "application/cbor"
+	MediaTypeXML     = "application/xml"
+	MediaTypeYAML    = "application/yaml"
+)
+
+// ErrMalformed 請求內容無法以宣告的格式解碼
+var ErrMalformed = errors.New("request body is malformed")
+
+// aliases 常見的別名對應到標準媒體類型
+var aliases = map[string]string{
+	MediaTypeJSON:             MediaTypeJSON,
+	MediaTypeMsgPack:          MediaTypeMsgPack,
+	"application/x-msgpack":   MediaTypeMsgPack,
+	"application/vnd.msgpack": MediaTypeMsgPack,
+	MediaTypeCBOR:             MediaTypeCBOR,
+	MediaTypeXML:              MediaTypeXML,
+	"text/xml":                MediaTypeXML,
+	MediaTypeYAML:             MediaTypeYAML,
+	"application/x-yaml":      MediaTypeYAML,
+	"text/yaml":               MediaTypeYAML,
+	"text/x-yaml":             MediaTypeYAML,
+}
+
+// Lookup 將媒體類型（可帶參數）轉為標準名稱，不支援時回傳 false
+func Lookup(mediaType string) (string, bool) {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = parsed
+	}
+	canonical, ok := aliases[strings.ToLower(strings.TrimSpace(mediaType))]
+	return canonical, ok
+}
+
+// IsText 判斷格式是否為文字，文字格式的 Content-Type 需要加上 charset
+func IsText(mediaType string) bool {
+	return mediaType != MediaTypeMsgPack && mediaType != MediaTypeCBOR
+}
+
+var (
+	msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+	cborHandle    = &codec.CborHandle{}
+)
+
+func init() {
+	mapType := reflect.TypeOf(map[string]interface{}(nil))
+	msgpackHandle.MapType = mapType
+	msgpackHandle.RawToString = true
+	msgpackHandle.Canonical = true
+	cborHandle.MapType = mapType
+	cborHandle.Canonical = true
+}
+
+// Marshal 以指定格式編碼 v，v 先依 JSON 標籤轉為一般的結構
+func Marshal(mediaType string, v interface{}) ([]byte, error) {
+	if mediaType == MediaTypeJSON {
+		return json.Marshal(v)
+	}
+	tree, err := toTree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	switch mediaType {
+	case MediaTypeMsgPack:
+		err = codec.NewEncoderBytes(&out, msgpackHandle).Encode(tree)
+	case MediaTypeCBOR:
+		err = codec.NewEncoderBytes(&out, cborHandle).Encode(tree)
+	case MediaTypeXML:
+		out, err = marshalXML(tree)
+	case MediaTypeYAML:
+		out, err = yaml.Marshal(tree)
+	default:
+		return json.Marshal(v)
+	}
+	return out, err
+}
+
+// ToJSON 將指定格式的請求內容轉為 JSON，之後可沿用 JSON 綁定與驗證。
+// XML 只有文字，依 target 的欄位型別將數字與布林值還原
+func ToJSON(mediaType string, body []byte, target interface{}) ([]byte, error) {
+	if mediaType == MediaTypeJSON {
+		return body, nil
+	}
+
+	var tree interface{}
+	var err error
+	switch mediaType {
+	case MediaTypeMsgPack:
+		err = codec.NewDecoderBytes(body, msgpackHandle).Decode(&tree)
+	case MediaTypeCBOR:
+		err = codec.NewDecoderBytes(body, cborHandle).Decode(&tree)
+	case MediaTypeXML:
+		tree, err = unmarshalXML(body)
+		if err == nil {
+			tree = coerce(tree, reflect.TypeOf(target))
+		}
+	case MediaTypeYAML:
+		err = yaml.Unmarshal(body, &tree)
+	default:
+		return body, nil
+	}
+	if err != nil {
+		return nil, errors.Join(ErrMalformed, err)
+	}
+
+	out, err := json.Marshal(tree)
+	if err != nil {
+		return nil, errors.Join(ErrMalformed, err)
+	}
+	return out, nil
+}
+
+// toTree 以 JSON 編碼後再解碼為 map、切片與純量，整數保留為 int64
+func toTree(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return numbers(tree), nil
+}
+
+// numbers 將 json.Number 轉為 int64 或 float64，避免其他格式把數字編碼為字串
+func numbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = numbers(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = numbers(item)
+		}
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	}
+	return v
+}
@@ -0,0 +1,284 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// XML 的對應方式：根元素為 response，物件的每個欄位是一個子元素，陣列的每一項是 item 子元素，
+// 不是合法 XML 名稱的欄位以 <entry key="..."> 表示，null 以空元素表示
+const (
+	xmlRoot  = "response"
+	xmlItem  = "item"
+	xmlEntry = "entry"
+)
+
+func marshalXML(tree interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if err := encodeXML(enc, xml.StartElement{Name: xml.Name{Local: xmlRoot}}, tree); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXML(enc *xml.Encoder, start xml.StartElement, v interface{}) error {
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := xml.StartElement{Name: xml.Name{Local: k}}
+			if !validXMLName(k) {
+				child = xml.StartElement{Name: xml.Name{Local: xmlEntry}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: k}}}
+			}
+			if err := encodeXML(enc, child, value[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if err := encodeXML(enc, xml.StartElement{Name: xml.Name{Local: xmlItem}}, item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(value))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// validXMLName 判斷欄位名稱可否直接作為元素名稱
+func validXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// xmlNode 解碼中的元素
+type xmlNode struct {
+	name     string
+	key      string
+	text     strings.Builder
+	children []*xmlNode
+}
+
+// unmarshalXML 依 marshalXML 的對應方式解碼：子元素全為 item 時為陣列，
+// 有子元素時為物件（同名的子元素合併為陣列），沒有子元素時為文字
+func unmarshalXML(body []byte) (interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local}
+			for _, attr := range t.Attr {
+				if t.Name.Local == xmlEntry && attr.Name.Local == "key" {
+					node.key = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root != nil {
+				return nil, errors.New("xml: multiple root elements")
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return root.value(), nil
+}
+
+func (n *xmlNode) value() interface{} {
+	if len(n.children) == 0 {
+		return strings.TrimSpace(n.text.String())
+	}
+
+	items := true
+	for _, child := range n.children {
+		items = items && child.name == xmlItem
+	}
+	if items {
+		list := make([]interface{}, 0, len(n.children))
+		for _, child := range n.children {
+			list = append(list, child.value())
+		}
+		return list
+	}
+
+	object := map[string]interface{}{}
+	for _, child := range n.children {
+		key := child.name
+		if child.name == xmlEntry && child.key != "" {
+			key = child.key
+		}
+		value := child.value()
+		switch existing := object[key].(type) {
+		case nil:
+			object[key] = value
+		case []interface{}:
+			object[key] = append(existing, value)
+		default:
+			object[key] = []interface{}{existing, value}
+		}
+	}
+	return object
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// coerce 依目標型別將 XML 的文字轉為數字或布林值，並將單一元素或空元素轉為陣列；
+// 自行實作 json.Unmarshaler 的型別保持原樣
+func coerce(v interface{}, t reflect.Type) interface{} {
+	if t == nil {
+		return v
+	}
+	for t.Kind() == reflect.Pointer {
+		if t.Implements(jsonUnmarshaler) {
+			return v
+		}
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshaler) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for key, field := range jsonFields(t) {
+			if value, exists := object[key]; exists {
+				object[key] = coerce(value, field)
+			}
+		}
+		return object
+	case reflect.Map:
+		if object, ok := v.(map[string]interface{}); ok {
+			for key, value := range object {
+				object[key] = coerce(value, t.Elem())
+			}
+		}
+		return v
+	case reflect.Slice, reflect.Array:
+		switch value := v.(type) {
+		case []interface{}:
+			for i, item := range value {
+				value[i] = coerce(item, t.Elem())
+			}
+			return value
+		case string:
+			if value == "" {
+				return []interface{}{}
+			}
+		}
+		return []interface{}{coerce(v, t.Elem())}
+	}
+
+	text, ok := v.(string)
+	if !ok {
+		return v
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(text, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// jsonFields 依 JSON 標籤列出結構的欄位型別，包含內嵌結構的欄位
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for key, value := range jsonFields(embedded) {
+					if _, exists := fields[key]; !exists {
+						fields[key] = value
+					}
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
@@ -0,0 +1,141 @@
+package graphql
+
+// Document 解析後的查詢文件，只包含可執行的定義
+type Document struct {
+	Operations []*Operation
+	Fragments  []*Fragment
+}
+
+// Operation query、mutation 或 subscription 操作
+type Operation struct {
+	Type         string // query、mutation 或 subscription
+	Name         string
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+	Pos          int
+}
+
+// VariableDefinition 操作宣告的變數
+type VariableDefinition struct {
+	Name    string
+	Type    *TypeRef
+	Default *Value
+	Pos     int
+}
+
+// TypeRef 查詢中引用的型別，Elem 不為 nil 時為列表
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+	Pos     int
+}
+
+func (t *TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// Directive 指令，例如 @include(if: $flag)
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Pos       int
+}
+
+// Argument 欄位或指令的參數
+type Argument struct {
+	Name  string
+	Value *Value
+	Pos   int
+}
+
+// Selection 選擇集中的欄位、片段展開或內嵌片段
+type Selection interface {
+	position() int
+}
+
+// Field 選擇的欄位
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Pos          int
+}
+
+// ResponseName 回應中使用的名稱，有別名時為別名
+func (f *Field) ResponseName() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread 具名片段的展開，例如 ...userFields
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Pos        int
+}
+
+// InlineFragment 內嵌片段，例如 ... on User { name }
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Pos           int
+}
+
+// Fragment 具名片段的定義
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Pos           int
+}
+
+func (f *Field) position() int          { return f.Pos }
+func (f *FragmentSpread) position() int { return f.Pos }
+func (f *InlineFragment) position() int { return f.Pos }
+
+// ValueKind 常值的種類
+type ValueKind int
+
+// 常值的種類
+const (
+	VariableValue ValueKind = iota
+	IntValue
+	FloatValue
+	StringValue
+	BooleanValue
+	NullValue
+	EnumValue
+	ListValue
+	ObjectValue
+)
+
+// Value 查詢中的常值；Raw 保存純量的文字、列舉名稱或變數名稱
+type Value struct {
+	Kind   ValueKind
+	Raw    string
+	List   []*Value
+	Fields []*ObjectField
+	Pos    int
+}
+
+// ObjectField 輸入物件常值的一個欄位
+type ObjectField struct {
+	Name  string
+	Value *Value
+	Pos   int
+}
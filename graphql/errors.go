@@ -0,0 +1,41 @@
+package graphql
+
+import "strings"
+
+// Location 錯誤在查詢文字中的行與欄，皆由 1 開始
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error 回應中 errors 陣列的一個錯誤。resolver 也可以直接回傳 *Error 以附帶 extensions
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError 建立帶有 extensions.code 的錯誤，供 resolver 回傳
+func NewError(code, message string) *Error {
+	return &Error{Message: message, Extensions: map[string]interface{}{"code": code}}
+}
+
+// locate 由位元組位置計算行與欄，欄以 Unicode 字元計算
+func locate(src string, pos int) Location {
+	if pos > len(src) {
+		pos = len(src)
+	}
+	before := src[:pos]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return Location{Line: line, Column: len([]rune(before[lineStart:])) + 1}
+}
+
+func syntaxError(src string, pos int, message string) *Error {
+	return &Error{Message: "Syntax Error: " + message, Locations: []Location{locate(src, pos)}}
+}
@@ -0,0 +1,522 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// Params 執行一個 GraphQL 請求的參數
+type Params struct {
+	Schema        *Schema
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+	Context       context.Context
+	RootValue     interface{}
+	MaxDepth      int      // 欄位巢狀的最大深度，0 表示不限制
+	MaxComplexity int      // 查詢的最大複雜度，0 表示不限制
+	Allow         []string // 允許的操作類型，空值表示全部允許；GET 請求只允許 query
+}
+
+// Result 執行結果。Data 為 nil 且 Executed 為 false 時表示請求在執行前就失敗
+type Result struct {
+	Data     interface{}
+	Errors   []*Error
+	Executed bool
+}
+
+// MarshalJSON 執行前失敗時省略 data，執行後即使為 null 也輸出 data
+func (r *Result) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if len(r.Errors) > 0 {
+		errs, err := json.Marshal(r.Errors)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`"errors":`)
+		buf.Write(errs)
+	}
+	if r.Executed {
+		if len(r.Errors) > 0 {
+			buf.WriteByte(',')
+		}
+		data, err := json.Marshal(r.Data)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`"data":`)
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// CodeOperationNotAllowed 操作類型不在 Params.Allow 中時錯誤的 extensions.code
+const CodeOperationNotAllowed = "OPERATION_NOT_ALLOWED"
+
+type schemaContextKey struct{}
+
+func schemaFromContext(ctx context.Context) *Schema {
+	return ctx.Value(schemaContextKey{}).(*Schema)
+}
+
+// Do 解析、驗證並執行查詢
+func Do(p Params) *Result {
+	doc, err := Parse(p.Query)
+	if err != nil {
+		return &Result{Errors: []*Error{err.(*Error)}}
+	}
+	if errs := Validate(p.Schema, doc, p.Query); len(errs) > 0 {
+		return &Result{Errors: errs}
+	}
+
+	op, gqlErr := selectOperation(doc, p.OperationName)
+	if gqlErr != nil {
+		return &Result{Errors: []*Error{gqlErr}}
+	}
+	if len(p.Allow) > 0 && !contains(p.Allow, op.Type) {
+		return &Result{Errors: []*Error{{
+			Message:    fmt.Sprintf("Can only perform a %s operation from this request.", strings.Join(p.Allow, " or ")),
+			Extensions: map[string]interface{}{"code": CodeOperationNotAllowed},
+		}}}
+	}
+	vars, errs := coerceVariables(p.Schema, op, p.Variables)
+	if len(errs) > 0 {
+		return &Result{Errors: errs}
+	}
+
+	e := &executor{schema: p.Schema, src: p.Query, fragments: map[string]*Fragment{}, vars: vars}
+	for _, f := range doc.Fragments {
+		e.fragments[f.Name] = f
+	}
+	root := p.Schema.Query
+	if op.Type == "mutation" {
+		root = p.Schema.Mutation
+	}
+	if errs := e.checkLimits(root, op, p.MaxDepth, p.MaxComplexity); len(errs) > 0 {
+		return &Result{Errors: errs}
+	}
+
+	ctx := p.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e.ctx = context.WithValue(ctx, schemaContextKey{}, p.Schema)
+	e.execute(root, op, p.RootValue)
+	return &Result{Data: e.data, Errors: e.errors, Executed: true}
+}
+
+// selectOperation 依名稱選擇要執行的操作，文件只有一個操作時可省略名稱
+func selectOperation(doc *Document, name string) (*Operation, *Error) {
+	if name == "" {
+		if len(doc.Operations) != 1 {
+			if len(doc.Operations) == 0 {
+				return nil, &Error{Message: "Must provide an operation."}
+			}
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return doc.Operations[0], nil
+	}
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named \"%s\".", name)}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedMap 依選擇順序輸出欄位的 JSON 物件
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// slot 結果中的一個位置。不可為 null 的位置得到 null 時，改為將上層可為 null 的位置設為 null
+type slot struct {
+	nullable bool
+	set      func(interface{})
+	parent   *slot
+	nulled   bool
+}
+
+// detached 位置或其上層已被設為 null，其下的欄位不必再解析
+func (s *slot) detached() bool {
+	for ; s != nil; s = s.parent {
+		if s.nulled {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldGroup 回應名稱相同而合併的欄位
+type fieldGroup struct {
+	name   string
+	fields []*Field
+}
+
+// objectTask 一個等待解析欄位的物件
+type objectTask struct {
+	typ    *Object
+	source interface{}
+	groups []fieldGroup
+	out    *orderedMap
+	slot   *slot
+	path   []interface{}
+}
+
+// fieldResult 已呼叫 resolver 但尚未完成的欄位值
+type fieldResult struct {
+	task  *objectTask
+	group fieldGroup
+	def   *FieldDefinition
+	value interface{}
+	err   error
+	slot  *slot
+	path  []interface{}
+}
+
+// executor 逐層執行查詢：同一層的 resolver 都呼叫完後才取出 Thunk 的值，
+// 讓 DataLoader 可以將整層的查詢合併為一次批次
+type executor struct {
+	schema    *Schema
+	src       string
+	fragments map[string]*Fragment
+	vars      map[string]interface{}
+	ctx       context.Context
+	data      interface{}
+	errors    []*Error
+}
+
+func (e *executor) execute(root *Object, op *Operation, rootValue interface{}) {
+	out := &orderedMap{values: map[string]interface{}{}}
+	e.data = out
+	rootSlot := &slot{nullable: true}
+	rootSlot.set = func(v interface{}) { e.data = v }
+	task := &objectTask{typ: root, source: rootValue, out: out, slot: rootSlot}
+	task.groups = e.collectFields(root, op.SelectionSet, map[string]bool{}, nil)
+	for _, g := range task.groups {
+		out.keys = append(out.keys, g.name)
+	}
+
+	if op.Type == "mutation" {
+		// mutation 的根欄位依序執行，每個欄位完成後才執行下一個
+		for _, g := range task.groups {
+			single := *task
+			single.groups = []fieldGroup{g}
+			e.run([]*objectTask{&single})
+		}
+		return
+	}
+	e.run([]*objectTask{task})
+}
+
+// run 逐層解析物件的欄位直到沒有下一層
+func (e *executor) run(tasks []*objectTask) {
+	for len(tasks) > 0 {
+		var results []*fieldResult
+		for _, t := range tasks {
+			if t.slot.detached() {
+				continue
+			}
+			for _, g := range t.groups {
+				results = append(results, e.resolveField(t, g))
+			}
+		}
+		for _, r := range results {
+			for {
+				thunk, ok := r.value.(Thunk)
+				if !ok || r.err != nil {
+					break
+				}
+				r.value, r.err = e.force(thunk)
+			}
+		}
+		var next []*objectTask
+		for _, r := range results {
+			if r.err != nil {
+				e.fieldError(r.group.fields, r.path, r.err)
+				e.nullify(r.slot)
+				continue
+			}
+			next = append(next, e.completeValue(r.def.Type, r.group.fields, r.value, r.slot, r.path)...)
+		}
+		tasks = next
+	}
+}
+
+// force 取出 Thunk 的值，resolver 的 panic 轉為欄位錯誤
+func (e *executor) force(thunk Thunk) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("graphql: panic while resolving: %v\n", r)
+			value, err = nil, fmt.Errorf("internal error")
+		}
+	}()
+	return thunk()
+}
+
+func (e *executor) resolveField(t *objectTask, g fieldGroup) (r *fieldResult) {
+	field := g.fields[0]
+	def := e.schema.fieldDefinition(t.typ, field.Name)
+	path := append(append([]interface{}{}, t.path...), g.name)
+	r = &fieldResult{task: t, group: g, def: def, path: path}
+
+	out := t.out
+	name := g.name
+	r.slot = &slot{nullable: true, parent: t.slot}
+	if _, ok := def.Type.(*NonNull); ok {
+		r.slot.nullable = false
+	}
+	r.slot.set = func(v interface{}) { out.values[name] = v }
+
+	args, err := coerceArguments(def.Args, field.Arguments, e.vars)
+	if err != nil {
+		r.err = err
+		return r
+	}
+
+	resolve := def.Resolve
+	if resolve == nil {
+		resolve = defaultResolve
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("graphql: panic while resolving %s.%s: %v\n", t.typ.Name, def.Name, rec)
+			r.value, r.err = nil, fmt.Errorf("internal error")
+		}
+	}()
+	r.value, r.err = resolve(ResolveParams{
+		Context: e.ctx,
+		Source:  t.source,
+		Args:    args,
+		Info:    ResolveInfo{FieldName: def.Name, ParentType: t.typ, Path: path},
+	})
+	return r
+}
+
+// completeValue 依欄位型別完成值；物件會產生下一層的工作
+func (e *executor) completeValue(t Type, fields []*Field, value interface{}, s *slot, path []interface{}) []*objectTask {
+	if nn, ok := t.(*NonNull); ok {
+		if isNil(value) {
+			e.fieldError(fields, path, fmt.Errorf("Cannot return null for non-nullable field."))
+			e.nullify(s)
+			return nil
+		}
+		t = nn.OfType
+	}
+	if isNil(value) {
+		s.set(nil)
+		return nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.fieldError(fields, path, fmt.Errorf("Expected Iterable, but did not find one for field."))
+			e.nullify(s)
+			return nil
+		}
+		items := make([]interface{}, rv.Len())
+		s.set(items)
+		_, itemNonNull := t.OfType.(*NonNull)
+		var tasks []*objectTask
+		for i := range items {
+			index := i
+			item := &slot{nullable: !itemNonNull, parent: s, set: func(v interface{}) { items[index] = v }}
+			itemPath := append(append([]interface{}{}, path...), i)
+			tasks = append(tasks, e.completeValue(t.OfType, fields, rv.Index(i).Interface(), item, itemPath)...)
+		}
+		return tasks
+	case *Scalar:
+		serialized, err := t.Serialize(value)
+		if err != nil {
+			e.fieldError(fields, path, err)
+			e.nullify(s)
+			return nil
+		}
+		s.set(serialized)
+	case *Enum:
+		for _, ev := range t.Values {
+			if reflect.DeepEqual(ev.Value, value) {
+				s.set(ev.Name)
+				return nil
+			}
+		}
+		e.fieldError(fields, path, fmt.Errorf("Enum \"%s\" cannot represent value: %v", t.Name, value))
+		e.nullify(s)
+	case *Object:
+		var selections []Selection
+		for _, f := range fields {
+			selections = append(selections, f.SelectionSet...)
+		}
+		out := &orderedMap{values: map[string]interface{}{}}
+		task := &objectTask{typ: t, source: value, out: out, slot: s, path: path}
+		task.groups = e.collectFields(t, selections, map[string]bool{}, nil)
+		for _, g := range task.groups {
+			out.keys = append(out.keys, g.name)
+		}
+		s.set(out)
+		return []*objectTask{task}
+	}
+	return nil
+}
+
+// nullify 將位置設為 null；不可為 null 時往上找到第一個可為 null 的位置
+func (e *executor) nullify(s *slot) {
+	for s != nil && !s.nullable {
+		s = s.parent
+	}
+	if s == nil {
+		e.data = nil
+		return
+	}
+	s.set(nil)
+	s.nulled = true
+}
+
+func (e *executor) fieldError(fields []*Field, path []interface{}, err error) {
+	gqlErr := &Error{Message: err.Error()}
+	if custom, ok := err.(*Error); ok {
+		gqlErr.Message = custom.Message
+		gqlErr.Extensions = custom.Extensions
+	}
+	gqlErr.Locations = []Location{locate(e.src, fields[0].Pos)}
+	gqlErr.Path = path
+	e.errors = append(e.errors, gqlErr)
+}
+
+// collectFields 展開片段並依 @skip、@include 篩選後，將回應名稱相同的欄位合併
+func (e *executor) collectFields(t *Object, selections []Selection, visited map[string]bool, groups []fieldGroup) []fieldGroup {
+	for _, sel := range selections {
+		switch s := sel.(type) {
+		case *Field:
+			if !e.included(s.Directives) {
+				continue
+			}
+			name := s.ResponseName()
+			merged := false
+			for i := range groups {
+				if groups[i].name == name {
+					groups[i].fields = append(groups[i].fields, s)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				groups = append(groups, fieldGroup{name: name, fields: []*Field{s}})
+			}
+		case *InlineFragment:
+			if !e.included(s.Directives) || (s.TypeCondition != "" && s.TypeCondition != t.Name) {
+				continue
+			}
+			groups = e.collectFields(t, s.SelectionSet, visited, groups)
+		case *FragmentSpread:
+			if visited[s.Name] || !e.included(s.Directives) {
+				continue
+			}
+			visited[s.Name] = true
+			f := e.fragments[s.Name]
+			if f == nil || f.TypeCondition != t.Name {
+				continue
+			}
+			groups = e.collectFields(t, f.SelectionSet, visited, groups)
+		}
+	}
+	return groups
+}
+
+// included 依 @skip 與 @include 判斷是否包含
+func (e *executor) included(list []*Directive) bool {
+	for _, d := range list {
+		if d.Name != "skip" && d.Name != "include" {
+			continue
+		}
+		args, err := coerceArguments(findDirective(d.Name).Args, d.Arguments, e.vars)
+		if err != nil {
+			continue
+		}
+		flag, _ := args["if"].(bool)
+		if d.Name == "skip" && flag || d.Name == "include" && !flag {
+			return false
+		}
+	}
+	return true
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// defaultResolve 沒有 resolver 的欄位從 map 或結構取值，結構欄位依 json 標籤或不分大小寫的名稱比對
+func defaultResolve(p ResolveParams) (interface{}, error) {
+	if m, ok := p.Source.(map[string]interface{}); ok {
+		return m[p.Info.FieldName], nil
+	}
+	rv := reflect.ValueOf(p.Source)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == p.Info.FieldName || tag == "" && strings.EqualFold(f.Name, p.Info.FieldName) {
+			return rv.Field(i).Interface(), nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,296 @@
+package graphql
+
+// directiveDefinition 支援的指令
+type directiveDefinition struct {
+	Name        string
+	Description string
+	Locations   []string
+	Args        []*InputValue
+}
+
+// directives 可執行的指令，@deprecated 只出現在內省結果中
+var directives = []*directiveDefinition{
+	{
+		Name:        "include",
+		Description: "Directs the executor to include this field or fragment only when the `if` argument is true.",
+		Locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		Args:        []*InputValue{{Name: "if", Description: "Included when true.", Type: &NonNull{OfType: Boolean}}},
+	},
+	{
+		Name:        "skip",
+		Description: "Directs the executor to skip this field or fragment when the `if` argument is true.",
+		Locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		Args:        []*InputValue{{Name: "if", Description: "Skipped when true.", Type: &NonNull{OfType: Boolean}}},
+	},
+	{
+		Name:        "deprecated",
+		Description: "Marks an element of a GraphQL schema as no longer supported.",
+		Locations:   []string{"FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INPUT_FIELD_DEFINITION", "ENUM_VALUE"},
+		Args:        []*InputValue{{Name: "reason", Type: String, Default: "No longer supported", HasDefault: true}},
+	},
+}
+
+func findDirective(name string) *directiveDefinition {
+	for _, d := range directives {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// 內省的型別，欄位在 init 中設定以處理型別之間的循環引用
+var (
+	schemaType            = &Object{Name: "__Schema", Description: "A GraphQL Schema defines the capabilities of a GraphQL server."}
+	typeType              = &Object{Name: "__Type", Description: "The fundamental unit of any GraphQL Schema is the type."}
+	fieldType             = &Object{Name: "__Field", Description: "Object and Interface types are described by a list of Fields, each of which has a name, potentially a list of arguments, and a return type."}
+	inputValueType        = &Object{Name: "__InputValue", Description: "Arguments provided to Fields or Directives and the input fields of an InputObject are represented as Input Values which describe their type and optionally a default value."}
+	enumValueType         = &Object{Name: "__EnumValue", Description: "One possible value for a given Enum."}
+	directiveType         = &Object{Name: "__Directive", Description: "A Directive provides a way to describe alternate runtime execution and type evaluation behavior in a GraphQL document."}
+	typeKindEnum          = &Enum{Name: "__TypeKind", Description: "An enum describing what kind of type a given `__Type` is."}
+	directiveLocationEnum = &Enum{Name: "__DirectiveLocation", Description: "A Directive can be adjacent to many parts of the GraphQL language."}
+
+	typenameField = &FieldDefinition{
+		Name:        "__typename",
+		Description: "The name of the current Object type at runtime.",
+		Type:        &NonNull{OfType: String},
+		Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Info.ParentType.Name, nil
+		},
+	}
+	schemaField = &FieldDefinition{
+		Name:        "__schema",
+		Description: "Access the current type schema of this server.",
+		Type:        &NonNull{OfType: schemaType},
+		Resolve: func(p ResolveParams) (interface{}, error) {
+			return schemaFromContext(p.Context), nil
+		},
+	}
+	typeField = &FieldDefinition{
+		Name:        "__type",
+		Description: "Request the type information of a single type.",
+		Type:        typeType,
+		Args:        []*InputValue{{Name: "name", Type: &NonNull{OfType: String}}},
+		Resolve: func(p ResolveParams) (interface{}, error) {
+			if t := schemaFromContext(p.Context).Type(p.Args["name"].(string)); t != nil {
+				return Type(t), nil
+			}
+			return nil, nil
+		},
+	}
+)
+
+// deprecatedArg 內省中 fields 與 enumValues 的 includeDeprecated 參數
+func deprecatedArg() []*InputValue {
+	return []*InputValue{{Name: "includeDeprecated", Type: Boolean, Default: false, HasDefault: true}}
+}
+
+func nonNullList(t Type) Type {
+	return &NonNull{OfType: &List{OfType: &NonNull{OfType: t}}}
+}
+
+func init() {
+	for _, kind := range []string{"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL"} {
+		typeKindEnum.Values = append(typeKindEnum.Values, &EnumValueDefinition{Name: kind, Value: kind})
+	}
+	for _, loc := range []string{"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT", "VARIABLE_DEFINITION",
+		"SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE", "UNION", "ENUM", "ENUM_VALUE", "INPUT_OBJECT", "INPUT_FIELD_DEFINITION"} {
+		directiveLocationEnum.Values = append(directiveLocationEnum.Values, &EnumValueDefinition{Name: loc, Value: loc})
+	}
+
+	schemaType.Fields = []*FieldDefinition{
+		{Name: "description", Type: String, Resolve: constant(nil)},
+		{Name: "types", Type: nonNullList(typeType), Resolve: func(p ResolveParams) (interface{}, error) {
+			s := p.Source.(*Schema)
+			types := make([]Type, 0, len(s.names))
+			for _, name := range s.names {
+				types = append(types, s.types[name])
+			}
+			return types, nil
+		}},
+		{Name: "queryType", Type: &NonNull{OfType: typeType}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return Type(p.Source.(*Schema).Query), nil
+		}},
+		{Name: "mutationType", Type: typeType, Resolve: func(p ResolveParams) (interface{}, error) {
+			if m := p.Source.(*Schema).Mutation; m != nil {
+				return Type(m), nil
+			}
+			return nil, nil
+		}},
+		{Name: "subscriptionType", Type: typeType, Resolve: constant(nil)},
+		{Name: "directives", Type: nonNullList(directiveType), Resolve: constant(directives)},
+	}
+
+	typeType.Fields = []*FieldDefinition{
+		{Name: "kind", Type: &NonNull{OfType: typeKindEnum}, Resolve: func(p ResolveParams) (interface{}, error) {
+			switch p.Source.(type) {
+			case *Scalar:
+				return "SCALAR", nil
+			case *Object:
+				return "OBJECT", nil
+			case *Enum:
+				return "ENUM", nil
+			case *InputObject:
+				return "INPUT_OBJECT", nil
+			case *List:
+				return "LIST", nil
+			}
+			return "NON_NULL", nil
+		}},
+		{Name: "name", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			if n, ok := p.Source.(NamedType); ok {
+				return n.TypeName(), nil
+			}
+			return nil, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			if n, ok := p.Source.(NamedType); ok && n.TypeDescription() != "" {
+				return n.TypeDescription(), nil
+			}
+			return nil, nil
+		}},
+		{Name: "specifiedByURL", Type: String, Resolve: constant(nil)},
+		{Name: "fields", Type: &List{OfType: &NonNull{OfType: fieldType}}, Args: deprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			obj, ok := p.Source.(*Object)
+			if !ok {
+				return nil, nil
+			}
+			fields := []*FieldDefinition{}
+			for _, f := range obj.Fields {
+				if f.DeprecationReason == "" || p.Args["includeDeprecated"] == true {
+					fields = append(fields, f)
+				}
+			}
+			return fields, nil
+		}},
+		{Name: "interfaces", Type: &List{OfType: &NonNull{OfType: typeType}}, Resolve: func(p ResolveParams) (interface{}, error) {
+			if _, ok := p.Source.(*Object); ok {
+				return []Type{}, nil
+			}
+			return nil, nil
+		}},
+		{Name: "possibleTypes", Type: &List{OfType: &NonNull{OfType: typeType}}, Resolve: constant(nil)},
+		{Name: "enumValues", Type: &List{OfType: &NonNull{OfType: enumValueType}}, Args: deprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			enum, ok := p.Source.(*Enum)
+			if !ok {
+				return nil, nil
+			}
+			values := []*EnumValueDefinition{}
+			for _, v := range enum.Values {
+				if v.DeprecationReason == "" || p.Args["includeDeprecated"] == true {
+					values = append(values, v)
+				}
+			}
+			return values, nil
+		}},
+		{Name: "inputFields", Type: &List{OfType: &NonNull{OfType: inputValueType}}, Args: deprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			if input, ok := p.Source.(*InputObject); ok {
+				return input.Fields, nil
+			}
+			return nil, nil
+		}},
+		{Name: "ofType", Type: typeType, Resolve: func(p ResolveParams) (interface{}, error) {
+			switch t := p.Source.(type) {
+			case *List:
+				return t.OfType, nil
+			case *NonNull:
+				return t.OfType, nil
+			}
+			return nil, nil
+		}},
+		{Name: "isOneOf", Type: Boolean, Resolve: func(p ResolveParams) (interface{}, error) {
+			if _, ok := p.Source.(*InputObject); ok {
+				return false, nil
+			}
+			return nil, nil
+		}},
+	}
+
+	fieldType.Fields = []*FieldDefinition{
+		{Name: "name", Type: &NonNull{OfType: String}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*FieldDefinition).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return optional(p.Source.(*FieldDefinition).Description), nil
+		}},
+		{Name: "args", Type: nonNullList(inputValueType), Args: deprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			if args := p.Source.(*FieldDefinition).Args; args != nil {
+				return args, nil
+			}
+			return []*InputValue{}, nil
+		}},
+		{Name: "type", Type: &NonNull{OfType: typeType}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*FieldDefinition).Type, nil
+		}},
+		{Name: "isDeprecated", Type: &NonNull{OfType: Boolean}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*FieldDefinition).DeprecationReason != "", nil
+		}},
+		{Name: "deprecationReason", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return optional(p.Source.(*FieldDefinition).DeprecationReason), nil
+		}},
+	}
+
+	inputValueType.Fields = []*FieldDefinition{
+		{Name: "name", Type: &NonNull{OfType: String}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*InputValue).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return optional(p.Source.(*InputValue).Description), nil
+		}},
+		{Name: "type", Type: &NonNull{OfType: typeType}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*InputValue).Type, nil
+		}},
+		{Name: "defaultValue", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			v := p.Source.(*InputValue)
+			if !v.HasDefault {
+				return nil, nil
+			}
+			return printValue(v.Type, v.Default), nil
+		}},
+		{Name: "isDeprecated", Type: &NonNull{OfType: Boolean}, Resolve: constant(false)},
+		{Name: "deprecationReason", Type: String, Resolve: constant(nil)},
+	}
+
+	enumValueType.Fields = []*FieldDefinition{
+		{Name: "name", Type: &NonNull{OfType: String}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*EnumValueDefinition).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return optional(p.Source.(*EnumValueDefinition).Description), nil
+		}},
+		{Name: "isDeprecated", Type: &NonNull{OfType: Boolean}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*EnumValueDefinition).DeprecationReason != "", nil
+		}},
+		{Name: "deprecationReason", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return optional(p.Source.(*EnumValueDefinition).DeprecationReason), nil
+		}},
+	}
+
+	directiveType.Fields = []*FieldDefinition{
+		{Name: "name", Type: &NonNull{OfType: String}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*directiveDefinition).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return optional(p.Source.(*directiveDefinition).Description), nil
+		}},
+		{Name: "isRepeatable", Type: &NonNull{OfType: Boolean}, Resolve: constant(false)},
+		{Name: "locations", Type: nonNullList(directiveLocationEnum), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*directiveDefinition).Locations, nil
+		}},
+		{Name: "args", Type: nonNullList(inputValueType), Args: deprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*directiveDefinition).Args, nil
+		}},
+	}
+}
+
+func constant(value interface{}) ResolveFunc {
+	return func(ResolveParams) (interface{}, error) { return value, nil }
+}
+
+// optional 空字串在內省結果中以 null 表示
+func optional(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkLimits 執行前以變數的實際值計算查詢的深度與複雜度，內省欄位不計入
+func (e *executor) checkLimits(root *Object, op *Operation, maxDepth, maxComplexity int) []*Error {
+	if maxDepth > 0 {
+		if depth := e.depth(root, op.SelectionSet); depth > maxDepth {
+			return []*Error{{
+				Message:    fmt.Sprintf("Query depth %d exceeds the maximum allowed depth of %d.", depth, maxDepth),
+				Extensions: map[string]interface{}{"code": "QUERY_TOO_DEEP", "depth": depth, "maxDepth": maxDepth},
+			}}
+		}
+	}
+	if maxComplexity > 0 {
+		if complexity := e.complexity(root, op.SelectionSet); complexity > maxComplexity {
+			return []*Error{{
+				Message:    fmt.Sprintf("Query complexity %d exceeds the maximum allowed complexity of %d.", complexity, maxComplexity),
+				Extensions: map[string]interface{}{"code": "QUERY_TOO_COMPLEX", "complexity": complexity, "maxComplexity": maxComplexity},
+			}}
+		}
+	}
+	return nil
+}
+
+// childSelections 合併同名欄位的子選擇集，回傳子欄位所屬的物件型別；葉節點與內省欄位回傳 nil
+func (e *executor) childSelections(parent *Object, g fieldGroup) (*FieldDefinition, *Object, []Selection) {
+	if strings.HasPrefix(g.fields[0].Name, "__") {
+		return nil, nil, nil
+	}
+	def := e.schema.fieldDefinition(parent, g.fields[0].Name)
+	if def == nil {
+		return nil, nil, nil
+	}
+	obj, _ := namedType(def.Type).(*Object)
+	var selections []Selection
+	for _, f := range g.fields {
+		selections = append(selections, f.SelectionSet...)
+	}
+	return def, obj, selections
+}
+
+func (e *executor) depth(t *Object, selections []Selection) int {
+	max := 0
+	for _, g := range e.collectFields(t, selections, map[string]bool{}, nil) {
+		def, obj, children := e.childSelections(t, g)
+		if def == nil {
+			continue
+		}
+		d := 1
+		if obj != nil {
+			d += e.depth(obj, children)
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// complexity 每個欄位預設為 1 加上子欄位的複雜度，欄位可以依參數自訂，例如列表乘上筆數
+func (e *executor) complexity(t *Object, selections []Selection) int {
+	total := 0
+	for _, g := range e.collectFields(t, selections, map[string]bool{}, nil) {
+		def, obj, children := e.childSelections(t, g)
+		if def == nil {
+			continue
+		}
+		child := 0
+		if obj != nil {
+			child = e.complexity(obj, children)
+		}
+		if def.Complexity == nil {
+			total += 1 + child
+			continue
+		}
+		args, err := coerceArguments(def.Args, g.fields[0].Arguments, e.vars)
+		if err != nil {
+			args = map[string]interface{}{}
+		}
+		total += def.Complexity(child, args)
+	}
+	return total
+}
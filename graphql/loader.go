@@ -0,0 +1,85 @@
+package graphql
+
+// BatchFunc 一次載入多個鍵的值，找不到的鍵不必出現在結果中
+type BatchFunc[K comparable, V any] func(keys []K) (map[K]V, error)
+
+// Loader DataLoader：同一層欄位呼叫 Load 時只登記鍵，
+// 第一個 Thunk 被取值時以一次 BatchFunc 載入所有登記的鍵，結果在請求內快取。
+// 執行器以單一 goroutine 執行，Loader 不需要也不支援並行使用，每個請求應建立新的 Loader
+type Loader[K comparable, V any] struct {
+	batch   BatchFunc[K, V]
+	entries map[K]*loaderEntry[V]
+	pending []K
+	batches int
+}
+
+type loaderEntry[V any] struct {
+	value  V
+	found  bool
+	err    error
+	loaded bool
+}
+
+// NewLoader 建立以 batch 載入的 Loader
+func NewLoader[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{batch: batch, entries: map[K]*loaderEntry[V]{}}
+}
+
+// Load 登記鍵並回傳取值用的 Thunk；找不到時 Thunk 回傳 nil
+func (l *Loader[K, V]) Load(key K) Thunk {
+	if _, ok := l.entries[key]; !ok {
+		l.entries[key] = &loaderEntry[V]{}
+		l.pending = append(l.pending, key)
+	}
+	return func() (interface{}, error) {
+		entry := l.entries[key]
+		if !entry.loaded {
+			l.dispatch()
+		}
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		if !entry.found {
+			return nil, nil
+		}
+		return entry.value, nil
+	}
+}
+
+// Prime 將已取得的值放入快取，之後的 Load 不會再查詢
+func (l *Loader[K, V]) Prime(key K, value V) {
+	if entry, ok := l.entries[key]; ok && entry.loaded {
+		return
+	}
+	l.entries[key] = &loaderEntry[V]{value: value, found: true, loaded: true}
+}
+
+// Batches 已執行的批次數
+func (l *Loader[K, V]) Batches() int {
+	return l.batches
+}
+
+func (l *Loader[K, V]) dispatch() {
+	var keys []K
+	for _, key := range l.pending {
+		if !l.entries[key].loaded {
+			keys = append(keys, key)
+		}
+	}
+	l.pending = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	l.batches++
+	values, err := l.batch(keys)
+	for _, key := range keys {
+		entry := l.entries[key]
+		entry.loaded = true
+		if err != nil {
+			entry.err = err
+			continue
+		}
+		entry.value, entry.found = values[key]
+	}
+}
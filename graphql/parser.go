@@ -0,0 +1,276 @@
+package graphql
+
+import "fmt"
+
+// parser 遞迴下降的查詢解析器，只接受可執行的定義
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// Parse 解析查詢文字
+func Parse(src string) (doc *Document, err error) {
+	p := &parser{lex: &lexer{src: src}}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, e
+		}
+	}()
+
+	p.advance()
+	doc = &Document{}
+	for {
+		switch {
+		case p.tok.kind == tokEOF:
+			if len(doc.Operations) == 0 && len(doc.Fragments) == 0 {
+				p.fail("Unexpected <EOF>")
+			}
+			return doc, nil
+		case p.peek("{"):
+			// 先取得位置再解析，結構字面值中欄位存取與函式呼叫的求值順序不固定
+			op := &Operation{Type: "query", Pos: p.tok.pos}
+			op.SelectionSet = p.selectionSet()
+			doc.Operations = append(doc.Operations, op)
+		case p.tok.kind == tokName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			doc.Operations = append(doc.Operations, p.operation())
+		case p.tok.kind == tokName && p.tok.value == "fragment":
+			doc.Fragments = append(doc.Fragments, p.fragment())
+		default:
+			p.unexpected()
+		}
+	}
+}
+
+func (p *parser) advance() {
+	tok, err := p.lex.next()
+	if err != nil {
+		panic(err)
+	}
+	p.tok = tok
+}
+
+func (p *parser) fail(message string) {
+	panic(syntaxError(p.lex.src, p.tok.pos, message))
+}
+
+func (p *parser) unexpected() {
+	p.fail(fmt.Sprintf("Unexpected %s", p.tok))
+}
+
+// peek 判斷目前是否為指定的標點
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == punct
+}
+
+// skip 目前為指定的標點時前進並回傳 true
+func (p *parser) skip(punct string) bool {
+	if p.peek(punct) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(punct string) {
+	if !p.skip(punct) {
+		p.fail(fmt.Sprintf("Expected %q, found %s", punct, p.tok))
+	}
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != tokName {
+		p.fail(fmt.Sprintf("Expected Name, found %s", p.tok))
+	}
+	name := p.tok.value
+	p.advance()
+	return name
+}
+
+func (p *parser) keyword(word string) {
+	if p.tok.kind != tokName || p.tok.value != word {
+		p.fail(fmt.Sprintf("Expected %q, found %s", word, p.tok))
+	}
+	p.advance()
+}
+
+func (p *parser) operation() *Operation {
+	op := &Operation{Type: p.tok.value, Pos: p.tok.pos}
+	p.advance()
+	if p.tok.kind == tokName {
+		op.Name = p.name()
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			op.Variables = append(op.Variables, p.variableDefinition())
+		}
+	}
+	op.Directives = p.directives(true)
+	op.SelectionSet = p.selectionSet()
+	return op
+}
+
+func (p *parser) variableDefinition() *VariableDefinition {
+	def := &VariableDefinition{Pos: p.tok.pos}
+	p.expect("$")
+	def.Name = p.name()
+	p.expect(":")
+	def.Type = p.typeRef()
+	if p.skip("=") {
+		def.Default = p.value(true)
+	}
+	p.directives(true)
+	return def
+}
+
+func (p *parser) typeRef() *TypeRef {
+	t := &TypeRef{Pos: p.tok.pos}
+	if p.skip("[") {
+		t.Elem = p.typeRef()
+		p.expect("]")
+	} else {
+		t.Name = p.name()
+	}
+	t.NonNull = p.skip("!")
+	return t
+}
+
+func (p *parser) fragment() *Fragment {
+	f := &Fragment{Pos: p.tok.pos}
+	p.advance()
+	if p.tok.kind == tokName && p.tok.value == "on" {
+		p.fail("Unexpected Name \"on\"")
+	}
+	f.Name = p.name()
+	p.keyword("on")
+	f.TypeCondition = p.name()
+	f.Directives = p.directives(false)
+	f.SelectionSet = p.selectionSet()
+	return f
+}
+
+func (p *parser) selectionSet() []Selection {
+	p.expect("{")
+	var selections []Selection
+	for !p.skip("}") {
+		selections = append(selections, p.selection())
+	}
+	if len(selections) == 0 {
+		p.fail("Expected Name, found \"}\"")
+	}
+	return selections
+}
+
+func (p *parser) selection() Selection {
+	pos := p.tok.pos
+	if !p.skip("...") {
+		return p.field()
+	}
+	if p.tok.kind == tokName && p.tok.value != "on" {
+		return &FragmentSpread{Name: p.name(), Directives: p.directives(false), Pos: pos}
+	}
+	inline := &InlineFragment{Pos: pos}
+	if p.tok.kind == tokName {
+		p.advance()
+		inline.TypeCondition = p.name()
+	}
+	inline.Directives = p.directives(false)
+	inline.SelectionSet = p.selectionSet()
+	return inline
+}
+
+func (p *parser) field() *Field {
+	f := &Field{Pos: p.tok.pos}
+	f.Name = p.name()
+	if p.skip(":") {
+		f.Alias, f.Name = f.Name, p.name()
+	}
+	f.Arguments = p.arguments(false)
+	f.Directives = p.directives(false)
+	if p.peek("{") {
+		f.SelectionSet = p.selectionSet()
+	}
+	return f
+}
+
+func (p *parser) arguments(constant bool) []*Argument {
+	if !p.skip("(") {
+		return nil
+	}
+	var args []*Argument
+	for !p.skip(")") {
+		arg := &Argument{Pos: p.tok.pos}
+		arg.Name = p.name()
+		p.expect(":")
+		arg.Value = p.value(constant)
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		p.fail("Expected Name, found \")\"")
+	}
+	return args
+}
+
+func (p *parser) directives(constant bool) []*Directive {
+	var directives []*Directive
+	for p.peek("@") {
+		d := &Directive{Pos: p.tok.pos}
+		p.advance()
+		d.Name = p.name()
+		d.Arguments = p.arguments(constant)
+		directives = append(directives, d)
+	}
+	return directives
+}
+
+// value 解析常值，constant 為 true 時不允許變數（變數預設值）
+func (p *parser) value(constant bool) *Value {
+	v := &Value{Pos: p.tok.pos}
+	switch {
+	case p.peek("$") && !constant:
+		p.advance()
+		v.Kind, v.Raw = VariableValue, p.name()
+	case p.peek("["):
+		p.advance()
+		v.Kind = ListValue
+		for !p.skip("]") {
+			v.List = append(v.List, p.value(constant))
+		}
+	case p.peek("{"):
+		p.advance()
+		v.Kind = ObjectValue
+		for !p.skip("}") {
+			field := &ObjectField{Pos: p.tok.pos}
+			field.Name = p.name()
+			p.expect(":")
+			field.Value = p.value(constant)
+			v.Fields = append(v.Fields, field)
+		}
+	case p.tok.kind == tokInt:
+		v.Kind, v.Raw = IntValue, p.tok.value
+		p.advance()
+	case p.tok.kind == tokFloat:
+		v.Kind, v.Raw = FloatValue, p.tok.value
+		p.advance()
+	case p.tok.kind == tokString:
+		v.Kind, v.Raw = StringValue, p.tok.value
+		p.advance()
+	case p.tok.kind == tokName:
+		switch p.tok.value {
+		case "true", "false":
+			v.Kind = BooleanValue
+		case "null":
+			v.Kind = NullValue
+		default:
+			v.Kind = EnumValue
+		}
+		v.Raw = p.tok.value
+		p.advance()
+	default:
+		p.unexpected()
+	}
+	return v
+}
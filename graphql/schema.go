@@ -0,0 +1,486 @@
+// Package graphql 以標準函式庫實作的 GraphQL 執行器：解析查詢、依結構描述驗證、逐層執行並支援內省。
+// 支援物件、純量、列舉與輸入物件型別，以及片段、變數與 @skip、@include 指令；不支援介面、聯集與 subscription
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Type 結構描述中的型別
+type Type interface {
+	String() string
+}
+
+// NamedType 有名稱的型別：純量、列舉、物件與輸入物件
+type NamedType interface {
+	Type
+	TypeName() string
+	TypeDescription() string
+}
+
+// Scalar 純量型別。Serialize 將 resolver 的結果轉為 JSON 值，
+// ParseValue 轉換變數中的 JSON 值，ParseLiteral 轉換查詢中的常值；無法轉換時回傳錯誤
+type Scalar struct {
+	Name         string
+	Description  string
+	Serialize    func(value interface{}) (interface{}, error)
+	ParseValue   func(value interface{}) (interface{}, error)
+	ParseLiteral func(value *Value) (interface{}, error)
+}
+
+// Enum 列舉型別，Value 為 resolver 與參數使用的 Go 值
+type Enum struct {
+	Name        string
+	Description string
+	Values      []*EnumValueDefinition
+}
+
+// EnumValueDefinition 列舉的一個值
+type EnumValueDefinition struct {
+	Name              string
+	Description       string
+	Value             interface{}
+	DeprecationReason string
+}
+
+// Object 物件型別
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*FieldDefinition
+}
+
+// FieldDefinition 物件的欄位
+type FieldDefinition struct {
+	Name              string
+	Description       string
+	Type              Type
+	Args              []*InputValue
+	Resolve           ResolveFunc
+	Complexity        ComplexityFunc // 未設定時為 1 加上子欄位的複雜度
+	DeprecationReason string
+}
+
+// InputObject 輸入物件型別
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      []*InputValue
+}
+
+// InputValue 參數或輸入物件的欄位，Default 為已轉換的 Go 值
+type InputValue struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     interface{}
+	HasDefault  bool
+}
+
+// List 列表型別
+type List struct {
+	OfType Type
+}
+
+// NonNull 不可為 null 的型別
+type NonNull struct {
+	OfType Type
+}
+
+// ResolveFunc 解析欄位的值，可以回傳 Thunk 延後到同一層的欄位都解析後再取值
+type ResolveFunc func(p ResolveParams) (interface{}, error)
+
+// ComplexityFunc 依子欄位的複雜度與參數計算欄位的複雜度
+type ComplexityFunc func(childComplexity int, args map[string]interface{}) int
+
+// Thunk 延後取值的結果，DataLoader 以此合併同一層的查詢
+type Thunk func() (interface{}, error)
+
+// ResolveParams resolver 的參數
+type ResolveParams struct {
+	Context context.Context
+	Source  interface{}
+	Args    map[string]interface{}
+	Info    ResolveInfo
+}
+
+// ResolveInfo 正在解析的欄位
+type ResolveInfo struct {
+	FieldName  string
+	ParentType *Object
+	Path       []interface{}
+}
+
+func (t *Scalar) String() string      { return t.Name }
+func (t *Enum) String() string        { return t.Name }
+func (t *Object) String() string      { return t.Name }
+func (t *InputObject) String() string { return t.Name }
+func (t *List) String() string        { return "[" + t.OfType.String() + "]" }
+func (t *NonNull) String() string     { return t.OfType.String() + "!" }
+
+func (t *Scalar) TypeName() string      { return t.Name }
+func (t *Enum) TypeName() string        { return t.Name }
+func (t *Object) TypeName() string      { return t.Name }
+func (t *InputObject) TypeName() string { return t.Name }
+
+func (t *Scalar) TypeDescription() string      { return t.Description }
+func (t *Enum) TypeDescription() string        { return t.Description }
+func (t *Object) TypeDescription() string      { return t.Description }
+func (t *InputObject) TypeDescription() string { return t.Description }
+
+// Field 依名稱取得欄位定義
+func (t *Object) Field(name string) *FieldDefinition {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Field 依名稱取得輸入欄位定義
+func (t *InputObject) Field(name string) *InputValue {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// value 依名稱取得列舉值
+func (t *Enum) value(name string) *EnumValueDefinition {
+	for _, v := range t.Values {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// namedType 去除列表與非 null 包裝後的型別
+func namedType(t Type) NamedType {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.OfType
+		case *NonNull:
+			t = w.OfType
+		default:
+			n, _ := t.(NamedType)
+			return n
+		}
+	}
+}
+
+func isInputType(t Type) bool {
+	switch namedType(t).(type) {
+	case *Scalar, *Enum, *InputObject:
+		return true
+	}
+	return false
+}
+
+// Schema 結構描述
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+	types    map[string]NamedType
+	names    []string
+}
+
+// NewSchema 收集 query 與 mutation 可以到達的所有型別並檢查名稱不重複
+func NewSchema(query, mutation *Object) (*Schema, error) {
+	if query == nil {
+		return nil, fmt.Errorf("graphql: schema requires a query type")
+	}
+	s := &Schema{Query: query, Mutation: mutation, types: map[string]NamedType{}}
+	for _, t := range []Type{String, Int, Float, Boolean, ID, query, schemaType} {
+		if err := s.collect(t); err != nil {
+			return nil, err
+		}
+	}
+	if mutation != nil {
+		if err := s.collect(mutation); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Schema) collect(t Type) error {
+	named := namedType(t)
+	if named == nil {
+		return fmt.Errorf("graphql: unsupported type %v", t)
+	}
+	if existing, ok := s.types[named.TypeName()]; ok {
+		if existing != named {
+			return fmt.Errorf("graphql: type %s is defined more than once", named.TypeName())
+		}
+		return nil
+	}
+	s.types[named.TypeName()] = named
+	s.names = append(s.names, named.TypeName())
+
+	switch n := named.(type) {
+	case *Object:
+		for _, f := range n.Fields {
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+			for _, arg := range f.Args {
+				if !isInputType(arg.Type) {
+					return fmt.Errorf("graphql: argument %s.%s(%s:) must be an input type", n.Name, f.Name, arg.Name)
+				}
+				if err := s.collect(arg.Type); err != nil {
+					return err
+				}
+			}
+		}
+	case *InputObject:
+		for _, f := range n.Fields {
+			if !isInputType(f.Type) {
+				return fmt.Errorf("graphql: field %s.%s must be an input type", n.Name, f.Name)
+			}
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Type 依名稱取得型別
+func (s *Schema) Type(name string) NamedType {
+	return s.types[name]
+}
+
+// typeOf 將查詢中引用的型別轉為結構描述的型別，未知的名稱回傳 nil
+func (s *Schema) typeOf(ref *TypeRef) Type {
+	var t Type
+	if ref.Elem != nil {
+		elem := s.typeOf(ref.Elem)
+		if elem == nil {
+			return nil
+		}
+		t = &List{OfType: elem}
+	} else {
+		named := s.types[ref.Name]
+		if named == nil {
+			return nil
+		}
+		t = named
+	}
+	if ref.NonNull {
+		t = &NonNull{OfType: t}
+	}
+	return t
+}
+
+// fieldDefinition 取得欄位定義，包含 __typename 與 query 型別上的 __schema、__type
+func (s *Schema) fieldDefinition(parent *Object, name string) *FieldDefinition {
+	switch {
+	case name == "__typename":
+		return typenameField
+	case name == "__schema" && parent == s.Query:
+		return schemaField
+	case name == "__type" && parent == s.Query:
+		return typeField
+	}
+	return parent.Field(name)
+}
+
+// 內建的純量型別
+var (
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "The `Int` scalar type represents non-fractional signed whole numeric values between -(2^31) and 2^31 - 1.",
+		Serialize:   coerceInt,
+		ParseValue:  coerceInt,
+		ParseLiteral: func(v *Value) (interface{}, error) {
+			if v.Kind != IntValue {
+				return nil, fmt.Errorf("Int cannot represent non-integer value: %s", printLiteral(v))
+			}
+			n, err := strconv.ParseInt(v.Raw, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Int cannot represent non 32-bit signed integer value: %s", v.Raw)
+			}
+			return int(n), nil
+		},
+	}
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "The `Float` scalar type represents signed double-precision fractional values as specified by IEEE 754.",
+		Serialize:   coerceFloat,
+		ParseValue:  coerceFloat,
+		ParseLiteral: func(v *Value) (interface{}, error) {
+			if v.Kind != IntValue && v.Kind != FloatValue {
+				return nil, fmt.Errorf("Float cannot represent non numeric value: %s", printLiteral(v))
+			}
+			return strconv.ParseFloat(v.Raw, 64)
+		},
+	}
+	String = &Scalar{
+		Name:        "String",
+		Description: "The `String` scalar type represents textual data, represented as UTF-8 character sequences.",
+		Serialize:   coerceString,
+		ParseValue: func(value interface{}) (interface{}, error) {
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent a non string value: %v", value)
+		},
+		ParseLiteral: func(v *Value) (interface{}, error) {
+			if v.Kind != StringValue {
+				return nil, fmt.Errorf("String cannot represent a non string value: %s", printLiteral(v))
+			}
+			return v.Raw, nil
+		},
+	}
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "The `Boolean` scalar type represents `true` or `false`.",
+		Serialize: func(value interface{}) (interface{}, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %v", value)
+		},
+		ParseValue: func(value interface{}) (interface{}, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %v", value)
+		},
+		ParseLiteral: func(v *Value) (interface{}, error) {
+			if v.Kind != BooleanValue {
+				return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %s", printLiteral(v))
+			}
+			return v.Raw == "true", nil
+		},
+	}
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "The `ID` scalar type represents a unique identifier, serialized as a string.",
+		Serialize:   coerceID,
+		ParseValue:  coerceID,
+		ParseLiteral: func(v *Value) (interface{}, error) {
+			if v.Kind != StringValue && v.Kind != IntValue {
+				return nil, fmt.Errorf("ID cannot represent a non-string and non-integer value: %s", printLiteral(v))
+			}
+			return v.Raw, nil
+		},
+	}
+)
+
+// coerceInt 接受 Go 的整數、整數值的浮點數與 json.Number，超出 32 位元時回傳錯誤
+func coerceInt(value interface{}) (interface{}, error) {
+	var n int64
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt32 {
+			return nil, fmt.Errorf("Int cannot represent non 32-bit signed integer value: %v", value)
+		}
+		n = int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) {
+			return nil, fmt.Errorf("Int cannot represent non-integer value: %v", value)
+		}
+		if f < math.MinInt32 || f > math.MaxInt32 {
+			return nil, fmt.Errorf("Int cannot represent non 32-bit signed integer value: %v", value)
+		}
+		n = int64(f)
+	case reflect.String:
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("Int cannot represent non-integer value: %q", value)
+		}
+		parsed, err := strconv.ParseInt(number.String(), 10, 64)
+		if err != nil {
+			f, ferr := number.Float64()
+			if ferr != nil {
+				return nil, fmt.Errorf("Int cannot represent non-integer value: %v", value)
+			}
+			return coerceInt(f)
+		}
+		n = parsed
+	default:
+		return nil, fmt.Errorf("Int cannot represent non-integer value: %v", value)
+	}
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		return nil, fmt.Errorf("Int cannot represent non 32-bit signed integer value: %v", value)
+	}
+	return int(n), nil
+}
+
+func coerceFloat(value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(rv.Float()) || math.IsInf(rv.Float(), 0) {
+			return nil, fmt.Errorf("Float cannot represent non numeric value: %v", value)
+		}
+		return rv.Float(), nil
+	case reflect.String:
+		if number, ok := value.(json.Number); ok {
+			return number.Float64()
+		}
+	}
+	return nil, fmt.Errorf("Float cannot represent non numeric value: %v", value)
+}
+
+// coerceString 接受字串與以字串為基礎的型別，例如列舉常數
+func coerceString(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	}
+	return nil, fmt.Errorf("String cannot represent value: %v", value)
+}
+
+func coerceID(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		if _, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return v.String(), nil
+		}
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Float64:
+		if f := rv.Float(); f == math.Trunc(f) {
+			return strconv.FormatInt(int64(f), 10), nil
+		}
+	}
+	return nil, fmt.Errorf("ID cannot represent value: %v", value)
+}
@@ -0,0 +1,434 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validator 執行前檢查查詢是否符合結構描述，錯誤訊息與 graphql-js 相同
+type validator struct {
+	schema    *Schema
+	src       string
+	fragments map[string]*Fragment
+	errors    []*Error
+	seen      map[string]bool
+}
+
+// variableUsage 變數在查詢中被使用的位置與期望的型別
+type variableUsage struct {
+	value      *Value
+	typ        Type
+	hasDefault bool
+}
+
+// Validate 驗證查詢文件，回傳所有錯誤
+func Validate(schema *Schema, doc *Document, src string) []*Error {
+	v := &validator{schema: schema, src: src, fragments: map[string]*Fragment{}, seen: map[string]bool{}}
+	v.validateDocument(doc)
+	return v.errors
+}
+
+func (v *validator) report(message string, positions ...int) {
+	key := message
+	locations := make([]Location, 0, len(positions))
+	for _, pos := range positions {
+		locations = append(locations, locate(v.src, pos))
+		key += fmt.Sprintf("@%d", pos)
+	}
+	if v.seen[key] {
+		return
+	}
+	v.seen[key] = true
+	v.errors = append(v.errors, &Error{Message: message, Locations: locations})
+}
+
+func (v *validator) validateDocument(doc *Document) {
+	names := map[string]*Operation{}
+	for _, op := range doc.Operations {
+		if op.Name == "" && len(doc.Operations) > 1 {
+			v.report("This anonymous operation must be the only defined operation.", op.Pos)
+		}
+		if prev, ok := names[op.Name]; ok && op.Name != "" {
+			v.report(fmt.Sprintf("There can be only one operation named \"%s\".", op.Name), prev.Pos, op.Pos)
+		}
+		names[op.Name] = op
+	}
+
+	for _, f := range doc.Fragments {
+		if prev, ok := v.fragments[f.Name]; ok {
+			v.report(fmt.Sprintf("There can be only one fragment named \"%s\".", f.Name), prev.Pos, f.Pos)
+			continue
+		}
+		v.fragments[f.Name] = f
+	}
+	v.validateFragmentCycles(doc.Fragments)
+
+	used := map[string]bool{}
+	for _, op := range doc.Operations {
+		v.validateOperation(op, used)
+	}
+	for _, f := range doc.Fragments {
+		if !used[f.Name] {
+			v.report(fmt.Sprintf("Fragment \"%s\" is never used.", f.Name), f.Pos)
+		}
+		if len(f.Directives) > 0 {
+			v.report(fmt.Sprintf("Directive \"@%s\" may not be used on FRAGMENT_DEFINITION.", f.Directives[0].Name), f.Directives[0].Pos)
+		}
+	}
+}
+
+// validateFragmentCycles 片段不可以直接或間接展開自己
+func (v *validator) validateFragmentCycles(fragments []*Fragment) {
+	const visiting, done = 1, 2
+	state := map[string]int{}
+	var visit func(f *Fragment)
+	visit = func(f *Fragment) {
+		state[f.Name] = visiting
+		for _, spread := range fragmentSpreads(f.SelectionSet) {
+			next, ok := v.fragments[spread.Name]
+			if !ok {
+				continue
+			}
+			switch state[spread.Name] {
+			case visiting:
+				v.report(fmt.Sprintf("Cannot spread fragment \"%s\" within itself.", spread.Name), spread.Pos)
+			case 0:
+				visit(next)
+			}
+		}
+		state[f.Name] = done
+	}
+	for _, f := range fragments {
+		if state[f.Name] == 0 {
+			visit(v.fragments[f.Name])
+		}
+	}
+}
+
+// fragmentSpreads 選擇集中直接或經由內嵌片段出現的片段展開
+func fragmentSpreads(selections []Selection) []*FragmentSpread {
+	var spreads []*FragmentSpread
+	for _, sel := range selections {
+		switch s := sel.(type) {
+		case *Field:
+			spreads = append(spreads, fragmentSpreads(s.SelectionSet)...)
+		case *InlineFragment:
+			spreads = append(spreads, fragmentSpreads(s.SelectionSet)...)
+		case *FragmentSpread:
+			spreads = append(spreads, s)
+		}
+	}
+	return spreads
+}
+
+func (v *validator) validateOperation(op *Operation, usedFragments map[string]bool) {
+	var root *Object
+	switch op.Type {
+	case "query":
+		root = v.schema.Query
+	case "mutation":
+		root = v.schema.Mutation
+	}
+	if root == nil {
+		v.report(fmt.Sprintf("Schema is not configured to execute %s operation.", op.Type), op.Pos)
+		return
+	}
+	for _, d := range op.Directives {
+		v.report(fmt.Sprintf("Directive \"@%s\" may not be used on %s.", d.Name, strings.ToUpper(op.Type)), d.Pos)
+	}
+
+	defs := map[string]*VariableDefinition{}
+	for _, def := range op.Variables {
+		if _, dup := defs[def.Name]; dup {
+			v.report(fmt.Sprintf("There can be only one variable named \"$%s\".", def.Name), def.Pos)
+			continue
+		}
+		defs[def.Name] = def
+		t := v.schema.typeOf(def.Type)
+		switch {
+		case t == nil:
+			v.report(fmt.Sprintf("Unknown type \"%s\".", namedRef(def.Type)), def.Type.Pos)
+		case !isInputType(t):
+			v.report(fmt.Sprintf("Variable \"$%s\" cannot be non-input type \"%s\".", def.Name, def.Type), def.Type.Pos)
+		case def.Default != nil:
+			if _, err := coerceLiteral(t, def.Default, nil); err != nil {
+				v.report(err.Error(), def.Default.Pos)
+			}
+		}
+	}
+
+	var usages []variableUsage
+	v.validateSelections(root, op.SelectionSet, &usages, usedFragments, map[string]bool{})
+
+	used := map[string]bool{}
+	for _, usage := range usages {
+		name := usage.value.Raw
+		used[name] = true
+		def, ok := defs[name]
+		if !ok {
+			if op.Name != "" {
+				v.report(fmt.Sprintf("Variable \"$%s\" is not defined by operation \"%s\".", name, op.Name), usage.value.Pos, op.Pos)
+			} else {
+				v.report(fmt.Sprintf("Variable \"$%s\" is not defined.", name), usage.value.Pos, op.Pos)
+			}
+			continue
+		}
+		varType := v.schema.typeOf(def.Type)
+		if varType == nil || !isInputType(varType) {
+			continue
+		}
+		if !allowedPosition(varType, def.Default != nil && def.Default.Kind != NullValue, usage.typ, usage.hasDefault) {
+			v.report(fmt.Sprintf("Variable \"$%s\" of type \"%s\" used in position expecting type \"%s\".", name, def.Type, usage.typ), def.Pos, usage.value.Pos)
+		}
+	}
+	for _, def := range op.Variables {
+		if !used[def.Name] {
+			if op.Name != "" {
+				v.report(fmt.Sprintf("Variable \"$%s\" is never used in operation \"%s\".", def.Name, op.Name), def.Pos)
+			} else {
+				v.report(fmt.Sprintf("Variable \"$%s\" is never used.", def.Name), def.Pos)
+			}
+		}
+	}
+}
+
+func namedRef(ref *TypeRef) string {
+	for ref.Elem != nil {
+		ref = ref.Elem
+	}
+	return ref.Name
+}
+
+// validateSelections 驗證選擇集並收集變數的使用位置；片段在每個操作中只展開一次
+func (v *validator) validateSelections(parent *Object, selections []Selection, usages *[]variableUsage, usedFragments, expanded map[string]bool) {
+	for _, sel := range selections {
+		switch s := sel.(type) {
+		case *Field:
+			v.validateDirectives(s.Directives, "FIELD", usages)
+			def := v.schema.fieldDefinition(parent, s.Name)
+			if def == nil {
+				v.report(fmt.Sprintf("Cannot query field \"%s\" on type \"%s\".", s.Name, parent.Name), s.Pos)
+				continue
+			}
+			v.validateArguments(def.Args, s.Arguments, fmt.Sprintf("field \"%s.%s\"", parent.Name, def.Name), s.Pos, usages)
+
+			named := namedType(def.Type)
+			obj, isObject := named.(*Object)
+			switch {
+			case isObject && len(s.SelectionSet) == 0:
+				v.report(fmt.Sprintf("Field \"%s\" of type \"%s\" must have a selection of subfields. Did you mean \"%s { ... }\"?", s.Name, def.Type, s.Name), s.Pos)
+			case !isObject && len(s.SelectionSet) > 0:
+				v.report(fmt.Sprintf("Field \"%s\" must not have a selection since type \"%s\" has no subfields.", s.Name, def.Type), s.Pos)
+			case isObject:
+				v.validateSelections(obj, s.SelectionSet, usages, usedFragments, expanded)
+			}
+		case *InlineFragment:
+			v.validateDirectives(s.Directives, "INLINE_FRAGMENT", usages)
+			target := parent
+			if s.TypeCondition != "" {
+				if target = v.conditionType(s.TypeCondition, s.Pos); target == nil {
+					continue
+				}
+				if target != parent {
+					v.report(fmt.Sprintf("Fragment cannot be spread here as objects of type \"%s\" can never be of type \"%s\".", parent.Name, target.Name), s.Pos)
+					continue
+				}
+			}
+			v.validateSelections(target, s.SelectionSet, usages, usedFragments, expanded)
+		case *FragmentSpread:
+			v.validateDirectives(s.Directives, "FRAGMENT_SPREAD", usages)
+			f, ok := v.fragments[s.Name]
+			if !ok {
+				v.report(fmt.Sprintf("Unknown fragment \"%s\".", s.Name), s.Pos)
+				continue
+			}
+			usedFragments[s.Name] = true
+			target := v.conditionType(f.TypeCondition, f.Pos)
+			if target == nil {
+				continue
+			}
+			if target != parent {
+				v.report(fmt.Sprintf("Fragment \"%s\" cannot be spread here as objects of type \"%s\" can never be of type \"%s\".", s.Name, parent.Name, target.Name), s.Pos)
+				continue
+			}
+			if expanded[s.Name] {
+				continue
+			}
+			expanded[s.Name] = true
+			v.validateSelections(target, f.SelectionSet, usages, usedFragments, expanded)
+		}
+	}
+	v.validateMergeable(parent, selections)
+}
+
+// conditionType 片段的型別條件必須是物件型別
+func (v *validator) conditionType(name string, pos int) *Object {
+	t := v.schema.Type(name)
+	if t == nil {
+		v.report(fmt.Sprintf("Unknown type \"%s\".", name), pos)
+		return nil
+	}
+	obj, ok := t.(*Object)
+	if !ok {
+		v.report(fmt.Sprintf("Fragment cannot condition on non composite type \"%s\".", name), pos)
+		return nil
+	}
+	return obj
+}
+
+// validateMergeable 同一層中回應名稱相同的欄位必須是同一個欄位並使用相同的參數
+func (v *validator) validateMergeable(parent *Object, selections []Selection) {
+	fields := map[string]*Field{}
+	var walk func(selections []Selection, visited map[string]bool)
+	walk = func(selections []Selection, visited map[string]bool) {
+		for _, sel := range selections {
+			switch s := sel.(type) {
+			case *Field:
+				name := s.ResponseName()
+				prev, ok := fields[name]
+				if !ok {
+					fields[name] = s
+					continue
+				}
+				if prev.Name != s.Name {
+					v.report(fmt.Sprintf("Fields \"%s\" conflict because \"%s\" and \"%s\" are different fields. Use different aliases on the fields to fetch both if this was intentional.", name, prev.Name, s.Name), prev.Pos, s.Pos)
+				} else if printArguments(prev.Arguments) != printArguments(s.Arguments) {
+					v.report(fmt.Sprintf("Fields \"%s\" conflict because they have differing arguments. Use different aliases on the fields to fetch both if this was intentional.", name), prev.Pos, s.Pos)
+				}
+			case *InlineFragment:
+				if s.TypeCondition == "" || s.TypeCondition == parent.Name {
+					walk(s.SelectionSet, visited)
+				}
+			case *FragmentSpread:
+				if f, ok := v.fragments[s.Name]; ok && !visited[s.Name] && f.TypeCondition == parent.Name {
+					visited[s.Name] = true
+					walk(f.SelectionSet, visited)
+				}
+			}
+		}
+	}
+	walk(selections, map[string]bool{})
+}
+
+func printArguments(args []*Argument) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg.Name + ":" + printLiteral(arg.Value)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (v *validator) validateDirectives(list []*Directive, location string, usages *[]variableUsage) {
+	seen := map[string]bool{}
+	for _, d := range list {
+		def := findDirective(d.Name)
+		if def == nil {
+			v.report(fmt.Sprintf("Unknown directive \"@%s\".", d.Name), d.Pos)
+			continue
+		}
+		allowed := false
+		for _, loc := range def.Locations {
+			allowed = allowed || loc == location
+		}
+		if !allowed {
+			v.report(fmt.Sprintf("Directive \"@%s\" may not be used on %s.", d.Name, location), d.Pos)
+			continue
+		}
+		if seen[d.Name] {
+			v.report(fmt.Sprintf("The directive \"@%s\" can only be used once at this location.", d.Name), d.Pos)
+		}
+		seen[d.Name] = true
+		v.validateArguments(def.Args, d.Arguments, fmt.Sprintf("directive \"@%s\"", d.Name), d.Pos, usages)
+	}
+}
+
+// validateArguments 檢查參數是否已定義、必填參數是否提供，以及常值是否符合型別
+func (v *validator) validateArguments(defs []*InputValue, args []*Argument, owner string, pos int, usages *[]variableUsage) {
+	provided := map[string]bool{}
+	for _, arg := range args {
+		if provided[arg.Name] {
+			v.report(fmt.Sprintf("There can be only one argument named \"%s\".", arg.Name), arg.Pos)
+			continue
+		}
+		provided[arg.Name] = true
+
+		var def *InputValue
+		for _, d := range defs {
+			if d.Name == arg.Name {
+				def = d
+			}
+		}
+		if def == nil {
+			v.report(fmt.Sprintf("Unknown argument \"%s\" on %s.", arg.Name, owner), arg.Pos)
+			continue
+		}
+		if _, err := coerceLiteral(def.Type, arg.Value, nil); err != nil {
+			v.report(err.Error(), arg.Value.Pos)
+		}
+		collectVariableUsages(def.Type, arg.Value, def.HasDefault, usages)
+	}
+	for _, def := range defs {
+		if _, required := def.Type.(*NonNull); required && !def.HasDefault && !provided[def.Name] {
+			v.report(fmt.Sprintf("Argument \"%s\" of type \"%s\" is required, but it was not provided.", def.Name, def.Type), pos)
+		}
+	}
+}
+
+// collectVariableUsages 記錄常值中每個變數所在位置的型別
+func collectVariableUsages(t Type, value *Value, hasDefault bool, usages *[]variableUsage) {
+	switch value.Kind {
+	case VariableValue:
+		*usages = append(*usages, variableUsage{value: value, typ: t, hasDefault: hasDefault})
+	case ListValue:
+		inner := t
+		if nn, ok := inner.(*NonNull); ok {
+			inner = nn.OfType
+		}
+		if list, ok := inner.(*List); ok {
+			for _, item := range value.List {
+				collectVariableUsages(list.OfType, item, false, usages)
+			}
+		}
+	case ObjectValue:
+		if input, ok := namedType(t).(*InputObject); ok {
+			for _, f := range value.Fields {
+				if def := input.Field(f.Name); def != nil {
+					collectVariableUsages(def.Type, f.Value, def.HasDefault, usages)
+				}
+			}
+		}
+	}
+}
+
+// allowedPosition 變數的型別是否可以用在該位置；位置不可為 null 時，可 null 的變數必須有預設值
+func allowedPosition(varType Type, varHasDefault bool, locType Type, locHasDefault bool) bool {
+	if nn, ok := locType.(*NonNull); ok {
+		if _, varNonNull := varType.(*NonNull); !varNonNull {
+			if !varHasDefault && !locHasDefault {
+				return false
+			}
+			return isSubType(varType, nn.OfType)
+		}
+	}
+	return isSubType(varType, locType)
+}
+
+func isSubType(t, super Type) bool {
+	if sn, ok := super.(*NonNull); ok {
+		tn, ok := t.(*NonNull)
+		return ok && isSubType(tn.OfType, sn.OfType)
+	}
+	if tn, ok := t.(*NonNull); ok {
+		return isSubType(tn.OfType, super)
+	}
+	if sl, ok := super.(*List); ok {
+		tl, ok := t.(*List)
+		return ok && isSubType(tl.OfType, sl.OfType)
+	}
+	if _, ok := t.(*List); ok {
+		return false
+	}
+	return namedType(t) == namedType(super)
+}
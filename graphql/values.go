@@ -0,0 +1,326 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// coerceVariables 依操作的變數宣告轉換請求中的變數，未提供時使用預設值
+func coerceVariables(schema *Schema, op *Operation, input map[string]interface{}) (map[string]interface{}, []*Error) {
+	values := map[string]interface{}{}
+	var errs []*Error
+	for _, def := range op.Variables {
+		t := schema.typeOf(def.Type)
+		raw, provided := input[def.Name]
+		switch {
+		case !provided && def.Default != nil:
+			v, err := coerceLiteral(t, def.Default, nil)
+			if err != nil {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" has invalid default value: %v", def.Name, err)})
+				continue
+			}
+			values[def.Name] = v
+		case !provided:
+			if _, ok := t.(*NonNull); ok {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type \"%s\" was not provided.", def.Name, def.Type)})
+			}
+		default:
+			v, err := coerceInput(t, raw)
+			if err != nil {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" got invalid value %s; %v", def.Name, printJSON(raw), err)})
+				continue
+			}
+			values[def.Name] = v
+		}
+	}
+	return values, errs
+}
+
+// coerceInput 轉換變數中的 JSON 值
+func coerceInput(t Type, value interface{}) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if value == nil {
+			return nil, fmt.Errorf("Expected non-nullable type \"%s\" not to be null.", t)
+		}
+		return coerceInput(nn.OfType, value)
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		items, ok := value.([]interface{})
+		if !ok {
+			item, err := coerceInput(t.OfType, value)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := coerceInput(t.OfType, item)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %w", i, err)
+			}
+			list[i] = v
+		}
+		return list, nil
+	case *InputObject:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected type \"%s\" to be an object.", t.Name)
+		}
+		for name := range fields {
+			if t.Field(name) == nil {
+				return nil, fmt.Errorf("Field \"%s\" is not defined by type \"%s\".", name, t.Name)
+			}
+		}
+		result := map[string]interface{}{}
+		for _, f := range t.Fields {
+			raw, provided := fields[f.Name]
+			if !provided {
+				if f.HasDefault {
+					result[f.Name] = f.Default
+				} else if _, required := f.Type.(*NonNull); required {
+					return nil, fmt.Errorf("Field \"%s\" of required type \"%s\" was not provided.", f.Name, f.Type)
+				}
+				continue
+			}
+			v, err := coerceInput(f.Type, raw)
+			if err != nil {
+				return nil, fmt.Errorf("at field %q: %w", f.Name, err)
+			}
+			result[f.Name] = v
+		}
+		return result, nil
+	case *Enum:
+		name, ok := value.(string)
+		if ok {
+			if ev := t.value(name); ev != nil {
+				return ev.Value, nil
+			}
+		}
+		return nil, fmt.Errorf("Value %s does not exist in \"%s\" enum.", printJSON(value), t.Name)
+	case *Scalar:
+		return t.ParseValue(value)
+	}
+	return nil, fmt.Errorf("Type \"%s\" is not an input type.", t)
+}
+
+// coerceLiteral 轉換查詢中的常值；vars 為 nil 時視為驗證階段，變數一律略過
+func coerceLiteral(t Type, v *Value, vars map[string]interface{}) (interface{}, error) {
+	if v.Kind == VariableValue {
+		if vars == nil {
+			return nil, nil
+		}
+		value, ok := vars[v.Raw]
+		if !ok || value == nil {
+			if _, required := t.(*NonNull); required {
+				return nil, fmt.Errorf("Expected non-nullable type \"%s\" not to be null.", t)
+			}
+		}
+		return value, nil
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if v.Kind == NullValue {
+			return nil, fmt.Errorf("Expected value of type \"%s\", found null.", t)
+		}
+		return coerceLiteral(nn.OfType, v, vars)
+	}
+	if v.Kind == NullValue {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		if v.Kind != ListValue {
+			item, err := coerceLiteral(t.OfType, v, vars)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		list := make([]interface{}, len(v.List))
+		for i, item := range v.List {
+			value, err := coerceLiteral(t.OfType, item, vars)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	case *InputObject:
+		if v.Kind != ObjectValue {
+			return nil, fmt.Errorf("Expected value of type \"%s\", found %s.", t.Name, printLiteral(v))
+		}
+		provided := map[string]*Value{}
+		for _, f := range v.Fields {
+			if t.Field(f.Name) == nil {
+				return nil, fmt.Errorf("Field \"%s\" is not defined by type \"%s\".", f.Name, t.Name)
+			}
+			if _, dup := provided[f.Name]; dup {
+				return nil, fmt.Errorf("There can be only one input field named \"%s\".", f.Name)
+			}
+			provided[f.Name] = f.Value
+		}
+		result := map[string]interface{}{}
+		for _, f := range t.Fields {
+			literal, ok := provided[f.Name]
+			if ok && literal.Kind == VariableValue && vars != nil {
+				if _, set := vars[literal.Raw]; !set {
+					ok = false
+				}
+			}
+			if !ok {
+				if f.HasDefault {
+					result[f.Name] = f.Default
+				} else if _, required := f.Type.(*NonNull); required {
+					if literal == nil || vars != nil {
+						return nil, fmt.Errorf("Field \"%s.%s\" of required type \"%s\" was not provided.", t.Name, f.Name, f.Type)
+					}
+				}
+				continue
+			}
+			value, err := coerceLiteral(f.Type, literal, vars)
+			if err != nil {
+				return nil, err
+			}
+			result[f.Name] = value
+		}
+		return result, nil
+	case *Enum:
+		if v.Kind != EnumValue {
+			return nil, fmt.Errorf("Enum \"%s\" cannot represent non-enum value: %s.", t.Name, printLiteral(v))
+		}
+		ev := t.value(v.Raw)
+		if ev == nil {
+			return nil, fmt.Errorf("Value \"%s\" does not exist in \"%s\" enum.", v.Raw, t.Name)
+		}
+		return ev.Value, nil
+	case *Scalar:
+		return t.ParseLiteral(v)
+	}
+	return nil, fmt.Errorf("Type \"%s\" is not an input type.", t)
+}
+
+// coerceArguments 轉換欄位或指令的參數；未提供且沒有預設值的可 null 參數不會出現在結果中
+func coerceArguments(defs []*InputValue, args []*Argument, vars map[string]interface{}) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, def := range defs {
+		var literal *Value
+		for _, arg := range args {
+			if arg.Name == def.Name {
+				literal = arg.Value
+				break
+			}
+		}
+		if literal != nil && literal.Kind == VariableValue {
+			if _, set := vars[literal.Raw]; !set {
+				literal = nil
+			}
+		}
+		if literal == nil {
+			if def.HasDefault {
+				values[def.Name] = def.Default
+			} else if _, required := def.Type.(*NonNull); required {
+				return nil, fmt.Errorf("Argument \"%s\" of required type \"%s\" was not provided.", def.Name, def.Type)
+			}
+			continue
+		}
+		value, err := coerceLiteral(def.Type, literal, vars)
+		if err != nil {
+			return nil, fmt.Errorf("Argument \"%s\" has invalid value %s. %v", def.Name, printLiteral(literal), err)
+		}
+		values[def.Name] = value
+	}
+	return values, nil
+}
+
+// printLiteral 以查詢語法印出常值
+func printLiteral(v *Value) string {
+	switch v.Kind {
+	case VariableValue:
+		return "$" + v.Raw
+	case StringValue:
+		return strconv.Quote(v.Raw)
+	case ListValue:
+		items := make([]string, len(v.List))
+		for i, item := range v.List {
+			items[i] = printLiteral(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case ObjectValue:
+		fields := make([]string, len(v.Fields))
+		for i, f := range v.Fields {
+			fields[i] = f.Name + ": " + printLiteral(f.Value)
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return v.Raw
+}
+
+// printValue 以查詢語法印出 Go 值，供內省的 defaultValue 使用
+func printValue(t Type, value interface{}) string {
+	if nn, ok := t.(*NonNull); ok {
+		t = nn.OfType
+	}
+	if value == nil {
+		return "null"
+	}
+	switch t := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice {
+			return printValue(t.OfType, value)
+		}
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = printValue(t.OfType, rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *InputObject:
+		fields, _ := value.(map[string]interface{})
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			if f := t.Field(name); f != nil {
+				parts = append(parts, name+": "+printValue(f.Type, fields[name]))
+			}
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case *Enum:
+		for _, ev := range t.Values {
+			if reflect.DeepEqual(ev.Value, value) {
+				return ev.Name
+			}
+		}
+	case *Scalar:
+		serialized, err := t.Serialize(value)
+		if err != nil {
+			break
+		}
+		if s, ok := serialized.(string); ok && t != Int && t != Float {
+			return strconv.Quote(s)
+		}
+		return fmt.Sprint(serialized)
+	}
+	return printJSON(value)
+}
+
+func printJSON(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
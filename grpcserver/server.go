@@ -0,0 +1,203 @@
+// Package grpcserver 與 Gin 伺服器並行的 gRPC 伺服器。攔截器以與 REST 相同的規則驗證 Bearer 存取權杖、
+// 沿用或產生請求識別碼並依 accept-language 選擇語言，伺服器另外提供健康檢查與選用的反射服務
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"go-api_for_main/i18n"
+	"go-api_for_main/middleware"
+	"go-api_for_main/oidc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDMetadata 傳遞請求識別碼的 metadata 鍵，與 REST 的 X-Request-ID 相同
+const requestIDMetadata = "x-request-id"
+
+type contextKey int
+
+const (
+	principalKey contextKey = iota
+	requestIDKey
+	languageKey
+)
+
+// Authorizer 檢查已驗證的主體能否呼叫服務，回傳的錯誤應為 gRPC 狀態
+type Authorizer func(ctx context.Context, claims *oidc.AccessTokenClaims) error
+
+// Options gRPC 伺服器的選項
+type Options struct {
+	// Reflection 是否註冊反射服務，反射會列出所有服務與訊息結構，預設關閉
+	Reflection bool
+	// Authorize 在權杖驗證通過後檢查主體的權限，nil 表示所有已驗證的主體都可以呼叫
+	Authorize Authorizer
+}
+
+// Server gRPC 伺服器與其健康檢查服務
+type Server struct {
+	*grpc.Server
+	Health *health.Server
+}
+
+// New 建立掛上驗證攔截器與健康檢查的伺服器，服務由呼叫者註冊。
+// 健康檢查與反射以外的方法都需要有效的 Bearer 權杖並通過 opts.Authorize
+func New(verify middleware.TokenVerifier, opts Options) *Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptor(verify, opts.Authorize)),
+		grpc.ChainStreamInterceptor(streamInterceptor(verify, opts.Authorize)),
+	)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthServer)
+	if opts.Reflection {
+		reflection.Register(srv)
+	}
+	return &Server{Server: srv, Health: healthServer}
+}
+
+// public 健康檢查與反射不需要權杖，讓負載平衡器與開發工具可以直接呼叫
+func public(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") ||
+		strings.HasPrefix(fullMethod, "/grpc.reflection.")
+}
+
+// authorize 非公開的方法需要已驗證的主體，並通過 authorizer 的檢查
+func authorize(ctx context.Context, fullMethod string, authorizer Authorizer) error {
+	if public(fullMethod) {
+		return nil
+	}
+	claims, ok := Principal(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authorization metadata with a Bearer token is required")
+	}
+	if authorizer == nil {
+		return nil
+	}
+	return authorizer(ctx, claims)
+}
+
+// SetServing 設定服務的健康狀態，service 為空字串時代表整個伺服器
+func (s *Server) SetServing(service string, serving bool) {
+	state := healthpb.HealthCheckResponse_SERVING
+	if !serving {
+		state = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.Health.SetServingStatus(service, state)
+}
+
+// ListenAndServe 在指定的 port 接受連線，直到伺服器停止
+func (s *Server) ListenAndServe(port string) error {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis)
+}
+
+// authenticate 帶有 Bearer 權杖時驗證並保存宣告，未帶權杖時交由 authorize 判斷；同時保存請求識別碼與語言
+func authenticate(ctx context.Context, verify middleware.TokenVerifier) (context.Context, string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	requestID := middleware.ResolveRequestID(first(requestIDMetadata))
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	ctx = context.WithValue(ctx, languageKey, i18n.Negotiate(first("accept-language")))
+
+	header := first("authorization")
+	if header == "" {
+		return ctx, requestID, nil
+	}
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") || strings.TrimSpace(header[7:]) == "" {
+		return ctx, requestID, status.Error(codes.Unauthenticated, "authorization metadata must be a Bearer token")
+	}
+	claims, err := verify(strings.TrimSpace(header[7:]))
+	if err != nil {
+		return ctx, requestID, status.Error(codes.Unauthenticated, "access token is invalid or expired")
+	}
+	return context.WithValue(ctx, principalKey, claims), requestID, nil
+}
+
+func unaryInterceptor(verify middleware.TokenVerifier, authorizer Authorizer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, requestID, err := authenticate(ctx, verify)
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+		if err != nil {
+			return nil, err
+		}
+		if err := authorize(ctx, info.FullMethod, authorizer); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// contextStream 以加上驗證資訊的 context 取代串流原本的 context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func streamInterceptor(verify middleware.TokenVerifier, authorizer Authorizer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, requestID, err := authenticate(ss.Context(), verify)
+		ss.SetHeader(metadata.Pairs(requestIDMetadata, requestID))
+		if err != nil {
+			return err
+		}
+		if err := authorize(ctx, info.FullMethod, authorizer); err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// Principal 取得已驗證的權杖宣告
+func Principal(ctx context.Context) (*oidc.AccessTokenClaims, bool) {
+	claims, ok := ctx.Value(principalKey).(*oidc.AccessTokenClaims)
+	return claims, ok
+}
+
+// RequestID 取得請求識別碼
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// Language 取得協商出的回應語言，未經過攔截器時使用預設語言
+func Language(ctx context.Context) string {
+	if lang, ok := ctx.Value(languageKey).(string); ok {
+		return lang
+	}
+	return i18n.Fallback()
+}
+
+// ClientIP 取得用戶端位址
+func ClientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,202 @@
+package hypermedia
+
+import (
+	"encoding/json"
+	"net/http"
+
+	user_models "go-api_for_main/models"
+)
+
+// Resource 以超媒體格式輸出的單一資源
+type Resource struct {
+	Type  string                    // 資源類型，例如 users
+	ID    string                    // 資源識別碼
+	Data  interface{}               // 資源內容，會以 JSON 欄位輸出為屬性
+	Links []user_models.HATEOASLink // 資源連結
+}
+
+// Collection 以超媒體格式輸出的資源集合
+type Collection struct {
+	Type  string
+	Items []Resource
+	Links []user_models.HATEOASLink
+	Meta  map[string]interface{} // 分頁等附加資訊
+}
+
+// attributes 將資源內容轉為欄位對照表
+func attributes(data interface{}) map[string]interface{} {
+	attrs := map[string]interface{}{}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return attrs
+	}
+	_ = json.Unmarshal(raw, &attrs)
+	return attrs
+}
+
+// isSafe 判斷連結是否只用於讀取；其他方法在 Siren 中以 action 表示
+func isSafe(link user_models.HATEOASLink) bool {
+	return link.Method == "" || link.Method == http.MethodGet
+}
+
+// RenderResource 依格式輸出單一資源
+func RenderResource(mediaType string, r Resource) interface{} {
+	switch mediaType {
+	case MediaTypeHAL:
+		return halResource(r)
+	case MediaTypeJSONAPI:
+		return map[string]interface{}{
+			"jsonapi": map[string]string{"version": "1.1"},
+			"data":    jsonAPIResource(r),
+			"links":   jsonAPILinks(r.Links),
+		}
+	case MediaTypeSiren:
+		return sirenEntity(r, nil)
+	}
+	return nil
+}
+
+// RenderCollection 依格式輸出資源集合
+func RenderCollection(mediaType string, c Collection) interface{} {
+	switch mediaType {
+	case MediaTypeHAL:
+		body := map[string]interface{}{}
+		for k, v := range c.Meta {
+			body[k] = v
+		}
+		items := make([]interface{}, 0, len(c.Items))
+		for _, item := range c.Items {
+			items = append(items, halResource(item))
+		}
+		body["_links"] = halLinks(c.Links)
+		body["_embedded"] = map[string]interface{}{c.Type: items}
+		return body
+	case MediaTypeJSONAPI:
+		data := make([]interface{}, 0, len(c.Items))
+		for _, item := range c.Items {
+			data = append(data, jsonAPIResource(item))
+		}
+		body := map[string]interface{}{
+			"jsonapi": map[string]string{"version": "1.1"},
+			"data":    data,
+			"links":   jsonAPILinks(c.Links),
+		}
+		if len(c.Meta) > 0 {
+			body["meta"] = c.Meta
+		}
+		return body
+	case MediaTypeSiren:
+		entities := make([]interface{}, 0, len(c.Items))
+		for _, item := range c.Items {
+			entities = append(entities, sirenEntity(item, []string{"item"}))
+		}
+		entity := sirenEntity(Resource{Type: c.Type, Data: c.Meta, Links: c.Links}, nil)
+		entity["class"] = []string{c.Type, "collection"}
+		entity["entities"] = entities
+		return entity
+	}
+	return nil
+}
+
+// halResource 輸出 HAL 資源，連結以 rel 為鍵，同一 rel 有多個連結時輸出為陣列
+func halResource(r Resource) map[string]interface{} {
+	body := attributes(r.Data)
+	delete(body, "_links")
+	body["_links"] = halLinks(r.Links)
+	return body
+}
+
+func halLinks(links []user_models.HATEOASLink) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, link := range links {
+		object := map[string]string{"href": link.Href}
+		if link.Title != "" {
+			object["title"] = link.Title
+		}
+		switch existing := result[link.Rel].(type) {
+		case nil:
+			result[link.Rel] = object
+		case map[string]string:
+			result[link.Rel] = []map[string]string{existing, object}
+		case []map[string]string:
+			result[link.Rel] = append(existing, object)
+		}
+	}
+	return result
+}
+
+// jsonAPIResource 輸出 JSON:API 資源物件，id 不重複出現在 attributes 中
+func jsonAPIResource(r Resource) map[string]interface{} {
+	attrs := attributes(r.Data)
+	delete(attrs, "id")
+	delete(attrs, "_links")
+	return map[string]interface{}{
+		"type":       r.Type,
+		"id":         r.ID,
+		"attributes": attrs,
+		"links":      jsonAPILinks(r.Links),
+	}
+}
+
+// jsonAPILinks 輸出 JSON:API 連結物件，HTTP 方法記錄於連結的 meta
+func jsonAPILinks(links []user_models.HATEOASLink) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, link := range links {
+		if _, exists := result[link.Rel]; exists {
+			continue
+		}
+		object := map[string]interface{}{"href": link.Href}
+		if link.Title != "" {
+			object["title"] = link.Title
+		}
+		if !isSafe(link) {
+			object["meta"] = map[string]string{"method": link.Method}
+		}
+		result[link.Rel] = object
+	}
+	return result
+}
+
+// sirenEntity 輸出 Siren 實體，GET 連結為 links，其他方法為 actions
+func sirenEntity(r Resource, rel []string) map[string]interface{} {
+	props := attributes(r.Data)
+	delete(props, "_links")
+
+	links := []map[string]interface{}{}
+	actions := []map[string]interface{}{}
+	for _, link := range r.Links {
+		if isSafe(link) {
+			object := map[string]interface{}{"rel": []string{link.Rel}, "href": link.Href}
+			if link.Title != "" {
+				object["title"] = link.Title
+			}
+			links = append(links, object)
+			continue
+		}
+		action := map[string]interface{}{
+			"name":   link.Rel,
+			"method": link.Method,
+			"href":   link.Href,
+		}
+		if link.Title != "" {
+			action["title"] = link.Title
+		}
+		if link.Method != http.MethodDelete {
+			action["type"] = "application/json"
+		}
+		actions = append(actions, action)
+	}
+
+	entity := map[string]interface{}{
+		"class":      []string{r.Type},
+		"properties": props,
+		"links":      links,
+	}
+	if len(actions) > 0 {
+		entity["actions"] = actions
+	}
+	if rel != nil {
+		entity["rel"] = rel
+	}
+	return entity
+}
@@ -0,0 +1,146 @@
+// Package hypermedia 將共用的 HATEOASLink 連結模型輸出為 HAL、JSON:API 與 Siren 格式
+package hypermedia
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"go-api_for_main/formats"
+)
+
+// 支援的超媒體格式
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeHAL     = "application/hal+json"
+	MediaTypeJSONAPI = "application/vnd.api+json"
+	MediaTypeSiren   = "application/vnd.siren+json"
+)
+
+// supported 依伺服器偏好排序，q 值相同時選擇較前面的格式；JSON 以外的編碼輸出原本的 _links 格式
+var supported = []string{
+	MediaTypeJSON, MediaTypeHAL, MediaTypeJSONAPI, MediaTypeSiren,
+	formats.MediaTypeMsgPack, formats.MediaTypeCBOR, formats.MediaTypeXML, formats.MediaTypeYAML,
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+	order     int
+}
+
+// Negotiate 依 Accept 標頭選擇回應格式，沒有可接受的格式時回傳 application/json
+func Negotiate(accept string) string {
+	mediaType, _ := Select(accept)
+	return mediaType
+}
+
+// Select 依 Accept 標頭選擇回應格式；Accept 只列出不支援或 q=0 排除的格式時回傳 false，呼叫者應回應 406。
+// 每個格式採用最明確符合的媒體範圍的 q 值，q=0 表示排除。用戶端最優先的格式可以產生時採用該格式；
+// 否則若以 */* 或 type/* 接受 JSON（例如瀏覽器的 Accept），依伺服器偏好回應 JSON，而不是 q 值較低的 XML
+func Select(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MediaTypeJSON, true
+	}
+	ranges := parseAccept(accept)
+
+	top := 0.0
+	for _, r := range ranges {
+		top = math.Max(top, r.q)
+	}
+	for _, r := range ranges {
+		if r.q == top && r.q > 0 && isSupported(r.mediaType) {
+			return r.mediaType, true
+		}
+	}
+
+	if r, ok := quality(ranges, MediaTypeJSON); ok && r.q > 0 && wildcard(r.mediaType) {
+		return MediaTypeJSON, true
+	}
+
+	var selected string
+	var best acceptRange
+	for _, mediaType := range supported {
+		r, ok := quality(ranges, mediaType)
+		if !ok || r.q <= 0 {
+			continue
+		}
+		if selected == "" || r.q > best.q || (r.q == best.q && r.order < best.order) {
+			selected, best = mediaType, r
+		}
+	}
+	if selected == "" {
+		return MediaTypeJSON, false
+	}
+	return selected, true
+}
+
+// parseAccept 解析 Accept 標頭，編碼的別名轉為標準名稱，無效的 q 值視為 1
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for i, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		r := acceptRange{mediaType: strings.ToLower(strings.TrimSpace(fields[0])), q: 1, order: i}
+		if r.mediaType == "" {
+			continue
+		}
+		if canonical, ok := formats.Lookup(r.mediaType); ok {
+			r.mediaType = canonical
+		}
+		for _, param := range fields[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// quality 回傳最明確符合該格式的媒體範圍：完全相同優先於 type/*，再優先於 */*
+func quality(ranges []acceptRange, mediaType string) (acceptRange, bool) {
+	var best acceptRange
+	specificity := -1
+	for _, r := range ranges {
+		var score int
+		switch {
+		case r.mediaType == mediaType:
+			score = 2
+		case r.mediaType == "*/*":
+			score = 0
+		case matches(r.mediaType, mediaType):
+			score = 1
+		default:
+			continue
+		}
+		if score > specificity {
+			best, specificity = r, score
+		}
+	}
+	return best, specificity >= 0
+}
+
+func isSupported(mediaType string) bool {
+	for _, candidate := range supported {
+		if candidate == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+func wildcard(mediaRange string) bool {
+	return strings.HasSuffix(mediaRange, "/*")
+}
+
+// matches 判斷 Accept 的媒體範圍是否包含該格式，支援 */* 與 type/* 萬用字元
+func matches(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
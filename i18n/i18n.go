@@ -0,0 +1,140 @@
+// Package i18n 提供 API 訊息與 HATEOAS 連結標題的多語系翻譯
+// 訊息以英文原文作為識別碼，locales 目錄中的 JSON 檔提供其他語言的譯文
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 支援的語言
+const (
+	English            = "en"
+	TraditionalChinese = "zh-TW"
+	Thai               = "th"
+)
+
+// Supported 所有支援的語言，英文為訊息原文
+var Supported = []string{English, TraditionalChinese, Thai}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	catalogues = map[string]map[string]string{}
+	fallback   = English
+	mu         sync.RWMutex
+)
+
+func init() {
+	for _, lang := range Supported {
+		if lang == English {
+			continue
+		}
+		data, err := localeFiles.ReadFile("locales/" + lang + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalogue for %s: %v", lang, err))
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalogue for %s: %v", lang, err))
+		}
+		catalogues[lang] = messages
+	}
+}
+
+// SetFallback 設定 Accept-Language 沒有可用語言時使用的語言
+func SetFallback(lang string) error {
+	matched, ok := match(lang)
+	if !ok {
+		return fmt.Errorf("i18n: unsupported fallback language %q", lang)
+	}
+	mu.Lock()
+	fallback = matched
+	mu.Unlock()
+	return nil
+}
+
+// Fallback 回傳目前的預設語言
+func Fallback() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return fallback
+}
+
+// match 將語言標籤對應到支援的語言，例如 zh-Hant、zh-HK 對應 zh-TW，th-TH 對應 th
+func match(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, lang := range Supported {
+		if tag == strings.ToLower(lang) {
+			return lang, true
+		}
+	}
+	primary, _, _ := strings.Cut(tag, "-")
+	switch primary {
+	case "en":
+		return English, true
+	case "zh":
+		return TraditionalChinese, true
+	case "th":
+		return Thai, true
+	}
+	return "", false
+}
+
+// Negotiate 依 Accept-Language 標頭選擇語言，沒有可用語言時回傳預設語言
+func Negotiate(acceptLanguage string) string {
+	type languageRange struct {
+		tag string
+		q   float64
+	}
+	var ranges []languageRange
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		r := languageRange{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, param := range fields[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		if r.tag != "" && r.q > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		if lang, ok := match(r.tag); ok {
+			return lang
+		}
+	}
+	return Fallback()
+}
+
+// T 翻譯訊息，找不到譯文時回傳原文；args 不為空時以 fmt.Sprintf 套用格式
+func T(lang, message string, args ...interface{}) string {
+	if translated, ok := catalogues[lang][message]; ok {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Has 判斷訊息在指定語言中是否有譯文，英文原文一律視為存在
+func Has(lang, message string) bool {
+	if lang == English {
+		return true
+	}
+	_, ok := catalogues[lang][message]
+	return ok
+}
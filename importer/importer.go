@@ -0,0 +1,333 @@
+// Package importer 讀取 CSV、NDJSON 與 XLSX 檔案中的資料列，並依欄位對應轉為用戶欄位
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go-api_for_main/xlsx"
+)
+
+// Format 匯入檔案的格式
+type Format string
+
+// 支援的匯入格式
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+)
+
+// MaxRows 單次匯入的資料列上限，不含標題列
+const MaxRows = 10000
+
+// 匯入檔案與欄位對應的錯誤
+var (
+	ErrUnknownFormat  = errors.New("unsupported import format")
+	ErrInvalidFile    = errors.New("import file could not be read")
+	ErrTooManyRows    = fmt.Errorf("import file has more than %d rows", MaxRows)
+	ErrInvalidMapping = errors.New("invalid column mapping")
+)
+
+// Fields 可匯入的用戶欄位
+var Fields = []string{"name", "email", "password", "sex", "age", "phone", "address", "role"}
+
+// Table 檔案中的標題與資料列
+type Table struct {
+	Headers []string
+	Rows    []Row
+}
+
+// Row 單一資料列，Number 為檔案中的行號或試算表的列號，Values 與標題對齊
+type Row struct {
+	Number int
+	Values []string
+}
+
+// Value 回傳指定欄位的值，超出範圍時為空字串
+func (r Row) Value(column int) string {
+	if column < len(r.Values) {
+		return r.Values[column]
+	}
+	return ""
+}
+
+// ParseFormat 解析格式名稱
+func ParseFormat(name string) (Format, bool) {
+	switch f := Format(strings.ToLower(name)); f {
+	case CSV, NDJSON, XLSX:
+		return f, true
+	}
+	return "", false
+}
+
+// DetectFormat 依副檔名或 Content-Type 判斷格式
+func DetectFormat(filename, contentType string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV, true
+	case ".ndjson", ".jsonl":
+		return NDJSON, true
+	case ".xlsx":
+		return XLSX, true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return CSV, true
+	case "application/x-ndjson", "application/jsonl":
+		return NDJSON, true
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return XLSX, true
+	}
+	return "", false
+}
+
+// Read 讀取整個檔案；空白列會被略過
+func Read(format Format, data []byte) (Table, error) {
+	var table Table
+	var err error
+	switch format {
+	case CSV:
+		table, err = readCSV(data)
+	case NDJSON:
+		table, err = readNDJSON(data)
+	case XLSX:
+		table, err = readXLSX(data)
+	default:
+		return table, ErrUnknownFormat
+	}
+	if err != nil {
+		return table, err
+	}
+	if len(table.Rows) > MaxRows {
+		return table, ErrTooManyRows
+	}
+	return table, nil
+}
+
+func readCSV(data []byte) (Table, error) {
+	var table Table
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return table, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if isBlank(record) {
+			continue
+		}
+		for i, value := range record {
+			record[i] = unescapeFormula(value)
+		}
+		if table.Headers == nil {
+			table.Headers = trimAll(record)
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		table.Rows = append(table.Rows, Row{Number: line, Values: record})
+		if len(table.Rows) > MaxRows {
+			return table, ErrTooManyRows
+		}
+	}
+	return table, nil
+}
+
+// unescapeFormula 移除匯出時為防止公式執行而加上的單引號（見 exporter.CSVSafe），讓匯出的檔案可以直接匯入
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// readNDJSON 每行一個 JSON 物件；標題依欄位首次出現的順序排列，同一行新出現的欄位依名稱排序
+func readNDJSON(data []byte) (Table, error) {
+	var table Table
+	columns := map[string]int{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.UseNumber()
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil || object == nil {
+			return table, fmt.Errorf("%w: line %d is not a JSON object", ErrInvalidFile, line)
+		}
+
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			if _, ok := columns[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			columns[key] = len(table.Headers)
+			table.Headers = append(table.Headers, key)
+		}
+
+		values := make([]string, len(table.Headers))
+		for key, value := range object {
+			values[columns[key]] = jsonString(value)
+		}
+		table.Rows = append(table.Rows, Row{Number: line, Values: values})
+		if len(table.Rows) > MaxRows {
+			return table, ErrTooManyRows
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return table, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return table, nil
+}
+
+func readXLSX(data []byte) (Table, error) {
+	var table Table
+	rows, err := xlsx.ReadRows(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return table, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	for i, cells := range rows {
+		if isBlank(cells) {
+			continue
+		}
+		if table.Headers == nil {
+			table.Headers = trimAll(cells)
+			continue
+		}
+		table.Rows = append(table.Rows, Row{Number: i + 1, Values: cells})
+	}
+	return table, nil
+}
+
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
+
+func isBlank(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func trimAll(values []string) []string {
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return trimmed
+}
+
+// Mapping 檔案欄位與用戶欄位的對應
+type Mapping struct {
+	columns map[int]string
+}
+
+// ParseMapping 解析欄位對應。spec 為 {"檔案欄位": "用戶欄位"} 的 JSON 物件；
+// 未提供時以名稱相同（不分大小寫）的欄位對應。email 必須有對應的欄位
+func ParseMapping(spec string, headers []string) (Mapping, error) {
+	mapping := Mapping{columns: map[int]string{}}
+	index := map[string]int{}
+	for i, header := range headers {
+		if _, ok := index[strings.ToLower(header)]; !ok {
+			index[strings.ToLower(header)] = i
+		}
+	}
+
+	pairs := map[string]string{}
+	if strings.TrimSpace(spec) == "" {
+		for _, field := range Fields {
+			if _, ok := index[field]; ok {
+				pairs[field] = field
+			}
+		}
+	} else if err := json.Unmarshal([]byte(spec), &pairs); err != nil {
+		return mapping, fmt.Errorf("%w: must be a JSON object of column to field", ErrInvalidMapping)
+	}
+
+	used := map[string]bool{}
+	for column, field := range pairs {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !isField(field) {
+			return mapping, fmt.Errorf("%w: unknown field %q", ErrInvalidMapping, field)
+		}
+		if used[field] {
+			return mapping, fmt.Errorf("%w: field %q is mapped more than once", ErrInvalidMapping, field)
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return mapping, fmt.Errorf("%w: column %q not found", ErrInvalidMapping, column)
+		}
+		used[field] = true
+		mapping.columns[i] = field
+	}
+	if !used["email"] {
+		return mapping, fmt.Errorf("%w: no column is mapped to email", ErrInvalidMapping)
+	}
+	return mapping, nil
+}
+
+func isField(name string) bool {
+	for _, field := range Fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply 取得資料列中對應到用戶欄位的值，值的前後空白會被去除
+func (m Mapping) Apply(row Row) map[string]string {
+	fields := make(map[string]string, len(m.columns))
+	for column, field := range m.columns {
+		fields[field] = strings.TrimSpace(row.Value(column))
+	}
+	return fields
+}
+
+// ReportColumns 回傳可以保存到錯誤報表的欄位索引，排除對應到 password 的欄位與名稱為 password 的欄位，
+// 避免明文密碼留在報表中
+func (m Mapping) ReportColumns(headers []string) []int {
+	columns := make([]int, 0, len(headers))
+	for i, header := range headers {
+		if m.columns[i] == "password" || strings.EqualFold(strings.TrimSpace(header), "password") {
+			continue
+		}
+		columns = append(columns, i)
+	}
+	return columns
+}
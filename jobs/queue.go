@@ -0,0 +1,481 @@
+// Package jobs 以 MongoDB 保存的背景工作佇列：worker 以租約取得工作並定期續約，
+// 失敗時以指數退避重試，輸入與結果檔案存放在 GridFS
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"go-api_for_main/clock"
+	user_models "go-api_for_main/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 佇列操作的錯誤
+var (
+	ErrNotFound  = errors.New("job not found")
+	ErrFinished  = errors.New("job has already finished")
+	ErrNoResult  = errors.New("job has no result")
+	ErrCancelled = errors.New("job was cancelled")
+	errLeaseLost = errors.New("job lease was taken over by another worker")
+)
+
+// Handler 執行一種工作。ctx 在工作被取消、租約遺失或 worker 停止時結束；
+// 回傳錯誤時工作在退避後重試，以 Permanent 包裝的錯誤不會重試
+type Handler func(ctx context.Context, run *Run) error
+
+// Permanent 標記重試也不會成功的錯誤，例如輸入檔案的格式錯誤
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Options 佇列設定，零值的欄位使用預設值
+type Options struct {
+	Workers       int           // 同時執行的工作數
+	PollInterval  time.Duration // 沒有可執行的工作時，再次查詢前等待的時間
+	LeaseDuration time.Duration // 租約長度，worker 每經過三分之一的租約長度續約一次
+	MaxAttempts   int           // 每個工作最多執行的次數
+	RetryBackoff  time.Duration // 第一次失敗後到重試前等待的時間，之後每次加倍
+	MaxBackoff    time.Duration // 重試等待時間的上限
+	Retention     time.Duration // 結束的工作與其檔案保存的時間
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = 2
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 2 * time.Second
+	}
+	if o.LeaseDuration <= 0 {
+		o.LeaseDuration = time.Minute
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 30 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 30 * time.Minute
+	}
+	if o.Retention <= 0 {
+		o.Retention = 7 * 24 * time.Hour
+	}
+	return o
+}
+
+// Backoff 回傳第 attempt 次執行失敗後到重試前等待的時間
+func (o Options) Backoff(attempt int) time.Duration {
+	o = o.withDefaults()
+	delay := o.RetryBackoff
+	for i := 1; i < attempt && delay < o.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > o.MaxBackoff {
+		delay = o.MaxBackoff
+	}
+	return delay
+}
+
+// Queue 背景工作佇列；處理函式必須在 Start 之前以 Handle 註冊
+type Queue struct {
+	jobs     *mongo.Collection
+	files    *gridfs.Bucket
+	clock    clock.Clock
+	opts     Options
+	owner    string
+	handlers map[string]Handler
+}
+
+// New 建立使用 jobs 集合與 job_files GridFS bucket 的佇列
+func New(db *mongo.Database, clk clock.Clock, opts Options) (*Queue, error) {
+	files, err := gridfs.NewBucket(db, options.GridFSBucket().SetName("job_files"))
+	if err != nil {
+		return nil, err
+	}
+	return &Queue{
+		jobs:     db.Collection("jobs"),
+		files:    files,
+		clock:    clk,
+		opts:     opts.withDefaults(),
+		owner:    workerID(),
+		handlers: map[string]Handler{},
+	}, nil
+}
+
+// workerID 以主機名稱、行程編號與亂數識別租約的持有者
+func workerID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// EnsureIndexes 建立取得工作與清除過期工作所需的索引
+func (q *Queue) EnsureIndexes(ctx context.Context) error {
+	_, err := q.jobs.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lease_until", Value: 1}}},
+		{Keys: bson.D{{Key: "finished_at", Value: 1}}},
+	})
+	return err
+}
+
+// Handle 註冊一種工作的處理函式
+func (q *Queue) Handle(kind string, handler Handler) {
+	q.handlers[kind] = handler
+}
+
+// Enqueue 新增工作，input 不為 nil 時先存入 GridFS 供 worker 讀取
+func (q *Queue) Enqueue(ctx context.Context, job user_models.Job, input io.Reader, inputName string) (user_models.Job, error) {
+	now := q.clock.Now()
+	job.ID = primitive.NewObjectID()
+	job.Status = user_models.JobQueued
+	job.MaxAttempts = q.opts.MaxAttempts
+	job.RunAt = now
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	if input != nil {
+		fileID, err := q.files.UploadFromStream(inputName, input, options.GridFSUpload().SetMetadata(bson.M{"job_id": job.ID, "purpose": "input"}))
+		if err != nil {
+			return job, err
+		}
+		job.InputFileID = &fileID
+	}
+	if _, err := q.jobs.InsertOne(ctx, job); err != nil {
+		if job.InputFileID != nil {
+			q.deleteFile(*job.InputFileID)
+		}
+		return job, err
+	}
+	return job, nil
+}
+
+// Get 取得工作
+func (q *Queue) Get(ctx context.Context, id primitive.ObjectID) (user_models.Job, error) {
+	var job user_models.Job
+	err := q.jobs.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return job, ErrNotFound
+	}
+	return job, err
+}
+
+// Cancel 取消工作：等待中的工作直接結束，執行中的工作由 worker 在下次續約時停止
+func (q *Queue) Cancel(ctx context.Context, id primitive.ObjectID) (user_models.Job, error) {
+	now := q.clock.Now()
+	queued := bson.D{{Key: "$eq", Value: bson.A{"$status", user_models.JobQueued}}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.D{
+		{Key: "cancel_requested", Value: true},
+		{Key: "updated_at", Value: now},
+		{Key: "finished_at", Value: bson.D{{Key: "$cond", Value: bson.A{queued, now, "$finished_at"}}}},
+		{Key: "status", Value: bson.D{{Key: "$cond", Value: bson.A{queued, user_models.JobCancelled, "$status"}}}},
+	}}}}
+
+	var job user_models.Job
+	err := q.jobs.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": bson.A{user_models.JobQueued, user_models.JobRunning}}},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		if job, err = q.Get(ctx, id); err != nil {
+			return job, err
+		}
+		return job, ErrFinished
+	}
+	if err != nil {
+		return job, err
+	}
+	if job.Status == user_models.JobCancelled && job.InputFileID != nil {
+		q.deleteFile(*job.InputFileID)
+	}
+	return job, nil
+}
+
+// OpenResult 開啟成功工作的結果檔案
+func (q *Queue) OpenResult(job user_models.Job) (io.ReadCloser, error) {
+	if job.Status != user_models.JobSucceeded || job.Result == nil {
+		return nil, ErrNoResult
+	}
+	stream, err := q.files.OpenDownloadStream(job.Result.FileID)
+	if err == gridfs.ErrFileNotFound {
+		return nil, ErrNoResult
+	}
+	return stream, err
+}
+
+// Start 啟動 worker 與清除過期工作的排程，ctx 結束時停止；執行中的工作會放回佇列
+func (q *Queue) Start(ctx context.Context) {
+	for i := 0; i < q.opts.Workers; i++ {
+		go q.work(ctx)
+	}
+	go q.purgeLoop(ctx)
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := q.lease(ctx)
+		if err == nil {
+			q.execute(ctx, job)
+			continue
+		}
+		if err != mongo.ErrNoDocuments && ctx.Err() == nil {
+			log.Printf("Error leasing job: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(q.opts.PollInterval):
+		}
+	}
+}
+
+// lease 取得一個到期的等待中工作，或租約已過期的執行中工作
+func (q *Queue) lease(ctx context.Context) (user_models.Job, error) {
+	kinds := make(bson.A, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+	now := q.clock.Now()
+	filter := bson.M{
+		"kind": bson.M{"$in": kinds},
+		"$or": bson.A{
+			bson.M{"status": user_models.JobQueued, "run_at": bson.M{"$lte": now}},
+			bson.M{"status": user_models.JobRunning, "lease_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      user_models.JobRunning,
+			"lease_owner": q.owner,
+			"lease_until": now.Add(q.opts.LeaseDuration),
+			"progress":    user_models.JobProgress{},
+			"started_at":  now,
+			"updated_at":  now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	var job user_models.Job
+	err := q.jobs.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After),
+	).Decode(&job)
+	return job, err
+}
+
+// execute 執行已取得租約的工作，並依結果結束、重試或放回佇列
+func (q *Queue) execute(parent context.Context, job user_models.Job) {
+	run := &Run{queue: q, job: job}
+	switch {
+	case job.CancelRequested:
+		// 取消時 worker 已停止，租約過期後由此完成取消
+		q.finish(run, user_models.JobCancelled, nil)
+		return
+	case job.Attempts > job.MaxAttempts:
+		// 每次租約過期都會消耗一次執行次數，避免讓 worker 當機的工作無限重試
+		q.finish(run, user_models.JobFailed, errors.New("job lease expired too many times"))
+		return
+	}
+
+	ctx, cancel := context.WithCancelCause(parent)
+	var heartbeat sync.WaitGroup
+	stop := make(chan struct{})
+	heartbeat.Add(1)
+	go func() {
+		defer heartbeat.Done()
+		q.heartbeat(run, cancel, stop)
+	}()
+
+	err := q.call(ctx, run)
+	cause := context.Cause(ctx)
+	close(stop)
+	heartbeat.Wait()
+	cancel(nil)
+
+	switch {
+	case errors.Is(cause, errLeaseLost):
+		log.Printf("Job %s lost its lease, leaving it to the new owner\n", job.ID.Hex())
+		run.discardResult()
+	case errors.Is(cause, ErrCancelled):
+		q.finish(run, user_models.JobCancelled, nil)
+	case err == nil:
+		q.finish(run, user_models.JobSucceeded, nil)
+	case parent.Err() != nil:
+		q.release(run)
+	default:
+		q.retry(run, err)
+	}
+}
+
+// call 執行處理函式，處理函式 panic 時視為失敗
+func (q *Queue) call(ctx context.Context, run *Run) (err error) {
+	handler, ok := q.handlers[run.job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job kind %q", run.job.Kind))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, run)
+}
+
+// heartbeat 定期續約並保存進度；發現工作被取消或租約已被接手時結束 ctx
+func (q *Queue) heartbeat(run *Run, cancel context.CancelCauseFunc, stop <-chan struct{}) {
+	ticker := time.NewTicker(q.opts.LeaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		now := q.clock.Now()
+		var current user_models.Job
+		err := q.jobs.FindOneAndUpdate(context.Background(),
+			bson.M{"_id": run.job.ID, "lease_owner": q.owner, "status": user_models.JobRunning},
+			bson.M{"$set": bson.M{"lease_until": now.Add(q.opts.LeaseDuration), "progress": run.Progress(), "updated_at": now}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&current)
+		switch {
+		case err == mongo.ErrNoDocuments:
+			cancel(errLeaseLost)
+			return
+		case err != nil:
+			log.Printf("Error renewing lease of job %s: %v\n", run.job.ID.Hex(), err)
+		case current.CancelRequested:
+			cancel(ErrCancelled)
+			return
+		}
+	}
+}
+
+// finish 結束工作；jobErr 不為 nil 時記錄為最後一次失敗的原因
+func (q *Queue) finish(run *Run, status user_models.JobStatus, jobErr error) {
+	now := q.clock.Now()
+	set := bson.M{"status": status, "progress": run.Progress(), "finished_at": now, "updated_at": now}
+	if status == user_models.JobSucceeded && run.result != nil {
+		set["result"] = run.result
+	}
+	update := bson.M{"$set": set, "$unset": bson.M{"lease_owner": "", "lease_until": ""}}
+	if jobErr != nil {
+		update["$push"] = bson.M{"errors": run.failure(jobErr, now)}
+	}
+
+	if !q.updateLeased(run, update) || status != user_models.JobSucceeded {
+		run.discardResult()
+	}
+	if run.job.InputFileID != nil {
+		q.deleteFile(*run.job.InputFileID)
+	}
+}
+
+// retry 記錄失敗原因，還有執行次數時在退避後重試，否則結束為失敗
+func (q *Queue) retry(run *Run, jobErr error) {
+	var permanent permanentError
+	if errors.As(jobErr, &permanent) || run.job.Attempts >= run.job.MaxAttempts {
+		q.finish(run, user_models.JobFailed, jobErr)
+		return
+	}
+
+	run.discardResult()
+	now := q.clock.Now()
+	q.updateLeased(run, bson.M{
+		"$set":   bson.M{"status": user_models.JobQueued, "run_at": now.Add(q.opts.Backoff(run.job.Attempts)), "progress": run.Progress(), "updated_at": now},
+		"$unset": bson.M{"lease_owner": "", "lease_until": ""},
+		"$push":  bson.M{"errors": run.failure(jobErr, now)},
+	})
+}
+
+// release 在 worker 停止時將工作放回佇列，這次中斷不計入執行次數
+func (q *Queue) release(run *Run) {
+	run.discardResult()
+	now := q.clock.Now()
+	q.updateLeased(run, bson.M{
+		"$set":   bson.M{"status": user_models.JobQueued, "run_at": now, "updated_at": now},
+		"$unset": bson.M{"lease_owner": "", "lease_until": ""},
+		"$inc":   bson.M{"attempts": -1},
+	})
+}
+
+// updateLeased 只在仍持有租約時更新工作，回傳是否更新成功
+func (q *Queue) updateLeased(run *Run, update bson.M) bool {
+	result, err := q.jobs.UpdateOne(context.Background(), bson.M{"_id": run.job.ID, "lease_owner": q.owner}, update)
+	if err != nil {
+		log.Printf("Error updating job %s: %v\n", run.job.ID.Hex(), err)
+		return false
+	}
+	return result.MatchedCount == 1
+}
+
+// purgeLoop 每小時刪除超過保存期限的工作與其檔案
+func (q *Queue) purgeLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		q.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) purge(ctx context.Context) {
+	cursor, err := q.jobs.Find(ctx, bson.M{"finished_at": bson.M{"$lt": q.clock.Now().Add(-q.opts.Retention)}})
+	if err != nil {
+		log.Printf("Error finding expired jobs: %v\n", err)
+		return
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var job user_models.Job
+		if err := cursor.Decode(&job); err != nil {
+			log.Printf("Error decoding expired job: %v\n", err)
+			continue
+		}
+		if job.Result != nil {
+			q.deleteFile(job.Result.FileID)
+		}
+		if job.InputFileID != nil {
+			q.deleteFile(*job.InputFileID)
+		}
+		if _, err := q.jobs.DeleteOne(ctx, bson.M{"_id": job.ID}); err != nil {
+			log.Printf("Error deleting expired job %s: %v\n", job.ID.Hex(), err)
+		}
+	}
+}
+
+// deleteFile 刪除 GridFS 檔案，檔案已不存在時視為成功
+func (q *Queue) deleteFile(id primitive.ObjectID) {
+	if err := q.files.Delete(id); err != nil && err != gridfs.ErrFileNotFound {
+		log.Printf("Error deleting job file %s: %v\n", id.Hex(), err)
+	}
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	user_models "go-api_for_main/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Run 執行中的工作，提供進度回報與輸入、結果檔案的存取
+type Run struct {
+	queue *Queue
+	job   user_models.Job
+
+	mu       sync.Mutex
+	progress user_models.JobProgress
+	result   *user_models.JobResult
+}
+
+// Job 回傳取得租約時的工作內容
+func (r *Run) Job() user_models.Job {
+	return r.job
+}
+
+// SetProgress 更新進度，進度在下次續約與工作結束時保存
+func (r *Run) SetProgress(done, total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress = user_models.JobProgress{Done: done, Total: total}
+}
+
+// Progress 回傳目前的進度
+func (r *Run) Progress() user_models.JobProgress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.progress
+}
+
+// OpenInput 開啟建立工作時上傳的輸入檔案
+func (r *Run) OpenInput() (io.ReadCloser, error) {
+	if r.job.InputFileID == nil {
+		return nil, Permanent(errors.New("job has no input file"))
+	}
+	return r.queue.files.OpenDownloadStream(*r.job.InputFileID)
+}
+
+// WriteResult 將 write 寫出的內容存為結果檔案，工作成功結束後才能下載；write 失敗時不保留檔案
+func (r *Run) WriteResult(filename, contentType string, write func(w io.Writer) error) error {
+	stream, err := r.queue.files.OpenUploadStream(filename, options.GridFSUpload().
+		SetMetadata(bson.M{"job_id": r.job.ID, "purpose": "result", "content_type": contentType}))
+	if err != nil {
+		return err
+	}
+	counter := &countingWriter{w: stream}
+	if err := write(counter); err != nil {
+		stream.Abort()
+		return err
+	}
+	if err := stream.Close(); err != nil {
+		return err
+	}
+
+	r.discardResult()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result = &user_models.JobResult{
+		FileID:      stream.FileID.(primitive.ObjectID),
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        counter.n,
+	}
+	return nil
+}
+
+// discardResult 刪除未被採用的結果檔案
+func (r *Run) discardResult() {
+	r.mu.Lock()
+	result := r.result
+	r.result = nil
+	r.mu.Unlock()
+	if result != nil {
+		r.queue.deleteFile(result.FileID)
+	}
+}
+
+func (r *Run) failure(err error, at time.Time) user_models.JobError {
+	return user_models.JobError{Attempt: r.job.Attempts, Message: err.Error(), At: at}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
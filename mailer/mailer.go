@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+
+	"go-api_for_main/config"
+)
+
+// Message 一封待寄送的電子郵件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 寄送電子郵件的介面，測試時可替換為 Recorder
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer 只將郵件內容寫入日誌，適用於未設定 SMTP 的開發環境
+type LogMailer struct{}
+
+// Send 將郵件寫入日誌
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	log.Printf("mailer: to=%s subject=%q\n%s\n", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPMailer 透過 SMTP 伺服器寄送郵件
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send 以純文字郵件寄出
+func (m SMTPMailer) Send(_ context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{msg.To}, []byte(b.String()))
+}
+
+// Recorder 將寄出的郵件保存在記憶體中，供測試檢查
+type Recorder struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send 記錄郵件
+func (r *Recorder) Send(_ context.Context, msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// Messages 回傳目前已記錄的郵件
+func (r *Recorder) Messages() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.messages...)
+}
+
+// New 依設定建立 Mailer，未設定 SMTP 主機時使用 LogMailer
+func New(cfg config.MailConfig) Mailer {
+	if cfg.SMTPHost == "" {
+		return LogMailer{}
+	}
+	return SMTPMailer{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.From,
+	}
+}
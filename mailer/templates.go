@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// InvitationMessage 產生邀請郵件，連結中帶有一次性的邀請權杖
+func InvitationMessage(to, role, acceptURL, token string, expiresAt time.Time) Message {
+	link := acceptURL
+	if strings.Contains(link, "?") {
+		link += "&"
+	} else {
+		link += "?"
+	}
+	link += "token=" + url.QueryEscape(token)
+
+	body := fmt.Sprintf("您好，\n\n您已受邀以 %s 身分加入。請在 %s 前開啟以下連結設定密碼並完成註冊：\n\n%s\n\n如果您不認識寄件者，請忽略這封郵件。\n",
+		role, expiresAt.UTC().Format("2006-01-02 15:04 MST"), link)
+
+	return Message{
+		To:      to,
+		Subject: "您收到一封加入邀請",
+		Body:    body,
+	}
+}
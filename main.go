@@ -7,9 +7,16 @@ import (
 	"strings"
 	"time"
 
+	"go-api_for_main/config"
 	"go-api_for_main/controllers"
 	_ "go-api_for_main/docs" // 導入 swagger 文檔
+	"go-api_for_main/grpcserver"
+	"go-api_for_main/i18n"
+	"go-api_for_main/mailer"
+	"go-api_for_main/middleware"
+	userv1 "go-api_for_main/proto/user/v1"
 	"go-api_for_main/routes"
+	"go-api_for_main/validation"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -26,6 +33,10 @@ import (
 // @schemes http https
 // @produce application/json
 // @consume application/json
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description 以 "Bearer <access_token>" 格式帶入 /oauth2/token 取得的存取權杖
 
 var client *mongo.Client
 var database *mongo.Database
@@ -55,6 +66,8 @@ func initMongoDB() error {
 }
 
 func main() {
+	cfg := config.LoadConfig()
+
 	// 初始化 MongoDB 連接
 	err := initMongoDB()
 	if err != nil {
@@ -68,9 +81,44 @@ func main() {
 		}()
 	}
 
+	// 初始化 OpenID Connect 身分提供者（MongoDB 未連接時金鑰只保存在記憶體中）
+	controllers.SetupOIDCController(database, cfg.OIDC)
+	controllers.SetupSCIMController(cfg.SCIM)
+	controllers.SetupInvitationController(database, cfg.Invitation, mailer.New(cfg.Mail))
+	controllers.SetupAuditController(database)
+	controllers.SetupVersionController(database)
+	controllers.SetupImportController(database)
+	controllers.SetupAvatarController(database)
+	controllers.SetupJobController(database, cfg.Jobs)
+	controllers.SetupFeedController(database)
+	controllers.SetupWebhookController(database, cfg.Webhooks)
+	controllers.SetupGraphQLController(cfg.GraphQL)
+
 	// 創建 Gin 路由器
 	r := gin.Default()
 
+	// 只信任設定的反向代理所轉送的用戶端位址與主機資訊
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	if err := controllers.SetupBaseURL(cfg.Server); err != nil {
+		log.Fatalf("Invalid base URL settings: %v", err)
+	}
+
+	// 依 Accept-Language 選擇回應語言
+	if err := i18n.SetFallback(cfg.I18n.FallbackLanguage); err != nil {
+		log.Fatalf("Invalid FALLBACK_LANGUAGE: %v", err)
+	}
+	r.Use(middleware.Localize())
+
+	// 每個請求帶有識別碼，寫入稽核紀錄並回傳於 X-Request-ID
+	r.Use(middleware.RequestID())
+
+	// 國內格式的電話號碼以此地區的國碼轉為 E.164
+	if err := validation.SetDefaultRegion(cfg.Validation.DefaultPhoneRegion); err != nil {
+		log.Fatalf("Invalid DEFAULT_PHONE_REGION: %v", err)
+	}
+
 	// 設定 CORS middleware
 	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
 	if allowedOrigins == "" {
@@ -81,8 +129,8 @@ func main() {
 	corsConfig := cors.Config{
 		AllowOrigins:     strings.Split(allowedOrigins, ","),
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization", "X-Requested-With", "X-Request-ID", "If-Match", "If-None-Match", "Last-Event-ID"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "Content-Language", "X-Request-ID", "ETag", "Location"},
 		AllowCredentials: allowedOrigins != "*", // 當允許所有來源時不能使用憑證
 		MaxAge:           12 * time.Hour,
 	}
@@ -107,6 +155,21 @@ func main() {
 		})
 	})
 
+	// gRPC 伺服器與 Gin 伺服器共用控制器的服務函式與存取權杖驗證，用戶服務只限管理員
+	grpcServer := grpcserver.New(controllers.VerifyAccessToken, grpcserver.Options{
+		Reflection: cfg.GRPC.Reflection,
+		Authorize:  controllers.AuthorizeGRPC,
+	})
+	userv1.RegisterUserServiceServer(grpcServer, controllers.NewUserServiceServer())
+	grpcServer.SetServing("", true)
+	grpcServer.SetServing(userv1.UserService_ServiceDesc.ServiceName, database != nil)
+	go func() {
+		log.Printf("gRPC server starting on :%s...", cfg.GRPC.Port)
+		if err := grpcServer.ListenAndServe(cfg.GRPC.Port); err != nil {
+			log.Fatalf("gRPC server failed: %v", err)
+		}
+	}()
+
 	// 啟動服務器
 	log.Println("Server starting on :8080...")
 	if err := r.Run(":8080"); err != nil {
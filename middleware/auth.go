@@ -0,0 +1,103 @@
+// Package middleware 提供 Gin 路由共用的中介軟體
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go-api_for_main/oidc"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrincipalKey 已驗證的存取權杖宣告在 gin.Context 中的鍵
+const PrincipalKey = "principal"
+
+// TokenVerifier 驗證存取權杖並回傳其宣告
+type TokenVerifier func(token string) (*oidc.AccessTokenClaims, error)
+
+// Authenticate 若請求帶有 Bearer 權杖則驗證並保存宣告，未帶權杖時直接放行
+func Authenticate(verify TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		claims, err := verify(token)
+		if err != nil {
+			abortUnauthorized(c, "invalid_token", "access token is invalid or expired")
+			return
+		}
+
+		c.Set(PrincipalKey, claims)
+		c.Next()
+	}
+}
+
+// RequireAuth 要求請求帶有有效的 Bearer 權杖，已由 Authenticate 驗證過的請求不會重複驗證
+func RequireAuth(verify TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := CurrentPrincipal(c); ok {
+			c.Next()
+			return
+		}
+
+		token, ok := bearerToken(c)
+		if !ok {
+			abortUnauthorized(c, "", "")
+			return
+		}
+
+		claims, err := verify(token)
+		if err != nil {
+			abortUnauthorized(c, "invalid_token", "access token is invalid or expired")
+			return
+		}
+
+		c.Set(PrincipalKey, claims)
+		c.Next()
+	}
+}
+
+// CurrentPrincipal 取得目前請求已驗證的權杖宣告
+func CurrentPrincipal(c *gin.Context) (*oidc.AccessTokenClaims, bool) {
+	value, exists := c.Get(PrincipalKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*oidc.AccessTokenClaims)
+	return claims, ok
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(header[7:])
+	return token, token != ""
+}
+
+// abortUnauthorized 依 RFC 6750 回傳 WWW-Authenticate 挑戰
+func abortUnauthorized(c *gin.Context, errCode, description string) {
+	challenge := `Bearer realm="api"`
+	if errCode != "" {
+		challenge += `, error="` + errCode + `", error_description="` + description + `"`
+	}
+	c.Header("WWW-Authenticate", challenge)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":             firstNonEmpty(errCode, "unauthorized"),
+		"error_description": firstNonEmpty(description, "missing bearer token"),
+	})
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
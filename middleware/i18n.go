@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"go-api_for_main/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LanguageKey 協商出的回應語言在 gin.Context 中的鍵
+const LanguageKey = "language"
+
+// Localize 依 Accept-Language 選擇回應語言並設定 Content-Language
+func Localize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		c.Set(LanguageKey, lang)
+		c.Header("Content-Language", lang)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// Language 取得目前請求的回應語言，未經過 Localize 時直接依標頭協商
+func Language(c *gin.Context) string {
+	if lang, ok := c.Get(LanguageKey); ok {
+		if s, ok := lang.(string); ok {
+			return s
+		}
+	}
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 傳遞請求識別碼的標頭
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey 請求識別碼在 gin.Context 中的鍵
+const RequestIDKey = "request_id"
+
+// maxRequestIDLength 沿用用戶端提供的識別碼時允許的最大長度
+const maxRequestIDLength = 128
+
+// RequestID 沿用用戶端提供的 X-Request-ID，未提供或格式不符時產生新的識別碼，並回傳於響應標頭
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := ResolveRequestID(c.GetHeader(RequestIDHeader))
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// CurrentRequestID 取得目前請求的識別碼，未經過 RequestID 時回傳空字串
+func CurrentRequestID(c *gin.Context) string {
+	if id, ok := c.Get(RequestIDKey); ok {
+		if s, ok := id.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// ResolveRequestID 沿用格式正確的識別碼，否則產生新的識別碼；gRPC 伺服器以相同規則處理 x-request-id
+func ResolveRequestID(id string) string {
+	if !validRequestID(id) {
+		return newRequestID()
+	}
+	return id
+}
+
+// validRequestID 只接受長度合理的可見 ASCII 字元，避免將任意內容寫入日誌與稽核紀錄
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,158 @@
+package user_models
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 稽核紀錄的動作
+const (
+	AuditUserCreate     = "user.create"
+	AuditUserUpdate     = "user.update"
+	AuditPasswordChange = "user.password_change"
+	AuditSessionRevoke  = "session.revoke"
+	AuditUserRestore    = "user.restore"
+	AuditAvatarUpdate   = "user.avatar_update"
+	AuditAvatarDelete   = "user.avatar_delete"
+)
+
+// AuditTransitionAction 狀態轉換對應的稽核動作，例如 user.suspend
+func AuditTransitionAction(action string) string {
+	return "user." + action
+}
+
+// RedactedValue 取代機密欄位內容的值
+const RedactedValue = "[REDACTED]"
+
+// AuditEntry 稽核紀錄，寫入後不會被修改或刪除
+// @Description 稽核紀錄
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id" example:"507f1f77bcf86cd799439013"`
+	Actor      string             `bson:"actor" json:"actor" example:"507f1f77bcf86cd799439099"`
+	Action     string             `bson:"action" json:"action" example:"user.update"`
+	TargetType string             `bson:"target_type" json:"target_type" example:"user"`
+	TargetID   string             `bson:"target_id" json:"target_id" example:"507f1f77bcf86cd799439011"`
+	Changes    []FieldChange      `bson:"changes,omitempty" json:"changes,omitempty"`
+	RequestID  string             `bson:"request_id,omitempty" json:"request_id,omitempty" example:"4f9c2a7d0b1e4c3a9e8d7c6b5a4f3e2d"`
+	IP         string             `bson:"ip,omitempty" json:"ip,omitempty" example:"203.0.113.7"`
+	At         time.Time          `bson:"at" json:"at" example:"2021-01-01T00:00:00Z"`
+}
+
+// FieldChange 單一欄位修改前後的值，機密欄位以 [REDACTED] 表示
+// @Description 欄位變更
+type FieldChange struct {
+	Field  string      `bson:"field" json:"field" example:"email"`
+	Before interface{} `bson:"before,omitempty" json:"before,omitempty" swaggertype:"string" example:"old@example.com"`
+	After  interface{} `bson:"after,omitempty" json:"after,omitempty" swaggertype:"string" example:"new@example.com"`
+}
+
+// AuditLogResponse 稽核紀錄列表響應
+// @Description 符合 HATEOAS 的稽核紀錄列表響應結構
+type AuditLogResponse struct {
+	Data  []AuditEntry  `json:"data"`
+	Links []HATEOASLink `json:"_links"`
+	Page  int           `json:"page" example:"1"`
+	Size  int           `json:"size" example:"20"`
+	Total int64         `json:"total" example:"42"`
+}
+
+// auditSecretFields 只記錄有變更、不記錄內容的欄位
+var auditSecretFields = map[string]bool{"password": true}
+
+// auditIgnoredFields 由伺服器維護或另有紀錄的欄位，不列入差異
+var auditIgnoredFields = map[string]bool{
+	"_id":            true,
+	"created_at":     true,
+	"created_by":     true,
+	"updated_at":     true,
+	"updated_by":     true,
+	"version":        true,
+	"status_history": true,
+	"avatar":         true,
+	"outbox":         true,
+}
+
+// DiffUsers 比較修改前後的用戶，回傳有變更的欄位；before 為 nil 表示新建立的用戶
+func DiffUsers(before, after *User) []FieldChange {
+	var beforeValue, afterValue reflect.Value
+	if before != nil {
+		beforeValue = reflect.ValueOf(*before)
+	}
+	if after != nil {
+		afterValue = reflect.ValueOf(*after)
+	}
+
+	t := reflect.TypeOf(User{})
+	var changes []FieldChange
+	for i := 0; i < t.NumField(); i++ {
+		field, _, _ := strings.Cut(t.Field(i).Tag.Get("bson"), ",")
+		if field == "" || field == "-" || auditIgnoredFields[field] {
+			continue
+		}
+
+		var prev, next interface{}
+		if beforeValue.IsValid() {
+			prev = beforeValue.Field(i).Interface()
+		}
+		if afterValue.IsValid() {
+			next = afterValue.Field(i).Interface()
+		}
+		if reflect.DeepEqual(prev, next) || (isZero(prev) && isZero(next)) {
+			continue
+		}
+
+		change := FieldChange{Field: field, Before: prev, After: next}
+		if auditSecretFields[field] {
+			change.Before, change.After = redact(prev), redact(next)
+		}
+		if isZero(change.Before) {
+			change.Before = nil
+		}
+		if isZero(change.After) {
+			change.After = nil
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func redact(value interface{}) interface{} {
+	if isZero(value) {
+		return nil
+	}
+	return RedactedValue
+}
+
+func isZero(value interface{}) bool {
+	return value == nil || reflect.ValueOf(value).IsZero()
+}
+
+// GenerateAuditLinks 產生稽核紀錄列表的 HATEOAS 連結，query 為不含分頁參數的查詢字串
+func GenerateAuditLinks(baseURL, query string, page, size int, total int64) []HATEOASLink {
+	auditURL := baseURL + "/audit"
+	withQuery := func(extra string) string {
+		if query == "" {
+			return auditURL + "?" + extra
+		}
+		return auditURL + "?" + query + "&" + extra
+	}
+	pageURL := func(p int) string {
+		return withQuery("page=" + strconv.Itoa(p) + "&size=" + strconv.Itoa(size))
+	}
+
+	links := []HATEOASLink{
+		{Href: pageURL(page), Rel: "self", Method: "GET", Title: "List audit entries"},
+		{Href: withQuery("format=csv"), Rel: "export", Method: "GET", Title: "Export audit entries as CSV"},
+	}
+	if page > 1 {
+		links = append(links, HATEOASLink{Href: pageURL(page - 1), Rel: "prev", Method: "GET", Title: "Previous page of audit entries"})
+	}
+	if int64(page*size) < total {
+		links = append(links, HATEOASLink{Href: pageURL(page + 1), Rel: "next", Method: "GET", Title: "Next page of audit entries"})
+	}
+	return links
+}
@@ -0,0 +1,90 @@
+package user_models
+
+import (
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Avatar 用戶頭像，只保存縮放後存放在 GridFS 的各尺寸，不保存原始檔案
+type Avatar struct {
+	Hash        string          `bson:"hash"` // 上傳內容的雜湊值，用於 ETag
+	ContentType string          `bson:"content_type"`
+	Width       int             `bson:"width"`
+	Height      int             `bson:"height"`
+	Variants    []AvatarVariant `bson:"variants"`
+	UpdatedAt   time.Time       `bson:"updated_at"`
+	UpdatedBy   string          `bson:"updated_by,omitempty"`
+}
+
+// AvatarVariant 單一尺寸的頭像檔案
+type AvatarVariant struct {
+	Size   int                `bson:"size"`
+	FileID primitive.ObjectID `bson:"file_id"`
+	Length int64              `bson:"length"`
+}
+
+// AvatarHash 回傳頭像的雜湊值，沒有頭像時為空字串
+func AvatarHash(a *Avatar) string {
+	if a == nil {
+		return ""
+	}
+	return a.Hash
+}
+
+// Variant 取得指定尺寸的檔案
+func (a Avatar) Variant(size int) (AvatarVariant, bool) {
+	for _, v := range a.Variants {
+		if v.Size == size {
+			return v, true
+		}
+	}
+	return AvatarVariant{}, false
+}
+
+// ETag 指定尺寸的實體標籤，頭像更新後改變
+func (a Avatar) ETag(size int) string {
+	return `"` + a.Hash + "-" + strconv.Itoa(size) + `"`
+}
+
+// AvatarView 頭像資訊
+// @Description 頭像資訊
+type AvatarView struct {
+	ContentType string    `json:"content_type" example:"image/jpeg"`
+	Width       int       `json:"width" example:"1024"`
+	Height      int       `json:"height" example:"768"`
+	Sizes       []int     `json:"sizes" example:"32,64,128,256,512"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// NewAvatarView 建立頭像資訊
+func NewAvatarView(avatar Avatar) AvatarView {
+	view := AvatarView{ContentType: avatar.ContentType, Width: avatar.Width, Height: avatar.Height, UpdatedAt: avatar.UpdatedAt}
+	for _, v := range avatar.Variants {
+		view.Sizes = append(view.Sizes, v.Size)
+	}
+	return view
+}
+
+// AvatarResponse 頭像響應
+// @Description 符合 HATEOAS 的頭像響應結構
+type AvatarResponse struct {
+	Data  AvatarView    `json:"data"`
+	Links []HATEOASLink `json:"_links"`
+}
+
+// GenerateAvatarLinks 產生頭像的 HATEOAS 連結，每個尺寸各有一個下載連結
+func GenerateAvatarLinks(baseURL, userID string, avatar Avatar) []HATEOASLink {
+	avatarURL := baseURL + "/users/" + userID + "/avatar"
+	links := []HATEOASLink{
+		{Href: avatarURL, Rel: "self", Method: "GET", Title: "Get avatar"},
+		{Href: avatarURL, Rel: "update", Method: "PUT", Title: "Upload avatar"},
+		{Href: avatarURL, Rel: "delete", Method: "DELETE", Title: "Delete avatar"},
+		{Href: baseURL + "/users/" + userID, Rel: "user", Method: "GET", Title: "Get user"},
+	}
+	for _, v := range avatar.Variants {
+		links = append(links, HATEOASLink{Href: avatarURL + "?size=" + strconv.Itoa(v.Size), Rel: "image", Method: "GET", Title: "Get avatar"})
+	}
+	return links
+}
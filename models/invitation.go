@@ -0,0 +1,94 @@
+package user_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InvitationStatus 邀請狀態
+type InvitationStatus string
+
+// 邀請狀態
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+// Invitation 邀請紀錄，只保存權杖的雜湊值
+// @Description 邀請紀錄
+type Invitation struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id" example:"507f1f77bcf86cd799439011"`
+	Email      string              `bson:"email" json:"email" example:"zhangsan@example.com"`
+	Role       string              `bson:"role" json:"role" example:"member"`
+	Status     InvitationStatus    `bson:"status" json:"status" example:"pending"`
+	TokenHash  string              `bson:"token_hash" json:"-"`
+	InvitedBy  string              `bson:"invited_by" json:"invited_by" example:"507f1f77bcf86cd799439011"`
+	ExpiresAt  time.Time           `bson:"expires_at" json:"expires_at" example:"2021-01-04T00:00:00Z"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at" example:"2021-01-01T00:00:00Z"`
+	AcceptedAt *time.Time          `bson:"accepted_at,omitempty" json:"accepted_at,omitempty" example:"2021-01-02T00:00:00Z"`
+	RevokedAt  *time.Time          `bson:"revoked_at,omitempty" json:"revoked_at,omitempty" example:"2021-01-02T00:00:00Z"`
+	UserID     *primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty" example:"507f1f77bcf86cd799439012"`
+}
+
+// EffectiveStatus 已過期但尚未處理的邀請視為 expired
+func (i Invitation) EffectiveStatus(now time.Time) InvitationStatus {
+	if i.Status == InvitationPending && !now.Before(i.ExpiresAt) {
+		return InvitationExpired
+	}
+	return i.Status
+}
+
+// CreateInvitationRequest 建立邀請請求
+// @Description 建立邀請請求
+type CreateInvitationRequest struct {
+	Email          string `json:"email" binding:"required,email" normalize:"email" example:"zhangsan@example.com"`
+	Role           string `json:"role" binding:"omitempty,oneof=admin member" example:"member"`
+	ExpiresInHours int    `json:"expires_in_hours" binding:"omitempty,min=1" example:"72"`
+}
+
+// AcceptInvitationRequest 接受邀請請求，受邀者在此設定密碼與個人資料
+// @Description 接受邀請請求
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required" example:"Jq3x..."`
+	Password string `json:"password" binding:"required,min=8" example:"s3cret-pass"`
+	Name     string `json:"name" binding:"required,person_name" normalize:"name" example:"張三"`
+	Sex      string `json:"sex" binding:"omitempty,sex" normalize:"sex" example:"male"`
+	Age      int    `json:"age" binding:"age" example:"20"`
+	Phone    string `json:"phone" binding:"omitempty,phone" normalize:"phone" example:"+886912345678"`
+	Address  string `json:"address" normalize:"trim" example:"台北市"`
+}
+
+// InvitationResponse 單一邀請響應
+// @Description 符合 HATEOAS 的邀請響應結構
+type InvitationResponse struct {
+	Data  Invitation    `json:"data"`
+	Links []HATEOASLink `json:"_links"`
+}
+
+// InvitationsCollectionResponse 邀請列表響應
+// @Description 符合 HATEOAS 的邀請列表響應結構
+type InvitationsCollectionResponse struct {
+	Data  []Invitation  `json:"data"`
+	Links []HATEOASLink `json:"_links"`
+	Total int           `json:"total" example:"3"`
+}
+
+// GenerateInvitationLinks 產生邀請的 HATEOAS 連結，只有待接受的邀請可以撤銷
+func GenerateInvitationLinks(baseURL string, invitation Invitation, now time.Time) []HATEOASLink {
+	invitationURL := baseURL + "/invitations/" + invitation.ID.Hex()
+	links := []HATEOASLink{
+		{Href: invitationURL, Rel: "self", Method: "GET", Title: "Get invitation"},
+	}
+	switch invitation.EffectiveStatus(now) {
+	case InvitationPending:
+		links = append(links, HATEOASLink{Href: invitationURL, Rel: "revoke", Method: "DELETE", Title: "Revoke invitation"})
+	case InvitationAccepted:
+		if invitation.UserID != nil {
+			links = append(links, HATEOASLink{Href: baseURL + "/users/" + invitation.UserID.Hex(), Rel: "user", Method: "GET", Title: "Get user"})
+		}
+	}
+	return links
+}
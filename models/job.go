@@ -0,0 +1,151 @@
+package user_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobStatus 背景工作的狀態
+type JobStatus string
+
+// 背景工作的狀態
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Finished 工作是否已結束，結束的工作不會再被執行
+func (s JobStatus) Finished() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCancelled
+}
+
+// 背景工作的種類
+const (
+	JobKindUserExport = "users.export"
+	JobKindUserImport = "users.import"
+)
+
+// Job 背景工作，BaseURL、Language 與來源請求取自建立工作的請求，供結果中的連結、訊息與稽核紀錄使用；
+// 租約欄位由取得工作的 worker 維護，租約過期表示 worker 已停止，工作可被其他 worker 接手
+type Job struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty"`
+	Kind            string              `bson:"kind"`
+	Status          JobStatus           `bson:"status"`
+	Params          map[string]string   `bson:"params,omitempty"`
+	Progress        JobProgress         `bson:"progress"`
+	Attempts        int                 `bson:"attempts"`
+	MaxAttempts     int                 `bson:"max_attempts"`
+	Errors          []JobError          `bson:"errors,omitempty"`
+	CancelRequested bool                `bson:"cancel_requested"`
+	InputFileID     *primitive.ObjectID `bson:"input_file_id,omitempty"`
+	Result          *JobResult          `bson:"result,omitempty"`
+	LeaseOwner      string              `bson:"lease_owner,omitempty"`
+	LeaseUntil      *time.Time          `bson:"lease_until,omitempty"`
+	RunAt           time.Time           `bson:"run_at"`
+	BaseURL         string              `bson:"base_url"`
+	Language        string              `bson:"language"`
+	RequestID       string              `bson:"request_id"`
+	IP              string              `bson:"ip"`
+	CreatedBy       string              `bson:"created_by"`
+	CreatedAt       time.Time           `bson:"created_at"`
+	UpdatedAt       time.Time           `bson:"updated_at"`
+	StartedAt       *time.Time          `bson:"started_at,omitempty"`
+	FinishedAt      *time.Time          `bson:"finished_at,omitempty"`
+}
+
+// JobProgress 工作進度，Total 為 0 表示總數未知
+// @Description 工作進度
+type JobProgress struct {
+	Done  int `bson:"done" json:"done" example:"1500"`
+	Total int `bson:"total" json:"total" example:"10000"`
+}
+
+// JobError 某次執行失敗的原因
+// @Description 某次執行失敗的原因
+type JobError struct {
+	Attempt int       `bson:"attempt" json:"attempt" example:"1"`
+	Message string    `bson:"message" json:"message" example:"connection reset by peer"`
+	At      time.Time `bson:"at" json:"at" example:"2021-01-01T00:00:00Z"`
+}
+
+// JobResult 存放在 GridFS 的結果檔案
+type JobResult struct {
+	FileID      primitive.ObjectID `bson:"file_id"`
+	Filename    string             `bson:"filename"`
+	ContentType string             `bson:"content_type"`
+	Size        int64              `bson:"size"`
+}
+
+// JobView 工作的公開欄位，不含租約與輸入檔案
+// @Description 背景工作
+type JobView struct {
+	ID              string            `json:"id" example:"507f1f77bcf86cd799439011"`
+	Kind            string            `json:"kind" example:"users.export"`
+	Status          JobStatus         `json:"status" example:"running"`
+	Params          map[string]string `json:"params,omitempty"`
+	Progress        JobProgress       `json:"progress"`
+	Attempts        int               `json:"attempts" example:"1"`
+	MaxAttempts     int               `json:"max_attempts" example:"3"`
+	Errors          []JobError        `json:"errors,omitempty"`
+	CancelRequested bool              `json:"cancel_requested" example:"false"`
+	ResultFilename  string            `json:"result_filename,omitempty" example:"users.csv"`
+	ResultSize      int64             `json:"result_size,omitempty" example:"1048576"`
+	RunAt           time.Time         `json:"run_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy       string            `json:"created_by" example:"507f1f77bcf86cd799439011"`
+	CreatedAt       time.Time         `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt       time.Time         `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	StartedAt       *time.Time        `json:"started_at,omitempty" example:"2021-01-01T00:00:01Z"`
+	FinishedAt      *time.Time        `json:"finished_at,omitempty" example:"2021-01-01T00:01:00Z"`
+}
+
+// NewJobView 建立工作的公開表示
+func NewJobView(job Job) JobView {
+	view := JobView{
+		ID:              job.ID.Hex(),
+		Kind:            job.Kind,
+		Status:          job.Status,
+		Params:          job.Params,
+		Progress:        job.Progress,
+		Attempts:        job.Attempts,
+		MaxAttempts:     job.MaxAttempts,
+		Errors:          job.Errors,
+		CancelRequested: job.CancelRequested,
+		RunAt:           job.RunAt,
+		CreatedBy:       job.CreatedBy,
+		CreatedAt:       job.CreatedAt,
+		UpdatedAt:       job.UpdatedAt,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+	if job.Result != nil {
+		view.ResultFilename = job.Result.Filename
+		view.ResultSize = job.Result.Size
+	}
+	return view
+}
+
+// JobResponse 單一工作響應
+// @Description 符合 HATEOAS 的背景工作響應結構，完成後 result 連結提供結果檔案的下載
+type JobResponse struct {
+	Data  JobView       `json:"data"`
+	Links []HATEOASLink `json:"_links"`
+}
+
+// GenerateJobLinks 產生工作的 HATEOAS 連結；未結束的工作可以取消，成功且有結果檔案時可以下載
+func GenerateJobLinks(baseURL string, job Job) []HATEOASLink {
+	jobURL := baseURL + "/jobs/" + job.ID.Hex()
+	links := []HATEOASLink{
+		{Href: jobURL, Rel: "self", Method: "GET", Title: "Get job"},
+	}
+	if !job.Status.Finished() {
+		links = append(links, HATEOASLink{Href: jobURL + "/cancel", Rel: "cancel", Method: "POST", Title: "Cancel job"})
+	}
+	if job.Status == JobSucceeded && job.Result != nil {
+		links = append(links, HATEOASLink{Href: jobURL + "/result", Rel: "result", Method: "GET", Title: "Download job result"})
+	}
+	return links
+}
@@ -0,0 +1,117 @@
+package user_models
+
+import "time"
+
+// UserStatus 使用者生命週期狀態
+type UserStatus string
+
+// 使用者生命週期狀態
+const (
+	StatusPendingVerification UserStatus = "pending_verification"
+	StatusActive              UserStatus = "active"
+	StatusSuspended           UserStatus = "suspended"
+	StatusLocked              UserStatus = "locked"
+	StatusDeactivated         UserStatus = "deactivated"
+	StatusDeleted             UserStatus = "deleted"
+)
+
+// Effective 回傳實際狀態；建立生命週期前的舊資料沒有狀態，視為 active
+func (s UserStatus) Effective() UserStatus {
+	if s == "" {
+		return StatusActive
+	}
+	return s
+}
+
+// CanAuthenticate 只有 active 狀態可以登入或使用權杖
+func (s UserStatus) CanAuthenticate() bool {
+	return s.Effective() == StatusActive
+}
+
+// StatusTransition 一筆狀態變更紀錄
+// @Description 使用者狀態變更紀錄
+type StatusTransition struct {
+	From   UserStatus `bson:"from" json:"from" example:"active"`
+	To     UserStatus `bson:"to" json:"to" example:"suspended"`
+	Action string     `bson:"action" json:"action" example:"suspend"`
+	Reason string     `bson:"reason,omitempty" json:"reason,omitempty" example:"違反使用規範"`
+	Actor  string     `bson:"actor" json:"actor" example:"507f1f77bcf86cd799439011"`
+	At     time.Time  `bson:"at" json:"at" example:"2021-01-01T00:00:00Z"`
+}
+
+// TransitionRequest 狀態變更請求
+// @Description 狀態變更請求
+type TransitionRequest struct {
+	Reason string `json:"reason" binding:"max=500" example:"違反使用規範"`
+}
+
+// StatusHistoryResponse 狀態變更歷史響應
+// @Description 使用者狀態變更歷史
+type StatusHistoryResponse struct {
+	Status  UserStatus         `json:"status" example:"suspended"`
+	History []StatusTransition `json:"history"`
+	Links   []HATEOASLink      `json:"_links"`
+}
+
+// LifecycleAction 將狀態轉換公開為 API 動作
+type LifecycleAction struct {
+	Name           string
+	From           []UserStatus
+	To             UserStatus
+	RequiresReason bool
+	Title          string
+}
+
+// LifecycleActions 所有合法的狀態轉換
+var LifecycleActions = []LifecycleAction{
+	{Name: "activate", From: []UserStatus{StatusPendingVerification}, To: StatusActive, Title: "Activate user"},
+	{Name: "suspend", From: []UserStatus{StatusActive, StatusLocked}, To: StatusSuspended, RequiresReason: true, Title: "Suspend user"},
+	{Name: "reinstate", From: []UserStatus{StatusSuspended}, To: StatusActive, Title: "Reinstate user"},
+	{Name: "lock", From: []UserStatus{StatusActive}, To: StatusLocked, Title: "Lock user"},
+	{Name: "unlock", From: []UserStatus{StatusLocked}, To: StatusActive, Title: "Unlock user"},
+	{Name: "deactivate", From: []UserStatus{StatusPendingVerification, StatusActive, StatusSuspended, StatusLocked}, To: StatusDeactivated, Title: "Deactivate user"},
+	{Name: "reactivate", From: []UserStatus{StatusDeactivated}, To: StatusActive, Title: "Reactivate user"},
+	{Name: "delete", From: []UserStatus{StatusPendingVerification, StatusActive, StatusSuspended, StatusLocked, StatusDeactivated}, To: StatusDeleted, Title: "Delete user"},
+}
+
+// Allows 判斷動作是否可由指定狀態執行
+func (a LifecycleAction) Allows(from UserStatus) bool {
+	from = from.Effective()
+	for _, s := range a.From {
+		if s == from {
+			return true
+		}
+	}
+	return false
+}
+
+// FindLifecycleAction 依名稱取得狀態轉換動作
+func FindLifecycleAction(name string) (LifecycleAction, bool) {
+	for _, action := range LifecycleActions {
+		if action.Name == name {
+			return action, true
+		}
+	}
+	return LifecycleAction{}, false
+}
+
+// FindTransition 取得從 from 到 to 的狀態轉換動作
+func FindTransition(from, to UserStatus) (LifecycleAction, bool) {
+	for _, action := range LifecycleActions {
+		if action.To == to && action.Allows(from) {
+			return action, true
+		}
+	}
+	return LifecycleAction{}, false
+}
+
+// AvailableActions 回傳目前狀態可以執行的動作
+func AvailableActions(status UserStatus) []LifecycleAction {
+	var actions []LifecycleAction
+	for _, action := range LifecycleActions {
+		if action.Allows(status) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
@@ -0,0 +1,153 @@
+package user_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OIDCClient 已註冊的 OpenID Connect 用戶端
+// @Description 已註冊的 OpenID Connect 用戶端
+type OIDCClient struct {
+	ID                      primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	ClientID                string             `bson:"client_id" json:"client_id" example:"c3VwZXItYXBw"`
+	ClientSecretHash        string             `bson:"client_secret_hash,omitempty" json:"-"` // 只保存雜湊值
+	ClientName              string             `bson:"client_name" json:"client_name" example:"內部管理系統"`
+	RedirectURIs            []string           `bson:"redirect_uris" json:"redirect_uris"`
+	GrantTypes              []string           `bson:"grant_types" json:"grant_types"`
+	ResponseTypes           []string           `bson:"response_types" json:"response_types"`
+	Scope                   string             `bson:"scope" json:"scope" example:"openid profile email"`
+	TokenEndpointAuthMethod string             `bson:"token_endpoint_auth_method" json:"token_endpoint_auth_method" example:"client_secret_basic"`
+	CreatedAt               time.Time          `bson:"created_at" json:"-"`
+}
+
+// IsPublic 判斷用戶端是否為無密鑰的公開用戶端
+func (c *OIDCClient) IsPublic() bool {
+	return c.TokenEndpointAuthMethod == "none"
+}
+
+// HasRedirectURI 判斷 redirect_uri 是否已註冊（必須完全相符）
+func (c *OIDCClient) HasRedirectURI(uri string) bool {
+	for _, registered := range c.RedirectURIs {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientRegistrationRequest 動態用戶端註冊請求 (RFC 7591)
+// @Description 動態用戶端註冊請求
+type ClientRegistrationRequest struct {
+	ClientName              string   `json:"client_name" binding:"required" example:"內部管理系統"`
+	RedirectURIs            []string `json:"redirect_uris" binding:"required,min=1,dive,url"`
+	GrantTypes              []string `json:"grant_types,omitempty"`
+	ResponseTypes           []string `json:"response_types,omitempty"`
+	Scope                   string   `json:"scope,omitempty" example:"openid profile email"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method,omitempty" example:"client_secret_basic"`
+}
+
+// ClientRegistrationResponse 動態用戶端註冊響應
+// @Description 動態用戶端註冊響應，client_secret 僅在此時回傳一次
+type ClientRegistrationResponse struct {
+	OIDCClient
+	ClientSecret          string `json:"client_secret,omitempty" example:"c2VjcmV0"`
+	ClientIDIssuedAt      int64  `json:"client_id_issued_at" example:"1700000000"`
+	ClientSecretExpiresAt int64  `json:"client_secret_expires_at" example:"0"`
+}
+
+// AuthorizationCode 授權碼文件，只保存授權碼的雜湊值
+type AuthorizationCode struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty"`
+	CodeHash            string             `bson:"code_hash"`
+	ClientID            string             `bson:"client_id"`
+	RedirectURI         string             `bson:"redirect_uri"`
+	UserID              primitive.ObjectID `bson:"user_id"`
+	Scope               string             `bson:"scope"`
+	Nonce               string             `bson:"nonce,omitempty"`
+	CodeChallenge       string             `bson:"code_challenge"`
+	CodeChallengeMethod string             `bson:"code_challenge_method"`
+	AuthTime            time.Time          `bson:"auth_time"`
+	UserAgent           string             `bson:"user_agent,omitempty"`
+	IPAddress           string             `bson:"ip_address,omitempty"`
+	ExpiresAt           time.Time          `bson:"expires_at"`
+	Used                bool               `bson:"used"`
+}
+
+// SigningKeyDocument 保存於 MongoDB 的簽章金鑰
+type SigningKeyDocument struct {
+	KeyID         string    `bson:"_id"`
+	PrivateKeyPEM string    `bson:"private_key_pem"`
+	CreatedAt     time.Time `bson:"created_at"`
+	Supersedes    *string   `bson:"supersedes,omitempty"` // 被取代的金鑰 kid，第一把金鑰為空字串；唯一索引確保每把金鑰只被輪替一次
+}
+
+// JSONWebKey 公開的 RSA 驗證金鑰
+// @Description JSON Web Key
+type JSONWebKey struct {
+	Kty string `json:"kty" example:"RSA"`
+	Use string `json:"use" example:"sig"`
+	Alg string `json:"alg" example:"RS256"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e" example:"AQAB"`
+}
+
+// JSONWebKeySet JWKS 響應
+// @Description JSON Web Key Set
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// OIDCDiscoveryDocument OpenID Provider 中繼資料
+// @Description OpenID Connect Discovery 文件
+type OIDCDiscoveryDocument struct {
+	Issuer                            string   `json:"issuer" example:"http://localhost:8080"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	RegistrationEndpoint              string   `json:"registration_endpoint,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// TokenResponse 權杖端點響應
+// @Description OAuth 2.0 權杖響應
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	ExpiresIn   int    `json:"expires_in" example:"3600"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope,omitempty" example:"openid profile email"`
+}
+
+// OAuthError OAuth 2.0 錯誤響應 (RFC 6749 §5.2)
+// @Description OAuth 2.0 錯誤響應
+type OAuthError struct {
+	Error            string `json:"error" example:"invalid_grant"`
+	ErrorDescription string `json:"error_description,omitempty" example:"authorization code expired"`
+}
+
+// UserInfoAddress OIDC address 宣告
+type UserInfoAddress struct {
+	Formatted string `json:"formatted" example:"台北市"`
+}
+
+// UserInfo 依 scope 對應 User 欄位的 userinfo 宣告
+// @Description OpenID Connect UserInfo 響應
+type UserInfo struct {
+	Subject       string           `json:"sub" example:"507f1f77bcf86cd799439011"`
+	Name          string           `json:"name,omitempty" example:"張三"`
+	Gender        string           `json:"gender,omitempty" example:"男"`
+	Email         string           `json:"email,omitempty" example:"zhangsan@example.com"`
+	EmailVerified *bool            `json:"email_verified,omitempty"`
+	PhoneNumber   string           `json:"phone_number,omitempty" example:"1234567890"`
+	Address       *UserInfoAddress `json:"address,omitempty"`
+}
@@ -0,0 +1,31 @@
+package user_models
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword 以 bcrypt 雜湊密碼或用戶端密鑰
+func HashPassword(plain string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// IsPasswordHashed 判斷字串是否已是 bcrypt 雜湊
+func IsPasswordHashed(value string) bool {
+	return strings.HasPrefix(value, "$2a$") || strings.HasPrefix(value, "$2b$") || strings.HasPrefix(value, "$2y$")
+}
+
+// CheckPassword 比對明文與已保存的密碼
+// 舊資料可能仍以明文保存，此時以常數時間比較
+func CheckPassword(stored, plain string) bool {
+	if IsPasswordHashed(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(plain)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(plain)) == 1
+}
@@ -8,20 +8,20 @@ type HATEOASLink struct {
 	Href   string `json:"href" example:"http://api.example.com/users/1"` // 連結目標 URL
 	Rel    string `json:"rel" example:"self"`                            // 關係類型
 	Method string `json:"method" example:"GET"`                          // HTTP 方法
-	Title  string `json:"title,omitempty" example:"取得使用者資訊"`             // 連結描述
+	Title  string `json:"title,omitempty" example:"Get user"`            // 連結描述
 }
 
 // UserResponse 為符合 HATEOAS 的使用者響應結構
 // @Description 符合 HATEOAS 的使用者響應結構
 type UserResponse struct {
-	Data  User          `json:"data"`   // 使用者資料
+	Data  UserView      `json:"data"`   // 使用者資料
 	Links []HATEOASLink `json:"_links"` // HATEOAS 連結
 }
 
 // UsersCollectionResponse 為包含多個使用者的 HATEOAS 響應結構
 // @Description 符合 HATEOAS 的多使用者響應結構
 type UsersCollectionResponse struct {
-	Data  []User        `json:"data"`                // 使用者資料陣列
+	Data  []UserView    `json:"data"`                // 使用者資料陣列
 	Links []HATEOASLink `json:"_links"`              // HATEOAS 連結
 	Page  int           `json:"page" example:"1"`    // 頁碼
 	Size  int           `json:"size" example:"10"`   // 每頁大小
@@ -36,31 +36,75 @@ type APIResponse struct {
 	Data    interface{}   `json:"data,omitempty"`                 // 響應資料 (可選)
 	Links   []HATEOASLink `json:"_links,omitempty"`               // HATEOAS 連結 (可選)
 	Error   string        `json:"error,omitempty" example:"錯誤訊息"` // 錯誤訊息 (可選)
+	Errors  []FieldError  `json:"errors,omitempty"`               // 欄位驗證錯誤 (可選)
+}
+
+// FieldError 單一欄位的驗證錯誤
+// @Description 欄位驗證錯誤，field 為 JSON 欄位名稱，code 為驗證規則
+type FieldError struct {
+	Field   string `json:"field" example:"email"`             // JSON 欄位名稱
+	Code    string `json:"code" example:"email"`              // 未通過的驗證規則
+	Message string `json:"message" example:"email必須是一個有效的信箱"` // 依 Accept-Language 翻譯的訊息
 }
 
 // GenerateUserLinks 產生使用者的 HATEOAS 連結
-// @Description 產生使用者的 HATEOAS 連結
-func GenerateUserLinks(baseURL string, userID string) []HATEOASLink {
-	return []HATEOASLink{
+// @Description 產生使用者的 HATEOAS 連結，只列出目前狀態可執行的狀態轉換
+func GenerateUserLinks(baseURL string, userID string, status UserStatus) []HATEOASLink {
+	userURL := baseURL + "/users/" + userID
+	links := []HATEOASLink{
 		{
-			Href:   baseURL + "/users/" + userID,
+			Href:   userURL,
 			Rel:    "self",
 			Method: "GET",
-			Title:  "取得使用者資訊",
+			Title:  "Get user",
 		},
-		{
-			Href:   baseURL + "/users/" + userID,
+	}
+
+	if status.Effective() != StatusDeleted {
+		links = append(links, HATEOASLink{
+			Href:   userURL,
 			Rel:    "update",
 			Method: "PUT",
-			Title:  "更新使用者資訊",
-		},
-		{
-			Href:   baseURL + "/users/" + userID,
-			Rel:    "delete",
-			Method: "DELETE",
-			Title:  "刪除使用者",
-		},
+			Title:  "Update user",
+		}, HATEOASLink{
+			Href:   userURL,
+			Rel:    "partial-update",
+			Method: "PATCH",
+			Title:  "Partially update user",
+		}, HATEOASLink{
+			Href:   userURL + "/avatar",
+			Rel:    "avatar",
+			Method: "GET",
+			Title:  "Get avatar",
+		})
 	}
+
+	for _, action := range AvailableActions(status) {
+		link := HATEOASLink{
+			Href:   userURL + "/" + action.Name,
+			Rel:    action.Name,
+			Method: "POST",
+			Title:  action.Title,
+		}
+		if action.Name == "delete" {
+			link.Href, link.Method = userURL, "DELETE"
+		}
+		links = append(links, link)
+	}
+
+	links = append(links, HATEOASLink{
+		Href:   userURL + "/status-history",
+		Rel:    "status-history",
+		Method: "GET",
+		Title:  "Get status history",
+	}, HATEOASLink{
+		Href:   userURL + "/versions",
+		Rel:    "versions",
+		Method: "GET",
+		Title:  "List user versions",
+	})
+
+	return links
 }
 
 // GenerateUsersCollectionLinks 產生使用者集合的 HATEOAS 連結
@@ -71,13 +115,19 @@ func GenerateUsersCollectionLinks(baseURL string, page int, size int, total int)
 			Href:   baseURL + "/users",
 			Rel:    "self",
 			Method: "GET",
-			Title:  "取得使用者列表",
+			Title:  "List users",
 		},
 		{
 			Href:   baseURL + "/users",
 			Rel:    "create",
 			Method: "POST",
-			Title:  "建立新使用者",
+			Title:  "Create user",
+		},
+		{
+			Href:   baseURL + "/users/export",
+			Rel:    "export",
+			Method: "GET",
+			Title:  "Export users",
 		},
 	}
 
@@ -87,7 +137,7 @@ func GenerateUsersCollectionLinks(baseURL string, page int, size int, total int)
 			Href:   baseURL + "/users?page=" + strconv.Itoa(page-1) + "&size=" + strconv.Itoa(size),
 			Rel:    "prev",
 			Method: "GET",
-			Title:  "上一頁使用者",
+			Title:  "Previous page of users",
 		})
 	}
 
@@ -97,7 +147,7 @@ func GenerateUsersCollectionLinks(baseURL string, page int, size int, total int)
 			Href:   baseURL + "/users?page=" + strconv.Itoa(page+1) + "&size=" + strconv.Itoa(size),
 			Rel:    "next",
 			Method: "GET",
-			Title:  "下一頁使用者",
+			Title:  "Next page of users",
 		})
 	}
 
@@ -0,0 +1,98 @@
+package user_models
+
+// SCIM 2.0 (RFC 7643 / RFC 7644) 使用的 schema URN
+const (
+	SCIMSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMSchemaProfileExtension      = "urn:go-api-for-main:scim:schemas:extension:profile:2.0:User"
+	SCIMSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SCIMSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMSchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SCIMSchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+// SCIMUser SCIM 使用者資源，對應 User 的欄位
+// @Description SCIM 2.0 使用者資源
+type SCIMUser struct {
+	Schemas      []string              `json:"schemas"`
+	ID           string                `json:"id,omitempty" example:"507f1f77bcf86cd799439011"`
+	UserName     string                `json:"userName" example:"zhangsan@example.com"`
+	Name         *SCIMName             `json:"name,omitempty"`
+	DisplayName  string                `json:"displayName,omitempty" example:"張三"`
+	Password     string                `json:"password,omitempty"` // 僅寫入，不會在響應中出現
+	Active       *bool                 `json:"active,omitempty"`
+	Emails       []SCIMMultiValued     `json:"emails,omitempty"`
+	PhoneNumbers []SCIMMultiValued     `json:"phoneNumbers,omitempty"`
+	Addresses    []SCIMAddress         `json:"addresses,omitempty"`
+	Profile      *SCIMProfileExtension `json:"urn:go-api-for-main:scim:schemas:extension:profile:2.0:User,omitempty"`
+	Meta         *SCIMMeta             `json:"meta,omitempty"`
+}
+
+// SCIMName SCIM name 複合屬性
+type SCIMName struct {
+	Formatted string `json:"formatted,omitempty" example:"張三"`
+}
+
+// SCIMMultiValued SCIM 多值屬性（emails、phoneNumbers）
+type SCIMMultiValued struct {
+	Value   string `json:"value" example:"zhangsan@example.com"`
+	Type    string `json:"type,omitempty" example:"work"`
+	Primary bool   `json:"primary,omitempty" example:"true"`
+}
+
+// SCIMAddress SCIM addresses 多值屬性
+type SCIMAddress struct {
+	Formatted string `json:"formatted" example:"台北市"`
+	Type      string `json:"type,omitempty" example:"home"`
+	Primary   bool   `json:"primary,omitempty" example:"true"`
+}
+
+// SCIMProfileExtension 承載性別、年齡與地址的擴充 schema
+type SCIMProfileExtension struct {
+	Sex     string `json:"sex,omitempty" example:"男"`
+	Age     *int   `json:"age,omitempty" example:"20"`
+	Address string `json:"address,omitempty" example:"台北市"`
+}
+
+// SCIMMeta SCIM 資源中繼資料
+type SCIMMeta struct {
+	ResourceType string `json:"resourceType" example:"User"`
+	Created      string `json:"created,omitempty" example:"2021-01-01T00:00:00Z"`
+	LastModified string `json:"lastModified,omitempty" example:"2021-01-01T00:00:00Z"`
+	Location     string `json:"location,omitempty"`
+	Version      string `json:"version,omitempty" example:"W/\"3694e05e9dff590\""`
+}
+
+// SCIMListResponse SCIM 查詢結果
+// @Description SCIM 2.0 查詢結果
+type SCIMListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int64         `json:"totalResults" example:"100"`
+	StartIndex   int           `json:"startIndex" example:"1"`
+	ItemsPerPage int           `json:"itemsPerPage" example:"10"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// SCIMPatchOperation 單一 PATCH 操作
+type SCIMPatchOperation struct {
+	Op    string      `json:"op" example:"replace"`
+	Path  string      `json:"path,omitempty" example:"name.formatted"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// SCIMPatchRequest SCIM PATCH 請求
+// @Description SCIM 2.0 PatchOp 請求
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMError SCIM 錯誤響應
+// @Description SCIM 2.0 錯誤響應
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status" example:"400"`
+	ScimType string   `json:"scimType,omitempty" example:"invalidFilter"`
+	Detail   string   `json:"detail,omitempty"`
+}
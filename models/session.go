@@ -0,0 +1,61 @@
+package user_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session 一次登入所簽發的存取權杖，權杖以 sid 宣告指向此紀錄
+// @Description 登入工作階段
+type Session struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id" example:"507f1f77bcf86cd799439013"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"-"`
+	ClientID  string             `bson:"client_id" json:"client_id" example:"my-app"`
+	UserAgent string             `bson:"user_agent,omitempty" json:"user_agent,omitempty" example:"Mozilla/5.0"`
+	IPAddress string             `bson:"ip_address,omitempty" json:"ip_address,omitempty" example:"203.0.113.7"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at" example:"2021-01-01T00:00:00Z"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at" example:"2021-01-01T01:00:00Z"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"-"`
+	Current   bool               `bson:"-" json:"current" example:"true"` // 是否為目前請求所使用的工作階段
+}
+
+// SessionsCollectionResponse 工作階段列表響應
+// @Description 符合 HATEOAS 的工作階段列表響應結構
+type SessionsCollectionResponse struct {
+	Data  []SessionResource `json:"data"`
+	Links []HATEOASLink     `json:"_links"`
+}
+
+// SessionResource 附帶連結的工作階段
+// @Description 附帶 HATEOAS 連結的工作階段
+type SessionResource struct {
+	Session
+	Links []HATEOASLink `json:"_links"`
+}
+
+// UpdatePasswordRequest 變更密碼請求
+// @Description 變更密碼請求，必須提供目前的密碼
+type UpdatePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"old-secret"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,nefield=CurrentPassword" example:"new-s3cret"`
+}
+
+// GenerateMeLinks 產生目前使用者的 HATEOAS 連結
+func GenerateMeLinks(baseURL string) []HATEOASLink {
+	meURL := baseURL + "/me"
+	return []HATEOASLink{
+		{Href: meURL, Rel: "self", Method: "GET", Title: "Get my profile"},
+		{Href: meURL, Rel: "update", Method: "PATCH", Title: "Update my profile"},
+		{Href: meURL + "/password", Rel: "change-password", Method: "POST", Title: "Change password"},
+		{Href: meURL + "/sessions", Rel: "sessions", Method: "GET", Title: "List my sessions"},
+		{Href: meURL, Rel: "deactivate", Method: "DELETE", Title: "Deactivate my account"},
+	}
+}
+
+// GenerateSessionLinks 產生工作階段的 HATEOAS 連結
+func GenerateSessionLinks(baseURL string, sessionID string) []HATEOASLink {
+	return []HATEOASLink{
+		{Href: baseURL + "/me/sessions/" + sessionID, Rel: "revoke", Method: "DELETE", Title: "Sign out this session"},
+	}
+}
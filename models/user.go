@@ -6,24 +6,40 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// User 模型
+// User 保存於資料庫的用戶文件，請求與響應分別使用 CreateUserRequest 等請求型別與 UserView
 // @Description 用戶模型
 type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty" example:"507f1f77bcf86cd799439011"`
-	Name      string             `bson:"name" json:"name" binding:"required" example:"張三"`
-	Email     string             `bson:"email" json:"email" binding:"required,email" example:"zhangsan@example.com"`
-	Password  string             `bson:"password" json:"-" binding:"required"` // 密碼不會在 JSON 中返回
-	Sex       string             `bson:"sex" json:"sex" binding:"required" example:"男"`
-	Age       int                `bson:"age" json:"age" binding:"required" example:"20"`
-	Phone     string             `bson:"phone" json:"phone" binding:"required" example:"1234567890"`
-	Address   string             `bson:"address" json:"address" binding:"required" example:"台北市"`
+	Name      string             `bson:"name" json:"name" example:"張三"`
+	Email     string             `bson:"email" json:"email" example:"zhangsan@example.com"`
+	Password  string             `bson:"password" json:"-"` // 密碼不會在 JSON 中返回
+	Sex       string             `bson:"sex" json:"sex" example:"male"`
+	Age       int                `bson:"age" json:"age" example:"20"`
+	Phone     string             `bson:"phone" json:"phone" example:"+886912345678"`
+	Address   string             `bson:"address" json:"address" example:"台北市"`
+	Role      string             `bson:"role,omitempty" json:"role,omitempty" example:"member"`
+	Status    UserStatus         `bson:"status,omitempty" json:"status" example:"active"` // 由生命週期端點管理
 	CreatedAt time.Time          `bson:"created_at" json:"created_at" example:"2021-01-01T00:00:00Z"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy string             `bson:"created_by,omitempty" json:"created_by,omitempty" example:"507f1f77bcf86cd799439011"` // 建立者
+	UpdatedBy string             `bson:"updated_by,omitempty" json:"updated_by,omitempty" example:"507f1f77bcf86cd799439011"` // 最後修改者
+	Version   int64              `bson:"version" json:"version" example:"1"`                                                  // 每次修改遞增，由伺服器管理
+
+	StatusHistory []StatusTransition `bson:"status_history,omitempty" json:"-"` // 狀態變更歷史
+	Avatar        *Avatar            `bson:"avatar,omitempty" json:"-"`         // 頭像，由頭像端點管理
+	Outbox        []OutboxEvent      `bson:"outbox,omitempty" json:"-"`         // 尚未轉送的 webhook 事件
 }
 
+// 使用者角色
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
 // ErrorResponse 錯誤響應結構
 type ErrorResponse struct {
-	Error string `json:"error" example:"error message"`
+	Error  string       `json:"error" example:"error message"`
+	Errors []FieldError `json:"errors,omitempty"` // 欄位驗證錯誤
 }
 
 // SuccessResponse 成功響應結構
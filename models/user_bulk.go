@@ -0,0 +1,95 @@
+package user_models
+
+import "encoding/json"
+
+// 批次操作的種類
+const (
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+)
+
+// 批次操作的執行模式
+const (
+	// BulkModeOrdered 依序執行，遇到第一個失敗即停止，之前的操作維持已寫入
+	BulkModeOrdered = "ordered"
+	// BulkModeUnordered 執行所有通過檢查的操作，個別失敗不影響其他操作
+	BulkModeUnordered = "unordered"
+	// BulkModeAtomic 在交易中執行，任一操作失敗時全部不寫入
+	BulkModeAtomic = "atomic"
+)
+
+// MaxBulkOperations 單次批次請求的操作上限；每個 create 都要計算 bcrypt 雜湊，上限過高會讓單一請求佔用過久
+const MaxBulkOperations = 100
+
+// BulkOperation 批次中的單一操作；create 的 data 與建立用戶相同，update 的 data 與部分更新相同，delete 不需要 data
+// @Description 批次中的單一操作
+type BulkOperation struct {
+	Op   string          `json:"op" binding:"required,oneof=create update delete" example:"update"`
+	ID   string          `json:"id,omitempty" example:"507f1f77bcf86cd799439011"`
+	Data json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+// BulkRequest 批次建立、更新與刪除用戶的請求
+// @Description 批次操作請求，mode 預設為 ordered
+type BulkRequest struct {
+	Mode       string          `json:"mode" binding:"omitempty,oneof=ordered unordered atomic" example:"ordered"`
+	Operations []BulkOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// EffectiveMode 回傳實際的執行模式，未指定時為 ordered
+func (r BulkRequest) EffectiveMode() string {
+	if r.Mode == "" {
+		return BulkModeOrdered
+	}
+	return r.Mode
+}
+
+// BulkItemResult 單一操作的結果，status 沿用對應單筆端點的 HTTP 狀態碼
+// @Description 批次中單一操作的結果
+type BulkItemResult struct {
+	Index  int           `json:"index" example:"0"`
+	Op     string        `json:"op" example:"update"`
+	ID     string        `json:"id,omitempty" example:"507f1f77bcf86cd799439011"`
+	Status int           `json:"status" example:"200"`
+	Error  string        `json:"error,omitempty" example:"email is already in use"`
+	Errors []FieldError  `json:"errors,omitempty"`
+	Data   *UserView     `json:"data,omitempty"`
+	Links  []HATEOASLink `json:"_links,omitempty"`
+}
+
+// Succeeded 判斷操作是否成功寫入
+func (r BulkItemResult) Succeeded() bool {
+	return r.Status >= 200 && r.Status < 300
+}
+
+// BulkResponse 批次操作響應
+// @Description 批次操作響應，results 與請求中的 operations 順序相同
+type BulkResponse struct {
+	Mode      string           `json:"mode" example:"ordered"`
+	Succeeded int              `json:"succeeded" example:"2"`
+	Failed    int              `json:"failed" example:"1"`
+	Results   []BulkItemResult `json:"results"`
+	Links     []HATEOASLink    `json:"_links"`
+}
+
+// NewBulkResponse 統計各操作的結果並建立響應
+func NewBulkResponse(mode string, results []BulkItemResult, links []HATEOASLink) BulkResponse {
+	response := BulkResponse{Mode: mode, Results: results, Links: links}
+	for _, result := range results {
+		if result.Succeeded() {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	return response
+}
+
+// GenerateBulkLinks 產生批次操作響應的 HATEOAS 連結
+func GenerateBulkLinks(baseURL string) []HATEOASLink {
+	return []HATEOASLink{
+		{Href: baseURL + "/users/bulk", Rel: "self", Method: "POST", Title: "Run bulk user operations"},
+		{Href: baseURL + "/users", Rel: "users", Method: "GET", Title: "List users"},
+	}
+}
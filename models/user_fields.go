@@ -0,0 +1,87 @@
+package user_models
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// selectableUserFields fields 參數可選擇的欄位，鍵為 UserView 的 JSON 名稱，值為資料庫欄位；
+// 密碼、狀態歷史等內部欄位不在其中
+var selectableUserFields = map[string]string{
+	"id":         "_id",
+	"name":       "name",
+	"email":      "email",
+	"sex":        "sex",
+	"age":        "age",
+	"phone":      "phone",
+	"address":    "address",
+	"role":       "role",
+	"status":     "status",
+	"version":    "version",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"created_by": "created_by",
+	"updated_by": "updated_by",
+}
+
+// FieldSet 用戶回應的欄位選擇，nil 表示所有欄位
+type FieldSet []string
+
+// ParseFieldSet 解析以逗號分隔的欄位清單，空字串回傳 nil；遇到不允許的欄位時回傳該欄位與 false
+func ParseFieldSet(value string) (FieldSet, string, bool) {
+	if strings.TrimSpace(value) == "" {
+		return nil, "", true
+	}
+	fields := FieldSet{}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := selectableUserFields[name]; !ok {
+			return nil, name, false
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	return fields, "", true
+}
+
+// Projection 產生 MongoDB 投影；status 與 version 一律載入，用於產生連結與 ETag
+func (f FieldSet) Projection() bson.M {
+	projection := bson.M{"_id": 1, "status": 1, "version": 1}
+	for _, name := range f {
+		projection[selectableUserFields[name]] = 1
+	}
+	return projection
+}
+
+// Select 回傳只輸出選擇欄位的用戶表示，id 一律輸出
+func (v UserView) Select(fields FieldSet) UserView {
+	v.fields = fields
+	return v
+}
+
+// MarshalJSON 有選擇欄位時只輸出這些欄位與 id
+func (v UserView) MarshalJSON() ([]byte, error) {
+	type plain UserView
+	raw, err := json.Marshal(plain(v))
+	if err != nil || v.fields == nil {
+		return raw, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+	selected := map[string]json.RawMessage{"id": all["id"]}
+	for _, name := range v.fields {
+		if value, ok := all[name]; ok {
+			selected[name] = value
+		}
+	}
+	return json.Marshal(selected)
+}
@@ -0,0 +1,110 @@
+package user_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 匯入時 email 已存在的處理方式
+const (
+	ImportOnDuplicateSkip   = "skip"
+	ImportOnDuplicateUpdate = "update"
+	ImportOnDuplicateFail   = "fail"
+)
+
+// 匯入資料列的結果
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionSkip   = "skip"
+	ImportActionError  = "error"
+)
+
+// ImportOptions 匯入用戶的表單欄位，檔案放在 file 欄位
+type ImportOptions struct {
+	Format      string `form:"format" binding:"omitempty,oneof=csv ndjson xlsx"`
+	Mapping     string `form:"mapping"`
+	OnDuplicate string `form:"on_duplicate" binding:"omitempty,oneof=skip update fail"`
+	DryRun      bool   `form:"dry_run"`
+}
+
+// DuplicatePolicy 回傳 email 已存在時的處理方式，未指定時為 fail
+func (o ImportOptions) DuplicatePolicy() string {
+	if o.OnDuplicate == "" {
+		return ImportOnDuplicateFail
+	}
+	return o.OnDuplicate
+}
+
+// ImportRowResult 單一資料列的匯入結果，dry run 時為預計執行的動作
+// @Description 單一資料列的匯入結果
+type ImportRowResult struct {
+	Row    int          `json:"row" example:"2"`
+	Action string       `json:"action" example:"create"`
+	ID     string       `json:"id,omitempty" example:"507f1f77bcf86cd799439011"`
+	Email  string       `json:"email,omitempty" example:"zhangsan@example.com"`
+	Error  string       `json:"error,omitempty" example:"email is already in use"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// ImportResponse 匯入結果
+// @Description 匯入結果，有失敗的資料列時 _links 提供錯誤報表的下載連結
+type ImportResponse struct {
+	DryRun  bool              `json:"dry_run" example:"false"`
+	Format  string            `json:"format" example:"csv"`
+	Created int               `json:"created" example:"10"`
+	Updated int               `json:"updated" example:"2"`
+	Skipped int               `json:"skipped" example:"0"`
+	Failed  int               `json:"failed" example:"1"`
+	Rows    []ImportRowResult `json:"rows"`
+	Links   []HATEOASLink     `json:"_links"`
+}
+
+// NewImportResponse 統計各資料列的結果並建立響應
+func NewImportResponse(format string, dryRun bool, rows []ImportRowResult) ImportResponse {
+	response := ImportResponse{DryRun: dryRun, Format: format, Rows: rows}
+	for _, row := range rows {
+		switch row.Action {
+		case ImportActionCreate:
+			response.Created++
+		case ImportActionUpdate:
+			response.Updated++
+		case ImportActionSkip:
+			response.Skipped++
+		default:
+			response.Failed++
+		}
+	}
+	return response
+}
+
+// ImportReport 匯入失敗資料列的報表，保留原始欄位供修正後重新匯入
+type ImportReport struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Format    string             `bson:"format"`
+	DryRun    bool               `bson:"dry_run"`
+	Headers   []string           `bson:"headers"`
+	Rows      []ImportFailedRow  `bson:"rows"`
+	CreatedBy string             `bson:"created_by"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// ImportFailedRow 失敗的資料列與錯誤訊息
+type ImportFailedRow struct {
+	Row    int      `bson:"row"`
+	Values []string `bson:"values"`
+	Errors []string `bson:"errors"`
+}
+
+// GenerateImportLinks 產生匯入結果的 HATEOAS 連結，reportID 為空表示沒有錯誤報表
+func GenerateImportLinks(baseURL, reportID string) []HATEOASLink {
+	links := []HATEOASLink{
+		{Href: baseURL + "/users/import", Rel: "self", Method: "POST", Title: "Import users"},
+		{Href: baseURL + "/users", Rel: "users", Method: "GET", Title: "List users"},
+	}
+	if reportID != "" {
+		links = append(links, HATEOASLink{Href: baseURL + "/users/import/" + reportID + "/errors", Rel: "errors", Method: "GET", Title: "Download import error report"})
+	}
+	return links
+}
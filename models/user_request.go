@@ -0,0 +1,149 @@
+package user_models
+
+import "time"
+
+// CreateUserRequest 建立用戶請求
+// @Description 建立用戶請求，id、狀態與時間戳記由伺服器設定
+type CreateUserRequest struct {
+	Name     string `json:"name" binding:"required,person_name" normalize:"name" example:"張三"`
+	Email    string `json:"email" binding:"required,email" normalize:"email" example:"zhangsan@example.com"`
+	Password string `json:"password" binding:"required,min=8" example:"s3cret-pass"`
+	Sex      string `json:"sex" binding:"required,sex" normalize:"sex" example:"male"`
+	Age      int    `json:"age" binding:"required,age" example:"20"`
+	Phone    string `json:"phone" binding:"required,phone" normalize:"phone" example:"+886912345678"`
+	Address  string `json:"address" binding:"required" normalize:"trim" example:"台北市"`
+}
+
+// AdminCreateUserRequest 管理員建立用戶的請求，可以指定角色；只用於限管理員的批次、匯入與 gRPC
+// @Description 管理員建立用戶請求，role 預設為 member
+type AdminCreateUserRequest struct {
+	CreateUserRequest
+	Role string `json:"role" binding:"omitempty,oneof=admin member" example:"member"`
+}
+
+// UpdateUserRequest 以 PUT 取代用戶資料的請求，不包含密碼
+// @Description 以 PUT 取代用戶資料的請求，所有個人資料欄位都必須提供
+type UpdateUserRequest struct {
+	Name    string `json:"name" binding:"required,person_name" normalize:"name" example:"張三"`
+	Email   string `json:"email" binding:"required,email" normalize:"email" example:"zhangsan@example.com"`
+	Sex     string `json:"sex" binding:"required,sex" normalize:"sex" example:"male"`
+	Age     int    `json:"age" binding:"required,age" example:"20"`
+	Phone   string `json:"phone" binding:"required,phone" normalize:"phone" example:"+886912345678"`
+	Address string `json:"address" binding:"required" normalize:"trim" example:"台北市"`
+}
+
+// PatchUserRequest 部分更新用戶的請求，只有提供的欄位會被修改；提供的欄位與建立時使用相同的規則
+// @Description 部分更新用戶的請求，省略的欄位維持原值
+type PatchUserRequest struct {
+	Name    *string `json:"name,omitempty" binding:"omitnil,person_name" normalize:"name" example:"張三"`
+	Email   *string `json:"email,omitempty" binding:"omitnil,email" normalize:"email" example:"zhangsan@example.com"`
+	Sex     *string `json:"sex,omitempty" binding:"omitnil,sex" normalize:"sex" example:"male"`
+	Age     *int    `json:"age,omitempty" binding:"omitnil,age" example:"20"`
+	Phone   *string `json:"phone,omitempty" binding:"omitnil,phone" normalize:"phone" example:"+886912345678"`
+	Address *string `json:"address,omitempty" binding:"omitnil,min=1" normalize:"trim" example:"台北市"`
+}
+
+// UserView 用戶的對外表示，不包含密碼與狀態歷史；時間戳記與建立者、修改者由伺服器設定
+// @Description 用戶資料
+type UserView struct {
+	ID        string     `json:"id" example:"507f1f77bcf86cd799439011"`
+	Name      string     `json:"name" example:"張三"`
+	Email     string     `json:"email" example:"zhangsan@example.com"`
+	Sex       string     `json:"sex" example:"male"`
+	Age       int        `json:"age" example:"20"`
+	Phone     string     `json:"phone" example:"+886912345678"`
+	Address   string     `json:"address" example:"台北市"`
+	Role      string     `json:"role,omitempty" example:"member"`
+	Status    UserStatus `json:"status" example:"active"`
+	Version   int64      `json:"version" example:"1"`
+	CreatedAt time.Time  `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt time.Time  `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy string     `json:"created_by,omitempty" example:"507f1f77bcf86cd799439011"`
+	UpdatedBy string     `json:"updated_by,omitempty" example:"507f1f77bcf86cd799439011"`
+
+	fields FieldSet // 由 Select 設定，nil 時輸出所有欄位
+}
+
+// NewUserView 由保存的用戶建立對外表示
+func NewUserView(user User) UserView {
+	return UserView{
+		ID:        user.ID.Hex(),
+		Name:      user.Name,
+		Email:     user.Email,
+		Sex:       user.Sex,
+		Age:       user.Age,
+		Phone:     user.Phone,
+		Address:   user.Address,
+		Role:      user.Role,
+		Status:    user.Status.Effective(),
+		Version:   user.Version,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		CreatedBy: user.CreatedBy,
+		UpdatedBy: user.UpdatedBy,
+	}
+}
+
+// NewUserViews 由保存的用戶列表建立對外表示
+func NewUserViews(users []User) []UserView {
+	views := make([]UserView, 0, len(users))
+	for _, user := range users {
+		views = append(views, NewUserView(user))
+	}
+	return views
+}
+
+// ToUser 建立新的 member 用戶，password 必須是已雜湊的密碼；ID、狀態與時間戳記由呼叫端設定
+func (r CreateUserRequest) ToUser(password string) User {
+	return User{
+		Name:     r.Name,
+		Email:    r.Email,
+		Password: password,
+		Sex:      r.Sex,
+		Age:      r.Age,
+		Phone:    r.Phone,
+		Address:  r.Address,
+		Role:     RoleMember,
+	}
+}
+
+// ToUser 建立新的用戶並套用指定的角色，未指定時為 member
+func (r AdminCreateUserRequest) ToUser(password string) User {
+	user := r.CreateUserRequest.ToUser(password)
+	if r.Role != "" {
+		user.Role = r.Role
+	}
+	return user
+}
+
+// Apply 以請求內容取代用戶的個人資料欄位
+func (r UpdateUserRequest) Apply(user *User) {
+	user.Name = r.Name
+	user.Email = r.Email
+	user.Sex = r.Sex
+	user.Age = r.Age
+	user.Phone = r.Phone
+	user.Address = r.Address
+}
+
+// Apply 只修改請求中提供的欄位
+func (r PatchUserRequest) Apply(user *User) {
+	if r.Name != nil {
+		user.Name = *r.Name
+	}
+	if r.Email != nil {
+		user.Email = *r.Email
+	}
+	if r.Sex != nil {
+		user.Sex = *r.Sex
+	}
+	if r.Age != nil {
+		user.Age = *r.Age
+	}
+	if r.Phone != nil {
+		user.Phone = *r.Phone
+	}
+	if r.Address != nil {
+		user.Address = *r.Address
+	}
+}
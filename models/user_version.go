@@ -0,0 +1,116 @@
+package user_models
+
+import (
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserVersion 用戶在某個版本的完整快照，每次修改後寫入一筆
+type UserVersion struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	UserID   primitive.ObjectID `bson:"user_id"`
+	Version  int64              `bson:"version"`
+	Action   string             `bson:"action"`
+	Actor    string             `bson:"actor"`
+	At       time.Time          `bson:"at"`
+	Snapshot User               `bson:"snapshot"`
+}
+
+// UserVersionView 版本快照的對外表示，快照內容不包含密碼
+// @Description 用戶版本快照
+type UserVersionView struct {
+	Version int64     `json:"version" example:"3"`
+	Action  string    `json:"action" example:"user.update"`
+	Actor   string    `json:"actor" example:"507f1f77bcf86cd799439099"`
+	At      time.Time `json:"at" example:"2021-01-01T00:00:00Z"`
+	User    UserView  `json:"user"`
+}
+
+// NewUserVersion 建立修改後的版本快照；密碼雜湊與待轉送的事件不保存在歷史中
+func NewUserVersion(user User, action, actor string, at time.Time) UserVersion {
+	user.Password = ""
+	user.Outbox = nil
+	return UserVersion{
+		UserID:   user.ID,
+		Version:  user.Version,
+		Action:   action,
+		Actor:    actor,
+		At:       at,
+		Snapshot: user,
+	}
+}
+
+// NewUserVersionView 建立版本快照的對外表示
+func NewUserVersionView(version UserVersion) UserVersionView {
+	return UserVersionView{
+		Version: version.Version,
+		Action:  version.Action,
+		Actor:   version.Actor,
+		At:      version.At,
+		User:    NewUserView(version.Snapshot),
+	}
+}
+
+// UserVersionResponse 單一版本響應
+// @Description 符合 HATEOAS 的用戶版本響應結構
+type UserVersionResponse struct {
+	Data  UserVersionView `json:"data"`
+	Links []HATEOASLink   `json:"_links"`
+}
+
+// UserVersionsResponse 版本列表響應
+// @Description 符合 HATEOAS 的用戶版本列表響應結構
+type UserVersionsResponse struct {
+	Data  []UserVersionView `json:"data"`
+	Links []HATEOASLink     `json:"_links"`
+	Page  int               `json:"page" example:"1"`
+	Size  int               `json:"size" example:"20"`
+	Total int64             `json:"total" example:"3"`
+}
+
+// UserVersionDiffResponse 兩個版本之間的差異
+// @Description 兩個版本之間的欄位差異，密碼以 [REDACTED] 表示
+type UserVersionDiffResponse struct {
+	From    int64         `json:"from" example:"2"`
+	To      int64         `json:"to" example:"3"`
+	Changes []FieldChange `json:"changes"`
+	Links   []HATEOASLink `json:"_links"`
+}
+
+// GenerateUserVersionsLinks 產生版本列表的分頁連結
+func GenerateUserVersionsLinks(baseURL, userID string, page, size int, total int64) []HATEOASLink {
+	userURL := baseURL + "/users/" + userID
+	pageURL := func(p int) string {
+		return userURL + "/versions?page=" + strconv.Itoa(p) + "&size=" + strconv.Itoa(size)
+	}
+
+	links := []HATEOASLink{
+		{Href: pageURL(page), Rel: "self", Method: "GET", Title: "List user versions"},
+		{Href: userURL, Rel: "user", Method: "GET", Title: "Get user"},
+	}
+	if page > 1 {
+		links = append(links, HATEOASLink{Href: pageURL(page - 1), Rel: "prev", Method: "GET", Title: "Previous page of user versions"})
+	}
+	if int64(page*size) < total {
+		links = append(links, HATEOASLink{Href: pageURL(page + 1), Rel: "next", Method: "GET", Title: "Next page of user versions"})
+	}
+	return links
+}
+
+// GenerateUserVersionLinks 產生版本快照的 HATEOAS 連結
+func GenerateUserVersionLinks(baseURL, userID string, version int64) []HATEOASLink {
+	userURL := baseURL + "/users/" + userID
+	versionURL := userURL + "/versions/" + strconv.FormatInt(version, 10)
+	links := []HATEOASLink{
+		{Href: versionURL, Rel: "self", Method: "GET", Title: "Get user version"},
+		{Href: versionURL + "/restore", Rel: "restore", Method: "POST", Title: "Restore this version"},
+		{Href: userURL + "/versions", Rel: "versions", Method: "GET", Title: "List user versions"},
+		{Href: userURL, Rel: "user", Method: "GET", Title: "Get user"},
+	}
+	if version > 1 {
+		links = append(links, HATEOASLink{Href: versionURL + "/diff", Rel: "diff", Method: "GET", Title: "Compare with previous version"})
+	}
+	return links
+}
@@ -0,0 +1,324 @@
+package user_models
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 可以訂閱的用戶事件
+const (
+	WebhookUserCreated       = "user.created"
+	WebhookUserUpdated       = "user.updated"
+	WebhookUserStatusChanged = "user.status_changed"
+	WebhookUserDeleted       = "user.deleted"
+)
+
+// WebhookEventTypes 所有可以訂閱的事件
+var WebhookEventTypes = []string{WebhookUserCreated, WebhookUserUpdated, WebhookUserStatusChanged, WebhookUserDeleted}
+
+// OutboxEvent 與用戶變更在同一次寫入中保存在用戶文件的事件（交易式 outbox）。
+// 單一文件的寫入是原子的，變更寫入成功時事件一定存在；轉送程序將事件移入傳遞佇列後才從用戶文件刪除
+type OutboxEvent struct {
+	ID         string    `bson:"id"`
+	Type       string    `bson:"type"`
+	OccurredAt time.Time `bson:"occurred_at"`
+	Payload    string    `bson:"payload"` // 傳送給訂閱者的 JSON 內容，建立時即固定，重新傳遞時內容不變
+}
+
+// WebhookPayload 傳送給訂閱者的事件內容
+// @Description Webhook 事件內容，以 HMAC-SHA256 簽章
+type WebhookPayload struct {
+	ID         string           `json:"id" example:"evt_507f1f77bcf86cd799439011"`
+	Type       string           `json:"type" example:"user.updated"`
+	OccurredAt time.Time        `json:"occurred_at" example:"2021-01-01T00:00:00Z"`
+	Data       WebhookEventData `json:"data"`
+}
+
+// WebhookEventData 事件的資料，user 為變更後的用戶
+// @Description Webhook 事件資料
+type WebhookEventData struct {
+	User          UserView          `json:"user"`
+	ChangedFields []string          `json:"changed_fields,omitempty" example:"email,phone"`
+	Transition    *StatusTransition `json:"transition,omitempty"`
+}
+
+// UserEvents 依變更前後的用戶產生事件，before 為 nil 表示新建立的用戶。
+// 個人資料與頭像的變更產生 user.updated，狀態變更另外產生 user.status_changed 或 user.deleted
+func UserEvents(before *User, after User, at time.Time) []OutboxEvent {
+	view := NewUserView(after)
+	if before == nil {
+		return []OutboxEvent{newOutboxEvent(WebhookUserCreated, at, WebhookEventData{User: view})}
+	}
+
+	var events []OutboxEvent
+	var changed []string
+	for _, change := range DiffUsers(before, &after) {
+		if change.Field != "status" && change.Field != "password" {
+			changed = append(changed, change.Field)
+		}
+	}
+	if AvatarHash(before.Avatar) != AvatarHash(after.Avatar) {
+		changed = append(changed, "avatar")
+	}
+	if len(changed) > 0 {
+		events = append(events, newOutboxEvent(WebhookUserUpdated, at, WebhookEventData{User: view, ChangedFields: changed}))
+	}
+
+	if before.Status.Effective() != after.Status.Effective() {
+		data := WebhookEventData{User: view}
+		if n := len(after.StatusHistory); n > 0 && after.StatusHistory[n-1].To == after.Status {
+			transition := after.StatusHistory[n-1]
+			data.Transition = &transition
+		}
+		eventType := WebhookUserStatusChanged
+		if after.Status == StatusDeleted {
+			eventType = WebhookUserDeleted
+		}
+		events = append(events, newOutboxEvent(eventType, at, data))
+	}
+	return events
+}
+
+func newOutboxEvent(eventType string, at time.Time, data WebhookEventData) OutboxEvent {
+	payload := WebhookPayload{
+		ID:         "evt_" + primitive.NewObjectID().Hex(),
+		Type:       eventType,
+		OccurredAt: at,
+		Data:       data,
+	}
+	body, _ := json.Marshal(payload)
+	return OutboxEvent{ID: payload.ID, Type: eventType, OccurredAt: at, Payload: string(body)}
+}
+
+// WebhookSubscription 事件訂閱，Secret 用於簽章，只在建立與更換時回傳
+type WebhookSubscription struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	URL         string             `bson:"url"`
+	Events      []string           `bson:"events"`
+	Description string             `bson:"description,omitempty"`
+	Secret      string             `bson:"secret"`
+	Active      bool               `bson:"active"`
+	CreatedBy   string             `bson:"created_by,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at"`
+}
+
+// CreateWebhookRequest 建立訂閱的請求
+// @Description 建立 webhook 訂閱的請求
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2048" example:"https://hooks.example.com/users"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=user.created user.updated user.status_changed user.deleted" example:"user.created,user.deleted"`
+	Description string   `json:"description" binding:"max=200" normalize:"trim" example:"CRM 同步"`
+	Active      *bool    `json:"active" example:"true"`
+}
+
+// UpdateWebhookRequest 修改訂閱的請求，未提供的欄位不會變更
+// @Description 修改 webhook 訂閱的請求
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url" binding:"omitempty,url,max=2048" example:"https://hooks.example.com/users"`
+	Events      *[]string `json:"events" binding:"omitempty,min=1,dive,oneof=user.created user.updated user.status_changed user.deleted" example:"user.updated"`
+	Description *string   `json:"description" binding:"omitempty,max=200" normalize:"trim" example:"CRM 同步"`
+	Active      *bool     `json:"active" example:"false"`
+}
+
+// WebhookView 訂閱的公開欄位；Secret 只在建立與更換後的響應中出現
+// @Description Webhook 訂閱
+type WebhookView struct {
+	ID          string    `json:"id" example:"507f1f77bcf86cd799439011"`
+	URL         string    `json:"url" example:"https://hooks.example.com/users"`
+	Events      []string  `json:"events" example:"user.created,user.deleted"`
+	Description string    `json:"description,omitempty" example:"CRM 同步"`
+	Active      bool      `json:"active" example:"true"`
+	Secret      string    `json:"secret,omitempty" example:"whsec_4f1c..."`
+	CreatedBy   string    `json:"created_by,omitempty" example:"507f1f77bcf86cd799439011"`
+	CreatedAt   time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// NewWebhookView 建立訂閱的公開表示，不含 Secret
+func NewWebhookView(sub WebhookSubscription) WebhookView {
+	return WebhookView{
+		ID:          sub.ID.Hex(),
+		URL:         sub.URL,
+		Events:      sub.Events,
+		Description: sub.Description,
+		Active:      sub.Active,
+		CreatedBy:   sub.CreatedBy,
+		CreatedAt:   sub.CreatedAt,
+		UpdatedAt:   sub.UpdatedAt,
+	}
+}
+
+// WebhookResponse 單一訂閱響應
+// @Description 符合 HATEOAS 的 webhook 訂閱響應結構
+type WebhookResponse struct {
+	Data  WebhookView   `json:"data"`
+	Links []HATEOASLink `json:"_links"`
+}
+
+// WebhookListResponse 訂閱列表響應
+// @Description 符合 HATEOAS 的 webhook 訂閱列表響應結構
+type WebhookListResponse struct {
+	Data  []WebhookResponse `json:"data"`
+	Links []HATEOASLink     `json:"_links"`
+}
+
+// WebhookDeliveryStatus 傳遞的狀態
+type WebhookDeliveryStatus string
+
+// 傳遞的狀態
+const (
+	DeliveryPending    WebhookDeliveryStatus = "pending"     // 等待傳遞或退避後重試
+	DeliveryDelivering WebhookDeliveryStatus = "delivering"  // 傳遞中，租約過期時視為中斷
+	DeliverySucceeded  WebhookDeliveryStatus = "succeeded"   // 訂閱者回應 2xx
+	DeliveryDeadLetter WebhookDeliveryStatus = "dead_letter" // 用盡重試次數，只能手動重新傳遞
+)
+
+// Finished 傳遞是否已結束，只有結束的傳遞可以重新傳遞
+func (s WebhookDeliveryStatus) Finished() bool {
+	return s == DeliverySucceeded || s == DeliveryDeadLetter
+}
+
+// WebhookDelivery 一個事件對一個訂閱的傳遞，保存事件內容與每次嘗試的紀錄
+type WebhookDelivery struct {
+	ID             primitive.ObjectID    `bson:"_id,omitempty"`
+	SubscriptionID primitive.ObjectID    `bson:"subscription_id"`
+	EventID        string                `bson:"event_id"`
+	EventType      string                `bson:"event_type"`
+	Payload        string                `bson:"payload"`
+	Status         WebhookDeliveryStatus `bson:"status"`
+	Attempts       int                   `bson:"attempts"` // 本輪的嘗試次數，重新傳遞時歸零
+	Log            []WebhookAttempt      `bson:"log,omitempty"`
+	NextAttemptAt  time.Time             `bson:"next_attempt_at"`
+	LeaseOwner     string                `bson:"lease_owner,omitempty"`
+	LeaseUntil     *time.Time            `bson:"lease_until,omitempty"`
+	CreatedAt      time.Time             `bson:"created_at"`
+	UpdatedAt      time.Time             `bson:"updated_at"`
+	DeliveredAt    *time.Time            `bson:"delivered_at,omitempty"`
+}
+
+// WebhookAttempt 一次傳遞嘗試的紀錄
+// @Description Webhook 傳遞嘗試紀錄
+type WebhookAttempt struct {
+	At           time.Time `bson:"at" json:"at" example:"2021-01-01T00:00:00Z"`
+	StatusCode   int       `bson:"status_code,omitempty" json:"status_code,omitempty" example:"503"`
+	Error        string    `bson:"error,omitempty" json:"error,omitempty" example:"unexpected status 503"`
+	DurationMS   int64     `bson:"duration_ms" json:"duration_ms" example:"182"`
+	ResponseBody string    `bson:"response_body,omitempty" json:"response_body,omitempty" example:"upstream unavailable"`
+}
+
+// WebhookDeliveryView 傳遞的公開欄位
+// @Description Webhook 傳遞紀錄
+type WebhookDeliveryView struct {
+	ID             string                `json:"id" example:"507f1f77bcf86cd799439012"`
+	SubscriptionID string                `json:"subscription_id" example:"507f1f77bcf86cd799439011"`
+	EventID        string                `json:"event_id" example:"evt_507f1f77bcf86cd799439013"`
+	EventType      string                `json:"event_type" example:"user.updated"`
+	Status         WebhookDeliveryStatus `json:"status" example:"pending"`
+	Attempts       int                   `json:"attempts" example:"2"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty" example:"2021-01-01T00:01:00Z"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty" example:"2021-01-01T00:00:01Z"`
+	Log            []WebhookAttempt      `json:"log"`
+	Payload        json.RawMessage       `json:"payload" swaggertype:"object"`
+	CreatedAt      time.Time             `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt      time.Time             `json:"updated_at" example:"2021-01-01T00:00:01Z"`
+}
+
+// NewWebhookDeliveryView 建立傳遞的公開表示，結束的傳遞不顯示下次嘗試時間
+func NewWebhookDeliveryView(d WebhookDelivery) WebhookDeliveryView {
+	view := WebhookDeliveryView{
+		ID:             d.ID.Hex(),
+		SubscriptionID: d.SubscriptionID.Hex(),
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		DeliveredAt:    d.DeliveredAt,
+		Log:            d.Log,
+		Payload:        json.RawMessage(d.Payload),
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+	if view.Log == nil {
+		view.Log = []WebhookAttempt{}
+	}
+	if !d.Status.Finished() {
+		next := d.NextAttemptAt
+		view.NextAttemptAt = &next
+	}
+	return view
+}
+
+// WebhookDeliveryResponse 單一傳遞響應
+// @Description 符合 HATEOAS 的 webhook 傳遞響應結構
+type WebhookDeliveryResponse struct {
+	Data  WebhookDeliveryView `json:"data"`
+	Links []HATEOASLink       `json:"_links"`
+}
+
+// WebhookDeliveryListResponse 傳遞紀錄列表響應
+// @Description 符合 HATEOAS 的 webhook 傳遞紀錄列表響應結構
+type WebhookDeliveryListResponse struct {
+	Data  []WebhookDeliveryView `json:"data"`
+	Links []HATEOASLink         `json:"_links"`
+	Page  int                   `json:"page" example:"1"`
+	Size  int                   `json:"size" example:"20"`
+	Total int64                 `json:"total" example:"42"`
+}
+
+// GenerateWebhookLinks 產生訂閱的 HATEOAS 連結
+func GenerateWebhookLinks(baseURL, id string) []HATEOASLink {
+	webhookURL := baseURL + "/webhooks/" + id
+	return []HATEOASLink{
+		{Href: webhookURL, Rel: "self", Method: "GET", Title: "Get webhook"},
+		{Href: webhookURL, Rel: "update", Method: "PATCH", Title: "Update webhook"},
+		{Href: webhookURL, Rel: "delete", Method: "DELETE", Title: "Delete webhook"},
+		{Href: webhookURL + "/rotate-secret", Rel: "rotate-secret", Method: "POST", Title: "Rotate webhook secret"},
+		{Href: webhookURL + "/deliveries", Rel: "deliveries", Method: "GET", Title: "List webhook deliveries"},
+		{Href: baseURL + "/webhooks", Rel: "collection", Method: "GET", Title: "List webhooks"},
+	}
+}
+
+// GenerateWebhookDeliveryLinks 產生傳遞的 HATEOAS 連結；結束的傳遞可以重新傳遞
+func GenerateWebhookDeliveryLinks(baseURL string, d WebhookDelivery) []HATEOASLink {
+	webhookURL := baseURL + "/webhooks/" + d.SubscriptionID.Hex()
+	deliveryURL := webhookURL + "/deliveries/" + d.ID.Hex()
+	links := []HATEOASLink{
+		{Href: deliveryURL, Rel: "self", Method: "GET", Title: "Get webhook delivery"},
+		{Href: webhookURL, Rel: "webhook", Method: "GET", Title: "Get webhook"},
+	}
+	if d.Status.Finished() {
+		links = append(links, HATEOASLink{Href: deliveryURL + "/redeliver", Rel: "redeliver", Method: "POST", Title: "Redeliver webhook"})
+	}
+	return links
+}
+
+// GenerateWebhookDeliveryListLinks 產生傳遞紀錄列表的 HATEOAS 連結，status 為篩選的狀態
+func GenerateWebhookDeliveryListLinks(baseURL, id, status string, page, size int, total int64) []HATEOASLink {
+	deliveriesURL := baseURL + "/webhooks/" + id + "/deliveries"
+	pageURL := func(p int) string {
+		values := url.Values{}
+		if status != "" {
+			values.Set("status", status)
+		}
+		values.Set("page", strconv.Itoa(p))
+		values.Set("size", strconv.Itoa(size))
+		return deliveriesURL + "?" + values.Encode()
+	}
+
+	links := []HATEOASLink{
+		{Href: pageURL(page), Rel: "self", Method: "GET", Title: "List webhook deliveries"},
+		{Href: baseURL + "/webhooks/" + id, Rel: "webhook", Method: "GET", Title: "Get webhook"},
+	}
+	if page > 1 {
+		links = append(links, HATEOASLink{Href: pageURL(page - 1), Rel: "prev", Method: "GET", Title: "Previous page of webhook deliveries"})
+	}
+	if int64(page*size) < total {
+		links = append(links, HATEOASLink{Href: pageURL(page + 1), Rel: "next", Method: "GET", Title: "Next page of webhook deliveries"})
+	}
+	return links
+}
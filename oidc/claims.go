@@ -0,0 +1,47 @@
+package oidc
+
+import (
+	"strings"
+
+	user_models "go-api_for_main/models"
+)
+
+// SupportedScopes 身分提供者支援的 scope
+var SupportedScopes = []string{"openid", "profile", "email", "phone", "address"}
+
+// SupportedClaims 可由 userinfo 取得的宣告
+var SupportedClaims = []string{"sub", "name", "gender", "email", "email_verified", "phone_number", "address"}
+
+// UserInfoFromUser 依授權的 scope 將 User 欄位對應為 OIDC 標準宣告
+func UserInfoFromUser(user user_models.User, scope string) user_models.UserInfo {
+	info := user_models.UserInfo{Subject: user.ID.Hex()}
+
+	if HasScope(scope, "profile") {
+		info.Name = user.Name
+		info.Gender = user.Sex
+	}
+	if HasScope(scope, "email") {
+		verified := false
+		info.Email = user.Email
+		info.EmailVerified = &verified
+	}
+	if HasScope(scope, "phone") {
+		info.PhoneNumber = user.Phone
+	}
+	if HasScope(scope, "address") && user.Address != "" {
+		info.Address = &user_models.UserInfoAddress{Formatted: user.Address}
+	}
+
+	return info
+}
+
+// FilterScope 只保留支援且用戶端已註冊的 scope
+func FilterScope(requested, allowed string) string {
+	var granted []string
+	for _, s := range SupportedScopes {
+		if HasScope(requested, s) && (allowed == "" || HasScope(allowed, s)) {
+			granted = append(granted, s)
+		}
+	}
+	return strings.Join(granted, " ")
+}
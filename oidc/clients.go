@@ -0,0 +1,9 @@
+package oidc
+
+import "net/url"
+
+// ValidRedirectURI 檢查註冊的 redirect_uri 必須是帶有主機的絕對 URI 且不含 fragment (RFC 6749 §3.1.2)
+func ValidRedirectURI(uri string) bool {
+	parsed, err := url.Parse(uri)
+	return err == nil && parsed.IsAbs() && parsed.Host != "" && parsed.Fragment == ""
+}
@@ -0,0 +1,269 @@
+// Package oidc 提供 OpenID Connect 身分提供者所需的金鑰、權杖與 PKCE 工具
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	user_models "go-api_for_main/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const rsaKeyBits = 2048
+
+// ErrNoSigningKey 表示沒有可用的簽章金鑰
+var ErrNoSigningKey = errors.New("no signing key available")
+
+// SigningKey 一把 RS256 簽章金鑰
+type SigningKey struct {
+	ID         string
+	PrivateKey *rsa.PrivateKey
+	CreatedAt  time.Time
+}
+
+// KeyManager 管理簽章金鑰的產生、輪替與公開
+// 最新的金鑰用於簽章，較舊的金鑰在保留期間內仍會出現在 JWKS 中供驗證
+type KeyManager struct {
+	mu         sync.RWMutex
+	collection *mongo.Collection
+	keys       []*SigningKey // 由新到舊排序
+	rotation   time.Duration
+	retention  time.Duration
+	now        func() time.Time
+}
+
+// NewKeyManager 建立金鑰管理器
+// collection 為 nil 時金鑰只保存在記憶體中，重新啟動後會重新產生
+func NewKeyManager(collection *mongo.Collection, rotation, retention time.Duration) *KeyManager {
+	return &KeyManager{
+		collection: collection,
+		rotation:   rotation,
+		retention:  retention,
+		now:        time.Now,
+	}
+}
+
+// Load 建立輪替所需的索引並載入金鑰，若沒有可用金鑰或最新金鑰已過期則輪替
+func (m *KeyManager) Load(ctx context.Context) error {
+	if m.collection != nil {
+		// supersedes 唯一：多個副本同時輪替時只有一個能寫入，其餘改用它產生的金鑰
+		_, err := m.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "supersedes", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"supersedes": bson.M{"$exists": true}}),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return m.RotateIfDue(ctx)
+}
+
+// reload 從 MongoDB 重新載入所有副本共用的金鑰，collection 為 nil 時保留記憶體中的金鑰
+func (m *KeyManager) reload(ctx context.Context) error {
+	if m.collection == nil {
+		return nil
+	}
+	cursor, err := m.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return err
+	}
+	var docs []user_models.SigningKeyDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+
+	keys := make([]*SigningKey, 0, len(docs))
+	for _, doc := range docs {
+		key, err := decodeSigningKey(doc)
+		if err != nil {
+			log.Printf("Skipping unreadable signing key %s: %v\n", doc.KeyID, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.mu.Unlock()
+	return nil
+}
+
+// RotateIfDue 先重新載入金鑰，讓各副本的 JWKS 一致，最新金鑰超過輪替週期時才產生新金鑰
+func (m *KeyManager) RotateIfDue(ctx context.Context) error {
+	if err := m.reload(ctx); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	due := len(m.keys) == 0 || m.now().Sub(m.keys[0].CreatedAt) >= m.rotation
+	m.mu.RUnlock()
+
+	if !due {
+		return nil
+	}
+	return m.Rotate(ctx)
+}
+
+// Rotate 產生新的簽章金鑰並淘汰超過保留期間的舊金鑰；
+// 其他副本已取代同一把金鑰時放棄本次產生的金鑰，改為載入對方的金鑰
+func (m *KeyManager) Rotate(ctx context.Context) error {
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return err
+	}
+
+	key := &SigningKey{
+		ID:         keyID(&privateKey.PublicKey),
+		PrivateKey: privateKey,
+		CreatedAt:  m.now().UTC(),
+	}
+
+	m.mu.RLock()
+	supersedes := ""
+	if len(m.keys) > 0 {
+		supersedes = m.keys[0].ID
+	}
+	m.mu.RUnlock()
+
+	if m.collection != nil {
+		doc := user_models.SigningKeyDocument{
+			KeyID: key.ID,
+			PrivateKeyPEM: string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+			})),
+			CreatedAt:  key.CreatedAt,
+			Supersedes: &supersedes,
+		}
+		if _, err := m.collection.InsertOne(ctx, doc); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				log.Printf("OIDC signing key %q was already rotated by another instance\n", supersedes)
+				return m.reload(ctx)
+			}
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = append([]*SigningKey{key}, m.keys...)
+	expired := m.pruneLocked()
+	m.mu.Unlock()
+
+	if m.collection != nil && len(expired) > 0 {
+		if _, err := m.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": expired}}); err != nil {
+			log.Printf("Error removing retired signing keys: %v\n", err)
+		}
+	}
+
+	log.Printf("Rotated OIDC signing key, active kid=%s\n", key.ID)
+	return nil
+}
+
+// pruneLocked 移除已被取代且超過保留期間的金鑰，回傳被移除的 kid
+// 金鑰被下一把取代後還需保留 retention，讓已簽發的權杖仍能驗證
+func (m *KeyManager) pruneLocked() []string {
+	var expired []string
+	kept := m.keys[:1]
+	for i := 1; i < len(m.keys); i++ {
+		supersededAt := m.keys[i-1].CreatedAt
+		if m.now().Sub(supersededAt) > m.retention {
+			expired = append(expired, m.keys[i].ID)
+			continue
+		}
+		kept = append(kept, m.keys[i])
+	}
+	m.keys = kept
+	return expired
+}
+
+// StartRotation 在背景定期重新載入金鑰並檢查是否需要輪替，直到 ctx 結束
+func (m *KeyManager) StartRotation(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.RotateIfDue(ctx); err != nil {
+					log.Printf("Error rotating OIDC signing key: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// Current 回傳目前用於簽章的金鑰
+func (m *KeyManager) Current() (*SigningKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.keys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	return m.keys[0], nil
+}
+
+// Lookup 依 kid 取得仍可用於驗證的公鑰
+func (m *KeyManager) Lookup(kid string) (*rsa.PublicKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.keys {
+		if key.ID == kid {
+			return &key.PrivateKey.PublicKey, true
+		}
+	}
+	return nil, false
+}
+
+// JWKS 回傳所有仍有效的公鑰
+func (m *KeyManager) JWKS() user_models.JSONWebKeySet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := user_models.JSONWebKeySet{Keys: make([]user_models.JSONWebKey, 0, len(m.keys))}
+	for _, key := range m.keys {
+		pub := key.PrivateKey.PublicKey
+		set.Keys = append(set.Keys, user_models.JSONWebKey{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: key.ID,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		})
+	}
+	return set
+}
+
+// keyID 以公鑰模數的 SHA-256 產生穩定的 kid
+func keyID(pub *rsa.PublicKey) string {
+	sum := sha256.Sum256(pub.N.Bytes())
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func decodeSigningKey(doc user_models.SigningKeyDocument) (*SigningKey, error) {
+	block, _ := pem.Decode([]byte(doc.PrivateKeyPEM))
+	if block == nil {
+		return nil, errors.New("invalid PEM block")
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{ID: doc.KeyID, PrivateKey: privateKey, CreatedAt: doc.CreatedAt}, nil
+}
@@ -0,0 +1,37 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// CodeChallengeMethodS256 是唯一支援的 PKCE 方法，plain 不被接受
+const CodeChallengeMethodS256 = "S256"
+
+// code_verifier 必須為 43 到 128 個 unreserved 字元 (RFC 7636 §4.1)
+var codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// codeChallengePattern S256 挑戰值為 32 位元組的 base64url 編碼
+var codeChallengePattern = regexp.MustCompile(`^[A-Za-z0-9\-_]{43}$`)
+
+// S256Challenge 由 code_verifier 計算 S256 code_challenge
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ValidCodeChallenge 檢查授權請求中的 code_challenge 格式
+func ValidCodeChallenge(challenge, method string) bool {
+	return method == CodeChallengeMethodS256 && codeChallengePattern.MatchString(challenge)
+}
+
+// VerifyPKCE 驗證權杖請求的 code_verifier 是否符合先前的 code_challenge
+func VerifyPKCE(verifier, challenge, method string) bool {
+	if method != CodeChallengeMethodS256 || !codeVerifierPattern.MatchString(verifier) {
+		return false
+	}
+	computed := S256Challenge(verifier)
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
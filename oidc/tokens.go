@@ -0,0 +1,127 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken 表示權杖無法通過驗證
+var ErrInvalidToken = errors.New("invalid token")
+
+// AccessTokenClaims 存取權杖中的宣告
+type AccessTokenClaims struct {
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// SessionID 對應 sessions 集合中的紀錄，撤銷工作階段後權杖立即失效
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// HasScope 判斷權杖是否包含指定 scope
+func (c *AccessTokenClaims) HasScope(scope string) bool {
+	return HasScope(c.Scope, scope)
+}
+
+// IDTokenClaims ID 權杖中的宣告
+type IDTokenClaims struct {
+	Nonce    string `json:"nonce,omitempty"`
+	AuthTime int64  `json:"auth_time,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Sign 以目前的簽章金鑰簽署宣告
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	key, err := m.Current()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// VerifyAccessToken 驗證存取權杖的簽章、簽發者與有效期限
+func (m *KeyManager) VerifyAccessToken(tokenString, issuer string) (*AccessTokenClaims, error) {
+	claims := &AccessTokenClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		pub, ok := m.Lookup(kid)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return pub, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+// NewAccessTokenClaims 建立存取權杖宣告
+func NewAccessTokenClaims(issuer, subject, clientID, scope string, issuedAt time.Time, ttl time.Duration) *AccessTokenClaims {
+	return &AccessTokenClaims{
+		Scope:    scope,
+		ClientID: clientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(ttl)),
+			ID:        RandomToken(16),
+		},
+	}
+}
+
+// NewIDTokenClaims 建立 ID 權杖宣告，aud 為取得權杖的用戶端
+func NewIDTokenClaims(issuer, subject, clientID, nonce string, authTime, issuedAt time.Time, ttl time.Duration) *IDTokenClaims {
+	return &IDTokenClaims{
+		Nonce:    nonce,
+		AuthTime: authTime.Unix(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(ttl)),
+		},
+	}
+}
+
+// HasScope 判斷以空白分隔的 scope 字串是否包含指定 scope
+func HasScope(scopes, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RandomToken 產生 URL 安全的隨機字串
+func RandomToken(byteLength int) string {
+	b := make([]byte, byteLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// HashToken 以 SHA-256 雜湊授權碼等一次性權杖，避免以明文保存
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
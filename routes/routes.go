@@ -2,6 +2,7 @@ package routes
 
 import (
 	"go-api_for_main/controllers"
+	"go-api_for_main/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,8 +10,11 @@ import (
 // SetupRouter 初始化所有路由
 func SetupRouter(r *gin.Engine) {
 	// API v1 路由組
-	v1 := r.Group("/api/v1")
+	v1 := r.Group("/api/v1", middleware.Authenticate(controllers.VerifyAccessToken))
 	{
+		// 會讀取或改寫所有用戶資料的操作只限已登入的管理員
+		requireAdmin := []gin.HandlerFunc{middleware.RequireAuth(controllers.VerifyAccessToken), controllers.RequireAdmin()}
+
 		// 用戶相關路由
 		users := v1.Group("/users")
 		{
@@ -18,8 +22,97 @@ func SetupRouter(r *gin.Engine) {
 			users.POST("/", controllers.CreateUser)      // 創建用戶
 			users.GET("/:id", controllers.GetUser)       // 獲取特定用戶
 			users.PUT("/:id", controllers.UpdateUser)    // 更新用戶
+			users.PATCH("/:id", controllers.PatchUser)   // 部分更新用戶
 			users.DELETE("/:id", controllers.DeleteUser) // 刪除用戶
 
+			// 批次、匯入匯出、事件流、生命週期與版本歷史
+			adminUsers := users.Group("", requireAdmin...)
+			{
+				adminUsers.POST("/bulk", controllers.BulkUsers)                   // 批次操作
+				adminUsers.GET("/export", controllers.ExportUsers)                // 匯出用戶
+				adminUsers.POST("/import", controllers.ImportUsers)               // 匯入用戶
+				adminUsers.GET("/import/:id/errors", controllers.GetImportErrors) // 下載匯入錯誤報表
+				adminUsers.GET("/stream", controllers.StreamUsers)                // 即時變更事件流（SSE / WebSocket）
+
+				// 生命週期狀態轉換
+				adminUsers.POST("/:id/activate", controllers.ActivateUser)              // 啟用
+				adminUsers.POST("/:id/suspend", controllers.SuspendUser)                // 停權
+				adminUsers.POST("/:id/reinstate", controllers.ReinstateUser)            // 恢復
+				adminUsers.POST("/:id/lock", controllers.LockUser)                      // 鎖定
+				adminUsers.POST("/:id/unlock", controllers.UnlockUser)                  // 解除鎖定
+				adminUsers.POST("/:id/deactivate", controllers.DeactivateUser)          // 停用
+				adminUsers.POST("/:id/reactivate", controllers.ReactivateUser)          // 重新啟用
+				adminUsers.GET("/:id/status-history", controllers.GetUserStatusHistory) // 狀態歷史
+
+				// 版本歷史
+				adminUsers.GET("/:id/versions", controllers.GetUserVersions)                // 版本列表
+				adminUsers.GET("/:id/versions/:n", controllers.GetUserVersion)              // 特定版本
+				adminUsers.GET("/:id/versions/:n/diff", controllers.DiffUserVersions)       // 版本差異
+				adminUsers.POST("/:id/versions/:n/restore", controllers.RestoreUserVersion) // 還原版本
+			}
+
+			// 頭像；上傳與刪除只限本人或管理員
+			users.GET("/:id/avatar", controllers.GetAvatar) // 獲取頭像
+			ownAvatar := users.Group("", middleware.RequireAuth(controllers.VerifyAccessToken), controllers.RequireOwnerOrAdmin("id"))
+			{
+				ownAvatar.PUT("/:id/avatar", controllers.UploadAvatar)    // 上傳頭像
+				ownAvatar.DELETE("/:id/avatar", controllers.DeleteAvatar) // 刪除頭像
+			}
+		}
+
+		// 目前用戶自助服務路由
+		me := v1.Group("/me", middleware.RequireAuth(controllers.VerifyAccessToken))
+		{
+			me.GET("", controllers.GetMe)                           // 獲取個人資料
+			me.PATCH("", controllers.UpdateMe)                      // 更新個人資料
+			me.DELETE("", controllers.DeactivateMe)                 // 停用自己的帳號
+			me.POST("/password", controllers.ChangeMyPassword)      // 變更密碼
+			me.GET("/sessions", controllers.GetMySessions)          // 獲取登入工作階段
+			me.DELETE("/sessions/:id", controllers.RevokeMySession) // 登出工作階段
+		}
+
+		// 邀請註冊路由
+		// 接受邀請由受邀者以郵件中的權杖完成，其餘操作只限管理員
+		invitations := v1.Group("/invitations")
+		{
+			invitations.POST("/accept", controllers.AcceptInvitation) // 接受邀請
+		}
+		manageInvitations := invitations.Group("", requireAdmin...)
+		{
+			manageInvitations.POST("", controllers.CreateInvitation)       // 建立邀請
+			manageInvitations.GET("", controllers.GetInvitations)          // 獲取邀請列表
+			manageInvitations.GET("/:id", controllers.GetInvitation)       // 獲取特定邀請
+			manageInvitations.DELETE("/:id", controllers.RevokeInvitation) // 撤銷邀請
+		}
+
+		// 稽核紀錄路由
+		audit := v1.Group("/audit", requireAdmin...)
+		{
+			audit.GET("", controllers.GetAuditLog) // 查詢或匯出稽核紀錄
+		}
+
+		// 背景工作路由
+		jobs := v1.Group("/jobs", requireAdmin...)
+		{
+			jobs.POST("/exports", controllers.CreateExportJob) // 建立匯出工作
+			jobs.POST("/imports", controllers.CreateImportJob) // 建立匯入工作
+			jobs.GET("/:id", controllers.GetJob)               // 獲取工作狀態
+			jobs.POST("/:id/cancel", controllers.CancelJob)    // 取消工作
+			jobs.GET("/:id/result", controllers.GetJobResult)  // 下載工作結果
+		}
+
+		// Webhook 訂閱路由
+		webhooks := v1.Group("/webhooks", requireAdmin...)
+		{
+			webhooks.POST("", controllers.CreateWebhook)                                          // 建立訂閱
+			webhooks.GET("", controllers.GetWebhooks)                                             // 獲取訂閱列表
+			webhooks.GET("/:id", controllers.GetWebhook)                                          // 獲取特定訂閱
+			webhooks.PATCH("/:id", controllers.UpdateWebhook)                                     // 修改訂閱
+			webhooks.DELETE("/:id", controllers.DeleteWebhook)                                    // 刪除訂閱
+			webhooks.POST("/:id/rotate-secret", controllers.RotateWebhookSecret)                  // 更換簽章密鑰
+			webhooks.GET("/:id/deliveries", controllers.GetWebhookDeliveries)                     // 傳遞紀錄
+			webhooks.GET("/:id/deliveries/:delivery_id", controllers.GetWebhookDelivery)          // 特定傳遞
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", controllers.RedeliverWebhook) // 重新傳遞
 		}
 
 		// 可以添加更多路由組
@@ -39,6 +132,45 @@ func SetupRouter(r *gin.Engine) {
 		}
 	}
 
+	// OpenID Connect 身分提供者路由
+	r.GET("/.well-known/openid-configuration", controllers.OpenIDConfiguration) // Discovery 文件
+	oauth2 := r.Group("/oauth2")
+	{
+		oauth2.GET("/jwks", controllers.JWKS)                 // 簽章公鑰
+		oauth2.GET("/authorize", controllers.Authorize)       // 授權請求與登入頁面
+		oauth2.POST("/authorize", controllers.AuthorizeLogin) // 登入並簽發授權碼
+		oauth2.POST("/token", controllers.Token)              // 授權碼交換權杖
+		oauth2.POST("/register", controllers.RegisterClient)  // 動態用戶端註冊
+		userinfo := oauth2.Group("/userinfo", middleware.RequireAuth(controllers.VerifyAccessToken))
+		{
+			userinfo.GET("", controllers.UserInfo)  // 使用者宣告
+			userinfo.POST("", controllers.UserInfo) // 使用者宣告 (POST)
+		}
+	}
+
+	// GraphQL 端點，與 REST 使用相同的選擇性驗證
+	graphQL := r.Group("/graphql", middleware.Authenticate(controllers.VerifyAccessToken))
+	{
+		graphQL.GET("", controllers.GraphQL)  // 查詢（debug 模式下瀏覽器開啟為 GraphiQL）
+		graphQL.POST("", controllers.GraphQL) // 查詢與變更
+	}
+
+	// SCIM 2.0 佈建路由
+	scimV2 := r.Group("/scim/v2", controllers.SCIMAuth())
+	{
+		scimV2.GET("/Users", controllers.SCIMListUsers)                             // 篩選與分頁查詢
+		scimV2.POST("/Users", controllers.SCIMCreateUser)                           // 建立使用者
+		scimV2.GET("/Users/:id", controllers.SCIMGetUser)                           // 取得使用者
+		scimV2.PUT("/Users/:id", controllers.SCIMReplaceUser)                       // 取代使用者
+		scimV2.PATCH("/Users/:id", controllers.SCIMPatchUser)                       // 部分更新使用者
+		scimV2.DELETE("/Users/:id", controllers.SCIMDeleteUser)                     // 刪除使用者
+		scimV2.GET("/ServiceProviderConfig", controllers.SCIMServiceProviderConfig) // 服務能力說明
+		scimV2.GET("/ResourceTypes", controllers.SCIMResourceTypes)                 // 資源類型
+		scimV2.GET("/ResourceTypes/:name", controllers.SCIMResourceTypes)           // 單一資源類型
+		scimV2.GET("/Schemas", controllers.SCIMSchemas)                             // Schema 定義
+		scimV2.GET("/Schemas/:id", controllers.SCIMSchemas)                         // 單一 Schema
+	}
+
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "is alive",
@@ -0,0 +1,378 @@
+// Package scim 實作 SCIM 2.0 的篩選語法、PATCH 操作與使用者資源對應
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	user_models "go-api_for_main/models"
+)
+
+// ErrInvalidFilter 表示篩選或路徑語法錯誤
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Expression 篩選運算式節點
+type Expression interface {
+	isExpression()
+}
+
+// AttributePath 屬性路徑，例如 name.formatted 或帶有擴充 schema URN 的路徑
+type AttributePath struct {
+	URN          string
+	Name         string
+	SubAttribute string
+}
+
+// Key 回傳小寫的完整路徑，用於查詢屬性對應
+func (p AttributePath) Key() string {
+	key := strings.ToLower(p.Name)
+	if p.SubAttribute != "" {
+		key += "." + strings.ToLower(p.SubAttribute)
+	}
+	if p.URN != "" && !strings.EqualFold(p.URN, user_models.SCIMSchemaUser) {
+		key = strings.ToLower(p.URN) + ":" + key
+	}
+	return key
+}
+
+// AttributeExpression 比較運算式，例如 userName eq "a@example.com"
+type AttributeExpression struct {
+	Path     AttributePath
+	Operator string // eq ne co sw ew gt ge lt le pr，一律小寫
+	Value    interface{}
+}
+
+// LogicalExpression and / or 運算式
+type LogicalExpression struct {
+	Operator string // and 或 or
+	Left     Expression
+	Right    Expression
+}
+
+// NotExpression not ( ... ) 運算式
+type NotExpression struct {
+	Expression Expression
+}
+
+// ValuePathExpression 多值屬性篩選，例如 emails[type eq "work"]
+type ValuePathExpression struct {
+	Path   AttributePath
+	Filter Expression
+}
+
+func (AttributeExpression) isExpression() {}
+func (LogicalExpression) isExpression()   {}
+func (NotExpression) isExpression()       {}
+func (ValuePathExpression) isExpression() {}
+
+var comparisonOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// ParseFilter 解析 RFC 7644 §3.4.2.2 的篩選運算式
+func ParseFilter(filter string) (Expression, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.peek().text)
+	}
+	return expr, nil
+}
+
+// PatchPath PATCH 操作的目標路徑
+type PatchPath struct {
+	Attribute    AttributePath
+	Filter       Expression // 選用的多值屬性篩選
+	SubAttribute string     // 篩選後的子屬性，例如 emails[type eq "work"].value
+}
+
+// ParsePath 解析 PATCH 操作的 path
+func ParsePath(path string) (PatchPath, error) {
+	tokens, err := tokenize(path)
+	if err != nil {
+		return PatchPath{}, err
+	}
+	p := &parser{tokens: tokens}
+
+	tok := p.next()
+	if tok.kind != tokenWord {
+		return PatchPath{}, p.errorf("expected attribute path")
+	}
+	result := PatchPath{Attribute: parseAttributePath(tok.text)}
+
+	if p.accept(tokenOpenBracket) {
+		if result.Filter, err = p.parseOr(); err != nil {
+			return PatchPath{}, err
+		}
+		if !p.accept(tokenCloseBracket) {
+			return PatchPath{}, p.errorf("expected ]")
+		}
+		if !p.done() {
+			tok := p.next()
+			if tok.kind != tokenWord || !strings.HasPrefix(tok.text, ".") || len(tok.text) < 2 {
+				return PatchPath{}, p.errorf("unexpected %q", tok.text)
+			}
+			result.SubAttribute = tok.text[1:]
+		}
+	}
+
+	if !p.done() {
+		return PatchPath{}, p.errorf("unexpected %q", p.peek().text)
+	}
+	return result, nil
+}
+
+// parseAttributePath 拆解 URN 前綴、屬性名稱與子屬性
+func parseAttributePath(text string) AttributePath {
+	var path AttributePath
+	if strings.HasPrefix(strings.ToLower(text), "urn:") {
+		idx := strings.LastIndex(text, ":")
+		path.URN = text[:idx]
+		text = text[idx+1:]
+	}
+	if name, sub, found := strings.Cut(text, "."); found {
+		path.Name, path.SubAttribute = name, sub
+	} else {
+		path.Name = text
+	}
+	return path
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		ch := input[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '(':
+			tokens = append(tokens, token{tokenOpenParen, "("})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{tokenCloseParen, ")"})
+			i++
+		case ch == '[':
+			tokens = append(tokens, token{tokenOpenBracket, "["})
+			i++
+		case ch == ']':
+			tokens = append(tokens, token{tokenCloseBracket, "]"})
+			i++
+		case ch == '"':
+			end := i + 1
+			for ; end < len(input); end++ {
+				if input[end] == '\\' {
+					end++
+					continue
+				}
+				if input[end] == '"' {
+					break
+				}
+			}
+			if end >= len(input) {
+				return nil, fmt.Errorf("%w: unterminated string", ErrInvalidFilter)
+			}
+			var value string
+			if err := json.Unmarshal([]byte(input[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+			}
+			tokens = append(tokens, token{tokenString, value})
+			i = end + 1
+		default:
+			end := i
+			for end < len(input) && !strings.ContainsRune(" \t\n\r()[]\"", rune(input[end])) {
+				end++
+			}
+			tokens = append(tokens, token{tokenWord, input[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{kind: -1}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *parser) accept(kind tokenKind) bool {
+	if !p.done() && p.tokens[p.pos].kind == kind {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	tok := p.peek()
+	if tok.kind == tokenWord && strings.EqualFold(tok.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFilter, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseOr() (Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = LogicalExpression{Operator: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = LogicalExpression{Operator: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expression, error) {
+	if p.acceptKeyword("not") {
+		if !p.accept(tokenOpenParen) {
+			return nil, p.errorf("expected ( after not")
+		}
+		inner, err := p.parseGroupRest()
+		if err != nil {
+			return nil, err
+		}
+		return NotExpression{Expression: inner}, nil
+	}
+	if p.accept(tokenOpenParen) {
+		return p.parseGroupRest()
+	}
+	return p.parseAttribute()
+}
+
+func (p *parser) parseGroupRest() (Expression, error) {
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(tokenCloseParen) {
+		return nil, p.errorf("expected )")
+	}
+	return inner, nil
+}
+
+func (p *parser) parseAttribute() (Expression, error) {
+	tok := p.next()
+	if tok.kind != tokenWord {
+		return nil, p.errorf("expected attribute path")
+	}
+	path := parseAttributePath(tok.text)
+
+	if p.accept(tokenOpenBracket) {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(tokenCloseBracket) {
+			return nil, p.errorf("expected ]")
+		}
+		return ValuePathExpression{Path: path, Filter: inner}, nil
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokenWord {
+		return nil, p.errorf("expected operator after %s", tok.text)
+	}
+	op := strings.ToLower(opTok.text)
+	if op == "pr" {
+		return AttributeExpression{Path: path, Operator: op}, nil
+	}
+	if !comparisonOperators[op] {
+		return nil, p.errorf("unknown operator %q", opTok.text)
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return AttributeExpression{Path: path, Operator: op, Value: value}, nil
+}
+
+// parseValue 解析比較值：字串、數字、true、false 或 null
+func (p *parser) parseValue() (interface{}, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return tok.text, nil
+	case tokenWord:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return n, nil
+		}
+		if f, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			return f, nil
+		}
+	}
+	return nil, p.errorf("invalid comparison value %q", tok.text)
+}
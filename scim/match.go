@@ -0,0 +1,124 @@
+package scim
+
+import (
+	"strings"
+)
+
+// Matches 判斷多值屬性中的單一元素是否符合篩選，用於 PATCH 的值路徑
+func Matches(expr Expression, element map[string]interface{}) bool {
+	switch e := expr.(type) {
+	case LogicalExpression:
+		if e.Operator == "and" {
+			return Matches(e.Left, element) && Matches(e.Right, element)
+		}
+		return Matches(e.Left, element) || Matches(e.Right, element)
+	case NotExpression:
+		return !Matches(e.Expression, element)
+	case AttributeExpression:
+		actual := lookupValue(element, e.Path.Name)
+		if e.Path.SubAttribute != "" {
+			nested, _ := actual.(map[string]interface{})
+			actual = lookupValue(nested, e.Path.SubAttribute)
+		}
+		return compareValues(actual, e.Operator, e.Value)
+	}
+	return false
+}
+
+// lookupValue 以不分大小寫的方式取得屬性值（SCIM 屬性名稱不分大小寫）
+func lookupValue(resource map[string]interface{}, name string) interface{} {
+	for key, value := range resource {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return nil
+}
+
+// compareValues 比較屬性值與篩選值，字串比對不分大小寫
+func compareValues(actual interface{}, op string, expected interface{}) bool {
+	if op == "pr" {
+		return actual != nil && actual != ""
+	}
+	if expected == nil {
+		empty := actual == nil || actual == ""
+		return (op == "eq" && empty) || (op == "ne" && !empty)
+	}
+
+	switch a := actual.(type) {
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return op == "ne"
+		}
+		a, e = strings.ToLower(a), strings.ToLower(e)
+		switch op {
+		case "eq":
+			return a == e
+		case "ne":
+			return a != e
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		}
+		return compareOrdered(strings.Compare(a, e), op)
+	case bool:
+		e, ok := expected.(bool)
+		switch op {
+		case "eq":
+			return ok && a == e
+		case "ne":
+			return !ok || a != e
+		}
+	default:
+		af, aok := toFloat(actual)
+		ef, eok := toFloat(expected)
+		if !aok || !eok {
+			return op == "ne"
+		}
+		switch op {
+		case "eq":
+			return af == ef
+		case "ne":
+			return af != ef
+		case "gt", "ge", "lt", "le":
+			switch {
+			case af < ef:
+				return compareOrdered(-1, op)
+			case af > ef:
+				return compareOrdered(1, op)
+			}
+			return compareOrdered(0, op)
+		}
+	}
+	return false
+}
+
+func compareOrdered(cmp int, op string) bool {
+	switch op {
+	case "gt":
+		return cmp > 0
+	case "ge":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	case "le":
+		return cmp <= 0
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,273 @@
+package scim
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	user_models "go-api_for_main/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type attributeKind int
+
+const (
+	kindString attributeKind = iota
+	kindInt
+	kindTime
+	kindObjectID
+	kindConstant
+	kindActive
+)
+
+// attribute 描述 SCIM 屬性如何對應到 users 集合的欄位
+type attribute struct {
+	field     string
+	kind      attributeKind
+	caseExact bool
+	constant  interface{} // kindConstant 時屬性的固定值
+}
+
+var profileURN = strings.ToLower(user_models.SCIMSchemaProfileExtension)
+
+// userAttributes SCIM 屬性路徑（小寫）與 MongoDB 欄位的對應
+var userAttributes = map[string]attribute{
+	"id":                    {field: "_id", kind: kindObjectID, caseExact: true},
+	"username":              {field: "email", kind: kindString},
+	"displayname":           {field: "name", kind: kindString},
+	"name":                  {field: "name", kind: kindString},
+	"name.formatted":        {field: "name", kind: kindString},
+	"emails":                {field: "email", kind: kindString},
+	"emails.value":          {field: "email", kind: kindString},
+	"emails.type":           {kind: kindConstant, constant: "work"},
+	"emails.primary":        {kind: kindConstant, constant: true},
+	"phonenumbers":          {field: "phone", kind: kindString},
+	"phonenumbers.value":    {field: "phone", kind: kindString},
+	"phonenumbers.type":     {kind: kindConstant, constant: "mobile"},
+	"phonenumbers.primary":  {kind: kindConstant, constant: true},
+	"addresses":             {field: "address", kind: kindString},
+	"addresses.formatted":   {field: "address", kind: kindString},
+	"addresses.type":        {kind: kindConstant, constant: "home"},
+	"addresses.primary":     {kind: kindConstant, constant: true},
+	"active":                {field: "status", kind: kindActive},
+	"meta.created":          {field: "created_at", kind: kindTime},
+	"meta.lastmodified":     {field: "updated_at", kind: kindTime},
+	"meta.resourcetype":     {kind: kindConstant, constant: "User"},
+	profileURN + ":sex":     {field: "sex", kind: kindString},
+	profileURN + ":age":     {field: "age", kind: kindInt},
+	profileURN + ":address": {field: "address", kind: kindString},
+}
+
+var (
+	matchAll  = bson.M{}
+	matchNone = bson.M{"_id": bson.M{"$exists": false}}
+)
+
+// lookupAttribute 依路徑取得屬性對應，prefix 為值路徑篩選中的父屬性
+func lookupAttribute(path AttributePath, prefix string) (attribute, error) {
+	key := path.Key()
+	if prefix != "" {
+		key = prefix + "." + key
+	}
+	attr, ok := userAttributes[key]
+	if !ok {
+		return attribute{}, fmt.Errorf("%w: unsupported attribute %q", ErrInvalidFilter, key)
+	}
+	return attr, nil
+}
+
+// SortField 將 sortBy 屬性轉換為 MongoDB 欄位
+func SortField(sortBy string) (string, error) {
+	attr, err := lookupAttribute(parseAttributePath(sortBy), "")
+	if err != nil || attr.kind == kindConstant {
+		return "", fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, sortBy)
+	}
+	return attr.field, nil
+}
+
+// ToMongo 將篩選運算式轉換為 MongoDB 查詢條件
+func ToMongo(expr Expression) (bson.M, error) {
+	return toMongo(expr, "")
+}
+
+func toMongo(expr Expression, prefix string) (bson.M, error) {
+	switch e := expr.(type) {
+	case LogicalExpression:
+		left, err := toMongo(e.Left, prefix)
+		if err != nil {
+			return nil, err
+		}
+		right, err := toMongo(e.Right, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$" + e.Operator: bson.A{left, right}}, nil
+	case NotExpression:
+		inner, err := toMongo(e.Expression, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": bson.A{inner}}, nil
+	case ValuePathExpression:
+		if prefix != "" {
+			return nil, fmt.Errorf("%w: nested value paths are not allowed", ErrInvalidFilter)
+		}
+		return toMongo(e.Filter, e.Path.Key())
+	case AttributeExpression:
+		attr, err := lookupAttribute(e.Path, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return comparison(attr, e.Operator, e.Value)
+	}
+	return nil, fmt.Errorf("%w: unsupported expression", ErrInvalidFilter)
+}
+
+func comparison(attr attribute, op string, value interface{}) (bson.M, error) {
+	if attr.kind == kindConstant {
+		if compareValues(attr.constant, op, value) {
+			return matchAll, nil
+		}
+		return matchNone, nil
+	}
+
+	if attr.kind == kindActive {
+		return activeComparison(attr, op, value)
+	}
+
+	if op == "pr" {
+		return bson.M{attr.field: bson.M{"$exists": true, "$nin": bson.A{nil, ""}}}, nil
+	}
+	if value == nil {
+		switch op {
+		case "eq":
+			return bson.M{attr.field: bson.M{"$in": bson.A{nil, ""}}}, nil
+		case "ne":
+			return bson.M{attr.field: bson.M{"$nin": bson.A{nil, ""}}}, nil
+		}
+		return nil, fmt.Errorf("%w: null can only be compared with eq or ne", ErrInvalidFilter)
+	}
+
+	typed, err := typedValue(attr, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := typed.(string); ok {
+		return stringComparison(attr, op, s)
+	}
+
+	switch op {
+	case "eq":
+		return bson.M{attr.field: typed}, nil
+	case "ne":
+		return bson.M{attr.field: bson.M{"$ne": typed}}, nil
+	case "gt", "ge", "lt", "le":
+		return bson.M{attr.field: bson.M{"$" + mongoOrdering(op): typed}}, nil
+	}
+	return nil, fmt.Errorf("%w: operator %s is not valid for %s", ErrInvalidFilter, op, attr.field)
+}
+
+// activeComparison active 由 status 推導，沒有 status 的舊資料視為 active
+func activeComparison(attr attribute, op string, value interface{}) (bson.M, error) {
+	if op == "pr" {
+		return matchAll, nil
+	}
+	active, ok := value.(bool)
+	if !ok || (op != "eq" && op != "ne") {
+		return nil, fmt.Errorf("%w: active can only be compared with true or false using eq or ne", ErrInvalidFilter)
+	}
+	if op == "ne" {
+		active = !active
+	}
+	activeValues := bson.A{nil, "", user_models.StatusActive}
+	if active {
+		return bson.M{attr.field: bson.M{"$in": activeValues}}, nil
+	}
+	return bson.M{attr.field: bson.M{"$nin": activeValues}}, nil
+}
+
+// stringComparison 非 caseExact 的字串比對以不分大小寫的正規表示式進行
+func stringComparison(attr attribute, op, value string) (bson.M, error) {
+	quoted := regexp.QuoteMeta(value)
+	options := "i"
+	if attr.caseExact {
+		options = ""
+	}
+
+	switch op {
+	case "eq":
+		if attr.caseExact {
+			return bson.M{attr.field: value}, nil
+		}
+		return bson.M{attr.field: primitive.Regex{Pattern: "^" + quoted + "$", Options: options}}, nil
+	case "ne":
+		if attr.caseExact {
+			return bson.M{attr.field: bson.M{"$ne": value}}, nil
+		}
+		return bson.M{attr.field: bson.M{"$not": primitive.Regex{Pattern: "^" + quoted + "$", Options: options}}}, nil
+	case "co":
+		return bson.M{attr.field: primitive.Regex{Pattern: quoted, Options: options}}, nil
+	case "sw":
+		return bson.M{attr.field: primitive.Regex{Pattern: "^" + quoted, Options: options}}, nil
+	case "ew":
+		return bson.M{attr.field: primitive.Regex{Pattern: quoted + "$", Options: options}}, nil
+	case "gt", "ge", "lt", "le":
+		return bson.M{attr.field: bson.M{"$" + mongoOrdering(op): value}}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operator %s", ErrInvalidFilter, op)
+}
+
+func mongoOrdering(op string) string {
+	switch op {
+	case "ge":
+		return "gte"
+	case "le":
+		return "lte"
+	}
+	return op
+}
+
+// typedValue 將篩選值轉換為欄位實際的型別
+func typedValue(attr attribute, value interface{}) (interface{}, error) {
+	switch attr.kind {
+	case kindObjectID:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: id must be a string", ErrInvalidFilter)
+		}
+		id, err := primitive.ObjectIDFromHex(s)
+		if err != nil {
+			// 不合法的 id 不會符合任何資源
+			return primitive.NilObjectID, nil
+		}
+		return id, nil
+	case kindTime:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: dateTime must be a string", ErrInvalidFilter)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid dateTime %q", ErrInvalidFilter, s)
+		}
+		return t, nil
+	case kindInt:
+		switch n := value.(type) {
+		case int64:
+			return n, nil
+		case float64:
+			return n, nil
+		}
+		return nil, fmt.Errorf("%w: integer value expected", ErrInvalidFilter)
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: string value expected", ErrInvalidFilter)
+		}
+		return s, nil
+	}
+}
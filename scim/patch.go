@@ -0,0 +1,230 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	user_models "go-api_for_main/models"
+)
+
+// PATCH 錯誤，對應 RFC 7644 §3.12 的 scimType
+var (
+	ErrInvalidPath   = errors.New("invalid path")
+	ErrNoTarget      = errors.New("no target")
+	ErrInvalidSyntax = errors.New("invalid syntax")
+)
+
+// ApplyPatch 依序套用 PatchOp 操作並回傳新的資源
+// 任一操作失敗時整個 PATCH 不生效
+func ApplyPatch(resource user_models.SCIMUser, operations []user_models.SCIMPatchOperation) (user_models.SCIMUser, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return resource, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return resource, err
+	}
+
+	for i, op := range operations {
+		if err := applyOperation(doc, op); err != nil {
+			return resource, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return resource, err
+	}
+	var patched user_models.SCIMUser
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return resource, errors.Join(ErrInvalidValue, err)
+	}
+	return patched, nil
+}
+
+func applyOperation(doc map[string]interface{}, op user_models.SCIMPatchOperation) error {
+	kind := strings.ToLower(op.Op)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidSyntax, op.Op)
+	}
+
+	if op.Path == "" {
+		if kind == "remove" {
+			return fmt.Errorf("%w: remove requires a path", ErrNoTarget)
+		}
+		values, ok := op.Value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w: value must be an object when path is omitted", ErrInvalidSyntax)
+		}
+		for key, value := range values {
+			// 部分用戶端會在無 path 的操作中使用完整 URN 屬性名稱
+			if strings.HasPrefix(strings.ToLower(key), "urn:") && !strings.EqualFold(key, user_models.SCIMSchemaProfileExtension) {
+				if err := applyOperation(doc, user_models.SCIMPatchOperation{Op: kind, Path: key, Value: value}); err != nil {
+					return err
+				}
+				continue
+			}
+			setValue(doc, key, value, kind == "add")
+		}
+		return nil
+	}
+
+	path, err := ParsePath(op.Path)
+	if err != nil {
+		return errors.Join(ErrInvalidPath, err)
+	}
+
+	container, err := resolveContainer(doc, path.Attribute.URN, kind != "remove")
+	if err != nil || container == nil {
+		return err
+	}
+
+	if path.Filter != nil {
+		return applyFiltered(container, path, kind, op.Value)
+	}
+
+	name := path.Attribute.Name
+	if path.Attribute.SubAttribute == "" {
+		if kind == "remove" {
+			deleteKey(container, name)
+		} else {
+			setValue(container, name, op.Value, kind == "add")
+		}
+		return nil
+	}
+
+	// 沒有篩選的子屬性路徑，例如 name.formatted
+	parent := lookupValue(container, name)
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		applySubAttribute(p, path.Attribute.SubAttribute, kind, op.Value)
+	case []interface{}:
+		for _, element := range p {
+			if m, ok := element.(map[string]interface{}); ok {
+				applySubAttribute(m, path.Attribute.SubAttribute, kind, op.Value)
+			}
+		}
+	case nil:
+		if kind != "remove" {
+			child := map[string]interface{}{}
+			applySubAttribute(child, path.Attribute.SubAttribute, kind, op.Value)
+			container[actualKey(container, name)] = child
+		}
+	default:
+		return fmt.Errorf("%w: %s is not a complex attribute", ErrInvalidPath, name)
+	}
+	return nil
+}
+
+// resolveContainer 取得路徑所屬的物件：核心 schema 為資源本身，擴充 schema 為其子物件
+func resolveContainer(doc map[string]interface{}, urn string, create bool) (map[string]interface{}, error) {
+	if urn == "" || strings.EqualFold(urn, user_models.SCIMSchemaUser) {
+		return doc, nil
+	}
+	if !strings.EqualFold(urn, user_models.SCIMSchemaProfileExtension) {
+		return nil, fmt.Errorf("%w: unknown schema %s", ErrInvalidPath, urn)
+	}
+
+	key := actualKey(doc, user_models.SCIMSchemaProfileExtension)
+	ext, ok := doc[key].(map[string]interface{})
+	if !ok {
+		if !create {
+			return nil, nil
+		}
+		ext = map[string]interface{}{}
+		doc[key] = ext
+	}
+	return ext, nil
+}
+
+func applyFiltered(container map[string]interface{}, path PatchPath, kind string, value interface{}) error {
+	key := actualKey(container, path.Attribute.Name)
+	elements, _ := container[key].([]interface{})
+
+	matched := false
+	kept := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		m, ok := element.(map[string]interface{})
+		if !ok || !Matches(path.Filter, m) {
+			kept = append(kept, element)
+			continue
+		}
+		matched = true
+
+		switch {
+		case path.SubAttribute != "":
+			applySubAttribute(m, path.SubAttribute, kind, value)
+			kept = append(kept, m)
+		case kind == "remove":
+			// 移除整個符合的元素
+		default:
+			replacement, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%w: value must be an object", ErrInvalidSyntax)
+			}
+			for k, v := range replacement {
+				setValue(m, k, v, false)
+			}
+			kept = append(kept, m)
+		}
+	}
+
+	if !matched {
+		return fmt.Errorf("%w: no value matches %s", ErrNoTarget, path.Attribute.Name)
+	}
+	container[key] = kept
+	return nil
+}
+
+func applySubAttribute(parent map[string]interface{}, name, kind string, value interface{}) {
+	if kind == "remove" {
+		deleteKey(parent, name)
+		return
+	}
+	setValue(parent, name, value, kind == "add")
+}
+
+// setValue 設定屬性值；add 對多值屬性為附加，對複合屬性為合併
+func setValue(container map[string]interface{}, name string, value interface{}, add bool) {
+	key := actualKey(container, name)
+	existing := container[key]
+
+	if add {
+		if list, ok := existing.([]interface{}); ok {
+			if values, ok := value.([]interface{}); ok {
+				container[key] = append(list, values...)
+			} else {
+				container[key] = append(list, value)
+			}
+			return
+		}
+	}
+
+	if current, ok := existing.(map[string]interface{}); ok {
+		if values, ok := value.(map[string]interface{}); ok {
+			for k, v := range values {
+				setValue(current, k, v, add)
+			}
+			return
+		}
+	}
+
+	container[key] = value
+}
+
+func deleteKey(container map[string]interface{}, name string) {
+	delete(container, actualKey(container, name))
+}
+
+// actualKey 回傳資源中與 name 不分大小寫相符的鍵，不存在時回傳 name
+func actualKey(container map[string]interface{}, name string) string {
+	for key := range container {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
@@ -0,0 +1,203 @@
+package scim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"go-api_for_main/i18n"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/validation"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ErrInvalidValue 表示資源內容不符合 schema
+var ErrInvalidValue = errors.New("invalid value")
+
+// FromUser 將 User 轉換為 SCIM 使用者資源
+func FromUser(user user_models.User, location string) user_models.SCIMUser {
+	active := user.Status.Effective() == user_models.StatusActive
+	age := user.Age
+	resource := user_models.SCIMUser{
+		Schemas:     []string{user_models.SCIMSchemaUser, user_models.SCIMSchemaProfileExtension},
+		ID:          user.ID.Hex(),
+		UserName:    user.Email,
+		DisplayName: user.Name,
+		Active:      &active,
+		Profile: &user_models.SCIMProfileExtension{
+			Sex:     user.Sex,
+			Age:     &age,
+			Address: user.Address,
+		},
+		Meta: &user_models.SCIMMeta{
+			ResourceType: "User",
+			Location:     location,
+		},
+	}
+	if user.Name != "" {
+		resource.Name = &user_models.SCIMName{Formatted: user.Name}
+	}
+	if user.Email != "" {
+		resource.Emails = []user_models.SCIMMultiValued{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	if user.Phone != "" {
+		resource.PhoneNumbers = []user_models.SCIMMultiValued{{Value: user.Phone, Type: "mobile", Primary: true}}
+	}
+	if user.Address != "" {
+		resource.Addresses = []user_models.SCIMAddress{{Formatted: user.Address, Type: "home", Primary: true}}
+	}
+	if !user.CreatedAt.IsZero() {
+		resource.Meta.Created = user.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if !user.UpdatedAt.IsZero() {
+		resource.Meta.LastModified = user.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	resource.Meta.Version = ETag(resource)
+	return resource
+}
+
+// ETag 以資源內容（不含 meta）計算弱 ETag
+func ETag(resource user_models.SCIMUser) string {
+	resource.Meta = nil
+	resource.Password = ""
+	data, _ := json.Marshal(resource)
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// ToUser 將 SCIM 資源套用到 original，回傳更新後的 User
+// 同一欄位可由多個 SCIM 屬性表示（例如 userName 與 emails），以與原值不同的屬性為準
+func ToUser(resource user_models.SCIMUser, original user_models.User) (user_models.User, error) {
+	user := original
+
+	// active 對應生命週期狀態，實際的狀態轉換是否合法由呼叫端驗證
+	if resource.Active != nil {
+		switch {
+		case *resource.Active && original.Status.Effective() != user_models.StatusActive:
+			user.Status = user_models.StatusActive
+		case !*resource.Active && original.Status.Effective() == user_models.StatusActive:
+			user.Status = user_models.StatusDeactivated
+		}
+	}
+
+	user.Email = pickChanged(original.Email, strings.TrimSpace(resource.UserName), primaryValue(resource.Emails))
+	if user.Email == "" {
+		return user, errors.Join(ErrInvalidValue, errors.New("userName is required"))
+	}
+
+	var formatted string
+	if resource.Name != nil {
+		formatted = resource.Name.Formatted
+	}
+	user.Name = pickChanged(original.Name, formatted, resource.DisplayName)
+	user.Phone = pickChanged(original.Phone, primaryValue(resource.PhoneNumbers))
+
+	var extAddress string
+	if resource.Profile != nil {
+		extAddress = resource.Profile.Address
+		user.Sex = resource.Profile.Sex
+		if resource.Profile.Age != nil {
+			user.Age = *resource.Profile.Age
+		} else {
+			user.Age = 0
+		}
+	} else {
+		user.Sex, user.Age = "", 0
+	}
+	user.Address = pickChanged(original.Address, primaryAddress(resource.Addresses), extAddress)
+	if err := normalize(&user, resource.Profile != nil && resource.Profile.Age != nil); err != nil {
+		return user, err
+	}
+
+	if resource.Password != "" {
+		hashed, err := user_models.HashPassword(resource.Password)
+		if err != nil {
+			return user, err
+		}
+		user.Password = hashed
+	}
+
+	return user, nil
+}
+
+// normalize 以 REST 部分更新相同的規則正規化並驗證有值的欄位；binding.Validator 會先套用 validation.Normalize。
+// SCIM 資源可以沒有性別、年齡、電話與地址，提供時必須符合與 REST 相同的格式
+func normalize(user *user_models.User, hasAge bool) error {
+	req := user_models.PatchUserRequest{
+		Name:    optional(user.Name),
+		Email:   optional(user.Email),
+		Sex:     optional(user.Sex),
+		Phone:   optional(user.Phone),
+		Address: optional(user.Address),
+	}
+	if hasAge {
+		age := user.Age
+		req.Age = &age
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		fieldErrors, ok := validation.Translate(err, i18n.English)
+		if !ok {
+			return errors.Join(ErrInvalidValue, err)
+		}
+		messages := make([]string, 0, len(fieldErrors))
+		for _, fe := range fieldErrors {
+			messages = append(messages, fe.Message)
+		}
+		return errors.Join(ErrInvalidValue, errors.New(strings.Join(messages, "; ")))
+	}
+	req.Apply(user)
+	return nil
+}
+
+// optional 將空字串視為未提供
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// pickChanged 回傳第一個與原值不同的非空候選值；沒有變更時回傳第一個非空候選值
+func pickChanged(original string, candidates ...string) string {
+	first := ""
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if candidate != original {
+			return candidate
+		}
+		if first == "" {
+			first = candidate
+		}
+	}
+	return first
+}
+
+func primaryValue(values []user_models.SCIMMultiValued) string {
+	for _, v := range values {
+		if v.Primary {
+			return strings.TrimSpace(v.Value)
+		}
+	}
+	if len(values) > 0 {
+		return strings.TrimSpace(values[0].Value)
+	}
+	return ""
+}
+
+func primaryAddress(addresses []user_models.SCIMAddress) string {
+	for _, a := range addresses {
+		if a.Primary {
+			return a.Formatted
+		}
+	}
+	if len(addresses) > 0 {
+		return addresses[0].Formatted
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package scim
+
+import (
+	user_models "go-api_for_main/models"
+)
+
+// MaxResults 單次查詢最多回傳的資源數
+const MaxResults = 200
+
+// DefaultCount 未指定 count 時每頁的資源數
+const DefaultCount = 100
+
+// ServiceProviderConfig 回傳服務提供者能力說明 (RFC 7643 §5)
+func ServiceProviderConfig(baseURL string) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":          []string{user_models.SCIMSchemaServiceProviderConfig},
+		"documentationUri": baseURL + "/swagger/index.html",
+		"patch":            map[string]interface{}{"supported": true},
+		"bulk":             map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           map[string]interface{}{"supported": true, "maxResults": MaxResults},
+		"changePassword":   map[string]interface{}{"supported": true},
+		"sort":             map[string]interface{}{"supported": true},
+		"etag":             map[string]interface{}{"supported": true},
+		"authenticationSchemes": []map[string]interface{}{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "Authentication using a bearer token",
+				"primary":     true,
+			},
+		},
+		"meta": map[string]interface{}{
+			"resourceType": "ServiceProviderConfig",
+			"location":     baseURL + "/ServiceProviderConfig",
+		},
+	}
+}
+
+// ResourceTypes 回傳支援的資源類型
+func ResourceTypes(baseURL string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"schemas":     []string{user_models.SCIMSchemaResourceType},
+			"id":          "User",
+			"name":        "User",
+			"endpoint":    "/Users",
+			"description": "User Account",
+			"schema":      user_models.SCIMSchemaUser,
+			"schemaExtensions": []map[string]interface{}{
+				{"schema": user_models.SCIMSchemaProfileExtension, "required": false},
+			},
+			"meta": map[string]interface{}{
+				"resourceType": "ResourceType",
+				"location":     baseURL + "/ResourceTypes/User",
+			},
+		},
+	}
+}
+
+func schemaAttribute(name, typ string, multiValued, required bool, extra map[string]interface{}) map[string]interface{} {
+	attr := map[string]interface{}{
+		"name":        name,
+		"type":        typ,
+		"multiValued": multiValued,
+		"required":    required,
+		"caseExact":   false,
+		"mutability":  "readWrite",
+		"returned":    "default",
+		"uniqueness":  "none",
+	}
+	for k, v := range extra {
+		attr[k] = v
+	}
+	return attr
+}
+
+func multiValuedAttribute(name, valueName string) map[string]interface{} {
+	return schemaAttribute(name, "complex", true, false, map[string]interface{}{
+		"subAttributes": []map[string]interface{}{
+			schemaAttribute(valueName, "string", false, false, nil),
+			schemaAttribute("type", "string", false, false, nil),
+			schemaAttribute("primary", "boolean", false, false, nil),
+		},
+	})
+}
+
+// Schemas 回傳核心 User 與擴充 schema 中本服務支援的屬性
+func Schemas(baseURL string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"schemas":     []string{user_models.SCIMSchemaSchema},
+			"id":          user_models.SCIMSchemaUser,
+			"name":        "User",
+			"description": "User Account",
+			"attributes": []map[string]interface{}{
+				schemaAttribute("userName", "string", false, true, map[string]interface{}{"uniqueness": "server"}),
+				schemaAttribute("name", "complex", false, false, map[string]interface{}{
+					"subAttributes": []map[string]interface{}{
+						schemaAttribute("formatted", "string", false, false, nil),
+					},
+				}),
+				schemaAttribute("displayName", "string", false, false, nil),
+				schemaAttribute("password", "string", false, false, map[string]interface{}{
+					"mutability": "writeOnly",
+					"returned":   "never",
+				}),
+				schemaAttribute("active", "boolean", false, false, nil),
+				multiValuedAttribute("emails", "value"),
+				multiValuedAttribute("phoneNumbers", "value"),
+				multiValuedAttribute("addresses", "formatted"),
+			},
+			"meta": map[string]interface{}{
+				"resourceType": "Schema",
+				"location":     baseURL + "/Schemas/" + user_models.SCIMSchemaUser,
+			},
+		},
+		{
+			"schemas":     []string{user_models.SCIMSchemaSchema},
+			"id":          user_models.SCIMSchemaProfileExtension,
+			"name":        "UserProfile",
+			"description": "Sex, age and address attributes of go-api users",
+			"attributes": []map[string]interface{}{
+				schemaAttribute("sex", "string", false, false, nil),
+				schemaAttribute("age", "integer", false, false, nil),
+				schemaAttribute("address", "string", false, false, nil),
+			},
+			"meta": map[string]interface{}{
+				"resourceType": "Schema",
+				"location":     baseURL + "/Schemas/" + user_models.SCIMSchemaProfileExtension,
+			},
+		},
+	}
+}
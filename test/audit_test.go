@@ -0,0 +1,136 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+)
+
+// TestDiffUsers 測試稽核紀錄的欄位差異與機密欄位遮蔽
+func TestDiffUsers(t *testing.T) {
+	before := user_models.User{
+		ID:        primitive.NewObjectID(),
+		Name:      "張三",
+		Email:     "zhangsan@example.com",
+		Password:  "$2a$10$old",
+		Sex:       "male",
+		Age:       20,
+		Status:    user_models.StatusActive,
+		UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Version:   1,
+	}
+
+	changes := func(list []user_models.FieldChange) map[string]user_models.FieldChange {
+		byField := map[string]user_models.FieldChange{}
+		for _, change := range list {
+			byField[change.Field] = change
+		}
+		return byField
+	}
+
+	t.Run("建立時列出所有非空欄位", func(t *testing.T) {
+		diff := changes(user_models.DiffUsers(nil, &before))
+		assert.Equal(t, "zhangsan@example.com", diff["email"].After)
+		assert.Nil(t, diff["email"].Before)
+		assert.Equal(t, user_models.RedactedValue, diff["password"].After)
+		assert.NotContains(t, diff, "phone")
+		assert.NotContains(t, diff, "updated_at")
+		assert.NotContains(t, diff, "version")
+	})
+
+	t.Run("只列出變更的欄位", func(t *testing.T) {
+		after := before
+		after.Email = "new@example.com"
+		after.UpdatedAt = after.UpdatedAt.Add(time.Hour)
+		after.Version = 2
+		diff := user_models.DiffUsers(&before, &after)
+		assert.Equal(t, []user_models.FieldChange{{Field: "email", Before: "zhangsan@example.com", After: "new@example.com"}}, diff)
+	})
+
+	t.Run("密碼變更不記錄內容", func(t *testing.T) {
+		after := before
+		after.Password = "$2a$10$new"
+		diff := user_models.DiffUsers(&before, &after)
+		assert.Equal(t, []user_models.FieldChange{{Field: "password", Before: user_models.RedactedValue, After: user_models.RedactedValue}}, diff)
+		for _, change := range diff {
+			assert.NotContains(t, change.After, "$2a$")
+		}
+	})
+
+	t.Run("狀態轉換", func(t *testing.T) {
+		after := before
+		after.Status = user_models.StatusDeleted
+		diff := changes(user_models.DiffUsers(&before, &after))
+		assert.Equal(t, user_models.StatusActive, diff["status"].Before)
+		assert.Equal(t, user_models.StatusDeleted, diff["status"].After)
+	})
+}
+
+// TestAuditLinks 測試稽核紀錄列表的分頁與匯出連結
+func TestAuditLinks(t *testing.T) {
+	links := user_models.GenerateAuditLinks("http://api.example.com/api/v1", "actor=admin", 2, 20, 45)
+	rels := map[string]string{}
+	for _, link := range links {
+		rels[link.Rel] = link.Href
+	}
+	assert.Equal(t, "http://api.example.com/api/v1/audit?actor=admin&page=2&size=20", rels["self"])
+	assert.Equal(t, "http://api.example.com/api/v1/audit?actor=admin&format=csv", rels["export"])
+	assert.Contains(t, rels["prev"], "page=1")
+	assert.Contains(t, rels["next"], "page=3")
+
+	last := user_models.GenerateAuditLinks("http://api.example.com/api/v1", "", 3, 20, 45)
+	for _, link := range last {
+		assert.NotEqual(t, "next", link.Rel)
+	}
+}
+
+// TestAuditEndpoint 測試資料庫未連接時的稽核紀錄查詢
+func TestAuditEndpoint(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/api/v1/audit", controllers.GetAuditLog)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/audit?format=csv", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// TestRequestID 測試請求識別碼的沿用與產生
+func TestRequestID(t *testing.T) {
+	r := setupTestRouter()
+	r.Use(middleware.RequestID())
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, middleware.CurrentRequestID(c))
+	})
+
+	request := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ping", nil)
+		if id != "" {
+			req.Header.Set(middleware.RequestIDHeader, id)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("trace-123")
+	assert.Equal(t, "trace-123", w.Header().Get(middleware.RequestIDHeader))
+	assert.Equal(t, "trace-123", w.Body.String())
+
+	for _, invalid := range []string{"", "has space", strings.Repeat("a", 200)} {
+		w := request(invalid)
+		generated := w.Header().Get(middleware.RequestIDHeader)
+		assert.Len(t, generated, 32)
+		assert.Equal(t, generated, w.Body.String())
+	}
+}
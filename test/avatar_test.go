@@ -0,0 +1,216 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/avatar"
+	"go-api_for_main/controllers"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/oidc"
+	"go-api_for_main/routes"
+)
+
+// twoColorImage 左半部紅色、右半部藍色的圖片
+func twoColorImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// withEXIFOrientation 在 JPEG 的 SOI 之後插入只含方向標籤的 EXIF 區段
+func withEXIFOrientation(jpegData []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))      // 項目數
+	binary.Write(&tiff, binary.BigEndian, uint16(0x0112)) // Orientation
+	binary.Write(&tiff, binary.BigEndian, uint16(3))      // SHORT
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, orientation)
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0)) // 沒有下一個 IFD
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(jpegData[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(jpegData[2:])
+	return out.Bytes()
+}
+
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > 0xC000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return b > 0xC000 && r < 0x4000
+}
+
+// TestAvatarSniff 測試依內容判斷類型，不接受非圖片與不支援的格式
+func TestAvatarSniff(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, twoColorImage(4, 4)))
+	contentType, ok := avatar.Sniff(buf.Bytes())
+	assert.True(t, ok)
+	assert.Equal(t, "image/png", contentType)
+
+	_, ok = avatar.Sniff([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.False(t, ok)
+	_, err := avatar.Process([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "))
+	assert.ErrorIs(t, err, avatar.ErrUnsupportedType)
+	_, err = avatar.Process(append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...))
+	assert.ErrorIs(t, err, avatar.ErrInvalidImage)
+}
+
+// TestAvatarVariants 測試裁切為正方形並產生每個尺寸
+func TestAvatarVariants(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, twoColorImage(300, 200)))
+
+	result, err := avatar.Process(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result.ContentType)
+	assert.Equal(t, 300, result.Width)
+	assert.Equal(t, 200, result.Height)
+	assert.Len(t, result.Variants, len(avatar.Sizes))
+	for i, variant := range result.Variants {
+		assert.Equal(t, avatar.Sizes[i], variant.Size)
+		img, err := png.Decode(bytes.NewReader(variant.Data))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, variant.Size, variant.Size), img.Bounds())
+		// 中央裁切保留左右兩種顏色
+		assert.True(t, isRed(img.At(1, variant.Size/2)))
+		assert.True(t, isBlue(img.At(variant.Size-2, variant.Size/2)))
+	}
+}
+
+// TestAvatarEXIFOrientation 測試依 EXIF 方向轉正，輸出不含 EXIF
+func TestAvatarEXIFOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, twoColorImage(80, 40), &jpeg.Options{Quality: 95}))
+	data := withEXIFOrientation(buf.Bytes(), 6)
+
+	result, err := avatar.Process(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", result.ContentType)
+	// 順時針旋轉 90 度後寬高互換，原本的左半部（紅色）轉到上半部
+	assert.Equal(t, 40, result.Width)
+	assert.Equal(t, 80, result.Height)
+
+	variant := result.Variants[1]
+	img, err := jpeg.Decode(bytes.NewReader(variant.Data))
+	assert.NoError(t, err)
+	assert.True(t, isRed(img.At(variant.Size/2, 2)))
+	assert.True(t, isBlue(img.At(variant.Size/2, variant.Size-3)))
+	for _, v := range result.Variants {
+		assert.NotContains(t, string(v.Data), "Exif")
+	}
+}
+
+// TestAvatarLimits 測試寬高上限與尺寸選擇
+func TestAvatarLimits(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, avatar.MaxDimension+1, 1))))
+	_, err := avatar.Process(buf.Bytes())
+	assert.ErrorIs(t, err, avatar.ErrTooLarge)
+
+	assert.Equal(t, 32, avatar.Pick(1))
+	assert.Equal(t, 64, avatar.Pick(64))
+	assert.Equal(t, 128, avatar.Pick(65))
+	assert.Equal(t, 512, avatar.Pick(4096))
+}
+
+// TestAvatarLinks 測試頭像的 ETag 與連結
+func TestAvatarLinks(t *testing.T) {
+	a := user_models.Avatar{Hash: "abc123", Variants: []user_models.AvatarVariant{{Size: 64}, {Size: 128}}}
+	assert.Equal(t, `"abc123-64"`, a.ETag(64))
+	_, ok := a.Variant(256)
+	assert.False(t, ok)
+
+	links := user_models.GenerateAvatarLinks("http://api.example.com/api/v1", "u1", a)
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1/avatar", links[0].Href)
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1/avatar?size=128", links[len(links)-1].Href)
+
+	var rels []string
+	for _, link := range user_models.GenerateUserLinks("http://localhost", "u1", user_models.StatusActive) {
+		rels = append(rels, link.Rel)
+	}
+	assert.Contains(t, rels, "avatar")
+}
+
+// TestAvatarEndpoints 測試頭像路由與資料庫未連接時的回應，上傳與刪除需要登入
+func TestAvatarEndpoints(t *testing.T) {
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+	path := "/api/v1/users/" + primitive.NewObjectID().Hex() + "/avatar"
+
+	for method, expected := range map[string]int{
+		"PUT":    http.StatusUnauthorized,
+		"GET":    http.StatusServiceUnavailable,
+		"DELETE": http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, expected, w.Code, method)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path+"?size=1024", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestRequireOwnerOrAdmin 測試本人可以直接通過，其他用戶需要確認管理員角色
+func TestRequireOwnerOrAdmin(t *testing.T) {
+	owner := primitive.NewObjectID().Hex()
+	verify := func(token string) (*oidc.AccessTokenClaims, error) {
+		return &oidc.AccessTokenClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: token}}, nil
+	}
+
+	r := setupTestRouter()
+	r.PUT("/api/v1/users/:id/avatar", middleware.Authenticate(verify), controllers.RequireOwnerOrAdmin("id"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	request := func(subject string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/users/"+owner+"/avatar", nil)
+		if subject != "" {
+			req.Header.Set("Authorization", "Bearer "+subject)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, request(owner))
+	assert.Equal(t, http.StatusUnauthorized, request(""))
+	// 其他用戶需要從資料庫確認角色，資料庫未連接時不會放行
+	assert.Equal(t, http.StatusServiceUnavailable, request(primitive.NewObjectID().Hex()))
+}
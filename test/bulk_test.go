@@ -0,0 +1,137 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-api_for_main/controllers"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/routes"
+)
+
+// TestBulkRequestValidation 測試批次請求的模式與操作驗證
+func TestBulkRequestValidation(t *testing.T) {
+	valid := &user_models.BulkRequest{Operations: []user_models.BulkOperation{{Op: user_models.BulkOpDelete, ID: "507f1f77bcf86cd799439011"}}}
+	assert.NoError(t, binding.Validator.ValidateStruct(valid))
+	assert.Equal(t, user_models.BulkModeOrdered, valid.EffectiveMode())
+
+	invalid := []*user_models.BulkRequest{
+		{},
+		{Mode: "parallel", Operations: valid.Operations},
+		{Operations: []user_models.BulkOperation{{Op: "upsert"}}},
+		{Operations: make([]user_models.BulkOperation, user_models.MaxBulkOperations+1)},
+	}
+	for _, req := range invalid {
+		assert.Error(t, binding.Validator.ValidateStruct(req))
+	}
+}
+
+// TestBulkResponse 測試批次響應的成功與失敗統計
+func TestBulkResponse(t *testing.T) {
+	response := user_models.NewBulkResponse(user_models.BulkModeUnordered, []user_models.BulkItemResult{
+		{Index: 0, Op: user_models.BulkOpCreate, Status: http.StatusCreated},
+		{Index: 1, Op: user_models.BulkOpUpdate, Status: http.StatusConflict},
+		{Index: 2, Op: user_models.BulkOpDelete, Status: http.StatusOK},
+		{Index: 3, Op: user_models.BulkOpUpdate, Status: http.StatusUnprocessableEntity},
+	}, user_models.GenerateBulkLinks("http://api.example.com/api/v1"))
+
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, 2, response.Failed)
+	assert.Equal(t, "http://api.example.com/api/v1/users/bulk", response.Links[0].Href)
+}
+
+// TestBulkErrorStatus 測試操作錯誤對應的狀態碼
+func TestBulkErrorStatus(t *testing.T) {
+	writeError := func(code int) error {
+		return mongo.BulkWriteError{WriteError: mongo.WriteError{Code: code, Message: "write failed"}}
+	}
+	testCases := []struct {
+		name     string // 測試用例名稱
+		err      error  // 操作的錯誤
+		expected int    // 預期的狀態碼
+	}{
+		{name: "用戶不存在", err: controllers.ErrUserNotFound, expected: http.StatusNotFound},
+		{name: "並行修改", err: controllers.ErrConcurrentModification, expected: http.StatusConflict},
+		{name: "重複的鍵", err: writeError(11000), expected: http.StatusConflict},
+		{name: "文件驗證失敗", err: writeError(121), expected: http.StatusUnprocessableEntity},
+		{name: "未執行", err: controllers.ErrBulkNotExecuted, expected: http.StatusFailedDependency},
+		{name: "其他錯誤", err: errors.New("connection reset"), expected: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, controllers.BulkErrorStatus(tc.err))
+		})
+	}
+}
+
+// TestMapBulkWriteError 測試各執行模式下 BulkWrite 錯誤分配到寫入的結果
+func TestMapBulkWriteError(t *testing.T) {
+	failure := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}},
+	}}
+	ok, conflict, skipped := http.StatusOK, http.StatusConflict, http.StatusFailedDependency
+
+	testCases := []struct {
+		name     string // 測試用例名稱
+		mode     string // 執行模式
+		err      error  // BulkWrite 的錯誤
+		expected []int  // 各寫入預期的狀態碼，200 表示沒有錯誤
+	}{
+		{name: "ordered在失敗後停止", mode: user_models.BulkModeOrdered, err: failure, expected: []int{ok, conflict, skipped, skipped}},
+		{name: "unordered只標記失敗的寫入", mode: user_models.BulkModeUnordered, err: failure, expected: []int{ok, conflict, ok, ok}},
+		{name: "atomic在失敗後停止", mode: user_models.BulkModeAtomic, err: failure, expected: []int{ok, conflict, skipped, skipped}},
+		{
+			name: "unordered多個失敗", mode: user_models.BulkModeUnordered,
+			err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+				{WriteError: mongo.WriteError{Index: 3, Code: 121, Message: "document failed validation"}},
+				{WriteError: mongo.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}},
+			}},
+			expected: []int{conflict, ok, ok, http.StatusUnprocessableEntity},
+		},
+		{name: "非寫入錯誤套用到所有寫入", mode: user_models.BulkModeOrdered, err: errors.New("connection reset"),
+			expected: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := make([]error, len(tc.expected))
+			controllers.MapBulkWriteError(tc.mode, tc.err, errs)
+			statuses := make([]int, len(errs))
+			for i, err := range errs {
+				statuses[i] = http.StatusOK
+				if err != nil {
+					statuses[i] = controllers.BulkErrorStatus(err)
+				}
+			}
+			assert.Equal(t, tc.expected, statuses)
+		})
+	}
+}
+
+// TestBulkEndpoint 測試批次路由需要登入，以及資料庫未連接時的回應
+func TestBulkEndpoint(t *testing.T) {
+	body := []byte(`{"operations":[{"op":"delete","id":"507f1f77bcf86cd799439011"}]}`)
+	post := func(r http.Handler) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/users/bulk", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+	assert.Equal(t, http.StatusUnauthorized, post(r))
+
+	direct := setupTestRouter()
+	direct.POST("/api/v1/users/bulk", controllers.BulkUsers)
+	assert.Equal(t, http.StatusServiceUnavailable, post(direct))
+}
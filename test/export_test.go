@@ -0,0 +1,141 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/exporter"
+	"go-api_for_main/importer"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/validation"
+)
+
+func exportSample() user_models.UserView {
+	return user_models.UserView{
+		ID:        "507f1f77bcf86cd799439011",
+		Name:      "張三",
+		Email:     "zhangsan@example.com",
+		Sex:       "male",
+		Age:       20,
+		Phone:     "+886912345678",
+		Address:   "=HYPERLINK(\"http://evil\"), 台北市; 信義區",
+		Status:    user_models.StatusActive,
+		Version:   3,
+		UpdatedAt: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+	}
+}
+
+func exportAll(t *testing.T, format exporter.Format, fields []string, users ...user_models.UserView) string {
+	var buf bytes.Buffer
+	w, err := exporter.NewWriter(format, &buf, fields)
+	assert.NoError(t, err)
+	for _, user := range users {
+		assert.NoError(t, w.Write(user))
+	}
+	assert.NoError(t, w.Close())
+	return buf.String()
+}
+
+// TestExportFields 測試欄位選擇，密碼不能被匯出
+func TestExportFields(t *testing.T) {
+	fields, err := exporter.ParseFields("")
+	assert.NoError(t, err)
+	assert.Equal(t, exporter.Fields, fields)
+	assert.NotContains(t, fields, "password")
+
+	fields, err = exporter.ParseFields(" Email, name,email ")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"email", "name"}, fields)
+
+	_, err = exporter.ParseFields("name,password")
+	assert.ErrorIs(t, err, exporter.ErrUnknownField)
+}
+
+// TestExportCSVAndNDJSON 測試 CSV 的公式防護與 NDJSON 的欄位順序
+func TestExportCSVAndNDJSON(t *testing.T) {
+	out := exportAll(t, exporter.CSV, []string{"name", "address", "age"}, exportSample())
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Equal(t, "name,address,age", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], `張三,"'=HYPERLINK`))
+	assert.True(t, strings.HasSuffix(lines[1], ",20"))
+
+	out = exportAll(t, exporter.NDJSON, []string{"version", "email", "updated_at"}, exportSample(), exportSample())
+	lines = strings.Split(strings.TrimSpace(out), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, `{"version":3,"email":"zhangsan@example.com","updated_at":"2024-05-06T07:08:09Z"}`, lines[0])
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &decoded))
+}
+
+// TestExportCSVRoundTrip 測試匯出的 CSV 可以再被匯入，電話號碼不加引號，防公式的引號在匯入時移除
+func TestExportCSVRoundTrip(t *testing.T) {
+	sample := exportSample()
+	sample.Name = "-張三"
+	out := exportAll(t, exporter.CSV, []string{"name", "email", "phone", "address"}, sample)
+	assert.Contains(t, out, ",+886912345678,")
+	assert.Contains(t, out, `'=HYPERLINK`)
+
+	table, err := importer.Read(importer.CSV, []byte(out))
+	assert.NoError(t, err)
+	mapping, err := importer.ParseMapping("", table.Headers)
+	assert.NoError(t, err)
+	row := mapping.Apply(table.Rows[0])
+	assert.Equal(t, sample.Name, row["name"])
+	assert.Equal(t, sample.Phone, row["phone"])
+	assert.Equal(t, sample.Address, row["address"])
+	assert.True(t, validation.IsE164(row["phone"]))
+
+	assert.Equal(t, "+886912345678", exporter.CSVSafe("+886912345678"))
+	assert.Equal(t, "'+1+2", exporter.CSVSafe("+1+2"))
+	assert.Equal(t, "'-1", exporter.CSVSafe("-1"))
+}
+
+// TestExportXLSX 測試匯出的 XLSX 可以再被匯入讀取
+func TestExportXLSX(t *testing.T) {
+	out := exportAll(t, exporter.XLSX, []string{"name", "email", "age", "address"}, exportSample(), exportSample())
+	table, err := importer.Read(importer.XLSX, []byte(out))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"name", "email", "age", "address"}, table.Headers)
+	assert.Len(t, table.Rows, 2)
+	assert.Equal(t, []string{"張三", "zhangsan@example.com", "20", exportSample().Address}, table.Rows[1].Values)
+}
+
+// TestExportVCard 測試 vCard 的屬性對應、轉義與折行
+func TestExportVCard(t *testing.T) {
+	user := exportSample()
+	user.Name = strings.Repeat("很長的名字", 10)
+	out := exportAll(t, exporter.VCF, []string{"id", "name", "email", "phone", "address", "sex", "updated_at", "age"}, user)
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCARD\r\nVERSION:4.0\r\nUID:urn:user:507f1f77bcf86cd799439011\r\n"))
+	assert.Contains(t, out, "TEL;VALUE=uri:tel:+886912345678\r\n")
+	assert.Contains(t, out, `ADR:;;=HYPERLINK("http://evil")\, 台北市\; 信義區;;;;`)
+	assert.Contains(t, out, "GENDER:M\r\n")
+	assert.Contains(t, out, "REV:20240506T070809Z\r\n")
+	assert.True(t, strings.HasSuffix(out, "END:VCARD\r\n"))
+
+	for _, line := range strings.Split(out, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	assert.Contains(t, unfolded, "FN:"+user.Name+"\r\n")
+	assert.NotContains(t, out, "20\r\n")
+}
+
+// TestExportEndpoint 測試資料庫未連接時的匯出端點
+func TestExportEndpoint(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/api/v1/users/export", controllers.ExportUsers)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/users/export?format=xlsx", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,201 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/feed"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/routes"
+)
+
+func feedEvent(eventType, userID string) feed.Event {
+	return feed.Event{Type: eventType, UserID: userID, OccurredAt: time.Now().UTC(), Data: json.RawMessage(`{"type":"` + eventType + `"}`)}
+}
+
+// receive 在時限內取得下一個事件，通道已關閉時回傳 false
+func receive(t *testing.T, events <-chan feed.Event) (feed.Event, bool) {
+	select {
+	case e, ok := <-events:
+		return e, ok
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return feed.Event{}, false
+	}
+}
+
+// TestFeedBusReplay 測試從事件ID續傳、依條件篩選，以及無法續傳時標記 Reset
+func TestFeedBusReplay(t *testing.T) {
+	bus := feed.NewBus(3)
+	first := bus.Publish(feedEvent(user_models.WebhookUserCreated, "u1"))
+	second := bus.Publish(feedEvent(user_models.WebhookUserUpdated, "u1"))
+	third := bus.Publish(feedEvent(user_models.WebhookUserCreated, "u2"))
+	assert.NotEqual(t, first.ID, second.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub, err := bus.Subscribe(ctx, first.ID, feed.Filter{})
+	assert.NoError(t, err)
+	assert.False(t, sub.Reset)
+	e, _ := receive(t, sub.Events)
+	assert.Equal(t, second.ID, e.ID)
+	e, _ = receive(t, sub.Events)
+	assert.Equal(t, third.ID, e.ID)
+
+	filtered, err := bus.Subscribe(ctx, first.ID, feed.Filter{Types: []string{user_models.WebhookUserCreated}})
+	assert.NoError(t, err)
+	e, _ = receive(t, filtered.Events)
+	assert.Equal(t, third.ID, e.ID)
+
+	byUser, err := bus.Subscribe(ctx, "", feed.Filter{UserIDs: []string{"u1"}})
+	assert.NoError(t, err)
+	bus.Publish(feedEvent(user_models.WebhookUserUpdated, "u2"))
+	live := bus.Publish(feedEvent(user_models.WebhookUserDeleted, "u1"))
+	e, _ = receive(t, byUser.Events)
+	assert.Equal(t, live.ID, e.ID)
+
+	// first 已超出保留範圍
+	stale, err := bus.Subscribe(ctx, first.ID, feed.Filter{})
+	assert.NoError(t, err)
+	assert.True(t, stale.Reset)
+
+	unknown, err := bus.Subscribe(ctx, "other-1", feed.Filter{})
+	assert.NoError(t, err)
+	assert.True(t, unknown.Reset)
+
+	cancel()
+	_, open := receive(t, byUser.Events)
+	assert.False(t, open)
+}
+
+// TestFeedBusSlowSubscriber 測試跟不上的訂閱者會被關閉
+func TestFeedBusSlowSubscriber(t *testing.T) {
+	bus := feed.NewBus(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := bus.Subscribe(ctx, "", feed.Filter{})
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		bus.Publish(feedEvent(user_models.WebhookUserUpdated, "u1"))
+	}
+	received := 0
+	for range sub.Events {
+		received++
+	}
+	assert.Less(t, received, 100)
+}
+
+func feedServer(t *testing.T) (*feed.Bus, *httptest.Server) {
+	bus := feed.NewBus(10)
+	controllers.SetUserFeed(bus)
+	r := setupTestRouter()
+	r.GET("/api/v1/users/stream", controllers.StreamUsers)
+	server := httptest.NewServer(r)
+	t.Cleanup(func() {
+		server.Close()
+		controllers.SetUserFeed(nil)
+	})
+	return bus, server
+}
+
+// readServerSentEvent 讀取下一個以空行結尾的 SSE 區塊
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return fields
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			return fields
+		}
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
+
+// TestStreamUsersSSE 測試 SSE 事件流依條件推送事件，並在無法續傳時送出 reset
+func TestStreamUsersSSE(t *testing.T) {
+	bus, server := feedServer(t)
+
+	resp, err := http.Get(server.URL + "/api/v1/users/stream?type=user.updated")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal(t, "3000", readServerSentEvent(t, reader)["retry"])
+
+	bus.Publish(feedEvent(user_models.WebhookUserCreated, "u1"))
+	updated := bus.Publish(feedEvent(user_models.WebhookUserUpdated, "u1"))
+	event := readServerSentEvent(t, reader)
+	assert.Equal(t, updated.ID, event["id"])
+	assert.Equal(t, user_models.WebhookUserUpdated, event["event"])
+	assert.JSONEq(t, `{"type":"user.updated"}`, event["data"])
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/users/stream", nil)
+	req.Header.Set("Last-Event-ID", "unknown-1")
+	resumed, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resumed.Body.Close()
+	reader = bufio.NewReader(resumed.Body)
+	readServerSentEvent(t, reader)
+	assert.Equal(t, "reset", readServerSentEvent(t, reader)["event"])
+}
+
+// TestStreamUsersWebSocket 測試 WebSocket 連線從 last_event_id 續傳符合條件的事件
+func TestStreamUsersWebSocket(t *testing.T) {
+	bus, server := feedServer(t)
+	first := bus.Publish(feedEvent(user_models.WebhookUserCreated, "6650a1b2c3d4e5f6a7b8c9d0"))
+	bus.Publish(feedEvent(user_models.WebhookUserCreated, "6650a1b2c3d4e5f6a7b8c9d1"))
+	want := bus.Publish(feedEvent(user_models.WebhookUserUpdated, "6650a1b2c3d4e5f6a7b8c9d0"))
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/users/stream?user_id=6650a1b2c3d4e5f6a7b8c9d0&last_event_id=" + first.ID
+	ws, err := websocket.Dial(url, "", server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+
+	var event feed.Event
+	assert.NoError(t, websocket.JSON.Receive(ws, &event))
+	assert.Equal(t, want.ID, event.ID)
+	assert.Equal(t, user_models.WebhookUserUpdated, event.Type)
+	assert.Equal(t, "6650a1b2c3d4e5f6a7b8c9d0", event.UserID)
+}
+
+// TestStreamUsersInvalidFilter 測試無效的事件類型或用戶ID回傳 400
+func TestStreamUsersInvalidFilter(t *testing.T) {
+	_, server := feedServer(t)
+	for _, query := range []string{"type=user.renamed", "user_id=abc", "type=user.created,nope"} {
+		resp, err := http.Get(server.URL + "/api/v1/users/stream?" + query)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+// TestStreamUsersRequiresAdmin 測試事件流需要登入的管理員
+func TestStreamUsersRequiresAdmin(t *testing.T) {
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/stream", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
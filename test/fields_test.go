@@ -0,0 +1,103 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"go-api_for_main/controllers"
+	user_models "go-api_for_main/models"
+)
+
+// TestParseFieldSet 測試 fields 參數的解析與允許清單
+func TestParseFieldSet(t *testing.T) {
+	testCases := []struct {
+		name     string               // 測試用例名稱
+		value    string               // fields 參數
+		expected user_models.FieldSet // 預期的欄位
+		unknown  string               // 預期被拒絕的欄位
+	}{
+		{name: "未指定", value: "", expected: nil},
+		{name: "多個欄位", value: "name,email", expected: user_models.FieldSet{"name", "email"}},
+		{name: "空白與重複", value: " name , ,name,age", expected: user_models.FieldSet{"name", "age"}},
+		{name: "密碼", value: "name,password", unknown: "password"},
+		{name: "不存在的欄位", value: "status_history", unknown: "status_history"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fields, unknown, ok := user_models.ParseFieldSet(tc.value)
+			assert.Equal(t, tc.unknown == "", ok)
+			assert.Equal(t, tc.unknown, unknown)
+			assert.Equal(t, tc.expected, fields)
+		})
+	}
+
+	t.Run("投影", func(t *testing.T) {
+		fields, _, _ := user_models.ParseFieldSet("id,email,created_at")
+		assert.Equal(t, bson.M{"_id": 1, "status": 1, "version": 1, "email": 1, "created_at": 1}, fields.Projection())
+	})
+}
+
+// TestSparseFieldsets 測試詳細與列表端點只輸出選擇的欄位，連結維持不變
+func TestSparseFieldsets(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/api/test/users", controllers.GetUsers_test)
+	r.GET("/api/test/users/:id", controllers.GetUser_test)
+
+	request := func(path, accept string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Accept", accept)
+		r.ServeHTTP(w, req)
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body
+	}
+
+	const path = "/api/test/users/507f1f77bcf86cd799439011"
+	_, full := request(path, "")
+
+	t.Run("詳細", func(t *testing.T) {
+		w, body := request(path+"?fields=name,email", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, map[string]interface{}{"id": "507f1f77bcf86cd799439011", "name": "test users", "email": "test@example.com"}, body["data"])
+		assert.Equal(t, full["_links"], body["_links"])
+	})
+
+	t.Run("列表", func(t *testing.T) {
+		w, body := request("/api/test/users?fields=name", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		items := body["data"].([]interface{})
+		assert.Len(t, items, 1)
+		assert.ElementsMatch(t, []string{"id", "name"}, mapKeys(items[0].(map[string]interface{})))
+		assert.NotEmpty(t, body["_links"])
+	})
+
+	t.Run("HAL保留連結", func(t *testing.T) {
+		w, body := request(path+"?fields=age", "application/hal+json")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []string{"id", "age", "_links"}, mapKeys(body))
+		assert.Contains(t, body["_links"], "self")
+	})
+
+	t.Run("不允許的欄位", func(t *testing.T) {
+		for _, target := range []string{path + "?fields=name,password", "/api/test/users?fields=password"} {
+			w, body := request(target, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "fields contains a field that cannot be selected: password", body["error"])
+		}
+	})
+}
+
+func mapKeys(m map[string]interface{}) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
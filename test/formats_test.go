@@ -0,0 +1,205 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v3"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/formats"
+	"go-api_for_main/hypermedia"
+	user_models "go-api_for_main/models"
+)
+
+// TestSelectEncoding 測試 Accept 標頭的編碼協商與無法滿足時的判斷
+func TestSelectEncoding(t *testing.T) {
+	testCases := []struct {
+		name     string // 測試用例名稱
+		accept   string // Accept 標頭
+		expected string // 預期的格式
+		ok       bool   // 是否可以滿足
+	}{
+		{name: "MessagePack別名", accept: "application/x-msgpack", expected: formats.MediaTypeMsgPack, ok: true},
+		{name: "CBOR", accept: "application/cbor", expected: formats.MediaTypeCBOR, ok: true},
+		{name: "text/xml", accept: "text/xml", expected: formats.MediaTypeXML, ok: true},
+		{name: "YAML依q值", accept: "application/xml;q=0.2, application/yaml", expected: formats.MediaTypeYAML, ok: true},
+		{name: "類型萬用字元", accept: "application/*", expected: hypermedia.MediaTypeJSON, ok: true},
+		{name: "不支援的格式", accept: "text/html", expected: hypermedia.MediaTypeJSON, ok: false},
+		{name: "不支援但有萬用字元", accept: "text/html, */*;q=0.1", expected: hypermedia.MediaTypeJSON, ok: true},
+		{name: "瀏覽器", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8", expected: hypermedia.MediaTypeJSON, ok: true},
+		{name: "最優先的XML", accept: "application/xml;q=0.9, */*;q=0.8", expected: formats.MediaTypeXML, ok: true},
+		{name: "q為0排除JSON", accept: "*/*, application/json;q=0", expected: hypermedia.MediaTypeHAL, ok: true},
+		{name: "類型萬用字元排除JSON", accept: "application/*, application/json;q=0, application/hal+json;q=0", expected: hypermedia.MediaTypeJSONAPI, ok: true},
+		{name: "只接受被排除的JSON", accept: "application/json;q=0", expected: hypermedia.MediaTypeJSON, ok: false},
+		{name: "萬用字元q為0", accept: "*/*;q=0", expected: hypermedia.MediaTypeJSON, ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mediaType, ok := hypermedia.Select(tc.accept)
+			assert.Equal(t, tc.expected, mediaType)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+// TestFormatsRoundTrip 測試各格式的編碼可以再轉回相同的 JSON，XML 依目標型別還原數字
+func TestFormatsRoundTrip(t *testing.T) {
+	req := user_models.UpdateUserRequest{Name: "張三", Email: "zhangsan@example.com", Sex: "male", Age: 20, Phone: "+886912345678", Address: "台北市 <1F> & 2F"}
+	expected, _ := json.Marshal(req)
+
+	for _, mediaType := range []string{formats.MediaTypeMsgPack, formats.MediaTypeCBOR, formats.MediaTypeXML, formats.MediaTypeYAML} {
+		t.Run(mediaType, func(t *testing.T) {
+			encoded, err := formats.Marshal(mediaType, req)
+			assert.NoError(t, err)
+			converted, err := formats.ToJSON(mediaType, encoded, &user_models.UpdateUserRequest{})
+			assert.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(converted))
+		})
+	}
+
+	t.Run("XML陣列與無法轉換的名稱", func(t *testing.T) {
+		encoded, err := formats.Marshal(formats.MediaTypeXML, map[string]interface{}{"ids": []string{"a", "b"}, "1st": nil})
+		assert.NoError(t, err)
+		assert.Contains(t, string(encoded), `<ids><item>a</item><item>b</item></ids>`)
+		assert.Contains(t, string(encoded), `<entry key="1st"></entry>`)
+	})
+
+	t.Run("格式錯誤", func(t *testing.T) {
+		_, err := formats.ToJSON(formats.MediaTypeXML, []byte("<response><name>"), &user_models.UpdateUserRequest{})
+		assert.ErrorIs(t, err, formats.ErrMalformed)
+	})
+}
+
+// TestEncodedResponses 測試 HATEOAS 回應與錯誤回應依 Accept 編碼
+func TestEncodedResponses(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/api/test/users", controllers.GetUsers_test)
+	r.POST("/api/test/users", controllers.CreateUser_test)
+	r.PUT("/api/test/users/:id", controllers.UpdateUser_test)
+
+	request := func(method, path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Accept", accept)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	decoders := map[string]func([]byte, interface{}) error{
+		formats.MediaTypeMsgPack: func(data []byte, v interface{}) error {
+			handle := &codec.MsgpackHandle{}
+			handle.RawToString = true
+			return codec.NewDecoderBytes(data, handle).Decode(v)
+		},
+		formats.MediaTypeCBOR: func(data []byte, v interface{}) error {
+			return codec.NewDecoderBytes(data, &codec.CborHandle{}).Decode(v)
+		},
+		formats.MediaTypeYAML: yaml.Unmarshal,
+	}
+	for mediaType, decode := range decoders {
+		t.Run(mediaType, func(t *testing.T) {
+			w := request("POST", "/api/test/users", mediaType)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), mediaType))
+			assert.Contains(t, w.Header().Values("Vary"), "Accept")
+
+			var body struct {
+				Data  map[string]interface{}   `codec:"data" yaml:"data"`
+				Links []map[string]interface{} `codec:"_links" yaml:"_links"`
+			}
+			assert.NoError(t, decode(w.Body.Bytes(), &body))
+			assert.Equal(t, "test users", body.Data["name"])
+			assert.NotContains(t, body.Data, "password")
+			assert.NotEmpty(t, body.Links)
+		})
+	}
+
+	t.Run("XML集合", func(t *testing.T) {
+		w := request("GET", "/api/test/users", "text/xml")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<response>")
+		assert.Contains(t, w.Body.String(), "<rel>self</rel>")
+	})
+
+	t.Run("無法滿足的Accept", func(t *testing.T) {
+		w := request("POST", "/api/test/users", "text/html")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	})
+
+	t.Run("錯誤回應依Accept編碼", func(t *testing.T) {
+		w := request("PUT", "/api/test/users/invalid", "application/yaml")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "error: Invalid ID")
+	})
+}
+
+// TestEncodedRequestBodies 測試以 Content-Type 解碼請求內容，不支援的格式回應 415，過大的內容回應 413
+func TestEncodedRequestBodies(t *testing.T) {
+	r := setupTestRouter()
+	r.PUT("/api/test/users/:id", controllers.UpdateUser_test)
+
+	send := func(contentType string, body []byte) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/test/users/507f1f77bcf86cd799439011", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	update := user_models.UpdateUserRequest{Name: "李四", Email: "lisi@example.com", Sex: "female", Age: 31, Phone: "0912345678", Address: "高雄市"}
+	for _, mediaType := range []string{formats.MediaTypeMsgPack, formats.MediaTypeCBOR, formats.MediaTypeXML, formats.MediaTypeYAML} {
+		t.Run(mediaType, func(t *testing.T) {
+			body, err := formats.Marshal(mediaType, update)
+			assert.NoError(t, err)
+			w, response := send(mediaType, body)
+			assert.Equal(t, http.StatusOK, w.Code)
+			data, _ := response["data"].(map[string]interface{})
+			assert.Equal(t, "李四", data["name"])
+			assert.Equal(t, float64(31), data["age"])
+		})
+	}
+
+	t.Run("XML驗證錯誤", func(t *testing.T) {
+		w, response := send("application/xml", []byte(`<user><name>李四</name><age>200</age></user>`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		fields := map[string]bool{}
+		for _, fe := range response["errors"].([]interface{}) {
+			fields[fe.(map[string]interface{})["field"].(string)] = true
+		}
+		assert.True(t, fields["age"])
+		assert.True(t, fields["email"])
+		assert.False(t, fields["name"])
+	})
+
+	t.Run("格式錯誤", func(t *testing.T) {
+		w, response := send("application/yaml", []byte("name: [unclosed"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "Request body is invalid", response["error"])
+	})
+
+	t.Run("不支援的Content-Type", func(t *testing.T) {
+		w, response := send("text/plain", []byte("name=李四"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Equal(t, "request body must be JSON, MessagePack, CBOR, XML or YAML", response["error"])
+	})
+
+	t.Run("內容過大", func(t *testing.T) {
+		body := []byte(`{"name":"` + strings.Repeat("李", 1<<19) + `"}`)
+		w, response := send("application/json", body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, "request body is too large", response["error"])
+	})
+}
@@ -0,0 +1,361 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/graphql"
+	"go-api_for_main/middleware"
+	"go-api_for_main/oidc"
+	"go-api_for_main/routes"
+)
+
+type testBook struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	AuthorID string `json:"-"`
+}
+
+var testAuthors = map[string]map[string]interface{}{
+	"a1": {"name": "Ursula"},
+	"a2": {"name": "Italo"},
+}
+
+var testBooks = []testBook{
+	{ID: "b1", Title: "The Dispossessed", AuthorID: "a1"},
+	{ID: "b2", Title: "Invisible Cities", AuthorID: "a2"},
+	{ID: "b3", Title: "The Lathe of Heaven", AuthorID: "a1"},
+	{ID: "b4", Title: "Unknown", AuthorID: "missing"},
+}
+
+// bookSchema 測試用的結構描述，作者透過 Loader 批次載入
+func bookSchema(t testing.TB) (*graphql.Schema, *graphql.Loader[string, map[string]interface{}]) {
+	authors := graphql.NewLoader(func(keys []string) (map[string]map[string]interface{}, error) {
+		found := map[string]map[string]interface{}{}
+		for _, key := range keys {
+			if author, ok := testAuthors[key]; ok {
+				found[key] = author
+			}
+		}
+		return found, nil
+	})
+
+	author := &graphql.Object{Name: "Author", Fields: []*graphql.FieldDefinition{
+		{Name: "name", Type: &graphql.NonNull{OfType: graphql.String}},
+	}}
+	book := &graphql.Object{Name: "Book", Fields: []*graphql.FieldDefinition{
+		{Name: "id", Type: &graphql.NonNull{OfType: graphql.ID}},
+		{Name: "title", Type: &graphql.NonNull{OfType: graphql.String}},
+		{Name: "author", Type: author, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return authors.Load(p.Source.(testBook).AuthorID), nil
+		}},
+		{Name: "strictAuthor", Type: &graphql.NonNull{OfType: author}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return authors.Load(p.Source.(testBook).AuthorID), nil
+		}},
+	}}
+	query := &graphql.Object{Name: "Query", Fields: []*graphql.FieldDefinition{
+		{
+			Name: "hello",
+			Type: &graphql.NonNull{OfType: graphql.String},
+			Args: []*graphql.InputValue{{Name: "name", Type: graphql.String, Default: "world", HasDefault: true}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return "hello " + p.Args["name"].(string), nil
+			},
+		},
+		{
+			Name: "book",
+			Type: book,
+			Args: []*graphql.InputValue{{Name: "id", Type: &graphql.NonNull{OfType: graphql.ID}}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				for _, b := range testBooks {
+					if b.ID == p.Args["id"] {
+						return b, nil
+					}
+				}
+				return nil, graphql.NewError("NOT_FOUND", "book not found")
+			},
+		},
+		{
+			Name: "books",
+			Type: &graphql.NonNull{OfType: &graphql.List{OfType: &graphql.NonNull{OfType: book}}},
+			Args: []*graphql.InputValue{{Name: "first", Type: graphql.Int, Default: 10, HasDefault: true}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				first := p.Args["first"].(int)
+				if first > len(testBooks) {
+					first = len(testBooks)
+				}
+				return testBooks[:first], nil
+			},
+			Complexity: func(child int, args map[string]interface{}) int {
+				return 1 + child*args["first"].(int)
+			},
+		},
+		{
+			Name: "fail",
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return nil, errors.New("boom")
+			},
+		},
+	}}
+	schema, err := graphql.NewSchema(query, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return schema, authors
+}
+
+// resultJSON 將結果序列化後再解析，與 HTTP 回應的內容相同
+func resultJSON(t *testing.T, result *graphql.Result) map[string]interface{} {
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &body))
+	return body
+}
+
+// TestGraphQLExecute 測試變數、預設值、別名、片段與指令
+func TestGraphQLExecute(t *testing.T) {
+	schema, _ := bookSchema(t)
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		Query: `query Q($id: ID!, $withTitle: Boolean!) {
+			greeting: hello
+			named: hello(name: "gopher")
+			book(id: $id) { __typename ...Fields title @include(if: $withTitle) }
+		}
+		fragment Fields on Book { id author { name } }`,
+		Variables: map[string]interface{}{"id": "b2", "withTitle": false},
+	})
+	assert.True(t, result.Executed)
+	assert.Empty(t, result.Errors)
+
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	// 輸出欄位依查詢中的順序排列
+	assert.JSONEq(t, `{"data":{"greeting":"hello world","named":"hello gopher","book":{"__typename":"Book","id":"b2","author":{"name":"Italo"}}}}`, string(data))
+	assert.True(t, strings.Index(string(data), `"greeting"`) < strings.Index(string(data), `"named"`))
+}
+
+// TestGraphQLErrors 測試語法錯誤、驗證錯誤與執行錯誤的格式與 null 傳遞
+func TestGraphQLErrors(t *testing.T) {
+	schema, _ := bookSchema(t)
+
+	syntax := graphql.Do(graphql.Params{Schema: schema, Query: `{ hello `})
+	assert.False(t, syntax.Executed)
+	assert.Len(t, syntax.Errors, 1)
+	assert.NotContains(t, resultJSON(t, syntax), "data")
+
+	invalid := graphql.Do(graphql.Params{Schema: schema, Query: "{\n  nope\n  book { id }\n}"})
+	assert.False(t, invalid.Executed)
+	if assert.Len(t, invalid.Errors, 2) {
+		assert.Contains(t, invalid.Errors[0].Message, `Cannot query field "nope" on type "Query"`)
+		assert.Equal(t, []graphql.Location{{Line: 2, Column: 3}}, invalid.Errors[0].Locations)
+		assert.Contains(t, invalid.Errors[1].Message, `Argument "id" of type "ID!" is required`)
+	}
+
+	variables := graphql.Do(graphql.Params{Schema: schema, Query: `query ($n: Int) { books(first: $n) { id } }`, Variables: map[string]interface{}{"n": "two"}})
+	assert.False(t, variables.Executed)
+	assert.Len(t, variables.Errors, 1)
+
+	// 可為 null 的欄位失敗時只有該欄位為 null
+	partial := graphql.Do(graphql.Params{Schema: schema, Query: `{ hello fail book(id: "zz") { id } }`})
+	assert.True(t, partial.Executed)
+	body := resultJSON(t, partial)
+	assert.Equal(t, map[string]interface{}{"hello": "hello world", "fail": nil, "book": nil}, body["data"])
+	if assert.Len(t, partial.Errors, 2) {
+		assert.Equal(t, []interface{}{"fail"}, partial.Errors[0].Path)
+		assert.Equal(t, "NOT_FOUND", partial.Errors[1].Extensions["code"])
+	}
+
+	// 不可為 null 的欄位為 null 時傳遞到最近可為 null 的上層
+	bubbled := graphql.Do(graphql.Params{Schema: schema, Query: `{ hello books { strictAuthor { name } } }`})
+	assert.Equal(t, nil, resultJSON(t, bubbled)["data"])
+	if assert.Len(t, bubbled.Errors, 1) {
+		assert.Equal(t, []interface{}{"books", 3, "strictAuthor"}, bubbled.Errors[0].Path)
+	}
+}
+
+// TestGraphQLLoaderBatching 測試同一層的作者只以一次批次載入
+func TestGraphQLLoaderBatching(t *testing.T) {
+	schema, authors := bookSchema(t)
+	result := graphql.Do(graphql.Params{Schema: schema, Query: `{ books { title author { name } } }`})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, 1, authors.Batches())
+
+	books := resultJSON(t, result)["data"].(map[string]interface{})["books"].([]interface{})
+	assert.Len(t, books, 4)
+	assert.Equal(t, map[string]interface{}{"name": "Ursula"}, books[2].(map[string]interface{})["author"])
+	assert.Nil(t, books[3].(map[string]interface{})["author"])
+
+	// 已載入的鍵會使用快取
+	graphql.Do(graphql.Params{Schema: schema, Query: `{ book(id: "b1") { author { name } } }`})
+	assert.Equal(t, 1, authors.Batches())
+}
+
+// TestGraphQLLimits 測試查詢深度與複雜度的限制，複雜度依參數計算
+func TestGraphQLLimits(t *testing.T) {
+	schema, _ := bookSchema(t)
+	query := `query ($n: Int) { books(first: $n) { id author { name } } }`
+
+	deep := graphql.Do(graphql.Params{Schema: schema, Query: query, MaxDepth: 2})
+	assert.False(t, deep.Executed)
+	if assert.Len(t, deep.Errors, 1) {
+		assert.Equal(t, "QUERY_TOO_DEEP", deep.Errors[0].Extensions["code"])
+	}
+	assert.True(t, graphql.Do(graphql.Params{Schema: schema, Query: query, MaxDepth: 3}).Executed)
+
+	// books 的複雜度為 1 + 3 * first
+	complex := graphql.Do(graphql.Params{Schema: schema, Query: query, MaxComplexity: 30, Variables: map[string]interface{}{"n": 10}})
+	assert.False(t, complex.Executed)
+	if assert.Len(t, complex.Errors, 1) {
+		assert.Equal(t, "QUERY_TOO_COMPLEX", complex.Errors[0].Extensions["code"])
+		assert.Equal(t, 31, complex.Errors[0].Extensions["complexity"])
+	}
+	assert.True(t, graphql.Do(graphql.Params{Schema: schema, Query: query, MaxComplexity: 30, Variables: map[string]interface{}{"n": 2}}).Executed)
+}
+
+// TestGraphQLIntrospection 測試內省查詢
+func TestGraphQLIntrospection(t *testing.T) {
+	schema, _ := bookSchema(t)
+	result := graphql.Do(graphql.Params{Schema: schema, Query: `{
+		__schema { queryType { name } mutationType { name } }
+		__type(name: "Book") { kind fields { name type { kind ofType { name } } } }
+	}`})
+	assert.Empty(t, result.Errors)
+	data := resultJSON(t, result)["data"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"queryType": map[string]interface{}{"name": "Query"}, "mutationType": nil}, data["__schema"])
+
+	book := data["__type"].(map[string]interface{})
+	assert.Equal(t, "OBJECT", book["kind"])
+	id := book["fields"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "id", id["name"])
+	assert.Equal(t, map[string]interface{}{"kind": "NON_NULL", "ofType": map[string]interface{}{"name": "ID"}}, id["type"])
+}
+
+func graphQLRequest(r *gin.Engine, method, target, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// TestGraphQLEndpoint 測試 /graphql 端點的請求格式與錯誤狀態碼（未連接資料庫）
+func TestGraphQLEndpoint(t *testing.T) {
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+
+	w, resp := graphQLRequest(r, http.MethodPost, "/graphql", `{"query":"{ users(first: 5) { totalCount } }"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, resp["data"])
+	errs := resp["errors"].([]interface{})
+	assert.Equal(t, "UNAUTHENTICATED", errs[0].(map[string]interface{})["extensions"].(map[string]interface{})["code"])
+
+	// 結構描述的內省不需要資料庫
+	w, resp = graphQLRequest(r, http.MethodGet, "/graphql?query="+url.QueryEscape(`{ __type(name: "UserStatus") { enumValues { name } } }`), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	values := resp["data"].(map[string]interface{})["__type"].(map[string]interface{})["enumValues"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "PENDING_VERIFICATION"}, values[0])
+
+	w, _ = graphQLRequest(r, http.MethodPost, "/graphql", `{"query":""}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = graphQLRequest(r, http.MethodPost, "/graphql", `not json`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, resp = graphQLRequest(r, http.MethodPost, "/graphql", `{"query":"{ __typename }","variables":{"pad":"`+strings.Repeat("x", 1<<20)+`"}}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "request body is too large", resp["errors"].([]interface{})[0].(map[string]interface{})["message"])
+
+	w, resp = graphQLRequest(r, http.MethodPost, "/graphql", `{"query":"{ users { nodes { password } } }"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NotContains(t, resp, "data")
+
+	// GET 不允許執行變更
+	w, resp = graphQLRequest(r, http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteUser(id: "507f1f77bcf86cd799439011") }`), "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST", w.Header().Get("Allow"))
+
+	// 每頁筆數乘上子欄位超過預設的複雜度上限
+	w, resp = graphQLRequest(r, http.MethodPost, "/graphql", `{"query":"query ($n: Int) { users(first: $n) { nodes { id name email createdAt updatedAt status version age phone address sex } } }","variables":{"n":100}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "QUERY_TOO_COMPLEX", resp["errors"].([]interface{})[0].(map[string]interface{})["extensions"].(map[string]interface{})["code"])
+}
+
+// TestGraphQLPlayground 測試只有 debug 模式會以 GraphiQL 回應瀏覽器
+func TestGraphQLPlayground(t *testing.T) {
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	gin.SetMode(gin.DebugMode)
+	defer gin.SetMode(gin.TestMode)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "GraphiQL")
+}
+
+// TestGraphQLAuthorization 測試列表與變更只限管理員，單一用戶只限本人或管理員
+func TestGraphQLAuthorization(t *testing.T) {
+	const owner = "507f1f77bcf86cd799439011"
+	verify := func(token string) (*oidc.AccessTokenClaims, error) {
+		return &oidc.AccessTokenClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: token}}, nil
+	}
+	r := setupTestRouter()
+	r.POST("/graphql", middleware.Authenticate(verify), controllers.GraphQL)
+
+	code := func(subject, query string) interface{} {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if subject != "" {
+			req.Header.Set("Authorization", "Bearer "+subject)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		errs, _ := resp["errors"].([]interface{})
+		if len(errs) == 0 {
+			return nil
+		}
+		return errs[0].(map[string]interface{})["extensions"].(map[string]interface{})["code"]
+	}
+
+	queries := []string{
+		`{ users(first: 5) { totalCount } }`,
+		`{ user(id: "` + owner + `") { id } }`,
+		`mutation { createUser(input: {name: "張三", email: "zhangsan@example.com", password: "s3cret-pass", sex: "male", age: 20, phone: "+886912345678", address: "台北市", role: "admin"}) { id role } }`,
+		`mutation { updateUser(id: "` + owner + `", input: {name: "李四"}) { id } }`,
+		`mutation { deleteUser(id: "` + owner + `") }`,
+	}
+	for _, query := range queries {
+		assert.Equal(t, "UNAUTHENTICATED", code("", query), query)
+		// 不是本人時需要從資料庫確認角色，資料庫未連接時不會放行
+		assert.Equal(t, "UNAVAILABLE", code("507f1f77bcf86cd799439012", query), query)
+	}
+
+	// 本人查詢自己不需要管理員角色
+	assert.Equal(t, "UNAVAILABLE", code(owner, `{ user(id: "`+owner+`") { id } }`))
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/graphql"
+)
+
+// FuzzGraphQLParse 任意輸入都不可使解析、驗證或執行發生 panic，
+// 解析失敗時一律回傳帶有位置的語法錯誤
+func FuzzGraphQLParse(f *testing.F) {
+	for _, seed := range []string{
+		`{ hello }`,
+		`query Q($id: ID!, $n: Int = 2) { book(id: $id) { ...F } books(first: $n) { id } }
+		fragment F on Book { title author { name } }`,
+		`{ book(id: "b1") { ... on Book { id } ... @skip(if: true) { title } } }`,
+		`mutation { hello }`,
+		`{ hello(name: """block
+		string""") }`,
+		`{ hello(name: "é\n") books(first: 1) { id } }`,
+		`query ($list: [[Int!]]!) { hello }`,
+		`{ a: hello, b: hello # comment
+		}`,
+		`fragment A on Book { ...A } { book(id: "b1") { ...A } }`,
+		`{ hello `,
+		`{ "unterminated`,
+		`{ hello(name: 1.5e }`,
+		`{ ...`,
+		"{ \xff }",
+		``,
+	} {
+		f.Add(seed)
+	}
+
+	schema, _ := bookSchema(&testing.T{})
+	f.Fuzz(func(t *testing.T, query string) {
+		doc, err := graphql.Parse(query)
+		if err != nil {
+			gqlErr, ok := err.(*graphql.Error)
+			if assert.True(t, ok, "Parse returned %T", err) {
+				assert.Len(t, gqlErr.Locations, 1)
+				assert.Contains(t, gqlErr.Message, "Syntax Error")
+			}
+			return
+		}
+		assert.NotNil(t, doc)
+		graphql.Validate(schema, doc, query)
+		graphql.Do(graphql.Params{Schema: schema, Query: query, MaxDepth: 5, MaxComplexity: 100})
+	})
+}
+
+// TestGraphQLValidationRules 測試每一條驗證規則的錯誤訊息與位置
+func TestGraphQLValidationRules(t *testing.T) {
+	schema, _ := bookSchema(t)
+
+	cases := []struct {
+		name    string
+		query   string
+		message string
+		line    int
+		column  int
+	}{
+		{"匿名操作不唯一", "{ hello }\n{ hello }", `This anonymous operation must be the only defined operation.`, 1, 1},
+		{"操作名稱重複", "query A { hello }\nquery A { hello }", `There can be only one operation named "A".`, 1, 1},
+		{"片段名稱重複", "{ book(id: \"b1\") { ...F } }\nfragment F on Book { id }\nfragment F on Book { title }", `There can be only one fragment named "F".`, 2, 1},
+		{"片段循環", "{ book(id: \"b1\") { ...A } }\nfragment A on Book { ...B }\nfragment B on Book { ...A }", `Cannot spread fragment "A" within itself.`, 3, 22},
+		{"片段未使用", "{ hello }\nfragment F on Book { id }", `Fragment "F" is never used.`, 2, 1},
+		{"未知片段", `{ book(id: "b1") { ...Missing } }`, `Unknown fragment "Missing".`, 1, 20},
+		{"不支援的操作類型", `mutation { hello }`, `Schema is not configured to execute mutation operation.`, 1, 1},
+		{"變數名稱重複", `query ($a: Int, $a: Int) { books(first: $a) { id } }`, `There can be only one variable named "$a".`, 1, 17},
+		{"未知的變數型別", `query ($a: Nope) { hello }`, `Unknown type "Nope".`, 1, 12},
+		{"變數不可為輸出型別", `query ($a: Book) { hello }`, `Variable "$a" cannot be non-input type "Book".`, 1, 12},
+		{"未定義的變數", `{ books(first: $n) { id } }`, `Variable "$n" is not defined.`, 1, 16},
+		{"變數型別不符", `query ($n: String) { books(first: $n) { id } }`, `Variable "$n" of type "String" used in position expecting type "Int".`, 1, 8},
+		{"變數未使用", `query Q($n: Int) { hello }`, `Variable "$n" is never used in operation "Q".`, 1, 9},
+		{"未知欄位", `{ nope }`, `Cannot query field "nope" on type "Query".`, 1, 3},
+		{"物件欄位缺少子欄位", `{ book(id: "b1") }`, `Field "book" of type "Book" must have a selection of subfields. Did you mean "book { ... }"?`, 1, 3},
+		{"純量欄位不可有子欄位", `{ hello { length } }`, `Field "hello" must not have a selection since type "String!" has no subfields.`, 1, 3},
+		{"內嵌片段型別不符", `{ book(id: "b1") { ... on Author { name } } }`, `Fragment cannot be spread here as objects of type "Book" can never be of type "Author".`, 1, 20},
+		{"片段型別不符", "{ book(id: \"b1\") { ...A } }\nfragment A on Author { name }", `Fragment "A" cannot be spread here as objects of type "Book" can never be of type "Author".`, 1, 20},
+		{"片段條件為純量", "{ book(id: \"b1\") { ...S } }\nfragment S on String { length }", `Fragment cannot condition on non composite type "String".`, 2, 1},
+		{"欄位名稱衝突", `{ x: hello x: fail }`, `Fields "x" conflict because "hello" and "fail" are different fields. Use different aliases on the fields to fetch both if this was intentional.`, 1, 3},
+		{"欄位參數衝突", `{ hello(name: "a") hello(name: "b") }`, `Fields "hello" conflict because they have differing arguments. Use different aliases on the fields to fetch both if this was intentional.`, 1, 3},
+		{"未知指令", `{ hello @nope }`, `Unknown directive "@nope".`, 1, 9},
+		{"指令位置錯誤", `query @skip(if: true) { hello }`, `Directive "@skip" may not be used on QUERY.`, 1, 7},
+		{"指令重複", `{ hello @skip(if: false) @skip(if: false) }`, `The directive "@skip" can only be used once at this location.`, 1, 26},
+		{"參數重複", `{ hello(name: "a", name: "b") }`, `There can be only one argument named "name".`, 1, 20},
+		{"未知參數", `{ hello(nope: 1) }`, `Unknown argument "nope" on field "Query.hello".`, 1, 9},
+		{"參數值型別錯誤", `{ books(first: "two") { id } }`, `Int cannot represent non-integer value: "two"`, 1, 16},
+		{"缺少必要參數", `{ book { id } }`, `Argument "id" of type "ID!" is required, but it was not provided.`, 1, 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := graphql.Parse(tc.query)
+			if !assert.NoError(t, err) {
+				return
+			}
+			errs := graphql.Validate(schema, doc, tc.query)
+			for _, e := range errs {
+				if e.Message == tc.message {
+					assert.Equal(t, graphql.Location{Line: tc.line, Column: tc.column}, e.Locations[0])
+					return
+				}
+			}
+			t.Errorf("expected %q, got %v", tc.message, errs)
+		})
+	}
+
+	t.Run("合法的查詢", func(t *testing.T) {
+		query := `query Q($id: ID!, $n: Int = 2) {
+			hello @include(if: true)
+			book(id: $id) { ...F ... on Book { title } }
+			books(first: $n) { id }
+		}
+		fragment F on Book { id author { name } }`
+		doc, err := graphql.Parse(query)
+		assert.NoError(t, err)
+		assert.Empty(t, graphql.Validate(schema, doc, query))
+	})
+}
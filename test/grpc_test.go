@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/feed"
+	"go-api_for_main/grpcserver"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/oidc"
+	userv1 "go-api_for_main/proto/user/v1"
+)
+
+// grpcClient 以記憶體連線啟動 gRPC 伺服器並註冊用戶服務（未連接資料庫）
+func grpcClient(t *testing.T, verify func(token string) (*oidc.AccessTokenClaims, error), opts grpcserver.Options) *grpc.ClientConn {
+	srv := grpcserver.New(verify, opts)
+	userv1.RegisterUserServiceServer(srv, controllers.NewUserServiceServer())
+	srv.SetServing("", true)
+	srv.SetServing(userv1.UserService_ServiceDesc.ServiceName, false)
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		srv.Stop()
+	})
+	return conn
+}
+
+func grpcVerifier(token string) (*oidc.AccessTokenClaims, error) {
+	if token != "valid-token" {
+		return nil, oidc.ErrInvalidToken
+	}
+	return &oidc.AccessTokenClaims{}, nil
+}
+
+// TestGRPCHealthAndReflection 測試健康檢查不需要權杖並依資料庫狀態回報，以及反射服務需要明確開啟
+func TestGRPCHealthAndReflection(t *testing.T) {
+	ctx := context.Background()
+	closed, err := reflectionpb.NewServerReflectionClient(grpcClient(t, grpcVerifier, grpcserver.Options{})).ServerReflectionInfo(ctx)
+	assert.NoError(t, err)
+	_, err = closed.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	conn := grpcClient(t, grpcVerifier, grpcserver.Options{Reflection: true, Authorize: controllers.AuthorizeGRPC})
+
+	health := healthpb.NewHealthClient(conn)
+	resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	resp, err = health.Check(ctx, &healthpb.HealthCheckRequest{Service: "user.v1.UserService"})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{ListServices: ""},
+	}))
+	reply, err := stream.Recv()
+	assert.NoError(t, err)
+	var services []string
+	for _, s := range reply.GetListServicesResponse().GetService() {
+		services = append(services, s.Name)
+	}
+	assert.Contains(t, services, "user.v1.UserService")
+	assert.Contains(t, services, "grpc.health.v1.Health")
+}
+
+// TestGRPCUserService 測試驗證攔截器、請求識別碼與未連接資料庫時的狀態碼
+func TestGRPCUserService(t *testing.T) {
+	conn := grpcClient(t, grpcVerifier, grpcserver.Options{Authorize: controllers.AuthorizeGRPC})
+	client := userv1.NewUserServiceClient(conn)
+
+	t.Run("未帶權杖", func(t *testing.T) {
+		_, err := client.GetUser(context.Background(), &userv1.GetUserRequest{Id: "507f1f77bcf86cd799439011"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		stream, err := client.WatchUsers(context.Background(), &userv1.WatchUsersRequest{})
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("無法確認管理員", func(t *testing.T) {
+		denied := userv1.NewUserServiceClient(grpcClient(t, grpcVerifier, grpcserver.Options{
+			Authorize: func(ctx context.Context, claims *oidc.AccessTokenClaims) error {
+				return status.Error(codes.PermissionDenied, "administrator role is required")
+			},
+		}))
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer valid-token")
+		_, err := denied.ListUsers(ctx, &userv1.ListUsersRequest{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("無效的權杖", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer expired")
+		_, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: "507f1f77bcf86cd799439011"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("資料庫未連接", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			"authorization", "Bearer valid-token",
+			"accept-language", "zh-TW",
+			"x-request-id", "grpc-test-1",
+		)
+		var header metadata.MD
+		calls := []func() error{
+			func() error {
+				_, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: "507f1f77bcf86cd799439011"}, grpc.Header(&header))
+				return err
+			},
+			func() error { _, err := client.ListUsers(ctx, &userv1.ListUsersRequest{}); return err },
+			func() error { _, err := client.CreateUser(ctx, &userv1.CreateUserRequest{Name: "張三"}); return err },
+			func() error {
+				_, err := client.UpdateUser(ctx, &userv1.UpdateUserRequest{Id: "507f1f77bcf86cd799439011"})
+				return err
+			},
+			func() error {
+				_, err := client.DeleteUser(ctx, &userv1.DeleteUserRequest{Id: "507f1f77bcf86cd799439011"})
+				return err
+			},
+		}
+		for _, call := range calls {
+			err := call()
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+			assert.Equal(t, "資料庫服務目前無法使用", status.Convert(err).Message())
+		}
+		assert.Equal(t, []string{"grpc-test-1"}, header.Get("x-request-id"))
+	})
+}
+
+// TestGRPCWatchUsers 測試事件串流的篩選、續傳重置與參數驗證
+func TestGRPCWatchUsers(t *testing.T) {
+	bus := feed.NewBus(10)
+	controllers.SetUserFeed(bus)
+	defer controllers.SetUserFeed(nil)
+	client := userv1.NewUserServiceClient(grpcClient(t, grpcVerifier, grpcserver.Options{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer valid-token")
+
+	for _, req := range []*userv1.WatchUsersRequest{{Types: []string{"user.exploded"}}, {UserIds: []string{"nope"}}} {
+		invalid, err := client.WatchUsers(ctx, req)
+		assert.NoError(t, err)
+		_, err = invalid.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	userID := "507f1f77bcf86cd799439011"
+	stream, err := client.WatchUsers(ctx, &userv1.WatchUsersRequest{
+		Types:       []string{user_models.WebhookUserUpdated},
+		LastEventId: "unknown-1",
+	})
+	assert.NoError(t, err)
+	event, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.True(t, event.ResetRequired)
+
+	// 收到 reset 時訂閱已建立，不符合類型的事件不會送出
+	bus.Publish(feed.Event{Type: user_models.WebhookUserCreated, UserID: userID, OccurredAt: time.Now(), Data: json.RawMessage(`{}`)})
+	bus.Publish(feed.Event{Type: user_models.WebhookUserUpdated, UserID: userID, OccurredAt: time.Now(), Data: json.RawMessage(`{"type":"user.updated"}`)})
+	event, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, user_models.WebhookUserUpdated, event.Type)
+	assert.Equal(t, userID, event.UserId)
+	assert.JSONEq(t, `{"type":"user.updated"}`, string(event.Data))
+	assert.NotEmpty(t, event.Id)
+}
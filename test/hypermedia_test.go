@@ -0,0 +1,106 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/hypermedia"
+)
+
+// TestNegotiate 測試 Accept 標頭的格式協商
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		name     string // 測試用例名稱
+		accept   string // Accept 標頭
+		expected string // 預期的格式
+	}{
+		{name: "未指定", accept: "", expected: hypermedia.MediaTypeJSON},
+		{name: "萬用字元", accept: "*/*", expected: hypermedia.MediaTypeJSON},
+		{name: "HAL", accept: "application/hal+json", expected: hypermedia.MediaTypeHAL},
+		{name: "依q值選擇", accept: "application/hal+json;q=0.5, application/vnd.siren+json", expected: hypermedia.MediaTypeSiren},
+		{name: "q為0不接受", accept: "application/vnd.api+json;q=0, text/html", expected: hypermedia.MediaTypeJSON},
+		{name: "不支援的格式", accept: "text/html", expected: hypermedia.MediaTypeJSON},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hypermedia.Negotiate(tc.accept))
+		})
+	}
+}
+
+// TestHypermediaFormats 測試使用者資源的各種超媒體格式
+func TestHypermediaFormats(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/api/test/users", controllers.GetUsers_test)
+	r.POST("/api/test/users", controllers.CreateUser_test)
+
+	request := func(method, path, accept string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Accept", accept)
+		r.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body
+	}
+
+	t.Run("預設格式", func(t *testing.T) {
+		w, body := request("POST", "/api/test/users", "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		assert.IsType(t, []interface{}{}, body["_links"])
+	})
+
+	t.Run("HAL單一資源", func(t *testing.T) {
+		w, body := request("POST", "/api/test/users", hypermedia.MediaTypeHAL)
+		assert.Contains(t, w.Header().Get("Content-Type"), hypermedia.MediaTypeHAL)
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		links := body["_links"].(map[string]interface{})
+		assert.Contains(t, links, "self")
+		assert.Contains(t, links, "suspend")
+		assert.Equal(t, "test users", body["name"])
+	})
+
+	t.Run("HAL集合", func(t *testing.T) {
+		_, body := request("GET", "/api/test/users", hypermedia.MediaTypeHAL)
+		embedded := body["_embedded"].(map[string]interface{})
+		assert.NotEmpty(t, embedded["users"])
+		assert.EqualValues(t, 10, body["size"])
+	})
+
+	t.Run("JSON:API", func(t *testing.T) {
+		w, body := request("GET", "/api/test/users", hypermedia.MediaTypeJSONAPI)
+		assert.Contains(t, w.Header().Get("Content-Type"), hypermedia.MediaTypeJSONAPI)
+		data := body["data"].([]interface{})
+		first := data[0].(map[string]interface{})
+		assert.Equal(t, "users", first["type"])
+		assert.NotEmpty(t, first["id"])
+		assert.NotContains(t, first["attributes"], "id")
+		assert.Contains(t, body["meta"], "total")
+		create := body["links"].(map[string]interface{})["create"].(map[string]interface{})
+		assert.Equal(t, "POST", create["meta"].(map[string]interface{})["method"])
+	})
+
+	t.Run("Siren", func(t *testing.T) {
+		w, body := request("POST", "/api/test/users", hypermedia.MediaTypeSiren)
+		assert.Contains(t, w.Header().Get("Content-Type"), hypermedia.MediaTypeSiren)
+		assert.Contains(t, body["properties"], "email")
+
+		names := []string{}
+		for _, action := range body["actions"].([]interface{}) {
+			names = append(names, action.(map[string]interface{})["name"].(string))
+		}
+		assert.Contains(t, names, "update")
+		assert.Contains(t, names, "delete")
+		for _, link := range body["links"].([]interface{}) {
+			assert.NotEqual(t, []interface{}{"update"}, link.(map[string]interface{})["rel"])
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/config"
+	"go-api_for_main/controllers"
+	"go-api_for_main/i18n"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+)
+
+// TestLanguageNegotiation 測試 Accept-Language 的語言協商
+func TestLanguageNegotiation(t *testing.T) {
+	testCases := []struct {
+		name     string // 測試用例名稱
+		header   string // Accept-Language 標頭
+		expected string // 預期的語言
+	}{
+		{name: "未指定使用預設語言", header: "", expected: i18n.English},
+		{name: "繁體中文", header: "zh-TW", expected: i18n.TraditionalChinese},
+		{name: "中文變體", header: "zh-Hant-HK,zh;q=0.8", expected: i18n.TraditionalChinese},
+		{name: "泰文地區標籤", header: "th-TH", expected: i18n.Thai},
+		{name: "依q值選擇", header: "en;q=0.3, th;q=0.9", expected: i18n.Thai},
+		{name: "不支援的語言", header: "fr-FR, de", expected: i18n.English},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, i18n.Negotiate(tc.header))
+		})
+	}
+
+	t.Run("可設定預設語言", func(t *testing.T) {
+		assert.NoError(t, i18n.SetFallback("zh-TW"))
+		defer i18n.SetFallback(i18n.English)
+		assert.Equal(t, i18n.TraditionalChinese, i18n.Negotiate("fr"))
+		assert.Error(t, i18n.SetFallback("fr"))
+	})
+
+	t.Run("設定檔預設為繁體中文", func(t *testing.T) {
+		t.Setenv("FALLBACK_LANGUAGE", "")
+		assert.Equal(t, i18n.TraditionalChinese, config.LoadConfig().I18n.FallbackLanguage)
+	})
+}
+
+// TestCatalogueParity 測試各語言的訊息目錄涵蓋相同的訊息
+func TestCatalogueParity(t *testing.T) {
+	load := func(lang string) map[string]string {
+		data, err := os.ReadFile("../i18n/locales/" + lang + ".json")
+		assert.NoError(t, err)
+		messages := map[string]string{}
+		assert.NoError(t, json.Unmarshal(data, &messages))
+		return messages
+	}
+
+	zh, th := load(i18n.TraditionalChinese), load(i18n.Thai)
+	for key := range zh {
+		assert.Contains(t, th, key)
+	}
+	for key := range th {
+		assert.Contains(t, zh, key)
+	}
+
+	// 所有狀態轉換動作的連結標題都必須有譯文
+	for _, action := range user_models.LifecycleActions {
+		assert.True(t, i18n.Has(i18n.Thai, action.Title), action.Title)
+		assert.True(t, i18n.Has(i18n.TraditionalChinese, action.Title), action.Title)
+	}
+}
+
+// TestCatalogueCoverage 測試控制器中傳給 tr、RespondWithAPIError 等函式的訊息，
+// 以及 errors.New 建立的錯誤訊息都有譯文
+func TestCatalogueCoverage(t *testing.T) {
+	// 訊息參數的位置
+	messageArg := map[string]int{
+		"tr":                    1,
+		"i18n.T":                1,
+		"RespondWithAPIError":   2,
+		"RespondWithAPISuccess": 2,
+		"graphQLError":          2,
+		"errors.New":            0,
+	}
+
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("../controllers/*.go")
+	assert.NoError(t, err)
+	checked := 0
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			name := ""
+			switch fn := call.Fun.(type) {
+			case *ast.Ident:
+				name = fn.Name
+			case *ast.SelectorExpr:
+				if pkg, ok := fn.X.(*ast.Ident); ok {
+					name = pkg.Name + "." + fn.Sel.Name
+				}
+			}
+			index, ok := messageArg[name]
+			if !ok || len(call.Args) <= index {
+				return true
+			}
+			lit, ok := call.Args[index].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			message, err := strconv.Unquote(lit.Value)
+			assert.NoError(t, err)
+			for _, lang := range []string{i18n.TraditionalChinese, i18n.Thai} {
+				assert.True(t, i18n.Has(lang, message), "%s: %q has no %s translation", fset.Position(lit.Pos()), message, lang)
+			}
+			checked++
+			return true
+		})
+	}
+	assert.Greater(t, checked, 100)
+}
+
+// TestLocalizedResponses 測試錯誤訊息、連結標題與驗證錯誤的翻譯
+func TestLocalizedResponses(t *testing.T) {
+	r := setupTestRouter()
+	r.Use(middleware.Localize())
+	r.GET("/api/v1/users", controllers.GetUsers)
+	r.POST("/api/test/users", controllers.CreateUser_test)
+	r.PUT("/api/test/users/:id", controllers.UpdateUser_test)
+
+	t.Run("錯誤訊息", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users", nil)
+		req.Header.Set("Accept-Language", "th")
+		r.ServeHTTP(w, req)
+
+		var response user_models.APIResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "th", w.Header().Get("Content-Language"))
+		assert.Equal(t, "บริการฐานข้อมูลไม่พร้อมใช้งานในขณะนี้", response.Error)
+		assert.Equal(t, "การดำเนินการล้มเหลว", response.Message)
+	})
+
+	t.Run("連結標題", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/test/users", nil)
+		req.Header.Set("Accept-Language", "zh-TW")
+		r.ServeHTTP(w, req)
+
+		var response user_models.UserResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "取得使用者資訊", response.Links[0].Title)
+	})
+
+	t.Run("驗證錯誤", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(map[string]string{"name": "張三", "email": "not-an-email"})
+		req, _ := http.NewRequest("PUT", "/api/test/users/507f1f77bcf86cd799439011", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "zh-TW")
+		r.ServeHTTP(w, req)
+
+		var response user_models.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, response.Error, "email必須是一個有效的信箱")
+	})
+}
@@ -0,0 +1,182 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/importer"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/routes"
+)
+
+// buildXLSX 建立只有一個工作表的最小 XLSX 檔案
+func buildXLSX(t *testing.T, sharedStrings, sheet string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Users" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/users.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       sharedStrings,
+		"xl/worksheets/users.xml":    sheet,
+	}
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		w.Write([]byte(content))
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// TestImportRead 測試三種格式的資料列與行號
+func TestImportRead(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		data := "\xef\xbb\xbfName,E-mail,Age\n張三,zhangsan@example.com,20\n\n\"李,四\",\"lisi@\nexample.com\",30\n王五,wangwu@example.com,40\n"
+		table, err := importer.Read(importer.CSV, []byte(data))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Name", "E-mail", "Age"}, table.Headers)
+		assert.Len(t, table.Rows, 3)
+		assert.Equal(t, 2, table.Rows[0].Number)
+		assert.Equal(t, "李,四", table.Rows[1].Values[0])
+		assert.Equal(t, 4, table.Rows[1].Number)
+		assert.Equal(t, 6, table.Rows[2].Number)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		data := `{"email":"zhangsan@example.com","age":20}` + "\n\n" + `{"name":"李四","email":"lisi@example.com","age":30.5,"active":true}` + "\n"
+		table, err := importer.Read(importer.NDJSON, []byte(data))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"age", "email", "active", "name"}, table.Headers)
+		assert.Equal(t, 3, table.Rows[1].Number)
+		assert.Equal(t, "30.5", table.Rows[1].Value(0))
+		assert.Equal(t, "true", table.Rows[1].Value(2))
+		assert.Equal(t, "", table.Rows[0].Value(3))
+
+		_, err = importer.Read(importer.NDJSON, []byte("[1,2]\n"))
+		assert.ErrorIs(t, err, importer.ErrInvalidFile)
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		data := buildXLSX(t,
+			`<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>name</t></si><si><t>email</t></si><si><r><t>張</t></r><r><t>三</t></r></si></sst>`,
+			`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+
+				`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>age</t></is></c></row>`+
+				`<row r="3"><c r="A3" t="s"><v>2</v></c><c r="C3"><v>20</v></c></row>`+
+				`</sheetData></worksheet>`)
+		table, err := importer.Read(importer.XLSX, data)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"name", "email", "age"}, table.Headers)
+		assert.Len(t, table.Rows, 1)
+		assert.Equal(t, 3, table.Rows[0].Number)
+		assert.Equal(t, []string{"張三", "", "20"}, table.Rows[0].Values)
+
+		_, err = importer.Read(importer.XLSX, []byte("not a zip"))
+		assert.ErrorIs(t, err, importer.ErrInvalidFile)
+	})
+}
+
+// TestImportFormatDetection 測試依副檔名與 Content-Type 判斷格式
+func TestImportFormatDetection(t *testing.T) {
+	format, ok := importer.DetectFormat("users.XLSX", "")
+	assert.True(t, ok)
+	assert.Equal(t, importer.XLSX, format)
+
+	format, ok = importer.DetectFormat("upload", "application/x-ndjson; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, importer.NDJSON, format)
+
+	_, ok = importer.DetectFormat("users.txt", "text/plain")
+	assert.False(t, ok)
+}
+
+// TestImportMapping 測試欄位對應
+func TestImportMapping(t *testing.T) {
+	headers := []string{"Full Name", "E-mail", "Email", "Note"}
+	row := importer.Row{Number: 2, Values: []string{" 張三 ", "a@example.com", "b@example.com", "x"}}
+
+	mapping, err := importer.ParseMapping("", headers)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"email": "b@example.com"}, mapping.Apply(row))
+
+	mapping, err = importer.ParseMapping(`{"Full Name":"name","e-mail":"email"}`, headers)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "張三", "email": "a@example.com"}, mapping.Apply(row))
+
+	for _, spec := range []string{
+		`{"Note":"nickname","Email":"email"}`,
+		`{"Full Name":"name"}`,
+		`{"Missing":"email"}`,
+		`{"E-mail":"email","Email":"email"}`,
+		`["email"]`,
+	} {
+		_, err := importer.ParseMapping(spec, headers)
+		assert.ErrorIs(t, err, importer.ErrInvalidMapping, spec)
+	}
+}
+
+// TestImportResponse 測試匯入結果的統計與錯誤報表連結
+func TestImportResponse(t *testing.T) {
+	response := user_models.NewImportResponse("csv", true, []user_models.ImportRowResult{
+		{Row: 2, Action: user_models.ImportActionCreate},
+		{Row: 3, Action: user_models.ImportActionUpdate},
+		{Row: 4, Action: user_models.ImportActionSkip},
+		{Row: 5, Action: user_models.ImportActionError},
+	})
+	assert.Equal(t, []int{1, 1, 1, 1}, []int{response.Created, response.Updated, response.Skipped, response.Failed})
+
+	links := user_models.GenerateImportLinks("http://api.example.com/api/v1", "abc")
+	assert.Equal(t, "http://api.example.com/api/v1/users/import/abc/errors", links[len(links)-1].Href)
+	assert.Len(t, user_models.GenerateImportLinks("http://api.example.com/api/v1", ""), 2)
+}
+
+// TestImportEndpoint 測試資料庫未連接時的匯入端點
+func TestImportEndpoint(t *testing.T) {
+	r := setupTestRouter()
+	r.POST("/api/v1/users/import", controllers.ImportUsers)
+	r.GET("/api/v1/users/import/:id/errors", controllers.GetImportErrors)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/api/v1/users/import", nil),
+		httptest.NewRequest("GET", "/api/v1/users/import/507f1f77bcf86cd799439011/errors", nil),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	}
+
+	t.Run("匯入與錯誤報表需要登入", func(t *testing.T) {
+		r := setupTestRouter()
+		routes.SetupRouter(r)
+		for _, req := range []*http.Request{
+			httptest.NewRequest("POST", "/api/v1/users/import", nil),
+			httptest.NewRequest("GET", "/api/v1/users/import/507f1f77bcf86cd799439011/errors", nil),
+		} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		}
+	})
+}
+
+// TestImportReportColumns 測試錯誤報表不保存密碼欄位
+func TestImportReportColumns(t *testing.T) {
+	headers := []string{"Name", "E-mail", "Secret", "Password", "Phone"}
+
+	t.Run("同名對應", func(t *testing.T) {
+		mapping, err := importer.ParseMapping("", []string{"name", "email", "password"})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 1}, mapping.ReportColumns([]string{"name", "email", "password"}))
+	})
+
+	t.Run("自訂對應與未使用的密碼欄位", func(t *testing.T) {
+		mapping, err := importer.ParseMapping(`{"E-mail": "email", "Secret": "password"}`, headers)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 1, 4}, mapping.ReportColumns(headers))
+	})
+}
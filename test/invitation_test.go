@@ -0,0 +1,181 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/mailer"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/oidc"
+	"go-api_for_main/routes"
+)
+
+// TestInvitationMail 測試邀請郵件內容與郵件擷取
+func TestInvitationMail(t *testing.T) {
+	recorder := &mailer.Recorder{}
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 0, 0, time.UTC)
+	msg := mailer.InvitationMessage("zhangsan@example.com", "member", "https://app.example.com/accept?lang=zh", "a+b/c", expiresAt)
+
+	assert.NoError(t, recorder.Send(context.Background(), msg))
+	messages := recorder.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "zhangsan@example.com", messages[0].To)
+	assert.Contains(t, messages[0].Body, "https://app.example.com/accept?lang=zh&token=a%2Bb%2Fc")
+	assert.Contains(t, messages[0].Body, "2030-01-02 03:04 UTC")
+}
+
+// TestInvitationStatus 測試邀請狀態與 HATEOAS 連結
+func TestInvitationStatus(t *testing.T) {
+	now := time.Now().UTC()
+	invitation := user_models.Invitation{
+		ID:        primitive.NewObjectID(),
+		Status:    user_models.InvitationPending,
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	t.Run("待接受的邀請可撤銷", func(t *testing.T) {
+		assert.Equal(t, user_models.InvitationPending, invitation.EffectiveStatus(now))
+		links := user_models.GenerateInvitationLinks("http://localhost", invitation, now)
+		assert.Len(t, links, 2)
+		assert.Equal(t, "revoke", links[1].Rel)
+		assert.Equal(t, "DELETE", links[1].Method)
+	})
+
+	t.Run("過期的邀請不可撤銷", func(t *testing.T) {
+		later := now.Add(2 * time.Hour)
+		assert.Equal(t, user_models.InvitationExpired, invitation.EffectiveStatus(later))
+		assert.Len(t, user_models.GenerateInvitationLinks("http://localhost", invitation, later), 1)
+	})
+
+	t.Run("已接受的邀請連結到用戶", func(t *testing.T) {
+		userID := primitive.NewObjectID()
+		accepted := invitation
+		accepted.Status = user_models.InvitationAccepted
+		accepted.UserID = &userID
+		links := user_models.GenerateInvitationLinks("http://localhost", accepted, now)
+		assert.Equal(t, "user", links[1].Rel)
+		assert.Equal(t, "http://localhost/users/"+userID.Hex(), links[1].Href)
+	})
+}
+
+// TestInvitationEndpoints 測試邀請端點
+func TestInvitationEndpoints(t *testing.T) {
+	r := setupTestRouter()
+	r.POST("/api/v1/invitations", controllers.CreateInvitation)
+	r.GET("/api/v1/invitations", controllers.GetInvitations)
+	r.POST("/api/v1/invitations/accept", controllers.AcceptInvitation)
+	r.DELETE("/api/v1/invitations/:id", controllers.RevokeInvitation)
+
+	// 預期：由於數據庫未連接，應返回 503 Service Unavailable
+	testCases := []struct {
+		name   string // 測試用例名稱
+		method string // HTTP 方法
+		path   string // 請求路徑
+		body   string // 請求內容
+	}{
+		{name: "測試建立邀請", method: "POST", path: "/api/v1/invitations", body: `{"email":"zhangsan@example.com","role":"member"}`},
+		{name: "測試獲取邀請列表", method: "GET", path: "/api/v1/invitations?status=pending"},
+		{name: "測試接受邀請", method: "POST", path: "/api/v1/invitations/accept", body: `{"token":"abc","password":"s3cret-pass","name":"張三"}`},
+		{name: "測試撤銷邀請", method: "DELETE", path: "/api/v1/invitations/507f1f77bcf86cd799439011"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		})
+	}
+}
+
+// TestInvitationAdmin 測試管理邀請需要登入的管理員，接受邀請不需要登入
+func TestInvitationAdmin(t *testing.T) {
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+
+	for _, tc := range []struct{ method, path, body string }{
+		{"POST", "/api/v1/invitations", `{"email":"zhangsan@example.com","role":"admin"}`},
+		{"GET", "/api/v1/invitations", ""},
+		{"GET", "/api/v1/invitations/507f1f77bcf86cd799439011", ""},
+		{"DELETE", "/api/v1/invitations/507f1f77bcf86cd799439011", ""},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, tc.method+" "+tc.path)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/invitations/accept", strings.NewReader(`{"token":"abc","password":"s3cret-pass","name":"張三"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// 已登入但無法確認角色時不放行
+	admin := setupTestRouter()
+	principal := &oidc.AccessTokenClaims{}
+	principal.Subject = primitive.NewObjectID().Hex()
+	admin.POST("/api/v1/invitations", func(c *gin.Context) {
+		c.Set(middleware.PrincipalKey, principal)
+	}, controllers.RequireAdmin(), controllers.CreateInvitation)
+	admin.GET("/api/v1/invitations", controllers.RequireAdmin(), controllers.GetInvitations)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/invitations", strings.NewReader(`{"email":"zhangsan@example.com","role":"admin"}`))
+	admin.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/invitations", nil)
+	admin.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestAnonymousCreateUserRole 測試匿名的 POST /api/v1/users 即使帶有 "role":"admin" 也不會建立管理員，
+// 角色只能透過邀請或限管理員的路徑指定
+func TestAnonymousCreateUserRole(t *testing.T) {
+	body := `{"name":"張三","email":"zhangsan@example.com","password":"s3cret-pass","sex":"male","age":20,"phone":"+886912345678","address":"台北市","role":"admin"}`
+
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	assert.NotEqual(t, http.StatusUnauthorized, w.Code)
+	assert.NotEqual(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), `"role":"admin"`)
+
+	// 公開路由綁定的請求不含角色，交給共用的建立流程時一律為 member
+	var public user_models.CreateUserRequest
+	assert.NoError(t, json.Unmarshal([]byte(body), &public))
+	assert.NoError(t, binding.Validator.ValidateStruct(&public))
+	created := user_models.AdminCreateUserRequest{CreateUserRequest: public}.ToUser("hashed")
+	assert.Equal(t, user_models.RoleMember, created.Role)
+
+	// 可以指定角色的路徑需要登入的管理員
+	for _, path := range []string{"/api/v1/invitations", "/api/v1/users/bulk"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(`{"role":"admin"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/jobs"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/routes"
+)
+
+// TestJobBackoff 測試重試等待時間每次加倍且不超過上限
+func TestJobBackoff(t *testing.T) {
+	opts := jobs.Options{RetryBackoff: 10 * time.Second, MaxBackoff: time.Minute}
+	assert.Equal(t, 10*time.Second, opts.Backoff(1))
+	assert.Equal(t, 20*time.Second, opts.Backoff(2))
+	assert.Equal(t, 40*time.Second, opts.Backoff(3))
+	assert.Equal(t, time.Minute, opts.Backoff(4))
+	assert.Equal(t, time.Minute, opts.Backoff(30))
+
+	// 未設定時使用預設值
+	assert.Equal(t, 30*time.Second, jobs.Options{}.Backoff(1))
+}
+
+// TestJobPermanentError 測試不重試的錯誤仍保留原本的錯誤
+func TestJobPermanentError(t *testing.T) {
+	cause := errors.New("bad file")
+	err := jobs.Permanent(cause)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "bad file", err.Error())
+}
+
+// TestJobLinks 測試工作在各狀態下的連結
+func TestJobLinks(t *testing.T) {
+	base := "http://api.example.com/api/v1"
+	job := user_models.Job{ID: primitive.NewObjectID(), Kind: user_models.JobKindUserExport, Status: user_models.JobRunning}
+	jobURL := base + "/jobs/" + job.ID.Hex()
+
+	links := user_models.GenerateJobLinks(base, job)
+	assert.Equal(t, []string{"self", "cancel"}, linkRels(links))
+	assert.Equal(t, jobURL+"/cancel", links[1].Href)
+
+	job.Status = user_models.JobSucceeded
+	job.Result = &user_models.JobResult{FileID: primitive.NewObjectID(), Filename: "users.csv", ContentType: "text/csv", Size: 42}
+	links = user_models.GenerateJobLinks(base, job)
+	assert.Equal(t, []string{"self", "result"}, linkRels(links))
+	assert.Equal(t, jobURL+"/result", links[1].Href)
+
+	job.Status = user_models.JobFailed
+	assert.Equal(t, []string{"self"}, linkRels(user_models.GenerateJobLinks(base, job)))
+}
+
+// TestJobView 測試工作的公開欄位不含租約與輸入檔案
+func TestJobView(t *testing.T) {
+	lease := time.Now()
+	input := primitive.NewObjectID()
+	job := user_models.Job{
+		ID:          primitive.NewObjectID(),
+		Status:      user_models.JobSucceeded,
+		Progress:    user_models.JobProgress{Done: 3, Total: 3},
+		LeaseOwner:  "worker-1",
+		LeaseUntil:  &lease,
+		InputFileID: &input,
+		Result:      &user_models.JobResult{Filename: "users.xlsx", Size: 2048},
+	}
+	view := user_models.NewJobView(job)
+	assert.Equal(t, job.ID.Hex(), view.ID)
+	assert.Equal(t, "users.xlsx", view.ResultFilename)
+	assert.Equal(t, int64(2048), view.ResultSize)
+	assert.Equal(t, 3, view.Progress.Done)
+
+	assert.True(t, user_models.JobCancelled.Finished())
+	assert.False(t, user_models.JobQueued.Finished())
+}
+
+func linkRels(links []user_models.HATEOASLink) []string {
+	rels := make([]string, len(links))
+	for i, link := range links {
+		rels[i] = link.Rel
+	}
+	return rels
+}
+
+// TestJobEndpoints 測試工作路由需要登入的管理員，以及資料庫未連接時的回應
+func TestJobEndpoints(t *testing.T) {
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+	direct := setupTestRouter()
+	direct.POST("/api/v1/jobs/exports", controllers.CreateExportJob)
+	direct.POST("/api/v1/jobs/imports", controllers.CreateImportJob)
+	direct.GET("/api/v1/jobs/:id", controllers.GetJob)
+	direct.POST("/api/v1/jobs/:id/cancel", controllers.CancelJob)
+	direct.GET("/api/v1/jobs/:id/result", controllers.GetJobResult)
+
+	id := primitive.NewObjectID().Hex()
+	for _, tc := range []struct{ method, path string }{
+		{"POST", "/api/v1/jobs/exports?format=csv"},
+		{"POST", "/api/v1/jobs/imports"},
+		{"GET", "/api/v1/jobs/" + id},
+		{"POST", "/api/v1/jobs/" + id + "/cancel"},
+		{"GET", "/api/v1/jobs/" + id + "/result"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, tc.path)
+
+		w = httptest.NewRecorder()
+		direct.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, tc.path)
+	}
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/controllers"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/routes"
+)
+
+// TestLifecycleTransitions 測試狀態轉換規則
+func TestLifecycleTransitions(t *testing.T) {
+	testCases := []struct {
+		name     string                 // 測試用例名稱
+		from     user_models.UserStatus // 目前狀態
+		action   string                 // 執行的動作
+		expected bool                   // 是否允許
+	}{
+		{name: "待驗證可啟用", from: user_models.StatusPendingVerification, action: "activate", expected: true},
+		{name: "active不可啟用", from: user_models.StatusActive, action: "activate", expected: false},
+		{name: "舊資料視為active可停權", from: "", action: "suspend", expected: true},
+		{name: "鎖定可停權", from: user_models.StatusLocked, action: "suspend", expected: true},
+		{name: "停權不可解除鎖定", from: user_models.StatusSuspended, action: "unlock", expected: false},
+		{name: "已刪除不可重新啟用", from: user_models.StatusDeleted, action: "reactivate", expected: false},
+		{name: "停用可刪除", from: user_models.StatusDeactivated, action: "delete", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			action, ok := user_models.FindLifecycleAction(tc.action)
+			assert.True(t, ok)
+			assert.Equal(t, tc.expected, action.Allows(tc.from))
+		})
+	}
+
+	t.Run("依狀態尋找轉換", func(t *testing.T) {
+		action, ok := user_models.FindTransition(user_models.StatusDeactivated, user_models.StatusActive)
+		assert.True(t, ok)
+		assert.Equal(t, "reactivate", action.Name)
+
+		_, ok = user_models.FindTransition(user_models.StatusDeleted, user_models.StatusActive)
+		assert.False(t, ok)
+	})
+
+	t.Run("只有active可登入", func(t *testing.T) {
+		assert.True(t, user_models.UserStatus("").CanAuthenticate())
+		assert.False(t, user_models.StatusLocked.CanAuthenticate())
+	})
+}
+
+// TestLifecycleLinks 測試 HATEOAS 連結依狀態提供可執行的動作
+func TestLifecycleLinks(t *testing.T) {
+	rels := func(status user_models.UserStatus) []string {
+		var result []string
+		for _, link := range user_models.GenerateUserLinks("http://localhost", "abc", status) {
+			result = append(result, link.Rel)
+		}
+		return result
+	}
+
+	active := rels(user_models.StatusActive)
+	assert.Contains(t, active, "suspend")
+	assert.Contains(t, active, "lock")
+	assert.NotContains(t, active, "reinstate")
+
+	suspended := rels(user_models.StatusSuspended)
+	assert.Contains(t, suspended, "reinstate")
+	assert.NotContains(t, suspended, "lock")
+
+	deleted := rels(user_models.StatusDeleted)
+	assert.NotContains(t, deleted, "update")
+	assert.Contains(t, deleted, "status-history")
+}
+
+// TestLifecycleEndpoints 測試狀態轉換端點，以及這些端點只限管理員
+func TestLifecycleEndpoints(t *testing.T) {
+	r := setupTestRouter()
+	r.POST("/api/v1/users/:id/suspend", controllers.SuspendUser)
+	r.GET("/api/v1/users/:id/status-history", controllers.GetUserStatusHistory)
+
+	// 預期：由於數據庫未連接，應返回 503 Service Unavailable
+	t.Run("測試停權用戶", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/users/507f1f77bcf86cd799439011/suspend", strings.NewReader(`{"reason":"違反使用規範"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("測試狀態歷史", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users/507f1f77bcf86cd799439011/status-history", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("未登入無法變更狀態", func(t *testing.T) {
+		router := setupTestRouter()
+		routes.SetupRouter(router)
+		for _, action := range []string{"activate", "suspend", "reinstate", "lock", "unlock", "deactivate", "reactivate"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/users/507f1f77bcf86cd799439011/"+action, strings.NewReader(`{"reason":"違反使用規範"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, action)
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users/507f1f77bcf86cd799439011/status-history", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/middleware"
+	"go-api_for_main/oidc"
+)
+
+// TestSessionClaim 測試存取權杖中的工作階段宣告
+func TestSessionClaim(t *testing.T) {
+	issuer := "http://localhost:8080"
+	km := oidc.NewKeyManager(nil, time.Hour, time.Hour)
+	assert.NoError(t, km.Load(context.Background()))
+
+	claims := oidc.NewAccessTokenClaims(issuer, primitive.NewObjectID().Hex(), "client", "openid", time.Now(), time.Minute)
+	claims.SessionID = primitive.NewObjectID().Hex()
+	token, err := km.Sign(claims)
+	assert.NoError(t, err)
+
+	verified, err := km.VerifyAccessToken(token, issuer)
+	assert.NoError(t, err)
+	assert.Equal(t, claims.SessionID, verified.SessionID)
+}
+
+// TestMeEndpoints 測試個人資料端點
+func TestMeEndpoints(t *testing.T) {
+	principal := &oidc.AccessTokenClaims{}
+	principal.Subject = primitive.NewObjectID().Hex()
+	verify := func(token string) (*oidc.AccessTokenClaims, error) {
+		if token != "valid-token" {
+			return nil, oidc.ErrInvalidToken
+		}
+		return principal, nil
+	}
+
+	r := setupTestRouter()
+	me := r.Group("/api/v1/me", middleware.RequireAuth(verify))
+	me.GET("", controllers.GetMe)
+	me.PATCH("", controllers.UpdateMe)
+	me.DELETE("", controllers.DeactivateMe)
+	me.POST("/password", controllers.ChangeMyPassword)
+	me.GET("/sessions", controllers.GetMySessions)
+	me.DELETE("/sessions/:id", controllers.RevokeMySession)
+
+	t.Run("未帶權杖", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+	})
+
+	// 預期：權杖有效但數據庫未連接，應返回 503 Service Unavailable
+	testCases := []struct {
+		name   string // 測試用例名稱
+		method string // HTTP 方法
+		path   string // 請求路徑
+		body   string // 請求內容
+	}{
+		{name: "測試獲取個人資料", method: "GET", path: "/api/v1/me"},
+		{name: "測試更新個人資料", method: "PATCH", path: "/api/v1/me", body: `{"phone":"0987654321"}`},
+		{name: "測試變更密碼", method: "POST", path: "/api/v1/me/password", body: `{"current_password":"old-secret","new_password":"new-s3cret"}`},
+		{name: "測試獲取工作階段", method: "GET", path: "/api/v1/me/sessions"},
+		{name: "測試登出工作階段", method: "DELETE", path: "/api/v1/me/sessions/507f1f77bcf86cd799439011"},
+		{name: "測試停用帳號", method: "DELETE", path: "/api/v1/me"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer valid-token")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		})
+	}
+}
+
+// TestRequireAuthReusesPrincipal 測試已驗證的請求不會重複驗證權杖
+func TestRequireAuthReusesPrincipal(t *testing.T) {
+	calls := 0
+	verify := func(token string) (*oidc.AccessTokenClaims, error) {
+		calls++
+		return &oidc.AccessTokenClaims{}, nil
+	}
+
+	r := setupTestRouter()
+	group := r.Group("/api/v1", middleware.Authenticate(verify))
+	group.GET("/me", middleware.RequireAuth(verify), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer any")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 1, calls)
+}
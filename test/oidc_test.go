@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/config"
+	"go-api_for_main/controllers"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/oidc"
+)
+
+// TestOIDCProvider 測試身分提供者在未連接資料庫時仍可提供的端點
+func TestOIDCProvider(t *testing.T) {
+	cfg := config.LoadConfig().OIDC
+	controllers.SetupOIDCController(nil, cfg)
+
+	r := setupTestRouter()
+	r.GET("/.well-known/openid-configuration", controllers.OpenIDConfiguration)
+	r.GET("/oauth2/jwks", controllers.JWKS)
+	r.POST("/oauth2/token", controllers.Token)
+	r.GET("/oauth2/userinfo", middleware.RequireAuth(controllers.VerifyAccessToken), controllers.UserInfo)
+
+	// 測試 Discovery 文件
+	t.Run("測試Discovery文件", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/.well-known/openid-configuration", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var doc user_models.OIDCDiscoveryDocument
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+		assert.Equal(t, cfg.Issuer, doc.Issuer)
+		assert.Equal(t, cfg.Issuer+"/oauth2/jwks", doc.JwksURI)
+		assert.Equal(t, []string{"S256"}, doc.CodeChallengeMethodsSupported)
+	})
+
+	// 測試 JWKS 至少包含一把金鑰
+	t.Run("測試JWKS", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/oauth2/jwks", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var set user_models.JSONWebKeySet
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+		assert.NotEmpty(t, set.Keys)
+		assert.Equal(t, "RS256", set.Keys[0].Alg)
+	})
+
+	// 測試權杖端點
+	// 預期：由於數據庫未連接，應返回 503 Service Unavailable
+	t.Run("測試權杖端點", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth2/token", strings.NewReader("grant_type=authorization_code"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	// 測試 userinfo 未帶權杖
+	// 預期：應返回 401 並帶有 WWW-Authenticate 標頭
+	t.Run("測試UserInfo未授權", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/oauth2/userinfo", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+	})
+}
+
+// TestPKCE 測試 S256 code_verifier 驗證
+func TestPKCE(t *testing.T) {
+	// RFC 7636 附錄 B 的範例值
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	assert.Equal(t, challenge, oidc.S256Challenge(verifier))
+	assert.True(t, oidc.ValidCodeChallenge(challenge, "S256"))
+	assert.False(t, oidc.ValidCodeChallenge(challenge, "plain"))
+	assert.True(t, oidc.VerifyPKCE(verifier, challenge, "S256"))
+	assert.False(t, oidc.VerifyPKCE("too-short", challenge, "S256"))
+	assert.False(t, oidc.VerifyPKCE(verifier+"x", challenge, "S256"))
+}
+
+// TestKeyRotation 測試金鑰輪替後舊金鑰簽發的權杖仍可驗證
+func TestKeyRotation(t *testing.T) {
+	issuer := "http://localhost:8080"
+	km := oidc.NewKeyManager(nil, time.Hour, time.Hour)
+	assert.NoError(t, km.Load(context.Background()))
+
+	claims := oidc.NewAccessTokenClaims(issuer, primitive.NewObjectID().Hex(), "client", "openid", time.Now(), time.Minute)
+	token, err := km.Sign(claims)
+	assert.NoError(t, err)
+
+	assert.NoError(t, km.Rotate(context.Background()))
+	assert.Len(t, km.JWKS().Keys, 2)
+
+	verified, err := km.VerifyAccessToken(token, issuer)
+	assert.NoError(t, err)
+	assert.Equal(t, claims.Subject, verified.Subject)
+
+	_, err = km.VerifyAccessToken(token, "http://other-issuer")
+	assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+}
+
+// TestUserInfoClaims 測試 scope 與 userinfo 宣告的對應
+func TestUserInfoClaims(t *testing.T) {
+	user := user_models.User{
+		ID:      primitive.NewObjectID(),
+		Name:    "張三",
+		Email:   "zhangsan@example.com",
+		Phone:   "1234567890",
+		Address: "台北市",
+	}
+
+	info := oidc.UserInfoFromUser(user, "openid email")
+	assert.Equal(t, user.ID.Hex(), info.Subject)
+	assert.Equal(t, user.Email, info.Email)
+	assert.Empty(t, info.Name)
+	assert.Empty(t, info.PhoneNumber)
+	assert.Nil(t, info.Address)
+
+	info = oidc.UserInfoFromUser(user, "openid profile phone address")
+	assert.Equal(t, user.Name, info.Name)
+	assert.Equal(t, user.Phone, info.PhoneNumber)
+	assert.Equal(t, user.Address, info.Address.Formatted)
+}
+
+// TestClientRegistration 測試動態註冊預設關閉，以及 redirect_uri 必須為絕對 URI
+func TestClientRegistration(t *testing.T) {
+	defer controllers.SetupOIDCController(nil, config.LoadConfig().OIDC)
+	r := setupTestRouter()
+	r.GET("/.well-known/openid-configuration", controllers.OpenIDConfiguration)
+	r.POST("/oauth2/register", controllers.RegisterClient)
+
+	register := func(authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth2/register", strings.NewReader(`{"redirect_uris":["https://app.example.com/cb"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	discovery := func() user_models.OIDCDiscoveryDocument {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/.well-known/openid-configuration", nil)
+		r.ServeHTTP(w, req)
+		var doc user_models.OIDCDiscoveryDocument
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+		return doc
+	}
+
+	cfg := config.LoadConfig().OIDC
+	cfg.RegistrationToken = ""
+	cfg.OpenRegistration = false
+	controllers.SetupOIDCController(nil, cfg)
+	assert.Equal(t, http.StatusForbidden, register("").Code)
+	assert.Empty(t, discovery().RegistrationEndpoint)
+
+	cfg.RegistrationToken = "initial-token"
+	controllers.SetupOIDCController(nil, cfg)
+	assert.Equal(t, http.StatusUnauthorized, register("").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, register("Bearer initial-token").Code)
+	assert.Equal(t, cfg.Issuer+"/oauth2/register", discovery().RegistrationEndpoint)
+
+	cfg.RegistrationToken = ""
+	cfg.OpenRegistration = true
+	controllers.SetupOIDCController(nil, cfg)
+	assert.Equal(t, http.StatusServiceUnavailable, register("").Code)
+
+	assert.True(t, oidc.ValidRedirectURI("https://app.example.com/callback"))
+	assert.True(t, oidc.ValidRedirectURI("http://localhost:3000/callback"))
+	for _, uri := range []string{"/callback", "callback", "//app.example.com/cb", "https:///cb", "https://app.example.com/cb#frag", "com.example.app:/cb"} {
+		assert.False(t, oidc.ValidRedirectURI(uri), uri)
+	}
+}
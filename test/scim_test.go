@@ -0,0 +1,207 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/config"
+	"go-api_for_main/controllers"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/scim"
+)
+
+// TestSCIMFilter 測試 SCIM 篩選語法的解析與 MongoDB 轉換
+func TestSCIMFilter(t *testing.T) {
+	testCases := []struct {
+		name     string // 測試用例名稱
+		filter   string // SCIM 篩選運算式
+		expected bson.M // 預期的 MongoDB 查詢
+	}{
+		{
+			name:     "userName相等不分大小寫",
+			filter:   `userName eq "Zhang.San@example.com"`,
+			expected: bson.M{"email": primitive.Regex{Pattern: `^Zhang\.San@example\.com$`, Options: "i"}},
+		},
+		{
+			name:   "and與or優先順序",
+			filter: `displayName sw "張" or emails co "@example.com" and urn:go-api-for-main:scim:schemas:extension:profile:2.0:User:age ge 18`,
+			expected: bson.M{"$or": bson.A{
+				bson.M{"name": primitive.Regex{Pattern: "^張", Options: "i"}},
+				bson.M{"$and": bson.A{
+					bson.M{"email": primitive.Regex{Pattern: `@example\.com`, Options: "i"}},
+					bson.M{"age": bson.M{"$gte": int64(18)}},
+				}},
+			}},
+		},
+		{
+			name:     "not與pr",
+			filter:   `not (phoneNumbers pr)`,
+			expected: bson.M{"$nor": bson.A{bson.M{"phone": bson.M{"$exists": true, "$nin": bson.A{nil, ""}}}}},
+		},
+		{
+			name:   "值路徑篩選",
+			filter: `emails[type eq "work" and value ew "example.com"]`,
+			expected: bson.M{"$and": bson.A{
+				bson.M{},
+				bson.M{"email": primitive.Regex{Pattern: `example\.com$`, Options: "i"}},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := scim.ParseFilter(tc.filter)
+			assert.NoError(t, err)
+			query, err := scim.ToMongo(expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, query)
+		})
+	}
+
+	// 測試無效的篩選
+	for _, filter := range []string{`userName eq`, `userName xx "a"`, `(userName eq "a"`, `unknown eq "a"`} {
+		expr, err := scim.ParseFilter(filter)
+		if err == nil {
+			_, err = scim.ToMongo(expr)
+		}
+		assert.ErrorIs(t, err, scim.ErrInvalidFilter, filter)
+	}
+}
+
+// TestSCIMPatch 測試 PATCH 操作套用到使用者資源
+func TestSCIMPatch(t *testing.T) {
+	original := user_models.User{
+		ID:      primitive.NewObjectID(),
+		Name:    "張三",
+		Email:   "zhangsan@example.com",
+		Sex:     "男",
+		Age:     20,
+		Phone:   "1234567890",
+		Address: "台北市",
+	}
+
+	resource, err := scim.ApplyPatch(scim.FromUser(original, ""), []user_models.SCIMPatchOperation{
+		{Op: "Replace", Path: `emails[type eq "work"].value`, Value: "zs@example.com"},
+		{Op: "replace", Value: map[string]interface{}{"displayName": "張小三"}},
+		{Op: "replace", Path: user_models.SCIMSchemaProfileExtension + ":age", Value: 21},
+		{Op: "remove", Path: "phoneNumbers"},
+	})
+	assert.NoError(t, err)
+
+	updated, err := scim.ToUser(resource, original)
+	assert.NoError(t, err)
+	assert.Equal(t, "zs@example.com", updated.Email)
+	assert.Equal(t, "張小三", updated.Name)
+	assert.Equal(t, 21, updated.Age)
+	assert.Empty(t, updated.Phone)
+	assert.Equal(t, original.Address, updated.Address)
+
+	// 篩選沒有符合的元素時應回傳 noTarget
+	_, err = scim.ApplyPatch(scim.FromUser(original, ""), []user_models.SCIMPatchOperation{
+		{Op: "replace", Path: `emails[type eq "home"].value`, Value: "x@example.com"},
+	})
+	assert.ErrorIs(t, err, scim.ErrNoTarget)
+
+	// ETag 應隨內容改變
+	assert.NotEqual(t, scim.ETag(scim.FromUser(original, "")), scim.ETag(scim.FromUser(updated, "")))
+}
+
+// TestSCIMToUserValidation 測試 SCIM 資源與 REST 使用相同的正規化與驗證規則
+func TestSCIMToUserValidation(t *testing.T) {
+	age := 30
+	resource := user_models.SCIMUser{
+		DisplayName:  "  王   小明 ",
+		Emails:       []user_models.SCIMMultiValued{{Value: "Wang@Example.COM", Primary: true}},
+		PhoneNumbers: []user_models.SCIMMultiValued{{Value: "0912 345 678", Primary: true}},
+		Profile:      &user_models.SCIMProfileExtension{Sex: "Male", Age: &age},
+	}
+
+	user, err := scim.ToUser(resource, user_models.User{})
+	assert.NoError(t, err)
+	assert.Equal(t, "王 小明", user.Name)
+	assert.Equal(t, "wang@example.com", user.Email)
+	assert.Equal(t, "+886912345678", user.Phone)
+	assert.Equal(t, "male", user.Sex)
+	assert.Equal(t, 30, user.Age)
+
+	invalid := map[string]func(r *user_models.SCIMUser){
+		"email": func(r *user_models.SCIMUser) { r.Emails[0].Value = "not-an-email" },
+		"phone": func(r *user_models.SCIMUser) { r.PhoneNumbers[0].Value = "12" },
+		"sex":   func(r *user_models.SCIMUser) { r.Profile.Sex = "unknown" },
+		"age": func(r *user_models.SCIMUser) {
+			tooOld := 200
+			r.Profile.Age = &tooOld
+		},
+		"name": func(r *user_models.SCIMUser) { r.DisplayName = "<script>" },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			r := resource
+			r.Emails = append([]user_models.SCIMMultiValued(nil), resource.Emails...)
+			r.PhoneNumbers = append([]user_models.SCIMMultiValued(nil), resource.PhoneNumbers...)
+			profile := *resource.Profile
+			r.Profile = &profile
+			mutate(&r)
+
+			_, err := scim.ToUser(r, user_models.User{})
+			assert.ErrorIs(t, err, scim.ErrInvalidValue)
+		})
+	}
+}
+
+// TestSCIMEndpoints 測試 SCIM 端點
+func TestSCIMEndpoints(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/scim/v2/Users", controllers.SCIMListUsers)
+	r.GET("/scim/v2/ServiceProviderConfig", controllers.SCIMServiceProviderConfig)
+
+	// 預期：由於數據庫未連接，應返回 503 Service Unavailable
+	t.Run("測試查詢使用者", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", `/scim/v2/Users?filter=userName%20eq%20%22a%22`, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "application/scim+json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("測試服務能力說明", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/scim/v2/ServiceProviderConfig", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), user_models.SCIMSchemaServiceProviderConfig)
+	})
+}
+
+// TestSCIMAuth 測試 SCIM 權杖驗證，未設定權杖時拒絕所有請求
+func TestSCIMAuth(t *testing.T) {
+	defer controllers.SetupSCIMController(config.SCIMConfig{})
+	r := setupTestRouter()
+	r.GET("/scim/v2/ServiceProviderConfig", controllers.SCIMAuth(), controllers.SCIMServiceProviderConfig)
+
+	request := func(authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/scim/v2/ServiceProviderConfig", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	controllers.SetupSCIMController(config.SCIMConfig{})
+	assert.Equal(t, http.StatusServiceUnavailable, request("").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, request("Bearer ").Code)
+
+	controllers.SetupSCIMController(config.SCIMConfig{BearerToken: "scim-secret"})
+	assert.Equal(t, http.StatusUnauthorized, request("").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("Bearer wrong").Code)
+	assert.Equal(t, http.StatusOK, request("Bearer scim-secret").Code)
+}
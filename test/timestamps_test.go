@@ -0,0 +1,79 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/clock"
+	"go-api_for_main/controllers"
+	"go-api_for_main/middleware"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/oidc"
+)
+
+// TestClock 測試系統時鐘使用 UTC 與凍結時鐘的推移
+func TestClock(t *testing.T) {
+	assert.Equal(t, time.UTC, clock.System{}.Now().Location())
+
+	taipei := time.FixedZone("Asia/Taipei", 8*60*60)
+	frozen := clock.NewFrozen(time.Date(2024, 5, 1, 8, 0, 0, 0, taipei))
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), frozen.Now())
+	assert.Equal(t, frozen.Now(), frozen.Now())
+
+	frozen.Advance(90 * time.Minute)
+	assert.Equal(t, time.Date(2024, 5, 1, 1, 30, 0, 0, time.UTC), frozen.Now())
+}
+
+// TestAuditFields 測試更新時由伺服器設定時間戳記與修改者
+func TestAuditFields(t *testing.T) {
+	frozen := clock.NewFrozen(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	controllers.SetClock(frozen)
+	defer controllers.SetClock(nil)
+
+	principal := &oidc.AccessTokenClaims{}
+	principal.Subject = "507f1f77bcf86cd799439099"
+	verify := func(token string) (*oidc.AccessTokenClaims, error) {
+		return principal, nil
+	}
+
+	r := setupTestRouter()
+	r.PUT("/api/test/users/:id", middleware.Authenticate(verify), controllers.UpdateUser_test)
+
+	update := func(token string) user_models.UserView {
+		body, _ := json.Marshal(map[string]interface{}{
+			"name": "李四", "email": "lisi@example.com", "sex": "female", "age": 30,
+			"phone": "+886912345678", "address": "台中市",
+			"updated_by": "spoofed", "updated_at": "2000-01-01T00:00:00Z",
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/test/users/507f1f77bcf86cd799439011", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+
+		var response user_models.UserResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Data
+	}
+
+	t.Run("已驗證的請求記錄修改者", func(t *testing.T) {
+		user := update("valid-token")
+		assert.Equal(t, principal.Subject, user.UpdatedBy)
+		assert.Equal(t, frozen.Now(), user.UpdatedAt)
+	})
+
+	t.Run("未驗證的請求記錄為匿名", func(t *testing.T) {
+		frozen.Advance(time.Hour)
+		user := update("")
+		assert.Equal(t, "anonymous", user.UpdatedBy)
+		assert.Equal(t, time.Date(2024, 5, 1, 1, 0, 0, 0, time.UTC), user.UpdatedAt)
+	})
+}
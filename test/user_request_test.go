@@ -0,0 +1,126 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/controllers"
+	user_models "go-api_for_main/models"
+)
+
+// TestServerManagedFields 測試用戶端無法設定 id、時間戳記與版本
+func TestServerManagedFields(t *testing.T) {
+	r := setupTestRouter()
+	r.PUT("/api/test/users/:id", controllers.UpdateUser_test)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":         "000000000000000000000000",
+		"name":       "李四",
+		"email":      "LiSi@Example.com",
+		"sex":        "女",
+		"age":        30,
+		"phone":      "0912345678",
+		"address":    "台中市",
+		"version":    99,
+		"created_at": "2000-01-01T00:00:00Z",
+		"password":   "should-be-ignored",
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/api/test/users/507f1f77bcf86cd799439011", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response user_models.UserResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "507f1f77bcf86cd799439011", response.Data.ID)
+	assert.Equal(t, "李四", response.Data.Name)
+	assert.Equal(t, "lisi@example.com", response.Data.Email)
+	assert.Equal(t, "female", response.Data.Sex)
+	assert.Equal(t, int64(2), response.Data.Version)
+	assert.True(t, response.Data.CreatedAt.After(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)))
+
+	var raw map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &raw)
+	data := raw["data"].(map[string]interface{})
+	assert.NotContains(t, data, "password")
+	assert.NotContains(t, data, "status_history")
+}
+
+// TestCreateUserRole 測試公開的建立請求無法指定角色，只有管理員的建立請求可以
+func TestCreateUserRole(t *testing.T) {
+	body := []byte(`{"name":"張三","email":"zhangsan@example.com","password":"s3cret-pass","sex":"male","age":20,"phone":"+886912345678","address":"台北市","role":"admin"}`)
+
+	var public user_models.CreateUserRequest
+	assert.NoError(t, json.Unmarshal(body, &public))
+	assert.NoError(t, binding.Validator.ValidateStruct(&public))
+	assert.Equal(t, user_models.RoleMember, public.ToUser("hashed").Role)
+
+	var admin user_models.AdminCreateUserRequest
+	assert.NoError(t, json.Unmarshal(body, &admin))
+	assert.NoError(t, binding.Validator.ValidateStruct(&admin))
+	assert.Equal(t, "zhangsan@example.com", admin.Email)
+	assert.Equal(t, user_models.RoleAdmin, admin.ToUser("hashed").Role)
+	assert.Equal(t, user_models.RoleMember, user_models.AdminCreateUserRequest{CreateUserRequest: public}.ToUser("hashed").Role)
+
+	admin.Role = "owner"
+	assert.Error(t, binding.Validator.ValidateStruct(&admin))
+	admin.Role = ""
+	admin.Email = "not-an-email"
+	assert.Error(t, binding.Validator.ValidateStruct(&admin))
+}
+
+// TestPatchUserRequest 測試部分更新只驗證並套用提供的欄位
+func TestPatchUserRequest(t *testing.T) {
+	original := user_models.User{
+		ID:       primitive.NewObjectID(),
+		Name:     "張三",
+		Email:    "zhangsan@example.com",
+		Password: "hashed",
+		Sex:      "male",
+		Age:      20,
+		Phone:    "+886912345678",
+		Address:  "台北市",
+		Role:     user_models.RoleAdmin,
+	}
+
+	bind := func(payload string) (user_models.PatchUserRequest, error) {
+		var req user_models.PatchUserRequest
+		err := binding.JSON.BindBody([]byte(payload), &req)
+		return req, err
+	}
+
+	t.Run("空的請求不修改任何欄位", func(t *testing.T) {
+		req, err := bind(`{}`)
+		assert.NoError(t, err)
+		updated := original
+		req.Apply(&updated)
+		assert.Equal(t, original, updated)
+	})
+
+	t.Run("只套用提供的欄位並正規化", func(t *testing.T) {
+		req, err := bind(`{"phone": "081-234-5678", "age": 0}`)
+		assert.NoError(t, err)
+		updated := original
+		req.Apply(&updated)
+		assert.Equal(t, "+886812345678", updated.Phone)
+		assert.Equal(t, 0, updated.Age)
+		assert.Equal(t, original.Name, updated.Name)
+		assert.Equal(t, user_models.RoleAdmin, updated.Role)
+	})
+
+	t.Run("提供的空值仍需通過驗證", func(t *testing.T) {
+		_, err := bind(`{"name": ""}`)
+		assert.Error(t, err)
+		_, err = bind(`{"age": -1}`)
+		assert.Error(t, err)
+	})
+}
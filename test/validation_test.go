@@ -0,0 +1,147 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+
+	"go-api_for_main/controllers"
+	"go-api_for_main/i18n"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/validation"
+)
+
+// TestFieldErrors 測試驗證錯誤轉為各語言的欄位錯誤清單
+func TestFieldErrors(t *testing.T) {
+	invalid := &user_models.CreateUserRequest{Name: "張三", Email: "not-an-email", Password: "s3cret-pass", Sex: "男", Age: 20, Phone: "0912345678", Address: "台北市"}
+	err := binding.Validator.ValidateStruct(invalid)
+	assert.Error(t, err)
+
+	testCases := []struct {
+		name     string // 測試用例名稱
+		lang     string // 語言
+		expected string // 預期的訊息
+	}{
+		{name: "英文", lang: i18n.English, expected: "email must be a valid email address"},
+		{name: "繁體中文", lang: i18n.TraditionalChinese, expected: "email必須是一個有效的信箱"},
+		{name: "泰文", lang: i18n.Thai, expected: "email ต้องเป็นอีเมลเท่านั้น"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fieldErrors, ok := validation.Translate(err, tc.lang)
+			assert.True(t, ok)
+			assert.Len(t, fieldErrors, 1)
+			assert.Equal(t, "email", fieldErrors[0].Field)
+			assert.Equal(t, "email", fieldErrors[0].Code)
+			assert.Equal(t, tc.expected, fieldErrors[0].Message)
+		})
+	}
+
+	t.Run("非驗證錯誤", func(t *testing.T) {
+		_, ok := validation.Translate(errors.New("boom"), i18n.English)
+		assert.False(t, ok)
+	})
+}
+
+// TestFieldErrorResponse 測試錯誤響應包含欄位錯誤清單
+func TestFieldErrorResponse(t *testing.T) {
+	r := setupTestRouter()
+	r.PUT("/api/test/users/:id", controllers.UpdateUser_test)
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(map[string]interface{}{"email": "zhangsan@example.com"})
+	req, _ := http.NewRequest("PUT", "/api/test/users/507f1f77bcf86cd799439011", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	var response user_models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	fields := map[string]string{}
+	for _, fe := range response.Errors {
+		fields[fe.Field] = fe.Code
+	}
+	assert.Equal(t, "required", fields["name"])
+	assert.Equal(t, "required", fields["phone"])
+	assert.NotContains(t, fields, "email")
+}
+
+// TestDomainRules 測試電話、年齡、性別、姓名的驗證規則與正規化
+func TestDomainRules(t *testing.T) {
+	valid := func() *user_models.CreateUserRequest {
+		return &user_models.CreateUserRequest{Name: "張三", Email: "zhangsan@example.com", Password: "s3cret-pass", Sex: "male", Age: 20, Phone: "+886912345678", Address: "台北市"}
+	}
+
+	t.Run("正規化", func(t *testing.T) {
+		user := valid()
+		user.Name = "  Mary   Jane  "
+		user.Email = "  ZhangSan@Example.COM "
+		user.Sex = " 女 "
+		user.Phone = "0912-345-678"
+		assert.NoError(t, binding.Validator.ValidateStruct(user))
+		assert.Equal(t, "Mary Jane", user.Name)
+		assert.Equal(t, "zhangsan@example.com", user.Email)
+		assert.Equal(t, validation.SexFemale, user.Sex)
+		assert.Equal(t, "+886912345678", user.Phone)
+	})
+
+	t.Run("泰國預設地區", func(t *testing.T) {
+		assert.NoError(t, validation.SetDefaultRegion("th"))
+		defer validation.SetDefaultRegion("TW")
+		assert.Equal(t, "+66812345678", validation.NormalizePhone("081 234 5678"))
+		assert.Equal(t, "+886912345678", validation.NormalizePhone("00886912345678"))
+		assert.Error(t, validation.SetDefaultRegion("JP"))
+	})
+
+	testCases := []struct {
+		name   string                               // 測試用例名稱
+		modify func(*user_models.CreateUserRequest) // 修改有效的用戶
+		field  string                               // 預期失敗的欄位
+		code   string                               // 預期失敗的規則
+	}{
+		{name: "年齡為負數", modify: func(u *user_models.CreateUserRequest) { u.Age = -5 }, field: "age", code: "age"},
+		{name: "年齡過大", modify: func(u *user_models.CreateUserRequest) { u.Age = 9999 }, field: "age", code: "age"},
+		{name: "無法辨識的性別", modify: func(u *user_models.CreateUserRequest) { u.Sex = "unknown" }, field: "sex", code: "sex"},
+		{name: "非E.164電話", modify: func(u *user_models.CreateUserRequest) { u.Phone = "12345" }, field: "phone", code: "phone"},
+		{name: "台灣號碼位數錯誤", modify: func(u *user_models.CreateUserRequest) { u.Phone = "+8869123" }, field: "phone", code: "phone"},
+		{name: "姓名含數字", modify: func(u *user_models.CreateUserRequest) { u.Name = "R2-D2" }, field: "name", code: "person_name"},
+		{name: "姓名過長", modify: func(u *user_models.CreateUserRequest) { u.Name = strings.Repeat("a", 101) }, field: "name", code: "person_name"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := valid()
+			tc.modify(user)
+			fieldErrors, ok := validation.Translate(binding.Validator.ValidateStruct(user), i18n.English)
+			assert.True(t, ok)
+			assert.Len(t, fieldErrors, 1)
+			assert.Equal(t, tc.field, fieldErrors[0].Field)
+			assert.Equal(t, tc.code, fieldErrors[0].Code)
+		})
+	}
+
+	t.Run("有效的泰文姓名與泰國電話", func(t *testing.T) {
+		user := valid()
+		user.Name = "สมชาย ใจดี"
+		user.Phone = "+66212345678"
+		user.Sex = "อื่นๆ"
+		assert.NoError(t, binding.Validator.ValidateStruct(user))
+		assert.Equal(t, validation.SexOther, user.Sex)
+	})
+
+	t.Run("翻譯包含範圍", func(t *testing.T) {
+		user := valid()
+		user.Age = 200
+		fieldErrors, _ := validation.Translate(binding.Validator.ValidateStruct(user), i18n.TraditionalChinese)
+		assert.Equal(t, "age必須介於0到150之間", fieldErrors[0].Message)
+	})
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-api_for_main/controllers"
+	user_models "go-api_for_main/models"
+	"go-api_for_main/routes"
+)
+
+// TestUserVersionView 測試版本快照的對外表示不包含密碼
+func TestUserVersionView(t *testing.T) {
+	id := primitive.NewObjectID()
+	view := user_models.NewUserVersionView(user_models.UserVersion{
+		UserID:  id,
+		Version: 3,
+		Action:  user_models.AuditUserUpdate,
+		Actor:   "admin",
+		At:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Snapshot: user_models.User{
+			ID:       id,
+			Name:     "張三",
+			Email:    "zhangsan@example.com",
+			Password: "$2a$10$secret",
+			Version:  3,
+		},
+	})
+
+	assert.Equal(t, int64(3), view.Version)
+	assert.Equal(t, "zhangsan@example.com", view.User.Email)
+	assert.Equal(t, id.Hex(), view.User.ID)
+}
+
+// TestNewUserVersion 測試保存的快照不包含密碼雜湊與待轉送的事件
+func TestNewUserVersion(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := user_models.User{
+		ID:       primitive.NewObjectID(),
+		Name:     "張三",
+		Password: "$2a$10$secret",
+		Version:  4,
+		Outbox:   []user_models.OutboxEvent{{}},
+	}
+
+	version := user_models.NewUserVersion(user, user_models.AuditUserUpdate, "admin", at)
+	assert.Equal(t, user.ID, version.UserID)
+	assert.Equal(t, int64(4), version.Version)
+	assert.Equal(t, at, version.At)
+	assert.Equal(t, "張三", version.Snapshot.Name)
+	assert.Empty(t, version.Snapshot.Password)
+	assert.Nil(t, version.Snapshot.Outbox)
+	assert.Equal(t, "$2a$10$secret", user.Password)
+}
+
+// TestUserVersionLinks 測試版本快照的連結，第一版沒有差異連結
+func TestUserVersionLinks(t *testing.T) {
+	rels := func(version int64) map[string]user_models.HATEOASLink {
+		byRel := map[string]user_models.HATEOASLink{}
+		for _, link := range user_models.GenerateUserVersionLinks("http://api.example.com/api/v1", "u1", version) {
+			byRel[link.Rel] = link
+		}
+		return byRel
+	}
+
+	second := rels(2)
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1/versions/2", second["self"].Href)
+	assert.Equal(t, "POST", second["restore"].Method)
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1/versions/2/diff", second["diff"].Href)
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1/versions", second["versions"].Href)
+
+	assert.NotContains(t, rels(1), "diff")
+}
+
+// TestUserVersionsLinks 測試版本列表的分頁連結
+func TestUserVersionsLinks(t *testing.T) {
+	rels := func(page int, total int64) map[string]string {
+		byRel := map[string]string{}
+		for _, link := range user_models.GenerateUserVersionsLinks("http://api.example.com/api/v1", "u1", page, 10, total) {
+			byRel[link.Rel] = link.Href
+		}
+		return byRel
+	}
+
+	first := rels(1, 25)
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1/versions?page=1&size=10", first["self"])
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1/versions?page=2&size=10", first["next"])
+	assert.NotContains(t, first, "prev")
+
+	last := rels(3, 25)
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1/versions?page=2&size=10", last["prev"])
+	assert.NotContains(t, last, "next")
+	assert.Equal(t, "http://api.example.com/api/v1/users/u1", last["user"])
+}
+
+// TestUserVersionEndpoints 測試資料庫未連接時的版本端點，以及未登入時的回應
+func TestUserVersionEndpoints(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/api/v1/users/:id/versions", controllers.GetUserVersions)
+	r.GET("/api/v1/users/:id/versions/:n", controllers.GetUserVersion)
+	r.GET("/api/v1/users/:id/versions/:n/diff", controllers.DiffUserVersions)
+	r.POST("/api/v1/users/:id/versions/:n/restore", controllers.RestoreUserVersion)
+	router := setupTestRouter()
+	routes.SetupRouter(router)
+
+	id := primitive.NewObjectID().Hex()
+	for _, tc := range []struct{ method, path string }{
+		{"GET", "/api/v1/users/" + id + "/versions"},
+		{"GET", "/api/v1/users/" + id + "/versions/1"},
+		{"GET", "/api/v1/users/" + id + "/versions/2/diff"},
+		{"POST", "/api/v1/users/" + id + "/versions/1/restore"},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("If-Match", `"2"`)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, tc.path)
+
+		// 版本歷史只限管理員，未登入時不會讀取或還原
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("If-Match", `"2"`)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, tc.path)
+	}
+}
+
+// TestUserETag 測試用戶響應以版本號作為 ETag
+func TestUserETag(t *testing.T) {
+	r := setupTestRouter()
+	r.PUT("/api/v1/users/:id", controllers.UpdateUser_test)
+
+	body := []byte(`{"name":"李四","email":"lisi@example.com","sex":"female","age":30,"phone":"0912345678","address":"台北市"}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/api/v1/users/"+primitive.NewObjectID().Hex(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+}
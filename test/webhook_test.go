@@ -0,0 +1,212 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	user_models "go-api_for_main/models"
+	"go-api_for_main/routes"
+	"go-api_for_main/webhooks"
+)
+
+// TestWebhookSignature 測試簽章可以驗證，內容、密鑰或時間不符時驗證失敗
+func TestWebhookSignature(t *testing.T) {
+	secret := webhooks.NewSecret()
+	assert.True(t, strings.HasPrefix(secret, "whsec_"))
+	assert.NotEqual(t, secret, webhooks.NewSecret())
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":"evt_1","type":"user.created"}`)
+	header := webhooks.Sign(secret, now.Unix(), body)
+	assert.True(t, strings.HasPrefix(header, "t=1714564800,v1="))
+
+	assert.True(t, webhooks.Verify(secret, header, body, now.Add(time.Minute), 5*time.Minute))
+	assert.False(t, webhooks.Verify(secret, header, []byte(`{"id":"evt_2"}`), now, 5*time.Minute))
+	assert.False(t, webhooks.Verify("whsec_other", header, body, now, 5*time.Minute))
+	assert.False(t, webhooks.Verify(secret, header, body, now.Add(10*time.Minute), 5*time.Minute))
+	assert.False(t, webhooks.Verify(secret, "v1=abc", body, now, 5*time.Minute))
+}
+
+// TestWebhookBackoff 測試重試等待時間每次加倍且不超過上限
+func TestWebhookBackoff(t *testing.T) {
+	opts := webhooks.Options{RetryBackoff: time.Minute, MaxBackoff: 5 * time.Minute}
+	assert.Equal(t, time.Minute, opts.Backoff(1))
+	assert.Equal(t, 2*time.Minute, opts.Backoff(2))
+	assert.Equal(t, 4*time.Minute, opts.Backoff(3))
+	assert.Equal(t, 5*time.Minute, opts.Backoff(4))
+	assert.Equal(t, 30*time.Second, webhooks.Options{}.Backoff(1))
+}
+
+func decodePayload(t *testing.T, event user_models.OutboxEvent) user_models.WebhookPayload {
+	var payload user_models.WebhookPayload
+	assert.NoError(t, json.Unmarshal([]byte(event.Payload), &payload))
+	assert.Equal(t, event.ID, payload.ID)
+	assert.Equal(t, event.Type, payload.Type)
+	return payload
+}
+
+// TestUserEvents 測試依變更前後的用戶產生的事件
+func TestUserEvents(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	user := user_models.User{ID: primitive.NewObjectID(), Name: "張三", Email: "zhangsan@example.com", Password: "hash", Status: user_models.StatusActive, Version: 1}
+
+	created := user_models.UserEvents(nil, user, at)
+	assert.Len(t, created, 1)
+	assert.Equal(t, user_models.WebhookUserCreated, created[0].Type)
+	assert.True(t, strings.HasPrefix(created[0].ID, "evt_"))
+	payload := decodePayload(t, created[0])
+	assert.Equal(t, user.ID.Hex(), payload.Data.User.ID)
+	assert.Equal(t, at, payload.OccurredAt)
+	assert.NotContains(t, created[0].Payload, "hash")
+
+	// 個人資料的變更列出變更的欄位，密碼只變更時不產生事件
+	updated := user
+	updated.Email = "new@example.com"
+	updated.Password = "other"
+	events := user_models.UserEvents(&user, updated, at)
+	assert.Len(t, events, 1)
+	assert.Equal(t, user_models.WebhookUserUpdated, events[0].Type)
+	assert.Equal(t, []string{"email"}, decodePayload(t, events[0]).Data.ChangedFields)
+
+	passwordOnly := user
+	passwordOnly.Password = "other"
+	assert.Empty(t, user_models.UserEvents(&user, passwordOnly, at))
+
+	// 頭像的上傳與刪除也產生 user.updated
+	withAvatar := user
+	withAvatar.Avatar = &user_models.Avatar{Hash: "abc"}
+	events = user_models.UserEvents(&user, withAvatar, at)
+	assert.Len(t, events, 1)
+	assert.Equal(t, []string{"avatar"}, decodePayload(t, events[0]).Data.ChangedFields)
+	events = user_models.UserEvents(&withAvatar, user, at)
+	assert.Len(t, events, 1)
+	assert.Equal(t, []string{"avatar"}, decodePayload(t, events[0]).Data.ChangedFields)
+
+	// 狀態轉換附上轉換紀錄，刪除產生 user.deleted
+	suspended := user
+	suspended.Status = user_models.StatusSuspended
+	suspended.StatusHistory = []user_models.StatusTransition{{From: user_models.StatusActive, To: user_models.StatusSuspended, Action: "suspend", Reason: "spam"}}
+	events = user_models.UserEvents(&user, suspended, at)
+	assert.Len(t, events, 1)
+	assert.Equal(t, user_models.WebhookUserStatusChanged, events[0].Type)
+	assert.Equal(t, "spam", decodePayload(t, events[0]).Data.Transition.Reason)
+
+	deleted := user
+	deleted.Status = user_models.StatusDeleted
+	events = user_models.UserEvents(&user, deleted, at)
+	assert.Len(t, events, 1)
+	assert.Equal(t, user_models.WebhookUserDeleted, events[0].Type)
+	assert.Nil(t, decodePayload(t, events[0]).Data.Transition)
+
+	// 同時變更個人資料與狀態時產生兩個事件
+	both := suspended
+	both.Name = "李四"
+	events = user_models.UserEvents(&user, both, at)
+	assert.Len(t, events, 2)
+	assert.Equal(t, user_models.WebhookUserUpdated, events[0].Type)
+	assert.Equal(t, user_models.WebhookUserStatusChanged, events[1].Type)
+	assert.NotEqual(t, events[0].ID, events[1].ID)
+
+	// outbox 不列入稽核差異
+	withOutbox := user
+	withOutbox.Outbox = created
+	assert.Empty(t, user_models.DiffUsers(&user, &withOutbox))
+}
+
+// TestWebhookDeliveryView 測試傳遞的公開表示與各狀態下的連結
+func TestWebhookDeliveryView(t *testing.T) {
+	base := "http://api.example.com/api/v1"
+	delivery := user_models.WebhookDelivery{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: primitive.NewObjectID(),
+		EventID:        "evt_1",
+		EventType:      user_models.WebhookUserCreated,
+		Payload:        `{"id":"evt_1"}`,
+		Status:         user_models.DeliveryPending,
+		NextAttemptAt:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		LeaseOwner:     "worker-1",
+	}
+	deliveryURL := base + "/webhooks/" + delivery.SubscriptionID.Hex() + "/deliveries/" + delivery.ID.Hex()
+
+	view := user_models.NewWebhookDeliveryView(delivery)
+	assert.NotNil(t, view.NextAttemptAt)
+	assert.Equal(t, []user_models.WebhookAttempt{}, view.Log)
+	body, err := json.Marshal(view)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"payload":{"id":"evt_1"}`)
+	assert.NotContains(t, string(body), "worker-1")
+	assert.Equal(t, []string{"self", "webhook"}, linkRels(user_models.GenerateWebhookDeliveryLinks(base, delivery)))
+
+	delivery.Status = user_models.DeliveryDeadLetter
+	assert.Nil(t, user_models.NewWebhookDeliveryView(delivery).NextAttemptAt)
+	links := user_models.GenerateWebhookDeliveryLinks(base, delivery)
+	assert.Equal(t, []string{"self", "webhook", "redeliver"}, linkRels(links))
+	assert.Equal(t, deliveryURL+"/redeliver", links[2].Href)
+
+	links = user_models.GenerateWebhookDeliveryListLinks(base, "w1", "dead_letter", 2, 10, 25)
+	assert.Equal(t, []string{"self", "webhook", "prev", "next"}, linkRels(links))
+	assert.Equal(t, base+"/webhooks/w1/deliveries?page=3&size=10&status=dead_letter", links[3].Href)
+}
+
+// TestWebhookEndpoints 測試 webhook 路由需要登入
+func TestWebhookEndpoints(t *testing.T) {
+	r := setupTestRouter()
+	routes.SetupRouter(r)
+	id := primitive.NewObjectID().Hex()
+	delivery := primitive.NewObjectID().Hex()
+
+	requests := []struct{ method, path, body string }{
+		{"POST", "/api/v1/webhooks", `{"url":"https://hooks.example.com","events":["user.created"]}`},
+		{"GET", "/api/v1/webhooks", ""},
+		{"GET", "/api/v1/webhooks/" + id, ""},
+		{"PATCH", "/api/v1/webhooks/" + id, `{"active":false}`},
+		{"DELETE", "/api/v1/webhooks/" + id, ""},
+		{"POST", "/api/v1/webhooks/" + id + "/rotate-secret", ""},
+		{"GET", "/api/v1/webhooks/" + id + "/deliveries", ""},
+		{"GET", "/api/v1/webhooks/" + id + "/deliveries/" + delivery, ""},
+		{"POST", "/api/v1/webhooks/" + id + "/deliveries/" + delivery + "/redeliver", ""},
+	}
+	for _, tc := range requests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, tc.method+" "+tc.path)
+	}
+}
+
+// TestWebhookURLGuard 測試 webhook 只能指向公開位址，訂閱時與實際連線時都會檢查
+func TestWebhookURLGuard(t *testing.T) {
+	ctx := context.Background()
+	for _, raw := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.10/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		assert.ErrorIs(t, webhooks.CheckURL(ctx, raw), webhooks.ErrForbiddenAddress, raw)
+	}
+	assert.NoError(t, webhooks.CheckURL(ctx, "https://8.8.8.8/hook"))
+	assert.True(t, webhooks.PublicIP(net.ParseIP("2001:4860:4860::8888")))
+	assert.False(t, webhooks.PublicIP(net.ParseIP("fd00::1")))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	_, err := webhooks.NewClient(time.Second).Post(server.URL, "application/json", strings.NewReader("{}"))
+	assert.ErrorIs(t, err, webhooks.ErrForbiddenAddress)
+}
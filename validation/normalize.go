@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// normalizers 以 normalize 標籤名稱對應的正規化函式，在驗證前套用於字串欄位
+var normalizers = map[string]func(string) string{
+	"trim":  strings.TrimSpace,
+	"email": NormalizeEmail,
+	"phone": NormalizePhone,
+	"sex":   NormalizeSex,
+	"name":  NormalizeName,
+}
+
+// normalizingValidator 在驗證前先依 normalize 標籤正規化欄位，使建立、PUT 與 PATCH 得到相同的結果
+type normalizingValidator struct {
+	binding.StructValidator
+}
+
+// ValidateStruct 正規化後交由原本的驗證器驗證
+func (v normalizingValidator) ValidateStruct(obj any) error {
+	Normalize(obj)
+	return v.StructValidator.ValidateStruct(obj)
+}
+
+// Normalize 依 normalize 標籤正規化結構中的字串欄位，obj 必須是指標才會被修改
+func Normalize(obj any) {
+	normalizeValue(reflect.ValueOf(obj))
+}
+
+// normalizeValue 遞迴處理指標、切片與巢狀結構
+func normalizeValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			normalizeValue(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			normalizeValue(v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			value := v.Field(i)
+			// 部分更新請求以 *string 表示可省略的欄位
+			if value.Kind() == reflect.Pointer && !value.IsNil() && value.Elem().Kind() == reflect.String {
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.String {
+				if fn, ok := normalizers[field.Tag.Get("normalize")]; ok && value.CanSet() {
+					value.SetString(fn(value.String()))
+				}
+				continue
+			}
+			normalizeValue(value)
+		}
+	}
+}
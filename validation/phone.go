@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Region 電話號碼的地區設定
+type Region struct {
+	Code        string // ISO 3166-1 地區代碼
+	CountryCode string // 國際電話國碼
+	MinDigits   int    // 去除國碼後的最少位數
+	MaxDigits   int    // 去除國碼後的最多位數
+}
+
+// regions 支援以國內格式輸入的地區，國內號碼以 0 開頭
+var regions = map[string]Region{
+	"TW": {Code: "TW", CountryCode: "886", MinDigits: 8, MaxDigits: 9},
+	"TH": {Code: "TH", CountryCode: "66", MinDigits: 8, MaxDigits: 9},
+}
+
+var (
+	regionMu      sync.RWMutex
+	defaultRegion = regions["TW"]
+)
+
+// SetDefaultRegion 設定國內格式電話號碼所屬的預設地區，只接受 TW 與 TH
+func SetDefaultRegion(code string) error {
+	region, ok := regions[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return fmt.Errorf("validation: unsupported phone region %q", code)
+	}
+	regionMu.Lock()
+	defaultRegion = region
+	regionMu.Unlock()
+	return nil
+}
+
+// DefaultRegion 取得目前的預設電話地區
+func DefaultRegion() Region {
+	regionMu.RLock()
+	defer regionMu.RUnlock()
+	return defaultRegion
+}
+
+// NormalizePhone 去除空白與分隔符號並轉為 E.164 格式
+// 以 00 開頭的國際冠碼改為 +，以 0 開頭的國內號碼加上預設地區的國碼；無法轉換時返回去除分隔符號後的值
+func NormalizePhone(phone string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	switch {
+	case cleaned == "", strings.HasPrefix(cleaned, "+"):
+		return cleaned
+	case strings.HasPrefix(cleaned, "00"):
+		return "+" + cleaned[2:]
+	case strings.HasPrefix(cleaned, "0"):
+		return "+" + DefaultRegion().CountryCode + cleaned[1:]
+	}
+	return cleaned
+}
@@ -0,0 +1,174 @@
+package validation
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// 年齡與姓名的允許範圍
+const (
+	MinAge        = 0
+	MaxAge        = 150
+	MaxNameLength = 100
+)
+
+// 正規化後的性別值
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+	SexOther  = "other"
+)
+
+// sexAliases 可接受的性別輸入，比對時不分大小寫
+var sexAliases = map[string]string{
+	"male": SexMale, "m": SexMale, "男": SexMale, "ชาย": SexMale,
+	"female": SexFemale, "f": SexFemale, "女": SexFemale, "หญิง": SexFemale,
+	"other": SexOther, "o": SexOther, "其他": SexOther, "อื่นๆ": SexOther,
+}
+
+// rules 自訂的驗證規則，以 binding 標籤名稱對應
+var rules = map[string]validator.Func{
+	"phone":       validatePhone,
+	"age":         validateAge,
+	"sex":         validateSex,
+	"person_name": validatePersonName,
+}
+
+// ruleMessages 自訂驗證規則的錯誤訊息，{0} 為欄位名稱
+var ruleMessages = map[string]map[string]string{
+	"en": {
+		"phone":       "{0} must be a valid phone number in E.164 format, e.g. +886912345678",
+		"age":         "{0} must be between {1} and {2}",
+		"sex":         "{0} must be one of male, female or other",
+		"person_name": "{0} must be 1 to {1} characters and contain only letters, spaces, apostrophes, hyphens or periods",
+	},
+	"zh_Hant_TW": {
+		"phone":       "{0}必須是有效的E.164格式電話號碼，例如+886912345678",
+		"age":         "{0}必須介於{1}到{2}之間",
+		"sex":         "{0}必須是male、female或other其中之一",
+		"person_name": "{0}長度必須為1到{1}個字元，且只能包含文字、空白、撇號、連字號或句點",
+	},
+	"th": {
+		"phone":       "{0} ต้องเป็นหมายเลขโทรศัพท์ในรูปแบบ E.164 ที่ถูกต้อง เช่น +66812345678",
+		"age":         "{0} ต้องอยู่ระหว่าง {1} ถึง {2}",
+		"sex":         "{0} ต้องเป็น male, female หรือ other เท่านั้น",
+		"person_name": "{0} ต้องมีความยาว 1 ถึง {1} ตัวอักษร และมีได้เฉพาะตัวอักษร ช่องว่าง อะพอสทรอฟี ขีดกลาง หรือจุด",
+	},
+}
+
+// registerRules 向驗證器註冊自訂驗證規則
+func registerRules(v *validator.Validate) {
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			panic("validation: registering " + tag + ": " + err.Error())
+		}
+	}
+}
+
+// registerRuleTranslations 註冊自訂驗證規則在指定語系的錯誤訊息
+func registerRuleTranslations(v *validator.Validate, trans ut.Translator, locale string) error {
+	for tag, message := range ruleMessages[locale] {
+		err := v.RegisterTranslation(tag, trans,
+			func(ut ut.Translator) error { return ut.Add(tag, message, true) },
+			ruleTranslation)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ruleTranslation 以欄位名稱與規則的範圍參數產生錯誤訊息
+func ruleTranslation(trans ut.Translator, fe validator.FieldError) string {
+	params := []string{fe.Field()}
+	switch fe.Tag() {
+	case "age":
+		params = append(params, strconv.Itoa(MinAge), strconv.Itoa(MaxAge))
+	case "person_name":
+		params = append(params, strconv.Itoa(MaxNameLength))
+	}
+	message, err := trans.T(fe.Tag(), params...)
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
+
+// validatePhone 檢查是否為 E.164 格式，台灣與泰國號碼另外檢查國內號碼長度
+func validatePhone(fl validator.FieldLevel) bool {
+	return IsE164(fl.Field().String())
+}
+
+// IsE164 檢查電話號碼是否為 E.164 格式
+func IsE164(phone string) bool {
+	if len(phone) < 8 || len(phone) > 16 || phone[0] != '+' || phone[1] == '0' {
+		return false
+	}
+	for _, r := range phone[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	for _, region := range regions {
+		if national, ok := strings.CutPrefix(phone[1:], region.CountryCode); ok {
+			return len(national) >= region.MinDigits && len(national) <= region.MaxDigits
+		}
+	}
+	return true
+}
+
+// validateAge 檢查年齡是否在允許範圍內
+func validateAge(fl validator.FieldLevel) bool {
+	age := fl.Field().Int()
+	return age >= MinAge && age <= MaxAge
+}
+
+// validateSex 檢查性別是否為正規化後的值
+func validateSex(fl validator.FieldLevel) bool {
+	switch fl.Field().String() {
+	case SexMale, SexFemale, SexOther:
+		return true
+	}
+	return false
+}
+
+// validatePersonName 檢查姓名長度，並只允許文字、組合符號、空白與常見的標點
+func validatePersonName(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if name == "" || utf8.RuneCountInString(name) > MaxNameLength {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r), unicode.IsMark(r), r == ' ':
+		case strings.ContainsRune("'’-.·", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeSex 將可接受的性別輸入轉為 male、female 或 other，無法辨識時原樣返回
+func NormalizeSex(sex string) string {
+	trimmed := strings.TrimSpace(sex)
+	if normalized, ok := sexAliases[strings.ToLower(trimmed)]; ok {
+		return normalized
+	}
+	return trimmed
+}
+
+// NormalizeEmail 去除前後空白並轉為小寫
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeName 去除前後空白並將連續空白合併為一個
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
@@ -0,0 +1,105 @@
+// Package validation 設定 gin 綁定使用的驗證器與自訂規則，並將驗證錯誤翻譯為欄位層級的錯誤清單
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"go-api_for_main/i18n"
+	user_models "go-api_for_main/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/th"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	th_translations "github.com/go-playground/validator/v10/translations/th"
+	zh_tw_translations "github.com/go-playground/validator/v10/translations/zh_tw"
+)
+
+// locales 對應 i18n 語言代碼與 universal-translator 的語系
+var locales = map[string]string{
+	i18n.English:            "en",
+	i18n.TraditionalChinese: "zh_Hant_TW",
+	i18n.Thai:               "th",
+}
+
+var universal *ut.UniversalTranslator
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	// 驗證錯誤使用 JSON 欄位名稱，與用戶端送出的欄位一致
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			// 不輸出於 JSON 的欄位（例如密碼）以小寫開頭的欄位名稱表示
+			return strings.ToLower(field.Name[:1]) + field.Name[1:]
+		}
+		return name
+	})
+	registerRules(v)
+
+	english := en.New()
+	universal = ut.New(english, english, zh_Hant_TW.New(), th.New())
+	registrations := map[string]func(*validator.Validate, ut.Translator) error{
+		"en":         en_translations.RegisterDefaultTranslations,
+		"zh_Hant_TW": zh_tw_translations.RegisterDefaultTranslations,
+		"th":         th_translations.RegisterDefaultTranslations,
+	}
+	for locale, register := range registrations {
+		trans, _ := universal.GetTranslator(locale)
+		if err := register(v, trans); err != nil {
+			panic("validation: registering " + locale + " translations: " + err.Error())
+		}
+		if err := registerRuleTranslations(v, trans, locale); err != nil {
+			panic("validation: registering " + locale + " rule translations: " + err.Error())
+		}
+	}
+
+	// 所有透過 gin 綁定的請求都會先正規化再驗證
+	binding.Validator = normalizingValidator{binding.Validator}
+}
+
+// translator 取得語言對應的翻譯器，不支援的語言使用英文
+func translator(lang string) ut.Translator {
+	trans, found := universal.GetTranslator(locales[lang])
+	if !found {
+		trans, _ = universal.GetTranslator("en")
+	}
+	return trans
+}
+
+// fieldPath 由驗證錯誤的命名空間去除最外層的結構名稱，例如 User.email 轉為 email
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+// Translate 將驗證錯誤轉為指定語言的欄位錯誤清單；err 不是驗證錯誤時回傳 false
+func Translate(err error, lang string) ([]user_models.FieldError, bool) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) || universal == nil {
+		return nil, false
+	}
+
+	trans := translator(lang)
+	fieldErrors := make([]user_models.FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fieldErrors = append(fieldErrors, user_models.FieldError{
+			Field:   fieldPath(fe),
+			Code:    fe.Tag(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return fieldErrors, true
+}
@@ -0,0 +1,536 @@
+// Package webhooks 將用戶變更的事件傳遞給 webhook 訂閱者。事件與變更在同一次寫入中保存在
+// 用戶文件的 outbox，轉送程序將事件展開為每個訂閱的傳遞後才從用戶文件刪除；worker 以租約取得
+// 到期的傳遞並送出以 HMAC-SHA256 簽章的請求，失敗時以指數退避重試，用盡次數後進入 dead letter。
+// 每個步驟都可以安全地重複執行，因此任何時間點當機都不會遺失事件，訂閱者可能收到重複的事件
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api_for_main/clock"
+	user_models "go-api_for_main/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 傳遞操作的錯誤
+var (
+	ErrNotFound    = errors.New("webhook delivery not found")
+	ErrNotFinished = errors.New("webhook delivery has not finished yet")
+)
+
+// 每次轉送處理的用戶數、保留的嘗試紀錄數與保存的回應內容長度
+const (
+	relayBatchSize   = 100
+	maxAttemptLog    = 20
+	maxResponseBytes = 1024
+)
+
+// Options 傳遞設定，零值的欄位使用預設值
+type Options struct {
+	Workers      int           // 同時進行的傳遞數
+	PollInterval time.Duration // 沒有事件或到期的傳遞時，再次查詢前等待的時間
+	Timeout      time.Duration // 每次請求的逾時
+	MaxAttempts  int           // 進入 dead letter 前最多嘗試的次數
+	RetryBackoff time.Duration // 第一次失敗後到重試前等待的時間，之後每次加倍
+	MaxBackoff   time.Duration // 重試等待時間的上限
+	Retention    time.Duration // 已結束的傳遞與已轉送的事件保存的時間
+
+	// OnPublish 事件第一次轉送時呼叫，供行程內的即時事件流使用；重複轉送的事件不會再次呼叫
+	OnPublish func(userID primitive.ObjectID, event user_models.OutboxEvent)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = 2
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 8
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 30 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 6 * time.Hour
+	}
+	if o.Retention <= 0 {
+		o.Retention = 30 * 24 * time.Hour
+	}
+	return o
+}
+
+// Backoff 回傳第 attempt 次嘗試失敗後到重試前等待的時間
+func (o Options) Backoff(attempt int) time.Duration {
+	o = o.withDefaults()
+	delay := o.RetryBackoff
+	for i := 1; i < attempt && delay < o.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > o.MaxBackoff {
+		delay = o.MaxBackoff
+	}
+	return delay
+}
+
+// EventsCollection 保存已轉送事件的集合
+const EventsCollection = "webhook_events"
+
+// eventRecord 已轉送的事件；FannedOut 在所有傳遞都建立後才設定，中斷的轉送會重新展開
+type eventRecord struct {
+	ID         string             `bson:"_id"`
+	Type       string             `bson:"type"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	OccurredAt time.Time          `bson:"occurred_at"`
+	Payload    string             `bson:"payload"`
+	FannedOut  bool               `bson:"fanned_out"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
+
+// Dispatcher 轉送 outbox 事件並傳遞給訂閱者
+type Dispatcher struct {
+	source        *mongo.Collection // 保存 outbox 事件的用戶集合
+	subscriptions *mongo.Collection
+	events        *mongo.Collection
+	deliveries    *mongo.Collection
+	clock         clock.Clock
+	opts          Options
+	owner         string
+	client        *http.Client
+}
+
+// New 建立傳遞程序，source 為保存 outbox 事件的集合；訂閱、事件與傳遞分別保存在
+// webhook_subscriptions、webhook_events 與 webhook_deliveries 集合
+func New(db *mongo.Database, source *mongo.Collection, clk clock.Clock, opts Options) *Dispatcher {
+	opts = opts.withDefaults()
+	return &Dispatcher{
+		source:        source,
+		subscriptions: db.Collection("webhook_subscriptions"),
+		events:        db.Collection(EventsCollection),
+		deliveries:    db.Collection("webhook_deliveries"),
+		clock:         clk,
+		opts:          opts,
+		owner:         workerID(),
+		client:        NewClient(opts.Timeout),
+	}
+}
+
+// workerID 以主機名稱、行程編號與亂數識別租約的持有者
+func workerID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Subscriptions 保存訂閱的集合
+func (d *Dispatcher) Subscriptions() *mongo.Collection {
+	return d.subscriptions
+}
+
+// EnsureIndexes 建立轉送、取得傳遞與清除過期資料所需的索引
+func (d *Dispatcher) EnsureIndexes(ctx context.Context) error {
+	if _, err := d.source.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "outbox.id", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}); err != nil {
+		return err
+	}
+	if _, err := d.subscriptions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "active", Value: 1}, {Key: "events", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	if _, err := d.events.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	_, err := d.deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "event_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lease_until", Value: 1}}},
+	})
+	return err
+}
+
+// Start 啟動轉送程序、worker 與清除過期資料的排程，ctx 結束時停止
+func (d *Dispatcher) Start(ctx context.Context) {
+	go d.relayLoop(ctx)
+	for i := 0; i < d.opts.Workers; i++ {
+		go d.work(ctx)
+	}
+	go d.purgeLoop(ctx)
+}
+
+// Get 取得訂閱的傳遞
+func (d *Dispatcher) Get(ctx context.Context, subscriptionID, id primitive.ObjectID) (user_models.WebhookDelivery, error) {
+	var delivery user_models.WebhookDelivery
+	err := d.deliveries.FindOne(ctx, bson.M{"_id": id, "subscription_id": subscriptionID}).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return delivery, ErrNotFound
+	}
+	return delivery, err
+}
+
+// List 由新到舊列出訂閱的傳遞，status 不為空時只列出該狀態
+func (d *Dispatcher) List(ctx context.Context, subscriptionID primitive.ObjectID, status string, page, size int) ([]user_models.WebhookDelivery, int64, error) {
+	filter := bson.M{"subscription_id": subscriptionID}
+	if status != "" {
+		filter["status"] = status
+	}
+	total, err := d.deliveries.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	cursor, err := d.deliveries.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page-1)*size)).
+		SetLimit(int64(size)))
+	if err != nil {
+		return nil, 0, err
+	}
+	deliveries := []user_models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// Redeliver 將已結束的傳遞放回佇列立即傳遞，重新計算嘗試次數並保留先前的紀錄
+func (d *Dispatcher) Redeliver(ctx context.Context, subscriptionID, id primitive.ObjectID) (user_models.WebhookDelivery, error) {
+	now := d.clock.Now()
+	var delivery user_models.WebhookDelivery
+	err := d.deliveries.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "subscription_id": subscriptionID, "status": bson.M{"$in": bson.A{user_models.DeliverySucceeded, user_models.DeliveryDeadLetter}}},
+		bson.M{
+			"$set":   bson.M{"status": user_models.DeliveryPending, "attempts": 0, "next_attempt_at": now, "updated_at": now},
+			"$unset": bson.M{"delivered_at": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		if delivery, err = d.Get(ctx, subscriptionID, id); err != nil {
+			return delivery, err
+		}
+		return delivery, ErrNotFinished
+	}
+	return delivery, err
+}
+
+// Forget 刪除訂閱的所有傳遞，在刪除訂閱後呼叫
+func (d *Dispatcher) Forget(ctx context.Context, subscriptionID primitive.ObjectID) error {
+	_, err := d.deliveries.DeleteMany(ctx, bson.M{"subscription_id": subscriptionID})
+	return err
+}
+
+func (d *Dispatcher) relayLoop(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := d.relay(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error relaying webhook events: %v\n", err)
+		}
+		if n == relayBatchSize && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(d.opts.PollInterval):
+		}
+	}
+}
+
+// relay 轉送一批用戶文件中的 outbox 事件，回傳處理的用戶數
+func (d *Dispatcher) relay(ctx context.Context) (int, error) {
+	cursor, err := d.source.Find(ctx, bson.M{"outbox.id": bson.M{"$exists": true}}, options.Find().
+		SetProjection(bson.M{"outbox": 1}).
+		SetLimit(relayBatchSize))
+	if err != nil {
+		return 0, err
+	}
+	var docs []struct {
+		ID     primitive.ObjectID        `bson:"_id"`
+		Outbox []user_models.OutboxEvent `bson:"outbox"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+
+	for _, doc := range docs {
+		// 依發生順序轉送，失敗時保留這個用戶其餘的事件，下次從失敗的事件繼續
+		for _, event := range doc.Outbox {
+			if err := d.publish(ctx, doc.ID, event); err != nil {
+				return len(docs), err
+			}
+			if _, err := d.source.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$pull": bson.M{"outbox": bson.M{"id": event.ID}}}); err != nil {
+				return len(docs), err
+			}
+		}
+	}
+	return len(docs), nil
+}
+
+// publish 保存事件並為每個訂閱此事件的有效訂閱建立傳遞；事件與傳遞以唯一鍵去除重複，可以重複執行
+func (d *Dispatcher) publish(ctx context.Context, userID primitive.ObjectID, event user_models.OutboxEvent) error {
+	now := d.clock.Now()
+	_, err := d.events.InsertOne(ctx, eventRecord{
+		ID:         event.ID,
+		Type:       event.Type,
+		UserID:     userID,
+		OccurredAt: event.OccurredAt,
+		Payload:    event.Payload,
+		CreatedAt:  now,
+	})
+	switch {
+	case err == nil:
+		if d.opts.OnPublish != nil {
+			d.opts.OnPublish(userID, event)
+		}
+	case !mongo.IsDuplicateKeyError(err):
+		return err
+	}
+	var record eventRecord
+	if err := d.events.FindOne(ctx, bson.M{"_id": event.ID}).Decode(&record); err != nil {
+		return err
+	}
+	if record.FannedOut {
+		return nil
+	}
+
+	cursor, err := d.subscriptions.Find(ctx, bson.M{"active": true, "events": event.Type})
+	if err != nil {
+		return err
+	}
+	var subs []user_models.WebhookSubscription
+	if err := cursor.All(ctx, &subs); err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		_, err := d.deliveries.InsertOne(ctx, user_models.WebhookDelivery{
+			ID:             primitive.NewObjectID(),
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        event.Payload,
+			Status:         user_models.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	_, err = d.events.UpdateOne(ctx, bson.M{"_id": event.ID}, bson.M{"$set": bson.M{"fanned_out": true}})
+	return err
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		delivery, err := d.lease(ctx)
+		if err == nil {
+			d.deliver(ctx, delivery)
+			continue
+		}
+		if err != mongo.ErrNoDocuments && ctx.Err() == nil {
+			log.Printf("Error leasing webhook delivery: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(d.opts.PollInterval):
+		}
+	}
+}
+
+// lease 取得一個到期的等待中傳遞，或租約已過期的傳遞中傳遞
+func (d *Dispatcher) lease(ctx context.Context) (user_models.WebhookDelivery, error) {
+	now := d.clock.Now()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": user_models.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"status": user_models.DeliveryDelivering, "lease_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      user_models.DeliveryDelivering,
+			"lease_owner": d.owner,
+			"lease_until": now.Add(2 * d.opts.Timeout),
+			"updated_at":  now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	var delivery user_models.WebhookDelivery
+	err := d.deliveries.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After),
+	).Decode(&delivery)
+	return delivery, err
+}
+
+// deliver 送出已取得租約的傳遞，並依結果結束、退避後重試或進入 dead letter
+func (d *Dispatcher) deliver(ctx context.Context, delivery user_models.WebhookDelivery) {
+	var sub user_models.WebhookSubscription
+	err := d.subscriptions.FindOne(ctx, bson.M{"_id": delivery.SubscriptionID}).Decode(&sub)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		d.fail(delivery, d.skipped("subscription no longer exists"), true)
+		return
+	case err != nil:
+		if ctx.Err() != nil {
+			d.release(delivery)
+			return
+		}
+		d.fail(delivery, d.skipped(err.Error()), false)
+		return
+	case !sub.Active:
+		d.fail(delivery, d.skipped("subscription is inactive"), true)
+		return
+	case delivery.Attempts > d.opts.MaxAttempts:
+		// 每次租約過期都會消耗一次嘗試次數，避免讓 worker 當機的傳遞無限重試
+		d.fail(delivery, d.skipped("delivery lease expired too many times"), true)
+		return
+	}
+
+	attempt, ok := d.send(ctx, sub, delivery)
+	switch {
+	case ok:
+		d.succeed(delivery, attempt)
+	case ctx.Err() != nil:
+		d.release(delivery)
+	default:
+		d.fail(delivery, attempt, false)
+	}
+}
+
+// skipped 沒有送出請求的嘗試紀錄
+func (d *Dispatcher) skipped(reason string) user_models.WebhookAttempt {
+	return user_models.WebhookAttempt{At: d.clock.Now(), Error: reason}
+}
+
+// send 送出簽章的請求，回應 2xx 視為成功
+func (d *Dispatcher) send(ctx context.Context, sub user_models.WebhookSubscription, delivery user_models.WebhookDelivery) (user_models.WebhookAttempt, bool) {
+	now := d.clock.Now()
+	attempt := user_models.WebhookAttempt{At: now}
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-api-webhooks/1.0")
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, now.Unix(), body))
+
+	started := time.Now()
+	resp, err := d.client.Do(req)
+	attempt.DurationMS = time.Since(started).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, false
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	attempt.ResponseBody = strings.ToValidUTF8(string(snippet), "")
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = "unexpected status " + strconv.Itoa(resp.StatusCode)
+		return attempt, false
+	}
+	return attempt, true
+}
+
+// logAttempt 加入嘗試紀錄，只保留最近的 maxAttemptLog 筆
+func logAttempt(attempt user_models.WebhookAttempt) bson.M {
+	return bson.M{"log": bson.M{"$each": bson.A{attempt}, "$slice": -maxAttemptLog}}
+}
+
+func (d *Dispatcher) succeed(delivery user_models.WebhookDelivery, attempt user_models.WebhookAttempt) {
+	now := d.clock.Now()
+	d.updateLeased(delivery, bson.M{
+		"$set":   bson.M{"status": user_models.DeliverySucceeded, "delivered_at": now, "updated_at": now},
+		"$unset": bson.M{"lease_owner": "", "lease_until": ""},
+		"$push":  logAttempt(attempt),
+	})
+}
+
+// fail 記錄失敗的嘗試，還有嘗試次數時在退避後重試，否則進入 dead letter；final 表示重試也不會成功
+func (d *Dispatcher) fail(delivery user_models.WebhookDelivery, attempt user_models.WebhookAttempt, final bool) {
+	now := d.clock.Now()
+	set := bson.M{"status": user_models.DeliveryDeadLetter, "updated_at": now}
+	if !final && delivery.Attempts < d.opts.MaxAttempts {
+		set["status"] = user_models.DeliveryPending
+		set["next_attempt_at"] = now.Add(d.opts.Backoff(delivery.Attempts))
+	}
+	d.updateLeased(delivery, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"lease_owner": "", "lease_until": ""},
+		"$push":  logAttempt(attempt),
+	})
+}
+
+// release 在 worker 停止時將傳遞放回佇列，這次中斷不計入嘗試次數
+func (d *Dispatcher) release(delivery user_models.WebhookDelivery) {
+	now := d.clock.Now()
+	d.updateLeased(delivery, bson.M{
+		"$set":   bson.M{"status": user_models.DeliveryPending, "next_attempt_at": now, "updated_at": now},
+		"$unset": bson.M{"lease_owner": "", "lease_until": ""},
+		"$inc":   bson.M{"attempts": -1},
+	})
+}
+
+// updateLeased 只在仍持有租約時更新傳遞
+func (d *Dispatcher) updateLeased(delivery user_models.WebhookDelivery, update bson.M) {
+	_, err := d.deliveries.UpdateOne(context.Background(), bson.M{"_id": delivery.ID, "lease_owner": d.owner}, update)
+	if err != nil {
+		log.Printf("Error updating webhook delivery %s: %v\n", delivery.ID.Hex(), err)
+	}
+}
+
+// purgeLoop 每小時刪除超過保存期限的已結束傳遞與已轉送事件
+func (d *Dispatcher) purgeLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		cutoff := d.clock.Now().Add(-d.opts.Retention)
+		if _, err := d.deliveries.DeleteMany(ctx, bson.M{
+			"status":     bson.M{"$in": bson.A{user_models.DeliverySucceeded, user_models.DeliveryDeadLetter}},
+			"updated_at": bson.M{"$lt": cutoff},
+		}); err != nil && ctx.Err() == nil {
+			log.Printf("Error purging webhook deliveries: %v\n", err)
+		}
+		if _, err := d.events.DeleteMany(ctx, bson.M{"fanned_out": true, "created_at": bson.M{"$lt": cutoff}}); err != nil && ctx.Err() == nil {
+			log.Printf("Error purging webhook events: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package xlsx 讀寫 Office Open XML 試算表的第一個工作表，只處理匯入與匯出用戶所需的字串、數字與布林儲存格
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrInvalidWorkbook 表示檔案不是可讀取的 XLSX 活頁簿
+var ErrInvalidWorkbook = errors.New("invalid xlsx workbook")
+
+// 讀取限制，避免壓縮炸彈耗盡記憶體
+const (
+	// MaxRows 單一工作表最多讀取的列數
+	MaxRows = 100000
+	// maxPartSize 單一 XML 檔案解壓縮後的大小上限
+	maxPartSize = 64 << 20
+)
+
+type workbookXML struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationshipsXML struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type sharedStringsXML struct {
+	Items []textXML `xml:"si"`
+}
+
+// textXML 共用字串或行內字串，rich text 由多個 r 組成
+type textXML struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t textXML) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type worksheetXML struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string  `xml:"r,attr"`
+			Type   string  `xml:"t,attr"`
+			Value  string  `xml:"v"`
+			Inline textXML `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadRows 讀取第一個工作表，rows[i] 對應試算表的第 i+1 列，空白列為 nil
+func ReadRows(r io.ReaderAt, size int64) ([][]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var shared sharedStringsXML
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, fmt.Errorf("%w: no worksheet", ErrInvalidWorkbook)
+	}
+	var sheet worksheetXML
+	if err := decodeXML(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, row := range sheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = len(rows) + 1
+		}
+		if number > MaxRows || number <= len(rows) {
+			return nil, fmt.Errorf("%w: row %d out of order or beyond %d rows", ErrInvalidWorkbook, number, MaxRows)
+		}
+		for len(rows) < number {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for j, cell := range row.Cells {
+			col := j
+			if cell.Ref != "" {
+				if col, ok = columnIndex(cell.Ref); !ok {
+					return nil, fmt.Errorf("%w: invalid cell reference %q in row %d", ErrInvalidWorkbook, cell.Ref, i+1)
+				}
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				var index int
+				if _, err := fmt.Sscan(cell.Value, &index); err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("%w: invalid shared string in cell %s", ErrInvalidWorkbook, cell.Ref)
+				}
+				cells[col] = shared.Items[index].String()
+			case "inlineStr":
+				cells[col] = cell.Inline.String()
+			case "b":
+				cells[col] = map[string]string{"1": "TRUE", "0": "FALSE"}[cell.Value]
+			default:
+				cells[col] = cell.Value
+			}
+		}
+		rows[number-1] = cells
+	}
+	return rows, nil
+}
+
+// firstSheetPath 依 workbook.xml 找出第一個工作表的路徑，找不到時使用慣用的 sheet1.xml
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook workbookXML
+	var rels relationshipsXML
+	wb, ok1 := files["xl/workbook.xml"]
+	rel, ok2 := files["xl/_rels/workbook.xml.rels"]
+	if !ok1 || !ok2 || decodeXML(wb, &workbook) != nil || decodeXML(rel, &rels) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	for _, r := range rels.Relationships {
+		if r.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(r.Target, "/") {
+			return strings.TrimPrefix(r.Target, "/")
+		}
+		return path.Join("xl", r.Target)
+	}
+	return fallback
+}
+
+// columnIndex 將 A1 形式的儲存格位置轉為從 0 開始的欄位索引
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		n++
+		if n > 3 {
+			return 0, false
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return col - 1, true
+}
+
+func decodeXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidWorkbook, f.Name, err)
+	}
+	return nil
+}